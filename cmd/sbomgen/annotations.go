@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hallucinaut/sbomgen/pkg/sbom"
+)
+
+// githubAnnotation formats a GitHub Actions workflow command that surfaces
+// message inline on file. level is "error", "warning" or "notice"; line is
+// omitted when zero.
+func githubAnnotation(level, file string, line int, message string) string {
+	var props []string
+	if file != "" {
+		props = append(props, "file="+escapeAnnotationProperty(file))
+		if line > 0 {
+			props = append(props, fmt.Sprintf("line=%d", line))
+		}
+	}
+
+	command := "::" + level
+	if len(props) > 0 {
+		command += " " + strings.Join(props, ",")
+	}
+	return command + "::" + escapeAnnotationData(message)
+}
+
+// printAnnotation writes a workflow command built by githubAnnotation.
+// GitHub Actions reads workflow commands from stderr as well as stdout, so
+// they go to stderr and never mix with an SBOM written to stdout.
+func printAnnotation(command string) {
+	fmt.Fprintln(os.Stderr, command)
+}
+
+// componentAnnotation annotates the manifest that declared comp.
+func componentAnnotation(level string, comp sbom.Component, message string) string {
+	return githubAnnotation(level, annotationPath(comp.Metadata.SourceFile), comp.Metadata.SourceLine, message)
+}
+
+// annotationPath makes path relative to the working directory, which is
+// the repository root in GitHub Actions, using forward slashes.
+func annotationPath(path string) string {
+	if path == "" {
+		return ""
+	}
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, path); err == nil && !strings.HasPrefix(rel, "..") {
+			path = rel
+		}
+	}
+	return filepath.ToSlash(path)
+}
+
+func escapeAnnotationData(s string) string {
+	s = strings.ReplaceAll(s, "%", "%25")
+	s = strings.ReplaceAll(s, "\r", "%0D")
+	return strings.ReplaceAll(s, "\n", "%0A")
+}
+
+func escapeAnnotationProperty(s string) string {
+	s = escapeAnnotationData(s)
+	s = strings.ReplaceAll(s, ":", "%3A")
+	return strings.ReplaceAll(s, ",", "%2C")
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/hallucinaut/sbomgen/pkg/analyzer"
+	"github.com/hallucinaut/sbomgen/pkg/config"
+)
+
+// doctor reports which analyzers apply to a project and what might keep
+// them from producing a complete SBOM, without generating one.
+func doctor(args []string) error {
+	projectDir := "."
+	var allow, skip []string
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "-d", "--dir":
+			if i+1 < len(args) {
+				projectDir = args[i+1]
+				i++
+			}
+		case "--analyzers":
+			if i+1 < len(args) {
+				allow = splitList(args[i+1])
+				i++
+			}
+		case "--skip-analyzers":
+			if i+1 < len(args) {
+				skip = splitList(args[i+1])
+				i++
+			}
+		default:
+			projectDir = args[i]
+		}
+	}
+
+	dir, err := config.ExpandPath(projectDir)
+	if err != nil {
+		return fmt.Errorf("--dir: %w", err)
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("failed to resolve directory path: %w", err)
+	}
+
+	projectAnalyzer := analyzer.NewProjectAnalyzer()
+	if err := projectAnalyzer.FilterAnalyzers(allow, skip); err != nil {
+		return err
+	}
+	diagnoses, warnings, err := projectAnalyzer.Diagnose(absDir)
+	if err != nil {
+		return fmt.Errorf("failed to analyze directory: %w", err)
+	}
+	writeDiagnosis(os.Stdout, absDir, diagnoses, warnings)
+	return nil
+}
+
+// writeDiagnosis prints a table with a row per analyzer followed by the
+// issues found, if any.
+func writeDiagnosis(w io.Writer, dir string, diagnoses []analyzer.Diagnosis, warnings []error) {
+	fmt.Fprintf(w, "Project: %s\n\n", dir)
+	fmt.Fprintf(w, "%-12s %-10s %-11s %s\n", "ANALYZER", "MANIFESTS", "COMPONENTS", "LOCKFILE")
+
+	var issues []string
+	for _, d := range diagnoses {
+		lockfile := "-"
+		switch {
+		case !d.Lockfiles || len(d.Manifests) == 0:
+		case len(d.Unlocked) == 0:
+			lockfile = "yes"
+		case len(d.Unlocked) == len(d.Manifests):
+			lockfile = "missing"
+		default:
+			lockfile = fmt.Sprintf("missing for %d of %d", len(d.Unlocked), len(d.Manifests))
+		}
+		fmt.Fprintf(w, "%-12s %-10d %-11d %s\n", d.Analyzer, len(d.Manifests), d.Components, lockfile)
+		for _, issue := range d.Issues {
+			issues = append(issues, d.Analyzer+": "+issue)
+		}
+	}
+	for _, warning := range warnings {
+		issues = append(issues, fmt.Sprintf("walk: %v", warning))
+	}
+
+	if len(issues) == 0 {
+		fmt.Fprintln(w, "\nNo issues found.")
+		return
+	}
+	fmt.Fprintf(w, "\nIssues:\n")
+	for _, issue := range issues {
+		fmt.Fprintf(w, "  ! %s\n", issue)
+	}
+}
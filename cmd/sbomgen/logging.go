@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// logFormats lists the values accepted by --log-format.
+var logFormats = []string{"text", "json"}
+
+// logger writes the progress and warning messages of sbomgen gen. In text
+// mode informational messages go to stdout and warnings to stderr with a
+// "Warning: " prefix. In JSON mode every message is written to stderr as a
+// JSON object on a line of its own, holding the level, the message and its
+// attributes, so that CI systems can collect them without scraping text.
+type logger struct {
+	stdout io.Writer
+	stderr io.Writer
+	json   *slog.Logger
+}
+
+// logs is the logger gen reports through; parseGenArgs replaces it when
+// --log-format is given.
+var logs = &logger{stdout: os.Stdout, stderr: os.Stderr}
+
+// newLogger returns a logger for format, which is "text" or "json".
+func newLogger(format string, stdout, stderr io.Writer) (*logger, error) {
+	l := &logger{stdout: stdout, stderr: stderr}
+	switch format {
+	case "text":
+	case "json":
+		l.json = slog.New(slog.NewJSONHandler(stderr, nil))
+	default:
+		return nil, fmt.Errorf("unknown log format %q (supported: %s)", format, strings.Join(logFormats, ", "))
+	}
+	return l, nil
+}
+
+// Info reports progress, such as the number of components found. args are
+// key-value attribute pairs as for slog; text output mostly leaves them
+// out, so msg should read well on its own.
+func (l *logger) Info(msg string, args ...any) {
+	if l.json != nil {
+		l.json.Info(msg, args...)
+		return
+	}
+	fmt.Fprintln(l.stdout, textMessage(msg, args))
+}
+
+// Status reports information about the run itself, such as the config
+// file in use; text mode writes it to stderr rather than stdout.
+func (l *logger) Status(msg string, args ...any) {
+	if l.json != nil {
+		l.json.Info(msg, args...)
+		return
+	}
+	fmt.Fprintln(l.stderr, textMessage(msg, args))
+}
+
+// Warn reports a problem that does not stop the SBOM from being written.
+func (l *logger) Warn(msg string, args ...any) {
+	if l.json != nil {
+		l.json.Warn(msg, args...)
+		return
+	}
+	fmt.Fprintln(l.stderr, "Warning: "+textMessage(msg, args))
+}
+
+// textMessage renders msg for text output. Attributes holding an error or
+// a list of strings are written below the message, as they are too long to
+// embed in it; other attributes are expected to be part of msg already.
+func textMessage(msg string, args []any) string {
+	var sb strings.Builder
+	sb.WriteString(msg)
+	for i := 1; i < len(args); i += 2 {
+		switch v := args[i].(type) {
+		case error:
+			sb.WriteString(":\n" + v.Error())
+		case []string:
+			sb.WriteString(":")
+			for _, item := range v {
+				sb.WriteString("\n  " + item)
+			}
+		}
+	}
+	return sb.String()
+}
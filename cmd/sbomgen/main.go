@@ -2,14 +2,28 @@
 package main
 
 import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/hallucinaut/sbomgen/pkg/analyzer"
+	"github.com/hallucinaut/sbomgen/pkg/config"
+	"github.com/hallucinaut/sbomgen/pkg/encrypt"
+	"github.com/hallucinaut/sbomgen/pkg/enrich"
 	"github.com/hallucinaut/sbomgen/pkg/formatter"
 	"github.com/hallucinaut/sbomgen/pkg/sbom"
+	"github.com/hallucinaut/sbomgen/pkg/upload"
+	"github.com/hallucinaut/sbomgen/pkg/validator"
 )
 
 const (
@@ -17,9 +31,31 @@ const (
 	appName = "sbomgen"
 )
 
+// exitPolicy is the exit code used when a policy gate fails, distinguishing
+// it from ordinary errors, which exit with 1.
+const exitPolicy = 2
+
+// exitError carries a specific process exit code alongside its error.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
 func main() {
 	if err := run(os.Args[1:]); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		var exitErr *exitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.code)
+		}
 		os.Exit(1)
 	}
 }
@@ -35,6 +71,18 @@ func run(args []string) error {
 		return generate(args[1:])
 	case "analyze":
 		return analyze(args[1:])
+	case "doctor":
+		return doctor(args[1:])
+	case "validate":
+		return validate(args[1:])
+	case "convert":
+		return convert(args[1:])
+	case "decrypt":
+		return decrypt(args[1:])
+	case "normalize":
+		return normalize(args[1:])
+	case "upload":
+		return uploadSBOM(args[1:])
 	case "version":
 		fmt.Printf("%s version %s\n", appName, version)
 		return nil
@@ -54,151 +102,2204 @@ Usage:
 Commands:
   gen       Generate SBOM from a project directory
   analyze   Analyze a project and list dependencies
+  doctor    Check which analyzers apply to a project and flag missing lockfiles
+  validate  Validate an SBOM file against its JSON Schema
+  convert   Convert an SBOM file to another output format
+  decrypt   Decrypt an SBOM written with 'gen --encrypt'
+  normalize Clean up an SBOM: merge duplicates, canonicalize licenses, sort
+  upload    Upload a CycloneDX SBOM to a Dependency-Track server
   version   Show version information
   help      Show this help message
 
 Options for 'gen':
   -o, --output <file>     Output file (default: stdout)
-  -f, --format <format>   Output format: json, yaml, markdown, table, spdx, cyclonedx (default: json)
+  --output-mode <mode>    Octal permissions of the output file, e.g. 0600 (default: 0644)
+  --deterministic         Derive the serial number from the content and take the timestamp from SOURCE_DATE_EPOCH (default: 0)
+  --dry-run               Analyze and format, but only print a summary of what would be written
+  --verbose               List every path that could not be read or analyzed
+  --log-format <f>        Progress and warning format: text (default) or json lines on stderr
+  --encrypt               Encrypt the output file with AES-256-GCM (requires -o and --passphrase-env)
+  --passphrase-env <var>  Environment variable holding the encryption passphrase
+  -f, --format <format>   Output format: %s, auto
+                          (default: auto, inferred from the -o extension, json for stdout)
   -d, --dir <dir>         Project directory (default: current directory)
-  
+  --binary <file>         Describe a compiled ELF, Mach-O or PE executable instead of a directory
+  --archive <file>        Analyze a .tar, .tar.gz, .tgz or .zip of the project instead of a directory
+  --venv <dir>            Take installed PyPI versions and dependency edges from a virtualenv's dist-info
+  --monorepo              Merge separate package.json files: dedupe shared dependencies, relate internal packages
+  --compact               Emit minified JSON instead of indented output
+  --spec-version <v>      SPDX (2.2, 2.3) or CycloneDX (1.4, 1.5) version to emit
+  --document-namespace-base <url> Base URL of the SPDX document namespace (default: https://spdx.org/spdxdocs)
+  --sort-by <key>         Order components by name, license, supplier or version (ties by name)
+  --minimize              Keep only the fields the output standard can represent
+  --strip-metadata        Drop component authors, links, source locations and annotations for external sharing
+  --components-only       Leave relationships and annotations out of the output, keeping every component
+  --split-by <mode>       Write one SBOM per supplier or per analyzed root into --output-dir, plus index.json
+  --output-dir <dir>      Directory for --split-by documents
+  --manifest-report <file> Write the manifests each analyzer consumed as JSON
+  --group-by <field>      Split markdown output into sections by supplier or license
+  --vex <file>            Record vulnerability triage states from a PURL-keyed VEX file
+  --provenance-map <file> Link components to build provenance from a PURL to URL mapping
+  --identity-map <file>   Merge components whose PURLs a PURL to PURL mapping links
+  --license-override <file> Set licenses from a PURL-glob to SPDX mapping (recorded as annotations)
+  --preferred-hash <alg>  Emit only this hash algorithm when a component has several, e.g. sha256
+  --omit-weak-hashes      Leave MD5 and SHA-1 hashes out of the output
+  --enrich                Fill in licenses and links from the npm and PyPI registries
+  --enrich-concurrency <n> Maximum concurrent registry requests (default: 4)
+  --enrich-rate <n>       Maximum registry requests per second, 0 for no limit (default: 10)
+  --source-links          Derive missing source URLs (Go on GitHub, ...) and registry pages (npm, PyPI, ...) from PURLs offline
+  --cpe-dict <file>       Set CPEs from a PURL to vendor:product dictionary over the bundled one ("builtin": bundled only)
+  --osv                   Record known vulnerabilities from OSV.dev batch queries
+  --batch-size <n>        PURLs per OSV batch request, sent up to --enrich-concurrency at a time (default: 1000)
+  --outdated              List components behind their latest release (needs --enrich or --latest-versions)
+  --latest-versions <file> JSON object of component name to latest version, for --outdated
+  --changed-since <ref>   Only analyze manifests changed since a git revision, e.g. origin/main
+  --template <file>       Render the SBOM through a Go text/template (overrides -f)
+  --source-hash           Record a SHA-256 of the project's source files on the root component
+  --no-root-component     Leave out the project's own component and its relationships
+  --purl-type <eco=type>  Use another package-url type for an ecosystem, e.g. deb=alpine (repeatable)
+  --exclude-package <glob> Drop components whose name or PURL matches (repeatable)
+  --scopes <list>         Keep only these scopes: runtime, dev, build, test, optional, runtime-toolchain
+  --top-level-only        Keep only the direct dependencies the project declares
+  --deny-license <id>     Fail (exit 2) if a component is only available under this license (repeatable)
+  --require-lockfile      Fail (exit 2) if an npm, Cargo or Python manifest has no lockfile pinning its ranges
+  --flag-git-deps <mode>  Warn about, or fail (exit 2) on, dependencies installed from git: warn, fail
+  --fail-on-missing-license Fail (exit 2) if a component has no known license
+  --fail-on-empty         Fail (exit 2) if no components are found
+  --expect-min-components <n> Fail (exit 2) if fewer than n components are found
+  --license-allowlist <file> Globs (one per line) of components exempt from --fail-on-missing-license
+  --baseline <file>       Fail (exit 2) if components are missing from this approved SBOM
+  --update-baseline       Rewrite the baseline file with the generated SBOM
+  --verify-integrity      Fail (exit 2) if installed or cached npm packages do not match the lockfile
+  --github-annotations    Print policy violations to stderr as GitHub Actions annotations
+  --max-files <n>         Abort if the project has more than n files (default: 1000000, 0: no limit)
+  --max-components-per-file <n> Keep at most n components per manifest and annotate the truncation (default: 100000, 0: no limit)
+  --analyzers <list>      Only run these analyzers, e.g. go,maven
+  --skip-analyzers <list> Run every analyzer except these, e.g. npm
+  --cpuprofile <file>     Write a CPU profile of the run to file
+  --memprofile <file>     Write a heap profile at the end of the run to file
+
+Defaults for 'gen' are read from the nearest .sbomgen.yaml in the project
+directory or its parents; explicit flags take precedence. --profile <name>
+layers the named entry of its profiles section over the top-level settings.
+
+Options for 'analyze':
+  -d, --dir <dir>         Project directory (default: current directory)
+  --json                  Print the component list as JSON instead of a table
+  --tree                  Print the depends_on graph as a tree rooted at the project
+  --wide                  Print full names and PURLs instead of truncating columns
+  --license-conflicts     Report potentially incompatible license combinations after the table
+  --license-conflicts     Report potentially incompatible license combinations
+  --max-files <n>         Abort if the project has more than n files (default: 1000000, 0: no limit)
+  --analyzers <list>      Only run these analyzers, e.g. go,maven
+  --skip-analyzers <list> Run every analyzer except these, e.g. npm
+  --github-annotations    Print conflicts to stderr as GitHub Actions annotations
+
+Options for 'doctor':
+  -d, --dir <dir>         Project directory, also accepted as an argument (default: current directory)
+  --analyzers <list>      Only check these analyzers, e.g. go,maven
+  --skip-analyzers <list> Check every analyzer except these, e.g. npm
+
+Options for 'validate':
+  --schema <schema>       Schema to validate against: cyclonedx, spdx
+
+Options for 'convert':
+  --from <format>         Input format: json, yaml, jsonl (default: detect from file)
+  --to <format>           Output format, as for 'gen -f', or jsonl (required)
+  -o, --output <file>     Output file (default: stdout)
+
+Options for 'normalize':
+  -f, --format <format>   Output format, as for 'gen -f' (default: json)
+  --merge-strategy <s>    How duplicates combine license, metadata and hashes:
+                          first-wins, last-wins, union (default: union)
+  -o, --output <file>     Output file (default: stdout)
+
+Options for 'decrypt':
+  --passphrase-env <var>  Environment variable holding the passphrase (required)
+  -o, --output <file>     Output file (default: stdout)
+
+Options for 'upload':
+  --url <url>             Base URL of the Dependency-Track server (required)
+  --project <name>        Project to upload to, created if missing (required)
+  --project-version <v>   Version of the project
+  --api-key-env <var>     Environment variable holding the API key (default: DTRACK_API_KEY)
+  --upload-retries <n>    Retry network errors, 429 and 5xx responses n times with backoff (default: 3)
+
 Examples:
   %s gen -o sbom.json -f json ./myproject
   %s gen --format markdown --dir ./myapp
   %s analyze ./myproject
+  %s doctor ./myproject
+  %s validate --schema cyclonedx sbom.json
+  %s convert --to yaml -o sbom.yaml sbom.json
 
 For more information, visit: https://github.com/hallucinaut/sbomgen
-`, appName, appName, appName, appName, appName)
+`, appName, appName, formatter.FormatList(), appName, appName, appName, appName, appName, appName)
 	return nil
 }
 
-func generate(args []string) error {
-	var outputFile, outputFormat, projectDir string
-	
+// genOptions holds the parsed flags of the gen command.
+type genOptions struct {
+	outputFile     string
+	outputFormat   string
+	projectDir     string
+	baselineFile   string
+	updateBaseline bool
+	compact        bool
+	cpuProfile     string
+	memProfile     string
+	ghAnnotations  bool
+	excludes       []string
+	maxFiles       int
+	sourceHash     bool
+	integrity      bool
+	templateFile   string
+	groupBy        formatter.GroupBy
+	denyLicenses   []string
+	analyzers      []string
+	skipAnalyzers  []string
+	vexFile        string
+	preferredHash  string
+	omitWeakHashes bool
+	enrich         bool
+	enrichWorkers  int
+	enrichRate     float64
+	sourceLinks    bool
+	cpeDictFile    string
+	osv            bool
+	osvBatchSize   int
+	changedSince   string
+	overridesFile  string
+	minimize       bool
+	stripMetadata  bool
+	requireLicense bool
+	flagGitDeps    string
+	allowlistFile  string
+	specVersion    string
+	binaryFile     string
+	archiveFile    string
+	venvDir        string
+	outputMode     os.FileMode
+	purlTypes      map[string]string
+	manifestReport string
+	scopes         []sbom.Scope
+	topLevelOnly   bool
+	outdated       bool
+	latestFile     string
+	noRoot         bool
+	provenanceFile string
+	identityFile   string
+	namespaceBase  string
+	componentsOnly bool
+	splitBy        string
+	sortBy         sbom.SortKey
+	outputDir      string
+	failOnEmpty    bool
+	minComponents  int
+	dryRun         bool
+	verbose        bool
+	requireLock    bool
+	monorepo       bool
+	maxPerFile     int
+	deterministic  bool
+	encrypt        bool
+	passphraseEnv  string
+}
+
+func parseGenArgs(args []string) (genOptions, error) {
+	opts := genOptions{
+		maxFiles:      analyzer.DefaultMaxFiles,
+		maxPerFile:    analyzer.DefaultMaxComponentsPerFile,
+		enrichWorkers: 4,
+		enrichRate:    10,
+		osvBatchSize:  enrich.DefaultOSVBatchSize,
+	}
+
+	if format := genLogFormatArg(args); format != "" {
+		l, err := newLogger(format, os.Stdout, os.Stderr)
+		if err != nil {
+			return opts, fmt.Errorf("--log-format: %w", err)
+		}
+		logs = l
+	}
+
+	dir, err := config.ExpandPath(genDirArg(args))
+	if err != nil {
+		return opts, fmt.Errorf("--dir: %w", err)
+	}
+	cfg, cfgPath, err := config.Find(dir)
+	if err != nil {
+		return opts, err
+	}
+	if profile := genProfileArg(args); profile != "" {
+		if cfg == nil {
+			return opts, fmt.Errorf("--profile %s: no %s found in %s or its parents", profile, config.FileName, dir)
+		}
+		if cfg, err = cfg.WithProfile(profile); err != nil {
+			return opts, fmt.Errorf("%s: %w", cfgPath, err)
+		}
+		logs.Status(fmt.Sprintf("Using config %s (profile %s)", cfgPath, profile), "path", cfgPath, "profile", profile)
+		applyConfig(&opts, cfg)
+	} else if cfg != nil {
+		logs.Status(fmt.Sprintf("Using config %s", cfgPath), "path", cfgPath)
+		applyConfig(&opts, cfg)
+	}
+
 	for i := 0; i < len(args); i++ {
 		switch args[i] {
 		case "-o", "--output":
 			if i+1 < len(args) {
-				outputFile = args[i+1]
+				if opts.outputFile, err = config.ExpandPath(args[i+1]); err != nil {
+					return opts, fmt.Errorf("--output: %w", err)
+				}
+				i++
+			}
+		case "--output-dir":
+			if i+1 < len(args) {
+				if opts.outputDir, err = config.ExpandPath(args[i+1]); err != nil {
+					return opts, fmt.Errorf("--output-dir: %w", err)
+				}
+				i++
+			}
+		case "--flag-git-deps":
+			if i+1 < len(args) {
+				if args[i+1] != "warn" && args[i+1] != "fail" {
+					return opts, fmt.Errorf("unknown --flag-git-deps mode %q (supported: warn, fail)", args[i+1])
+				}
+				opts.flagGitDeps = args[i+1]
+				i++
+			}
+		case "--sort-by":
+			if i+1 < len(args) {
+				sortBy, err := sbom.ParseSortKey(args[i+1])
+				if err != nil {
+					return opts, fmt.Errorf("--sort-by: %w", err)
+				}
+				opts.sortBy = sortBy
+				i++
+			}
+		case "--split-by":
+			if i+1 < len(args) {
+				if args[i+1] != "supplier" && args[i+1] != "root" {
+					return opts, fmt.Errorf("unknown --split-by mode %q (supported: %s)", args[i+1], strings.Join(splitModes, ", "))
+				}
+				opts.splitBy = args[i+1]
+				i++
+			}
+		case "--output-mode":
+			if i+1 < len(args) {
+				if opts.outputMode, err = parseFileMode(args[i+1]); err != nil {
+					return opts, err
+				}
 				i++
 			}
 		case "-f", "--format":
 			if i+1 < len(args) {
-				outputFormat = args[i+1]
+				opts.outputFormat = args[i+1]
 				i++
 			}
 		case "-d", "--dir":
 			if i+1 < len(args) {
-				projectDir = args[i+1]
+				if opts.projectDir, err = config.ExpandPath(args[i+1]); err != nil {
+					return opts, fmt.Errorf("--dir: %w", err)
+				}
+				i++
+			}
+		case "--group-by":
+			if i+1 < len(args) {
+				groupBy, err := formatter.ParseGroupBy(args[i+1])
+				if err != nil {
+					return opts, err
+				}
+				opts.groupBy = groupBy
+				i++
+			}
+		case "--analyzers":
+			if i+1 < len(args) {
+				opts.analyzers = splitList(args[i+1])
+				i++
+			}
+		case "--skip-analyzers":
+			if i+1 < len(args) {
+				opts.skipAnalyzers = splitList(args[i+1])
+				i++
+			}
+		case "--vex":
+			if i+1 < len(args) {
+				opts.vexFile = args[i+1]
+				i++
+			}
+		case "--manifest-report":
+			if i+1 < len(args) {
+				opts.manifestReport = args[i+1]
+				i++
+			}
+		case "--provenance-map":
+			if i+1 < len(args) {
+				opts.provenanceFile = args[i+1]
+				i++
+			}
+		case "--components-only":
+			opts.componentsOnly = true
+		case "--document-namespace-base":
+			if i+1 < len(args) {
+				if err := formatter.ValidateDocumentNamespaceBase(args[i+1]); err != nil {
+					return opts, fmt.Errorf("invalid --document-namespace-base: %w", err)
+				}
+				opts.namespaceBase = args[i+1]
+				i++
+			}
+		case "--identity-map":
+			if i+1 < len(args) {
+				opts.identityFile = args[i+1]
+				i++
+			}
+		case "--license-override":
+			if i+1 < len(args) {
+				opts.overridesFile = args[i+1]
+				i++
+			}
+		case "--preferred-hash":
+			if i+1 < len(args) {
+				if sbom.CanonicalHashAlgorithm(args[i+1]) == "" {
+					return opts, fmt.Errorf("unknown hash algorithm: %s", args[i+1])
+				}
+				opts.preferredHash = args[i+1]
+				i++
+			}
+		case "--omit-weak-hashes":
+			opts.omitWeakHashes = true
+		case "--enrich":
+			opts.enrich = true
+		case "--source-links":
+			opts.sourceLinks = true
+		case "--cpe-dict":
+			if i+1 < len(args) {
+				opts.cpeDictFile = args[i+1]
+				i++
+			}
+		case "--osv":
+			opts.osv = true
+		case "--batch-size":
+			if i+1 < len(args) {
+				n, err := strconv.Atoi(args[i+1])
+				if err != nil || n < 1 || n > enrich.DefaultOSVBatchSize {
+					return opts, fmt.Errorf("invalid --batch-size value %q: must be between 1 and %d", args[i+1], enrich.DefaultOSVBatchSize)
+				}
+				opts.osvBatchSize = n
+				i++
+			}
+		case "--encrypt":
+			opts.encrypt = true
+		case "--passphrase-env":
+			if i+1 < len(args) {
+				opts.passphraseEnv = args[i+1]
+				i++
+			}
+		case "--verbose":
+			opts.verbose = true
+		case "--dry-run":
+			opts.dryRun = true
+		case "--require-lockfile":
+			opts.requireLock = true
+		case "--monorepo":
+			opts.monorepo = true
+		case "--deterministic":
+			opts.deterministic = true
+		case "--fail-on-empty":
+			opts.failOnEmpty = true
+		case "--expect-min-components":
+			if i+1 < len(args) {
+				n, err := parseLimit("--expect-min-components", args[i+1])
+				if err != nil {
+					return opts, err
+				}
+				opts.minComponents = n
+				i++
+			}
+		case "--no-root-component":
+			opts.noRoot = true
+		case "--outdated":
+			opts.outdated = true
+		case "--latest-versions":
+			if i+1 < len(args) {
+				opts.latestFile = args[i+1]
+				opts.outdated = true
+				i++
+			}
+		case "--enrich-concurrency":
+			if i+1 < len(args) {
+				n, err := strconv.Atoi(args[i+1])
+				if err != nil || n < 1 {
+					return opts, fmt.Errorf("invalid --enrich-concurrency value %q: must be a positive integer", args[i+1])
+				}
+				opts.enrichWorkers = n
+				i++
+			}
+		case "--enrich-rate":
+			if i+1 < len(args) {
+				rate, err := strconv.ParseFloat(args[i+1], 64)
+				if err != nil || rate < 0 {
+					return opts, fmt.Errorf("invalid --enrich-rate value %q: must be a non-negative number", args[i+1])
+				}
+				opts.enrichRate = rate
+				i++
+			}
+		case "--changed-since":
+			if i+1 < len(args) {
+				opts.changedSince = args[i+1]
+				i++
+			}
+		case "--deny-license":
+			if i+1 < len(args) {
+				opts.denyLicenses = append(opts.denyLicenses, args[i+1])
+				i++
+			}
+		case "--fail-on-missing-license":
+			opts.requireLicense = true
+		case "--license-allowlist":
+			if i+1 < len(args) {
+				opts.allowlistFile = args[i+1]
+				i++
+			}
+		case "--template":
+			if i+1 < len(args) {
+				opts.templateFile = args[i+1]
+				i++
+			}
+		case "--baseline":
+			if i+1 < len(args) {
+				opts.baselineFile = args[i+1]
+				i++
+			}
+		case "--update-baseline":
+			opts.updateBaseline = true
+		case "--compact":
+			opts.compact = true
+		case "--minimize":
+			opts.minimize = true
+		case "--strip-metadata":
+			opts.stripMetadata = true
+		case "--purl-type":
+			if i+1 < len(args) {
+				ecosystem, purlType, ok := strings.Cut(args[i+1], "=")
+				if !ok || ecosystem == "" || purlType == "" {
+					return opts, fmt.Errorf("--purl-type expects <ecosystem>=<type>, got %q", args[i+1])
+				}
+				if opts.purlTypes == nil {
+					opts.purlTypes = make(map[string]string)
+				}
+				opts.purlTypes[ecosystem] = purlType
+				i++
+			}
+		case "--binary":
+			if i+1 < len(args) {
+				opts.binaryFile = args[i+1]
+				i++
+			}
+		case "--venv":
+			if i+1 < len(args) {
+				if opts.venvDir, err = config.ExpandPath(args[i+1]); err != nil {
+					return opts, fmt.Errorf("--venv: %w", err)
+				}
+				i++
+			}
+		case "--archive":
+			if i+1 < len(args) {
+				if opts.archiveFile, err = config.ExpandPath(args[i+1]); err != nil {
+					return opts, fmt.Errorf("--archive: %w", err)
+				}
+				i++
+			}
+		case "--spec-version":
+			if i+1 < len(args) {
+				opts.specVersion = args[i+1]
+				i++
+			}
+		case "--source-hash":
+			opts.sourceHash = true
+		case "--verify-integrity":
+			opts.integrity = true
+		case "--github-annotations":
+			opts.ghAnnotations = true
+		case "--exclude-package":
+			if i+1 < len(args) {
+				if _, err := path.Match(args[i+1], ""); err != nil {
+					return opts, fmt.Errorf("invalid --exclude-package pattern %q: %w", args[i+1], err)
+				}
+				opts.excludes = append(opts.excludes, args[i+1])
+				i++
+			}
+		case "--scopes":
+			if i+1 < len(args) {
+				scopes, err := sbom.ParseScopes(args[i+1])
+				if err != nil {
+					return opts, fmt.Errorf("invalid --scopes: %w", err)
+				}
+				opts.scopes = scopes
+				i++
+			}
+		case "--top-level-only":
+			opts.topLevelOnly = true
+		case "--max-files":
+			if i+1 < len(args) {
+				n, err := parseLimit("--max-files", args[i+1])
+				if err != nil {
+					return opts, err
+				}
+				opts.maxFiles = n
 				i++
 			}
+		case "--max-components-per-file":
+			if i+1 < len(args) {
+				n, err := parseLimit("--max-components-per-file", args[i+1])
+				if err != nil {
+					return opts, err
+				}
+				opts.maxPerFile = n
+				i++
+			}
+		case "--cpuprofile":
+			if i+1 < len(args) {
+				opts.cpuProfile = args[i+1]
+				i++
+			}
+		case "--memprofile":
+			if i+1 < len(args) {
+				opts.memProfile = args[i+1]
+				i++
+			}
+		case "--profile", "--log-format":
+			// Applied before the config file is read, above.
+			i++
 		}
 	}
 
-	if projectDir == "" {
-		projectDir = "."
+	if opts.projectDir == "" {
+		opts.projectDir = "."
 	}
-	
-	absDir, err := filepath.Abs(projectDir)
-	if err != nil {
-		return fmt.Errorf("failed to resolve directory path: %w", err)
+	if opts.outputFormat == "" || opts.outputFormat == string(formatter.Auto) {
+		opts.outputFormat = string(formatter.FormatForPath(opts.outputFile))
 	}
-	
-	projectType := analyzer.DetectProjectType(absDir)
-	fmt.Printf("Detected project type: %s\n", projectType)
-	
-	gen := sbom.New(appName, version, "sbom-001")
-	
-	analyzer := analyzer.NewProjectAnalyzer()
-	components, err := analyzer.AnalyzeDir(absDir)
-	if err != nil {
-		return fmt.Errorf("failed to analyze directory: %w", err)
+	if opts.templateFile == "" {
+		if _, err := formatter.ParseFormat(opts.outputFormat); err != nil {
+			return opts, err
+		}
 	}
-	
-	fmt.Printf("Found %d components\n", len(components))
-	
-	for _, comp := range components {
-		gen.AddComponent(comp)
+	if opts.groupBy == "" && cfg != nil && opts.outputFormat == string(formatter.Markdown) {
+		opts.groupBy = formatter.GroupBy(cfg.GroupBy)
+	}
+	if opts.groupBy != "" && opts.outputFormat != string(formatter.Markdown) {
+		return opts, fmt.Errorf("--group-by is only supported for markdown output")
+	}
+	if opts.updateBaseline && opts.baselineFile == "" {
+		return opts, fmt.Errorf("--update-baseline requires --baseline <file>")
+	}
+	if opts.binaryFile != "" && (opts.changedSince != "" || opts.sourceHash) {
+		return opts, fmt.Errorf("--binary cannot be combined with --changed-since or --source-hash")
+	}
+	if opts.archiveFile != "" && (opts.binaryFile != "" || opts.changedSince != "" || opts.sourceHash) {
+		return opts, fmt.Errorf("--archive cannot be combined with --binary, --changed-since or --source-hash")
+	}
+	if opts.encrypt && (opts.outputFile == "" || opts.passphraseEnv == "") {
+		return opts, fmt.Errorf("--encrypt requires -o <file> and --passphrase-env <var>")
+	}
+	if (opts.splitBy == "") != (opts.outputDir == "") {
+		return opts, fmt.Errorf("--split-by and --output-dir must be given together")
+	}
+	if opts.splitBy != "" && (opts.outputFile != "" || opts.encrypt || opts.dryRun) {
+		return opts, fmt.Errorf("--split-by cannot be combined with -o, --encrypt or --dry-run")
+	}
+	if opts.noRoot && opts.sourceHash {
+		return opts, fmt.Errorf("--no-root-component cannot be combined with --source-hash")
+	}
+	if opts.outdated && opts.latestFile == "" && !opts.enrich {
+		return opts, fmt.Errorf("--outdated requires --enrich or --latest-versions <file>")
+	}
+	if opts.specVersion != "" {
+		if _, err := formatter.NewVersionedFormatter(formatter.Format(opts.outputFormat), opts.specVersion); err != nil {
+			return opts, fmt.Errorf("--spec-version: %w", err)
+		}
 	}
-	
-	var instance formatter.Formatter
-	if outputFormat == "" {
-		outputFormat = "json"
+
+	return opts, nil
+}
+
+// analyzeBinary reads the components of a compiled executable and records
+// the executable itself as the root of gen.
+func analyzeBinary(file string, gen *sbom.SBOM) (*analyzer.Result, error) {
+	binaryAnalyzer := analyzer.NewBinaryAnalyzer()
+	components, err := binaryAnalyzer.Analyze(file)
+	if err != nil {
+		return nil, err
 	}
-	instance = formatter.GetFormatter(formatter.Format(outputFormat))
-	
-	output, err := instance.Format(gen)
+	root, err := binaryAnalyzer.AnalyzeRoot(file)
 	if err != nil {
-		return fmt.Errorf("failed to format output: %w", err)
+		return nil, err
 	}
-	
-	if outputFile != "" {
-		err = os.WriteFile(outputFile, []byte(output), 0644)
+	gen.SetRoot(*root)
+	return &analyzer.Result{
+		Components: components,
+		Scanned: []analyzer.ManifestRecord{{
+			Analyzer:       binaryAnalyzer.Name(),
+			Path:           filepath.ToSlash(file),
+			ComponentCount: len(components),
+		}},
+	}, nil
+}
+
+// latestVersions returns the latest version of each component, read from
+// opts.latestFile or, without one, looked up in the npm and PyPI registries.
+func latestVersions(opts genOptions, gen *sbom.SBOM) (map[string]string, error) {
+	if opts.latestFile != "" {
+		data, err := os.ReadFile(opts.latestFile)
 		if err != nil {
-			return fmt.Errorf("failed to write output file: %w", err)
+			return nil, fmt.Errorf("failed to read latest versions: %w", err)
 		}
-		fmt.Printf("SBOM written to %s\n", outputFile)
-	} else {
-		fmt.Println(output)
+		var latest map[string]string
+		if err := json.Unmarshal(data, &latest); err != nil {
+			return nil, fmt.Errorf("failed to parse latest versions: %w", err)
+		}
+		return latest, nil
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	lookup := enrich.NewLatestVersions(enrich.NewHTTPFetcher())
+	err := enrich.Enrich(ctx, gen.Components, lookup, enrich.Options{
+		Concurrency: opts.enrichWorkers,
+		Rate:        opts.enrichRate,
+	})
+	if errors.Is(err, context.Canceled) {
+		return nil, fmt.Errorf("latest version lookup interrupted")
+	}
+	if err != nil {
+		logs.Warn("some latest versions could not be looked up", "error", err)
+	}
+	return lookup.Versions(), nil
+}
+
+// writeManifestReport writes the manifests consumed during an analysis to
+// file as a JSON array.
+func writeManifestReport(file string, records []analyzer.ManifestRecord) error {
+	if records == nil {
+		records = []analyzer.ManifestRecord{}
+	}
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest report: %w", err)
+	}
+	if err := os.WriteFile(file, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write manifest report: %w", err)
 	}
-	
 	return nil
 }
 
-func analyze(args []string) error {
-	var projectDir string
-	
-	for i := 0; i < len(args); i++ {
-		if args[i] != "-d" && args[i] != "--dir" {
-			continue
+// genProfileArg returns the value of --profile in args, or "" if none is
+// given.
+func genProfileArg(args []string) string {
+	for i := 0; i+1 < len(args); i++ {
+		if args[i] == "--profile" {
+			return args[i+1]
 		}
-		if i+1 < len(args) {
-			projectDir = args[i+1]
-			i++
+	}
+	return ""
+}
+
+// genLogFormatArg returns the --log-format value in args, so that logging
+// is set up before the configuration file is reported.
+func genLogFormatArg(args []string) string {
+	for i := 0; i+1 < len(args); i++ {
+		if args[i] == "--log-format" {
+			return args[i+1]
 		}
 	}
+	return ""
+}
 
-	if projectDir == "" {
-		projectDir = "."
+// genDirArg returns the project directory named by args, so that the
+// configuration file can be located before the remaining flags are parsed.
+func genDirArg(args []string) string {
+	for i := 0; i+1 < len(args); i++ {
+		if args[i] == "-d" || args[i] == "--dir" {
+			return args[i+1]
+		}
 	}
-	
-	absDir, err := filepath.Abs(projectDir)
+	return "."
+}
+
+// applyConfig copies configuration defaults into opts. Flags parsed
+// afterwards override them; list settings such as excludes accumulate.
+// group_by is applied separately once the final output format is known.
+func applyConfig(opts *genOptions, cfg *config.Config) {
+	opts.outputFormat = cfg.Format
+	opts.outputFile = cfg.Output
+	opts.excludes = append(opts.excludes, cfg.Exclude...)
+	if cfg.MaxFiles != nil {
+		opts.maxFiles = *cfg.MaxFiles
+	}
+	opts.denyLicenses = append(opts.denyLicenses, cfg.LicensePolicy.Deny...)
+	if cfg.Enrich != nil {
+		opts.enrich = *cfg.Enrich
+	}
+	if cfg.EnrichConcurrency != nil {
+		opts.enrichWorkers = *cfg.EnrichConcurrency
+	}
+	if cfg.EnrichRate != nil {
+		opts.enrichRate = *cfg.EnrichRate
+	}
+	if cfg.OSV != nil {
+		opts.osv = *cfg.OSV
+	}
+	if cfg.OSVBatchSize != nil {
+		opts.osvBatchSize = *cfg.OSVBatchSize
+	}
+	for ecosystem, purlType := range cfg.PURLTypes {
+		if opts.purlTypes == nil {
+			opts.purlTypes = make(map[string]string)
+		}
+		opts.purlTypes[ecosystem] = purlType
+	}
+}
+
+func generate(args []string) (err error) {
+	opts, err := parseGenArgs(args)
+	if err != nil {
+		return err
+	}
+
+	stopProfiling, err := startProfiling(opts.cpuProfile, opts.memProfile)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if stopErr := stopProfiling(); stopErr != nil && err == nil {
+			err = stopErr
+		}
+	}()
+
+	absDir, err := filepath.Abs(opts.projectDir)
 	if err != nil {
 		return fmt.Errorf("failed to resolve directory path: %w", err)
 	}
-	
-	projectType := analyzer.DetectProjectType(absDir)
-	fmt.Printf("Project: %s\n", absDir)
-	fmt.Printf("Type: %s\n", projectType)
-	
-	analyzer := analyzer.NewProjectAnalyzer()
-	components, err := analyzer.AnalyzeDir(absDir)
+
+	purlTypes, err := sbom.NewPURLTypes(opts.purlTypes)
 	if err != nil {
-		return fmt.Errorf("failed to analyze directory: %w", err)
+		return err
 	}
-	
-	fmt.Printf("\nFound %d components:\n\n", len(components))
-	fmt.Printf("%-30s %-20s %-15s %-12s\n", "NAME", "VERSION", "SUPPLIER", "PURL")
-	fmt.Println(strings.Repeat("-", 80))
-	
-	for _, comp := range components {
-		purl := comp.PURL
-		if len(purl) > 12 {
-			purl = purl[:9] + "..."
+
+	switch {
+	case opts.binaryFile != "":
+		logs.Info(fmt.Sprintf("Analyzing binary: %s", opts.binaryFile), "path", opts.binaryFile)
+	case opts.archiveFile != "":
+		logs.Info(fmt.Sprintf("Analyzing archive: %s", opts.archiveFile), "path", opts.archiveFile)
+	default:
+		projectType := analyzer.DetectProjectType(absDir)
+		logs.Info(fmt.Sprintf("Detected project type: %s", projectType), "path", absDir, "type", projectType)
+	}
+
+	gen := sbom.New(appName, version, sbom.NewSerialNumber())
+	gen.ToolVersion = version
+
+	var sourceDigest string
+	if opts.sourceHash {
+		var exclude []string
+		if opts.outputFile != "" {
+			exclude = append(exclude, opts.outputFile)
+		}
+		sourceDigest, err = analyzer.SourceHash(absDir, exclude...)
+		if err != nil {
+			return fmt.Errorf("failed to hash source tree: %w", err)
 		}
-		fmt.Printf("%-30s %-20s %-15s %-12s\n", 
-			truncate(comp.Name, 30), 
-			truncate(comp.Version, 20), 
-			truncate(comp.Supplier, 15),
-			truncate(purl, 12))
 	}
-	
-	return nil
-}
 
-func truncate(s string, max int) string {
-	if len(s) <= max {
-		return s
+	projectAnalyzer := analyzer.NewProjectAnalyzer()
+	projectAnalyzer.MaxFiles = opts.maxFiles
+	projectAnalyzer.RequireLockfile = opts.requireLock
+	projectAnalyzer.MaxComponentsPerFile = opts.maxPerFile
+	projectAnalyzer.Monorepo = opts.monorepo
+	if err := projectAnalyzer.FilterAnalyzers(opts.analyzers, opts.skipAnalyzers); err != nil {
+		return err
 	}
-	return s[:max-3] + "..."
-}
\ No newline at end of file
+	var result *analyzer.Result
+	switch {
+	case opts.binaryFile != "":
+		if result, err = analyzeBinary(opts.binaryFile, gen); err != nil {
+			return fmt.Errorf("failed to analyze binary: %w", err)
+		}
+	case opts.archiveFile != "":
+		var root *sbom.Component
+		if result, root, err = projectAnalyzer.AnalyzeArchive(opts.archiveFile); root != nil {
+			gen.SetRoot(*root)
+		}
+	case opts.changedSince != "":
+		result, err = projectAnalyzer.AnalyzeChanged(absDir, opts.changedSince, analyzer.NewGitChangeLister())
+	default:
+		result, err = projectAnalyzer.AnalyzeProject(absDir)
+	}
+	if errors.Is(err, analyzer.ErrNoLockfile) {
+		return &exitError{code: exitPolicy, err: fmt.Errorf("--require-lockfile: %w", err)}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to analyze directory: %w", err)
+	}
+	if opts.venvDir != "" {
+		venv, err := analyzer.NewVenvAnalyzer().AnalyzeResult(opts.venvDir)
+		if err != nil {
+			return fmt.Errorf("failed to analyze virtualenv: %w", err)
+		}
+		result.Supersede(venv)
+	}
+
+	logs.Info(fmt.Sprintf("Found %d components", len(result.Components)), "count", len(result.Components))
+	annotateTruncated(gen, result.Scanned)
+	reportIncomplete(result, opts.verbose)
+	if len(result.Components) == 0 {
+		logs.Warn("no dependencies detected — is this the right directory?", "path", absDir)
+	}
+	if opts.manifestReport != "" && !opts.dryRun {
+		if err := writeManifestReport(opts.manifestReport, result.Scanned); err != nil {
+			return err
+		}
+	}
+
+	if opts.binaryFile == "" && opts.archiveFile == "" {
+		if root := projectAnalyzer.DetectRoot(absDir); root != nil {
+			gen.SetRoot(*root)
+		}
+	}
+
+	if sourceDigest != "" {
+		root := sbom.Component{Name: filepath.Base(absDir)}
+		if gen.Root != nil {
+			root = *gen.Root
+		}
+		root.Hashes = append(root.Hashes, sbom.Hash{Algorithm: "SHA-256", Value: sourceDigest})
+		gen.SetRoot(root)
+	}
+
+	for _, comp := range result.Components {
+		gen.AddComponent(comp)
+	}
+	for _, rel := range result.Relationships {
+		if err := gen.AddRelationship(rel.RefA, rel.RefB, rel.Relationship); err != nil {
+			logs.Warn(fmt.Sprintf("skipping relationship: %v", err), "from", rel.RefA, "to", rel.RefB)
+		}
+	}
+	gen.RetypePURLs(purlTypes)
+	if opts.noRoot {
+		gen.DropRoot()
+	}
+	// Filter before --enrich so that excluded components, internal ones
+	// included, are never looked up in public registries.
+	for _, pattern := range opts.excludes {
+		if n := gen.Remove(pattern); n > 0 {
+			logs.Info(fmt.Sprintf("Excluded %d component(s) matching %s", n, pattern), "count", n, "pattern", pattern)
+		}
+	}
+	if opts.scopes != nil {
+		if n := gen.KeepScopes(opts.scopes); n > 0 {
+			logs.Info(fmt.Sprintf("Excluded %d component(s) outside scopes %v", n, opts.scopes), "count", n, "scopes", opts.scopes)
+		}
+	}
+	if opts.topLevelOnly {
+		if n := gen.KeepDirect(); n > 0 {
+			logs.Info(fmt.Sprintf("Excluded %d transitive component(s)", n), "count", n)
+		}
+	}
+	if opts.enrich {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		err := enrich.Enrich(ctx, gen.Components, enrich.NewRegistryEnricher(enrich.NewHTTPFetcher()), enrich.Options{
+			Concurrency: opts.enrichWorkers,
+			Rate:        opts.enrichRate,
+		})
+		stop()
+		if errors.Is(err, context.Canceled) {
+			return fmt.Errorf("enrichment interrupted")
+		}
+		if err != nil {
+			logs.Warn("some components could not be enriched", "error", err)
+		}
+		if n := countDeprecated(gen.Components); n > 0 {
+			logs.Info(fmt.Sprintf("Found %d deprecated or yanked component version(s)", n), "count", n)
+		}
+	}
+	if opts.sourceLinks {
+		// Runs after --enrich so that registry metadata takes precedence.
+		linker := enrich.NewSourceLinker()
+		linked := 0
+		for i := range gen.Components {
+			if linker.Link(&gen.Components[i]) {
+				linked++
+			}
+		}
+		logs.Info(fmt.Sprintf("Linked sources or registry pages for %d component(s)", linked), "count", linked)
+	}
+	if opts.cpeDictFile != "" {
+		dict := enrich.DefaultCPEDictionary()
+		if opts.cpeDictFile != "builtin" {
+			if dict, err = enrich.LoadCPEDictionary(opts.cpeDictFile); err != nil {
+				return err
+			}
+		}
+		resolved := 0
+		for i := range gen.Components {
+			comp := &gen.Components[i]
+			if comp.CPE != "" {
+				continue
+			}
+			if cpe := dict.Resolve(comp.PURL, comp.Version); cpe != "" {
+				comp.CPE = cpe
+				resolved++
+			}
+		}
+		logs.Info(fmt.Sprintf("Resolved CPEs for %d component(s)", resolved), "count", resolved)
+	}
+	if opts.overridesFile != "" {
+		overrides, err := sbom.LoadLicenseOverrides(opts.overridesFile)
+		if err != nil {
+			return err
+		}
+		n := gen.ApplyLicenseOverrides(overrides, opts.overridesFile)
+		logs.Info(fmt.Sprintf("Overrode %d license(s)", n), "count", n, "path", opts.overridesFile)
+	}
+
+	if opts.osv {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		vulns, err := enrich.QueryOSV(ctx, enrich.NewHTTPFetcher(), gen.Components, enrich.OSVOptions{
+			BatchSize:   opts.osvBatchSize,
+			Concurrency: opts.enrichWorkers,
+		})
+		stop()
+		if errors.Is(err, context.Canceled) {
+			return fmt.Errorf("vulnerability lookup interrupted")
+		}
+		if err != nil {
+			logs.Warn("some OSV batches failed", "error", err)
+		}
+		gen.Vulnerabilities = append(gen.Vulnerabilities, vulns...)
+		logs.Info(fmt.Sprintf("Found %d known vulnerabilities in OSV", len(vulns)), "count", len(vulns))
+	}
+
+	if opts.vexFile != "" {
+		vex, err := sbom.LoadVEX(opts.vexFile)
+		if err != nil {
+			return err
+		}
+		n := gen.ApplyVEX(vex)
+		logs.Info(fmt.Sprintf("Applied %d VEX statement(s)", n), "count", n, "path", opts.vexFile)
+	}
+
+	if opts.provenanceFile != "" {
+		provenance, err := sbom.LoadProvenanceMap(opts.provenanceFile)
+		if err != nil {
+			return err
+		}
+		n := gen.ApplyProvenance(provenance)
+		logs.Info(fmt.Sprintf("Linked provenance for %d component(s)", n), "count", n, "path", opts.provenanceFile)
+	}
+
+	if opts.identityFile != "" {
+		identities, err := sbom.LoadIdentityMap(opts.identityFile)
+		if err != nil {
+			return err
+		}
+		n := gen.LinkIdentities(identities)
+		logs.Info(fmt.Sprintf("Linked %d component identities", n), "count", n, "path", opts.identityFile)
+	}
+
+	if opts.outdated {
+		latest, err := latestVersions(opts, gen)
+		if err != nil {
+			return err
+		}
+		for _, o := range gen.OutdatedAgainst(latest) {
+			logs.Info(fmt.Sprintf("Outdated: %s %s (latest %s)", o.Name, o.Current, o.Latest),
+				"component", o.Name, "version", o.Current, "latest", o.Latest)
+		}
+	}
+
+	for _, comp := range gen.WeakHashes() {
+		logs.Warn(fmt.Sprintf("%s only has MD5/SHA-1 hashes", componentLabel(comp)), "component", componentLabel(comp))
+		if opts.ghAnnotations {
+			printAnnotation(componentAnnotation("warning", comp,
+				fmt.Sprintf("%s is only identified by weak MD5/SHA-1 hashes", componentLabel(comp))))
+		}
+	}
+
+	// Mismatches are recorded in the SBOM before it is written, and fail
+	// the run once it has been.
+	var mismatches []analyzer.IntegrityMismatch
+	if opts.integrity {
+		if mismatches, err = verifyIntegrity(gen, absDir, npmCacheDir()); err != nil {
+			return err
+		}
+	}
+
+	if opts.deterministic {
+		created, err := sourceDateEpoch()
+		if err != nil {
+			return err
+		}
+		gen.MakeDeterministic(created)
+	}
+
+	if opts.sortBy != "" {
+		// After --deterministic, which sorts by name, so the requested
+		// order wins.
+		if err := gen.SortComponentsBy(opts.sortBy); err != nil {
+			return err
+		}
+	}
+
+	instance := formatter.GetFormatter(formatter.Format(opts.outputFormat))
+	if opts.specVersion != "" {
+		if instance, err = formatter.NewVersionedFormatter(formatter.Format(opts.outputFormat), opts.specVersion); err != nil {
+			return err
+		}
+	}
+	switch spdx := instance.(type) {
+	case *formatter.SPDXFormatter:
+		spdx.DocumentNamespaceBase = opts.namespaceBase
+		spdx.OmitRelationships = opts.componentsOnly
+	case *formatter.SPDXJSONFormatter:
+		spdx.DocumentNamespaceBase = opts.namespaceBase
+		spdx.OmitRelationships = opts.componentsOnly
+	}
+	if opts.compact && instance.Name() == string(formatter.JSON) {
+		instance = formatter.NewCompactJSONFormatter()
+	}
+	if opts.groupBy != "" {
+		instance = formatter.NewGroupedMarkdownFormatter(opts.groupBy)
+	}
+	if opts.templateFile != "" {
+		text, err := os.ReadFile(opts.templateFile)
+		if err != nil {
+			return fmt.Errorf("failed to read template: %w", err)
+		}
+		instance, err = formatter.NewTemplateFormatter(filepath.Base(opts.templateFile), string(text))
+		if err != nil {
+			return err
+		}
+	}
+
+	if opts.stripMetadata {
+		gen.StripMetadata()
+	}
+
+	doc := gen
+	if opts.preferredHash != "" || opts.omitWeakHashes {
+		doc = formatter.WithHashPolicy(gen, opts.preferredHash, opts.omitWeakHashes)
+	}
+	if opts.minimize {
+		doc = formatter.MinimizeFor(doc, formatter.Format(opts.outputFormat))
+	}
+	if opts.componentsOnly {
+		doc = formatter.ComponentsOnly(doc)
+	}
+
+	// Streaming formatters write straight to the destination unless the
+	// whole output is needed, to measure or encrypt it.
+	streamer, stream := instance.(formatter.StreamFormatter)
+	stream = stream && !opts.dryRun && !opts.encrypt
+	var output string
+	if !stream && opts.splitBy == "" {
+		if output, err = instance.Format(doc); err != nil {
+			return fmt.Errorf("failed to format output: %w", err)
+		}
+	}
+
+	if opts.splitBy != "" {
+		n, err := writeSplit(instance, doc, opts, absDir)
+		if err != nil {
+			return err
+		}
+		logs.Info(fmt.Sprintf("%d SBOM(s) written to %s", n, opts.outputDir), "count", n, "path", opts.outputDir)
+	} else if stream {
+		if err := streamOutput(streamer, doc, opts.outputFile, opts.outputMode); err != nil {
+			return err
+		}
+		if opts.outputFile != "" {
+			logs.Info(fmt.Sprintf("SBOM written to %s", opts.outputFile), "path", opts.outputFile)
+		}
+	} else if opts.dryRun {
+		// Check the passphrase is available, but skip the slow key
+		// derivation.
+		if opts.encrypt {
+			if _, err := passphraseFromEnv(opts.passphraseEnv); err != nil {
+				return err
+			}
+		}
+		printDryRun(opts, instance.Name(), len(gen.Components), output)
+	} else if opts.outputFile != "" {
+		data := []byte(output)
+		if opts.encrypt {
+			passphrase, err := passphraseFromEnv(opts.passphraseEnv)
+			if err != nil {
+				return err
+			}
+			if data, err = encrypt.Encrypt(data, passphrase); err != nil {
+				return fmt.Errorf("failed to encrypt output: %w", err)
+			}
+		}
+		if err := writeOutputFile(opts.outputFile, data, opts.outputMode); err != nil {
+			return err
+		}
+		logs.Info(fmt.Sprintf("SBOM written to %s", opts.outputFile), "path", opts.outputFile)
+	} else {
+		fmt.Println(output)
+	}
+
+	if opts.failOnEmpty && len(result.Components) == 0 {
+		return &exitError{code: exitPolicy, err: fmt.Errorf("no components found")}
+	}
+	if found := len(result.Components); found < opts.minComponents {
+		return &exitError{code: exitPolicy, err: fmt.Errorf("found %d components, expected at least %d", found, opts.minComponents)}
+	}
+
+	if len(opts.denyLicenses) > 0 {
+		if err := checkLicensePolicy(gen, opts.denyLicenses, opts.ghAnnotations); err != nil {
+			return err
+		}
+	}
+
+	if opts.flagGitDeps != "" {
+		if err := checkGitDependencies(gen, opts.flagGitDeps == "fail", opts.ghAnnotations); err != nil {
+			return err
+		}
+	}
+
+	if opts.requireLicense {
+		var allow []string
+		if opts.allowlistFile != "" {
+			if allow, err = loadPatterns(opts.allowlistFile); err != nil {
+				return err
+			}
+		}
+		if err := checkMissingLicenses(gen, allow, opts.ghAnnotations); err != nil {
+			return err
+		}
+	}
+
+	if len(mismatches) > 0 {
+		if err := checkIntegrity(mismatches, absDir, opts.ghAnnotations); err != nil {
+			return err
+		}
+	}
+
+	// A dry run reports the baseline it would have rewritten instead.
+	if opts.baselineFile != "" && !(opts.updateBaseline && opts.dryRun) {
+		return checkBaseline(gen, opts.baselineFile, opts.updateBaseline, opts.ghAnnotations)
+	}
+
+	return nil
+}
+
+// checkBaseline fails with exitPolicy when gen introduces components that
+// are absent from the approved baseline, or rewrites the baseline when
+// update is set.
+func checkBaseline(gen *sbom.SBOM, baselineFile string, update, annotate bool) error {
+	if update {
+		output, err := formatter.NewJSONFormatter().Format(gen)
+		if err != nil {
+			return fmt.Errorf("failed to format baseline: %w", err)
+		}
+		if err := os.WriteFile(baselineFile, []byte(output), 0644); err != nil {
+			return fmt.Errorf("failed to write baseline: %w", err)
+		}
+		logs.Status(fmt.Sprintf("Baseline updated: %s", baselineFile), "baseline", baselineFile)
+		return nil
+	}
+
+	baseline, err := sbom.LoadFile(baselineFile)
+	if err != nil {
+		return fmt.Errorf("failed to load baseline: %w", err)
+	}
+
+	diff := sbom.Diff(baseline, gen)
+	if !diff.HasAdditions() {
+		return nil
+	}
+
+	labels := make([]string, len(diff.Added))
+	for i, comp := range diff.Added {
+		labels[i] = componentLabel(comp)
+		if annotate {
+			printAnnotation(componentAnnotation("error", comp,
+				fmt.Sprintf("%s is not in the approved baseline %s", componentLabel(comp), baselineFile)))
+		}
+	}
+	logs.Warn(fmt.Sprintf("New dependencies not in baseline %s", baselineFile), "baseline", baselineFile, "components", labels)
+	return &exitError{
+		code: exitPolicy,
+		err:  fmt.Errorf("%d component(s) not present in baseline", len(diff.Added)),
+	}
+}
+
+// checkLicensePolicy fails with exitPolicy when a component can only be
+// used under denied licenses. A component with an OR expression passes as
+// long as one of its alternatives is allowed.
+func checkLicensePolicy(gen *sbom.SBOM, deny []string, annotate bool) error {
+	denied := make(map[string]bool)
+	for _, license := range deny {
+		denied[license] = true
+	}
+
+	var violations []sbom.Component
+	for _, comp := range gen.Components {
+		terms := sbom.LicenseDisjuncts(comp.License)
+		allowed := len(terms) == 0
+		for _, term := range terms {
+			if !denied[term] {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			violations = append(violations, comp)
+		}
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+
+	labels := make([]string, len(violations))
+	for i, comp := range violations {
+		labels[i] = fmt.Sprintf("%s (%s)", componentLabel(comp), comp.License)
+		if annotate {
+			printAnnotation(componentAnnotation("error", comp,
+				fmt.Sprintf("%s is licensed under denied license %s", componentLabel(comp), comp.License)))
+		}
+	}
+	logs.Warn("Components with denied licenses", "components", labels)
+	return &exitError{
+		code: exitPolicy,
+		err:  fmt.Errorf("%d component(s) use denied licenses", len(violations)),
+	}
+}
+
+// checkMissingLicenses fails with exitPolicy when a component has no known
+// license, unless its name or PURL matches one of the allow globs.
+func checkMissingLicenses(gen *sbom.SBOM, allow []string, annotate bool) error {
+	var violations []sbom.Component
+	for _, comp := range gen.MissingLicenses() {
+		if !matchesAny(allow, comp) {
+			violations = append(violations, comp)
+		}
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+
+	labels := make([]string, len(violations))
+	for i, comp := range violations {
+		labels[i] = componentLabel(comp)
+		if annotate {
+			printAnnotation(componentAnnotation("error", comp,
+				fmt.Sprintf("%s has no known license", componentLabel(comp))))
+		}
+	}
+	logs.Warn("Components without a license", "components", labels)
+	return &exitError{
+		code: exitPolicy,
+		err:  fmt.Errorf("%d component(s) have no license", len(violations)),
+	}
+}
+
+// checkGitDependencies reports the components installed from git rather
+// than at an immutable registry version, failing with exitPolicy if fail is
+// set and only warning otherwise.
+func checkGitDependencies(gen *sbom.SBOM, fail, annotate bool) error {
+	deps := gen.GitDependencies()
+	if len(deps) == 0 {
+		return nil
+	}
+
+	level := "warning"
+	if fail {
+		level = "error"
+	}
+	labels := make([]string, len(deps))
+	for i, comp := range deps {
+		labels[i] = componentLabel(comp)
+		if annotate {
+			printAnnotation(componentAnnotation(level, comp,
+				fmt.Sprintf("%s is installed from git rather than a registry release", labels[i])))
+		}
+	}
+	logs.Warn(fmt.Sprintf("%d component(s) are installed from git", len(deps)), "components", labels)
+	if !fail {
+		return nil
+	}
+	return &exitError{
+		code: exitPolicy,
+		err:  fmt.Errorf("%d component(s) are installed from git", len(deps)),
+	}
+}
+
+func matchesAny(patterns []string, comp sbom.Component) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, comp.Name); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, comp.PURL); ok && comp.PURL != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// loadPatterns reads a file of globs, one per line, ignoring blank lines and
+// lines starting with #.
+func loadPatterns(file string) ([]string, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", file, err)
+	}
+
+	var patterns []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if _, err := path.Match(line, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q in %s: %w", line, file, err)
+		}
+		patterns = append(patterns, line)
+	}
+	return patterns, nil
+}
+
+// verifyIntegrity checks the integrity hashes in the npm or Yarn lockfile of
+// projectDir against the packages installed in its node_modules and the
+// tarballs in cacheDir, and records each mismatch as an annotation on the
+// affected component of gen.
+func verifyIntegrity(gen *sbom.SBOM, projectDir, cacheDir string) ([]analyzer.IntegrityMismatch, error) {
+	mismatches, err := analyzer.VerifyIntegrity(os.DirFS(projectDir), os.DirFS(cacheDir))
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify integrity: %w", err)
+	}
+
+	for _, m := range mismatches {
+		ref := sbom.PURL("npm", m.Package, m.Version)
+		for _, comp := range gen.Components {
+			if comp.Name == m.Package && comp.Version == m.Version && comp.PURL != "" {
+				ref = comp.PURL
+				break
+			}
+		}
+		gen.Annotations = append(gen.Annotations, sbom.Annotation{
+			ComponentRef: ref,
+			EventType:    "integrity_mismatch",
+			Time:         time.Now().UTC(),
+			Summary:      integritySummary(m),
+		})
+	}
+	return mismatches, nil
+}
+
+// integritySummary describes a mismatch in a sentence.
+func integritySummary(m analyzer.IntegrityMismatch) string {
+	return fmt.Sprintf("%s@%s in %s does not match the integrity recorded in %s: expected %s, got %s",
+		m.Package, m.Version, m.Source, m.Lockfile, m.Expected, m.Actual)
+}
+
+// checkIntegrity reports the mismatches found by verifyIntegrity and fails
+// with exitPolicy.
+func checkIntegrity(mismatches []analyzer.IntegrityMismatch, projectDir string, annotate bool) error {
+	summaries := make([]string, len(mismatches))
+	for i, m := range mismatches {
+		summaries[i] = integritySummary(m)
+		if annotate {
+			printAnnotation(githubAnnotation("error", annotationPath(filepath.Join(projectDir, m.Lockfile)), 0,
+				fmt.Sprintf("%s@%s in %s does not match its recorded integrity", m.Package, m.Version, m.Source)))
+		}
+	}
+	logs.Warn(fmt.Sprintf("%d package(s) failed integrity verification", len(mismatches)), "mismatches", summaries)
+	return &exitError{
+		code: exitPolicy,
+		err:  fmt.Errorf("%d package(s) failed integrity verification", len(mismatches)),
+	}
+}
+
+// npmCacheDir returns the npm cache directory, honouring npm_config_cache.
+func npmCacheDir() string {
+	if dir := os.Getenv("npm_config_cache"); dir != "" {
+		return dir
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".npm"
+	}
+	return filepath.Join(home, ".npm")
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseFileMode parses an --output-mode value, octal permissions such as
+// 0600 or 640. The owner must keep read and write access so that later
+// runs can overwrite the file.
+func parseFileMode(value string) (os.FileMode, error) {
+	mode, err := strconv.ParseUint(value, 8, 32)
+	if err != nil || mode > 0777 {
+		return 0, fmt.Errorf("invalid --output-mode value %q: must be octal permissions up to 0777", value)
+	}
+	if mode&0600 != 0600 {
+		return 0, fmt.Errorf("invalid --output-mode value %q: the owner needs read and write access", value)
+	}
+	return os.FileMode(mode), nil
+}
+
+// writeOutputFile writes data to file. Without an explicit mode the file
+// is created 0644, subject to the umask. An explicit mode is applied
+// exactly, overriding both the umask and the permissions of an existing
+// file.
+func writeOutputFile(file string, data []byte, mode os.FileMode) error {
+	perm := mode
+	if perm == 0 {
+		perm = 0644
+	}
+	if err := os.WriteFile(file, data, perm); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	if mode != 0 {
+		if err := os.Chmod(file, mode); err != nil {
+			return fmt.Errorf("failed to set output file permissions: %w", err)
+		}
+	}
+	return nil
+}
+
+// streamOutput writes doc through f to file, created as writeOutputFile
+// does, or to stdout if file is empty.
+func streamOutput(f formatter.StreamFormatter, doc *sbom.SBOM, file string, mode os.FileMode) error {
+	if file == "" {
+		if err := f.FormatTo(os.Stdout, doc); err != nil {
+			return fmt.Errorf("failed to format output: %w", err)
+		}
+		return nil
+	}
+
+	perm := mode
+	if perm == 0 {
+		perm = 0644
+	}
+	out, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	err = f.FormatTo(out, doc)
+	if closeErr := out.Close(); err == nil && closeErr != nil {
+		return fmt.Errorf("failed to write output file: %w", closeErr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to format output: %w", err)
+	}
+	if mode != 0 {
+		if err := os.Chmod(file, mode); err != nil {
+			return fmt.Errorf("failed to set output file permissions: %w", err)
+		}
+	}
+	return nil
+}
+
+// reportIncomplete warns when paths could not be read or manifests could
+// not be analyzed, listing each one if verbose is set.
+func reportIncomplete(result *analyzer.Result, verbose bool) {
+	err := result.Incomplete()
+	if err == nil {
+		return
+	}
+	problems := err.(interface{ Unwrap() []error }).Unwrap()
+	msg := fmt.Sprintf("%d path(s) could not be read or analyzed, so the SBOM may be incomplete", len(problems))
+	if !verbose {
+		logs.Warn(msg+" (use --verbose to list them)", "count", len(problems))
+		return
+	}
+	paths := make([]string, len(problems))
+	for i, problem := range problems {
+		paths[i] = problem.Error()
+	}
+	logs.Warn(msg, "count", len(problems), "problems", paths)
+}
+
+// annotateTruncated warns about every manifest whose components were cut
+// off by --max-components-per-file and records the truncation as an SBOM
+// annotation, so that consumers know the document is incomplete.
+func annotateTruncated(doc *sbom.SBOM, records []analyzer.ManifestRecord) {
+	for _, record := range records {
+		if record.Truncated == 0 {
+			continue
+		}
+		summary := fmt.Sprintf("%s analyzer result for %s truncated to %d components (%d dropped)",
+			record.Analyzer, record.Path, record.ComponentCount, record.Truncated)
+		logs.Warn(summary+"; raise --max-components-per-file if this manifest is genuine",
+			"analyzer", record.Analyzer, "path", record.Path, "dropped", record.Truncated)
+		doc.Annotations = append(doc.Annotations, sbom.Annotation{
+			EventType: "truncated",
+			Time:      time.Now().UTC(),
+			Summary:   summary,
+		})
+	}
+}
+
+// parseLimit parses the value of a limit flag such as --max-files. Zero
+// disables the limit.
+func parseLimit(flag, value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid %s value %q: must be a non-negative integer", flag, value)
+	}
+	return n, nil
+}
+
+// componentLabel returns the PURL of comp, or name@version without one.
+func componentLabel(comp sbom.Component) string {
+	if comp.PURL != "" {
+		return comp.PURL
+	}
+	return comp.Name + "@" + comp.Version
+}
+
+func analyze(args []string) error {
+	var projectDir string
+	var licenseConflicts, ghAnnotations, jsonOutput, tree, wide, monorepo bool
+	var allow, skip []string
+	maxFiles := analyzer.DefaultMaxFiles
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "-d", "--dir":
+			if i+1 < len(args) {
+				projectDir = args[i+1]
+				i++
+			}
+		case "--license-conflicts":
+			licenseConflicts = true
+		case "--json":
+			jsonOutput = true
+		case "--tree":
+			tree = true
+		case "--wide":
+			wide = true
+		case "--monorepo":
+			monorepo = true
+		case "--analyzers":
+			if i+1 < len(args) {
+				allow = splitList(args[i+1])
+				i++
+			}
+		case "--skip-analyzers":
+			if i+1 < len(args) {
+				skip = splitList(args[i+1])
+				i++
+			}
+		case "--max-files":
+			if i+1 < len(args) {
+				n, err := parseLimit("--max-files", args[i+1])
+				if err != nil {
+					return err
+				}
+				maxFiles = n
+				i++
+			}
+		case "--github-annotations":
+			ghAnnotations = true
+		}
+	}
+
+	if licenseConflicts && (jsonOutput || tree) {
+		return fmt.Errorf("--license-conflicts cannot be combined with --json or --tree")
+	}
+	if projectDir == "" {
+		projectDir = "."
+	}
+
+	absDir, err := filepath.Abs(projectDir)
+	if err != nil {
+		return fmt.Errorf("failed to resolve directory path: %w", err)
+	}
+
+	projectType := analyzer.DetectProjectType(absDir)
+
+	analyzer := analyzer.NewProjectAnalyzer()
+	analyzer.MaxFiles = maxFiles
+	analyzer.Monorepo = monorepo
+	if err := analyzer.FilterAnalyzers(allow, skip); err != nil {
+		return err
+	}
+	result, err := analyzer.AnalyzeProject(absDir)
+	if err != nil {
+		return fmt.Errorf("failed to analyze directory: %w", err)
+	}
+	components := result.Components
+
+	if jsonOutput {
+		return writeComponentsJSON(os.Stdout, components)
+	}
+
+	if tree {
+		graph := sbom.New(filepath.Base(absDir), "", "")
+		if root := analyzer.DetectRoot(absDir); root != nil {
+			graph.SetRoot(*root)
+		}
+		graph.Components = components
+		graph.Relationships = result.Relationships
+		return graph.WriteTree(os.Stdout)
+	}
+
+	fmt.Printf("Project: %s\n", absDir)
+	fmt.Printf("Type: %s\n", projectType)
+
+	fmt.Printf("\nFound %d components:\n\n", len(components))
+	writeComponentTable(os.Stdout, components, wide)
+
+	if licenseConflicts {
+		doc := sbom.New(appName, version, "sbom-001")
+		for _, comp := range components {
+			doc.AddComponent(comp)
+		}
+		printLicenseConflicts(doc.LicenseCompatibility(), ghAnnotations)
+	}
+
+	return nil
+}
+
+// writeComponentTable writes components to w as a fixed-width table. Values
+// are truncated to fit an 80 column terminal unless wide is set, in which
+// case each column grows to fit its longest value.
+func writeComponentTable(w io.Writer, components []sbom.Component, wide bool) {
+	header := []string{"NAME", "VERSION", "SUPPLIER", "PURL"}
+	widths := []int{30, 20, 15, 12}
+	rows := make([][]string, len(components))
+	for i, comp := range components {
+		rows[i] = []string{comp.Name, comp.Version, comp.Supplier, comp.PURL}
+	}
+
+	if wide {
+		for i, title := range header {
+			widths[i] = len(title)
+		}
+		for _, row := range rows {
+			for i, value := range row {
+				widths[i] = max(widths[i], len(value))
+			}
+		}
+	}
+
+	writeRow := func(values []string) {
+		fmt.Fprintf(w, "%-*s %-*s %-*s %-*s\n",
+			widths[0], values[0], widths[1], values[1], widths[2], values[2], widths[3], values[3])
+	}
+
+	writeRow(header)
+	ruler := 80
+	if wide {
+		ruler = widths[0] + widths[1] + widths[2] + widths[3] + 3
+	}
+	fmt.Fprintln(w, strings.Repeat("-", ruler))
+	for _, row := range rows {
+		if !wide {
+			for i := range row {
+				row[i] = truncate(row[i], widths[i])
+			}
+		}
+		writeRow(row)
+	}
+}
+
+// writeComponentsJSON writes components to w as an indented JSON array using
+// the same component structure as the JSON formatter.
+func writeComponentsJSON(w io.Writer, components []sbom.Component) error {
+	if components == nil {
+		components = []sbom.Component{}
+	}
+	data, err := json.MarshalIndent(components, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal components: %w", err)
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+func printLicenseConflicts(conflicts []sbom.Conflict, annotate bool) {
+	fmt.Printf("\nLicense conflicts (advisory only, not legal advice):\n\n")
+	if len(conflicts) == 0 {
+		fmt.Println("No potential license conflicts found.")
+		return
+	}
+	for _, c := range conflicts {
+		fmt.Printf("  %s (%s) <-> %s (%s): %s\n",
+			c.A.Name, c.A.License, c.B.Name, c.B.License, c.Reason)
+		if annotate {
+			printAnnotation(componentAnnotation("warning", c.B,
+				fmt.Sprintf("%s (%s) may conflict with %s (%s): %s",
+					c.B.Name, c.B.License, c.A.Name, c.A.License, c.Reason)))
+		}
+	}
+}
+
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max-3] + "..."
+}
+
+func validate(args []string) error {
+	var schema, file string
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--schema":
+			if i+1 < len(args) {
+				schema = args[i+1]
+				i++
+			}
+		default:
+			file = args[i]
+		}
+	}
+
+	if schema == "" {
+		return fmt.Errorf("--schema is required (cyclonedx or spdx)")
+	}
+	if file == "" {
+		return fmt.Errorf("no SBOM file given")
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("failed to read SBOM file: %w", err)
+	}
+
+	violations, err := validator.Validate(validator.Schema(schema), data)
+	if err != nil {
+		return err
+	}
+	if len(violations) > 0 {
+		for _, v := range violations {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", file, v)
+		}
+		return fmt.Errorf("%s failed %s schema validation with %d violation(s)", file, schema, len(violations))
+	}
+
+	fmt.Printf("%s is valid %s\n", file, schema)
+	return nil
+}
+
+func convert(args []string) error {
+	var from, to, outputFile, inputFile string
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--from":
+			if i+1 < len(args) {
+				from = args[i+1]
+				i++
+			}
+		case "--to":
+			if i+1 < len(args) {
+				to = args[i+1]
+				i++
+			}
+		case "-o", "--output":
+			if i+1 < len(args) {
+				outputFile = args[i+1]
+				i++
+			}
+		default:
+			inputFile = args[i]
+		}
+	}
+
+	if to == "" {
+		return fmt.Errorf("--to is required")
+	}
+	if to != "jsonl" {
+		if _, err := formatter.ParseFormat(to); err != nil {
+			return err
+		}
+	}
+	if inputFile == "" {
+		return fmt.Errorf("no SBOM file given")
+	}
+
+	if from == "" && isJSONLFile(inputFile) {
+		from = "jsonl"
+	}
+	if from == "jsonl" {
+		return convertJSONL(inputFile, to, outputFile)
+	}
+
+	var doc *sbom.SBOM
+	var err error
+	switch from {
+	case "":
+		doc, err = sbom.LoadFile(inputFile)
+	case "json", "yaml":
+		var data []byte
+		data, err = os.ReadFile(inputFile)
+		if err != nil {
+			return fmt.Errorf("failed to read SBOM file: %w", err)
+		}
+		if from == "json" {
+			doc, err = sbom.LoadJSON(data)
+		} else {
+			doc, err = sbom.LoadYAML(data)
+		}
+	default:
+		return fmt.Errorf("unsupported input format: %s (supported: json, yaml, jsonl)", from)
+	}
+	if err != nil {
+		return err
+	}
+	doc.NormalizeRelationships()
+
+	if to == "jsonl" {
+		return writeConverted(outputFile, func(w io.Writer) error {
+			out := sbom.NewJSONLWriter(w)
+			for _, comp := range doc.Components {
+				if err := out.Write(comp); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+	return writeSBOM(doc, to, outputFile)
+}
+
+// convertJSONL converts a JSONL component stream. Converting to jsonl
+// passes components through one at a time; any other format needs the
+// whole document, so the components are collected first.
+func convertJSONL(inputFile, to, outputFile string) error {
+	f, err := os.Open(inputFile)
+	if err != nil {
+		return fmt.Errorf("failed to read SBOM file: %w", err)
+	}
+	defer f.Close()
+
+	if to == "jsonl" {
+		return writeConverted(outputFile, func(w io.Writer) error {
+			out := sbom.NewJSONLWriter(w)
+			return sbom.ReadJSONL(f, out.Write)
+		})
+	}
+
+	name := strings.TrimSuffix(filepath.Base(inputFile), filepath.Ext(inputFile))
+	doc := sbom.New(name, "", "")
+	err = sbom.ReadJSONL(f, func(comp sbom.Component) error {
+		doc.AddComponent(comp)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return writeSBOM(doc, to, outputFile)
+}
+
+// isJSONLFile reports whether path has a JSONL or NDJSON extension.
+func isJSONLFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jsonl", ".ndjson":
+		return true
+	}
+	return false
+}
+
+// writeSBOM formats doc as one of formatter.ValidFormats and writes it to
+// outputFile, or stdout if empty.
+func writeSBOM(doc *sbom.SBOM, to, outputFile string) error {
+	format, err := formatter.ParseFormat(to)
+	if err != nil {
+		return err
+	}
+	output, err := formatter.GetFormatter(format).Format(doc)
+	if err != nil {
+		return fmt.Errorf("failed to format output: %w", err)
+	}
+	return writeConverted(outputFile, func(w io.Writer) error {
+		_, err := fmt.Fprintln(w, output)
+		return err
+	})
+}
+
+// writeConverted runs write against outputFile through a buffer, or
+// against stdout if outputFile is empty.
+func writeConverted(outputFile string, write func(io.Writer) error) error {
+	if outputFile == "" {
+		w := bufio.NewWriter(os.Stdout)
+		if err := write(w); err != nil {
+			return err
+		}
+		return w.Flush()
+	}
+
+	f, err := os.Create(outputFile)
+	if err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	w := bufio.NewWriter(f)
+	if err := write(w); err != nil {
+		f.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "SBOM written to %s\n", outputFile)
+	return nil
+}
+
+// countDeprecated returns how many components are deprecated or yanked.
+func countDeprecated(components []sbom.Component) int {
+	n := 0
+	for _, comp := range components {
+		if comp.Metadata.Deprecated {
+			n++
+		}
+	}
+	return n
+}
+
+// normalize loads an SBOM, typically one produced by another tool, and
+// writes it back in canonical form.
+func normalize(args []string) error {
+	to, outputFile, inputFile := "json", "", ""
+	strategy := sbom.MergeUnion
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--merge-strategy":
+			if i+1 < len(args) {
+				var err error
+				if strategy, err = sbom.ParseMergeStrategy(args[i+1]); err != nil {
+					return err
+				}
+				i++
+			}
+		case "-f", "--format":
+			if i+1 < len(args) {
+				to = args[i+1]
+				i++
+			}
+		case "-o", "--output":
+			if i+1 < len(args) {
+				outputFile = args[i+1]
+				i++
+			}
+		default:
+			inputFile = args[i]
+		}
+	}
+
+	if _, err := formatter.ParseFormat(to); err != nil {
+		return err
+	}
+	if inputFile == "" {
+		return fmt.Errorf("no SBOM file given")
+	}
+	doc, err := sbom.LoadFile(inputFile)
+	if err != nil {
+		return err
+	}
+	before := len(doc.Components)
+	doc.NormalizeWith(strategy)
+
+	output, err := formatter.GetFormatter(formatter.Format(to)).Format(doc)
+	if err != nil {
+		return fmt.Errorf("failed to format output: %w", err)
+	}
+	if outputFile == "" {
+		fmt.Println(output)
+		return nil
+	}
+	if err := os.WriteFile(outputFile, []byte(output), 0644); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Normalized %d component(s) into %d, written to %s\n", before, len(doc.Components), outputFile)
+	return nil
+}
+
+// sourceDateEpoch returns the creation time for deterministic output: the
+// SOURCE_DATE_EPOCH environment variable used by reproducible builds, or
+// the Unix epoch when it is not set.
+func sourceDateEpoch() (time.Time, error) {
+	value := os.Getenv("SOURCE_DATE_EPOCH")
+	if value == "" {
+		return time.Unix(0, 0).UTC(), nil
+	}
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid SOURCE_DATE_EPOCH %q: must be a Unix timestamp", value)
+	}
+	return time.Unix(seconds, 0).UTC(), nil
+}
+
+// printDryRun reports what generate would have written.
+func printDryRun(opts genOptions, format string, components int, output string) {
+	target := opts.outputFile
+	if target == "" {
+		target = "stdout"
+	}
+	size := len(output)
+	if opts.encrypt {
+		size += encrypt.Overhead
+	}
+	logs.Status(fmt.Sprintf("Dry run: nothing was written; %d component(s) would be written as %s to %s (%d bytes)", components, format, target, size),
+		"format", format, "components", components, "target", target, "size", size)
+	if opts.manifestReport != "" {
+		logs.Status(fmt.Sprintf("Dry run: the manifest report would be written to %s", opts.manifestReport), "manifestReport", opts.manifestReport)
+	}
+	if opts.updateBaseline {
+		logs.Status(fmt.Sprintf("Dry run: the baseline %s would be updated", opts.baselineFile), "baseline", opts.baselineFile)
+	}
+}
+
+// passphraseFromEnv reads a passphrase from the environment variable name.
+func passphraseFromEnv(name string) ([]byte, error) {
+	passphrase := os.Getenv(name)
+	if passphrase == "" {
+		return nil, fmt.Errorf("environment variable %s holds no passphrase", name)
+	}
+	return []byte(passphrase), nil
+}
+
+func decrypt(args []string) error {
+	var outputFile, inputFile, passphraseEnv string
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--passphrase-env":
+			if i+1 < len(args) {
+				passphraseEnv = args[i+1]
+				i++
+			}
+		case "-o", "--output":
+			if i+1 < len(args) {
+				outputFile = args[i+1]
+				i++
+			}
+		default:
+			inputFile = args[i]
+		}
+	}
+
+	if passphraseEnv == "" {
+		return fmt.Errorf("--passphrase-env is required")
+	}
+	if inputFile == "" {
+		return fmt.Errorf("no SBOM file given")
+	}
+	passphrase, err := passphraseFromEnv(passphraseEnv)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(inputFile)
+	if err != nil {
+		return fmt.Errorf("failed to read SBOM file: %w", err)
+	}
+	plaintext, err := encrypt.Decrypt(data, passphrase)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt %s: %w", inputFile, err)
+	}
+
+	if outputFile == "" {
+		_, err = os.Stdout.Write(plaintext)
+		return err
+	}
+	if err := os.WriteFile(outputFile, plaintext, 0600); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "SBOM decrypted to %s\n", outputFile)
+	return nil
+}
+
+func uploadSBOM(args []string) error {
+	uploader := upload.NewDependencyTrack("", "")
+	apiKeyEnv := "DTRACK_API_KEY"
+	var inputFile string
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--url":
+			if i+1 < len(args) {
+				uploader.URL = strings.TrimSuffix(args[i+1], "/")
+				i++
+			}
+		case "--project":
+			if i+1 < len(args) {
+				uploader.Project = args[i+1]
+				i++
+			}
+		case "--project-version":
+			if i+1 < len(args) {
+				uploader.Version = args[i+1]
+				i++
+			}
+		case "--api-key-env":
+			if i+1 < len(args) {
+				apiKeyEnv = args[i+1]
+				i++
+			}
+		case "--upload-retries":
+			if i+1 < len(args) {
+				n, err := parseLimit("--upload-retries", args[i+1])
+				if err != nil {
+					return err
+				}
+				uploader.Retries = n
+				i++
+			}
+		default:
+			inputFile = args[i]
+		}
+	}
+
+	if uploader.URL == "" {
+		return fmt.Errorf("--url is required")
+	}
+	if uploader.Project == "" {
+		return fmt.Errorf("--project is required")
+	}
+	if inputFile == "" {
+		return fmt.Errorf("no SBOM file given")
+	}
+	uploader.APIKey = os.Getenv(apiKeyEnv)
+	if uploader.APIKey == "" {
+		return fmt.Errorf("environment variable %s holds no API key", apiKeyEnv)
+	}
+
+	inputFile, err := config.ExpandPath(inputFile)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(inputFile)
+	if err != nil {
+		return fmt.Errorf("failed to read SBOM file: %w", err)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	token, err := uploader.Upload(ctx, data)
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %w", inputFile, err)
+	}
+	fmt.Fprintf(os.Stderr, "SBOM uploaded to %s (token %s)\n", uploader.URL, token)
+	return nil
+}
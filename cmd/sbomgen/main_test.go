@@ -0,0 +1,1198 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/hallucinaut/sbomgen/pkg/analyzer"
+	"github.com/hallucinaut/sbomgen/pkg/encrypt"
+	"github.com/hallucinaut/sbomgen/pkg/formatter"
+	"github.com/hallucinaut/sbomgen/pkg/sbom"
+)
+
+func TestStartProfiling(t *testing.T) {
+	tmpDir := t.TempDir()
+	cpuFile := filepath.Join(tmpDir, "cpu.pprof")
+	memFile := filepath.Join(tmpDir, "mem.pprof")
+
+	stop, err := startProfiling(cpuFile, memFile)
+	if err != nil {
+		t.Fatalf("Failed to start profiling: %v", err)
+	}
+
+	total := 0
+	for i := 0; i < 1000000; i++ {
+		total += i % 7
+	}
+	_ = total
+
+	if err := stop(); err != nil {
+		t.Fatalf("Failed to stop profiling: %v", err)
+	}
+
+	for _, file := range []string{cpuFile, memFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			t.Fatalf("Expected profile %s to exist: %v", file, err)
+		}
+		if info.Size() == 0 {
+			t.Errorf("Expected profile %s to be non-empty", file)
+		}
+	}
+}
+
+func TestGenerate_ProfilesFlushOnError(t *testing.T) {
+	tmpDir := t.TempDir()
+	cpuFile := filepath.Join(tmpDir, "cpu.pprof")
+
+	err := generate([]string{"--cpuprofile", cpuFile, "-f", "markdown", "--baseline", filepath.Join(tmpDir, "missing.json"), "-o", filepath.Join(tmpDir, "out.md"), "-d", tmpDir})
+	if err == nil {
+		t.Fatal("Expected error for missing baseline")
+	}
+
+	info, err := os.Stat(cpuFile)
+	if err != nil {
+		t.Fatalf("Expected CPU profile despite error: %v", err)
+	}
+	if info.Size() == 0 {
+		t.Error("Expected CPU profile to be non-empty")
+	}
+}
+
+func TestGenerate_ManifestReport(t *testing.T) {
+	tmpDir := t.TempDir()
+	packageJSON := filepath.Join(tmpDir, "package.json")
+	if err := os.WriteFile(packageJSON, []byte(`{"dependencies": {"express": "^4.18.0", "lodash": "4.17.21"}}`), 0644); err != nil {
+		t.Fatalf("Failed to write package.json: %v", err)
+	}
+	reportFile := filepath.Join(tmpDir, "report.json")
+
+	if err := generate([]string{"--manifest-report", reportFile, "-o", filepath.Join(tmpDir, "sbom.json"), "-d", tmpDir}); err != nil {
+		t.Fatalf("Failed to generate: %v", err)
+	}
+
+	data, err := os.ReadFile(reportFile)
+	if err != nil {
+		t.Fatalf("Failed to read report: %v", err)
+	}
+	var records []analyzer.ManifestRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		t.Fatalf("Failed to parse report: %v", err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("Expected 1 record, got %+v", records)
+	}
+	want := analyzer.ManifestRecord{Analyzer: "npm", Path: filepath.ToSlash(packageJSON), ComponentCount: 2}
+	if records[0] != want {
+		t.Errorf("Expected %+v, got %+v", want, records[0])
+	}
+}
+
+func TestGenerate_NoRootComponent(t *testing.T) {
+	tmpDir := t.TempDir()
+	packageJSON := `{"name": "app", "version": "1.0.0", "dependencies": {"express": "^4.18.0"}}`
+	if err := os.WriteFile(filepath.Join(tmpDir, "package.json"), []byte(packageJSON), 0644); err != nil {
+		t.Fatalf("Failed to write package.json: %v", err)
+	}
+
+	for _, tt := range []struct {
+		name     string
+		args     []string
+		wantRoot bool
+	}{
+		{"default", nil, true},
+		{"no root", []string{"--no-root-component"}, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			outFile := filepath.Join(tmpDir, "sbom.json")
+			if err := generate(append([]string{"-o", outFile, "-d", tmpDir}, tt.args...)); err != nil {
+				t.Fatalf("Failed to generate: %v", err)
+			}
+			data, err := os.ReadFile(outFile)
+			if err != nil {
+				t.Fatalf("Failed to read SBOM: %v", err)
+			}
+			doc, err := sbom.LoadJSON(data)
+			if err != nil {
+				t.Fatalf("Failed to parse SBOM: %v", err)
+			}
+			if (doc.Root != nil) != tt.wantRoot {
+				t.Errorf("Expected root present=%v, got %+v", tt.wantRoot, doc.Root)
+			}
+			if len(doc.Components) != 1 || doc.Components[0].Name != "express" {
+				t.Errorf("Expected the express dependency to be kept, got %+v", doc.Components)
+			}
+		})
+	}
+}
+
+func TestGenerate_FailOnEmpty(t *testing.T) {
+	tmpDir := t.TempDir()
+	outFile := filepath.Join(tmpDir, "sbom.json")
+
+	if err := generate([]string{"-o", outFile, "-d", tmpDir}); err != nil {
+		t.Errorf("Expected an empty project to succeed by default, got %v", err)
+	}
+
+	err := generate([]string{"--fail-on-empty", "-o", outFile, "-d", tmpDir})
+	var exitErr *exitError
+	if !errors.As(err, &exitErr) || exitErr.code != exitPolicy {
+		t.Fatalf("Expected policy exit for an empty project, got %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "requirements.txt"), []byte("requests==2.28.0\n"), 0644); err != nil {
+		t.Fatalf("Failed to write requirements.txt: %v", err)
+	}
+	if err := generate([]string{"--fail-on-empty", "-o", outFile, "-d", tmpDir}); err != nil {
+		t.Errorf("Expected success once a dependency is found, got %v", err)
+	}
+}
+
+func TestGenerate_FlagGitDeps(t *testing.T) {
+	tmpDir := t.TempDir()
+	outFile := filepath.Join(tmpDir, "sbom.json")
+	pkg := `{"dependencies": {"express": "4.18.2", "left-pad": "github:org/left-pad#main"}}`
+	if err := os.WriteFile(filepath.Join(tmpDir, "package.json"), []byte(pkg), 0644); err != nil {
+		t.Fatalf("Failed to write package.json: %v", err)
+	}
+
+	if err := generate([]string{"--flag-git-deps", "warn", "-o", outFile, "-d", tmpDir}); err != nil {
+		t.Errorf("Expected warn mode to succeed, got %v", err)
+	}
+	err := generate([]string{"--flag-git-deps", "fail", "-o", outFile, "-d", tmpDir})
+	var exitErr *exitError
+	if !errors.As(err, &exitErr) || exitErr.code != exitPolicy {
+		t.Fatalf("Expected policy exit for a git dependency, got %v", err)
+	}
+	if err := generate([]string{"--flag-git-deps", "deny", "-o", outFile, "-d", tmpDir}); err == nil {
+		t.Error("Expected error for an unknown mode")
+	}
+}
+
+func TestGenerate_ExpectMinComponents(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "requirements.txt"), []byte("requests==2.28.0\nflask==2.3.0\n"), 0644); err != nil {
+		t.Fatalf("Failed to write requirements.txt: %v", err)
+	}
+	outFile := filepath.Join(tmpDir, "sbom.json")
+
+	err := generate([]string{"--expect-min-components", "3", "-o", outFile, "-d", tmpDir})
+	var exitErr *exitError
+	if !errors.As(err, &exitErr) || exitErr.code != exitPolicy {
+		t.Fatalf("Expected policy exit below the threshold, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "found 2 components, expected at least 3") {
+		t.Errorf("Expected the actual and expected counts, got %v", err)
+	}
+
+	for _, min := range []string{"2", "1"} {
+		if err := generate([]string{"--fail-on-empty", "--expect-min-components", min, "-o", outFile, "-d", tmpDir}); err != nil {
+			t.Errorf("Expected success with --expect-min-components %s, got %v", min, err)
+		}
+	}
+}
+
+func TestGenerate_Venv(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "requirements.txt"), []byte("requests>=2.28\n"), 0644); err != nil {
+		t.Fatalf("Failed to write requirements.txt: %v", err)
+	}
+	site := filepath.Join(tmpDir, ".venv", "lib", "python3.12", "site-packages")
+	for name, metadata := range map[string]string{
+		"requests-2.31.0.dist-info": "Name: requests\nVersion: 2.31.0\nRequires-Dist: idna<4,>=2.5\n",
+		"idna-3.6.dist-info":        "Name: idna\nVersion: 3.6\n",
+	} {
+		if err := os.MkdirAll(filepath.Join(site, name), 0755); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+		if err := os.WriteFile(filepath.Join(site, name, "METADATA"), []byte(metadata), 0644); err != nil {
+			t.Fatalf("Failed to write METADATA: %v", err)
+		}
+	}
+	outFile := filepath.Join(tmpDir, "sbom.json")
+
+	if err := generate([]string{"--venv", filepath.Join(tmpDir, ".venv"), "-o", outFile, "-d", tmpDir}); err != nil {
+		t.Fatalf("Failed to generate: %v", err)
+	}
+	doc, err := sbom.LoadFile(outFile)
+	if err != nil {
+		t.Fatalf("Failed to load SBOM: %v", err)
+	}
+	var purls []string
+	for _, comp := range doc.Components {
+		purls = append(purls, comp.PURL)
+	}
+	if !reflect.DeepEqual(purls, []string{"pkg:pypi/idna@3.6", "pkg:pypi/requests@2.31.0"}) {
+		t.Errorf("Expected the installed versions only, got %v", purls)
+	}
+	found := false
+	for _, rel := range doc.Relationships {
+		found = found || (rel.RefA == "pkg:pypi/requests@2.31.0" && rel.RefB == "pkg:pypi/idna@3.6")
+	}
+	if !found {
+		t.Errorf("Expected requests to depend on idna, got %+v", doc.Relationships)
+	}
+}
+
+func TestGenerate_OutputMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file permissions are not POSIX modes on Windows")
+	}
+	tmpDir := t.TempDir()
+	outFile := filepath.Join(tmpDir, "sbom.json")
+
+	for _, mode := range []os.FileMode{0600, 0664} {
+		if err := generate([]string{"--output-mode", fmt.Sprintf("%o", mode), "-o", outFile, "-d", tmpDir}); err != nil {
+			t.Fatalf("Failed to generate: %v", err)
+		}
+		info, err := os.Stat(outFile)
+		if err != nil {
+			t.Fatalf("Failed to stat output: %v", err)
+		}
+		if info.Mode().Perm() != mode {
+			t.Errorf("Expected mode %o, got %o", mode, info.Mode().Perm())
+		}
+	}
+
+	for _, value := range []string{"0999", "1777", "rw-r--r--", "0400"} {
+		if _, err := parseGenArgs([]string{"--output-mode", value}); err == nil {
+			t.Errorf("Expected --output-mode %s to be rejected", value)
+		}
+	}
+}
+
+func TestGenerate_DryRun(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "requirements.txt"), []byte("requests==2.28.0\n"), 0644); err != nil {
+		t.Fatalf("Failed to write requirements.txt: %v", err)
+	}
+	outFile := filepath.Join(tmpDir, "sbom.json")
+	reportFile := filepath.Join(tmpDir, "manifests.json")
+	baselineFile := filepath.Join(tmpDir, "baseline.json")
+
+	if err := generate([]string{"--dry-run", "-o", outFile, "--manifest-report", reportFile, "--baseline", baselineFile, "--update-baseline", "-d", tmpDir}); err != nil {
+		t.Fatalf("Dry run failed: %v", err)
+	}
+	for _, path := range []string{outFile, reportFile, baselineFile} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("Expected %s not to be written, got %v", filepath.Base(path), err)
+		}
+	}
+
+	t.Setenv("SBOMGEN_TEST_PASSPHRASE", "")
+	err := generate([]string{"--dry-run", "--encrypt", "--passphrase-env", "SBOMGEN_TEST_PASSPHRASE", "-o", outFile, "-d", tmpDir})
+	if err == nil {
+		t.Error("Expected a dry run to report a missing passphrase")
+	}
+}
+
+func TestGenerate_VerifyIntegrity(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("npm_config_cache", t.TempDir())
+	if err := os.WriteFile(filepath.Join(tmpDir, "package.json"), []byte(`{"dependencies": {"left-pad": "1.3.0"}}`), 0644); err != nil {
+		t.Fatalf("Failed to write package.json: %v", err)
+	}
+	outFile := filepath.Join(tmpDir, "sbom.json")
+
+	if err := generate([]string{"--verify-integrity", "-o", outFile, "-d", tmpDir}); err != nil {
+		t.Fatalf("Expected a project without a lockfile to pass, got %v", err)
+	}
+
+	yarnLock := "left-pad@1.3.0:\n  version \"1.3.0\"\n  integrity sha512-" + strings.Repeat("A", 86) + "==\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, "yarn.lock"), []byte(yarnLock), 0644); err != nil {
+		t.Fatalf("Failed to write yarn.lock: %v", err)
+	}
+	if err := generate([]string{"--verify-integrity", "-o", outFile, "-d", tmpDir}); err != nil {
+		t.Fatalf("Expected a yarn project with nothing installed to pass, got %v", err)
+	}
+
+	installed := `{"name": "left-pad", "version": "1.3.0", "_integrity": "sha512-` + strings.Repeat("B", 86) + `=="}`
+	if err := os.MkdirAll(filepath.Join(tmpDir, "node_modules", "left-pad"), 0755); err != nil {
+		t.Fatalf("Failed to create node_modules: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "node_modules", "left-pad", "package.json"), []byte(installed), 0644); err != nil {
+		t.Fatalf("Failed to write installed package.json: %v", err)
+	}
+	err := generate([]string{"--verify-integrity", "-o", outFile, "-d", tmpDir})
+	var exitErr *exitError
+	if !errors.As(err, &exitErr) || exitErr.code != exitPolicy {
+		t.Fatalf("Expected policy exit for a tampered install, got %v", err)
+	}
+	doc, err := sbom.LoadFile(outFile)
+	if err != nil {
+		t.Fatalf("Failed to load SBOM: %v", err)
+	}
+	if len(doc.Annotations) != 1 || doc.Annotations[0].EventType != "integrity_mismatch" ||
+		doc.Annotations[0].ComponentRef != "pkg:npm/left-pad@1.3.0" {
+		t.Errorf("Expected an integrity annotation on left-pad, got %+v", doc.Annotations)
+	}
+}
+
+func TestGenerate_RequireLockfile(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "package.json"), []byte(`{"dependencies": {"express": "^4.18.0"}}`), 0644); err != nil {
+		t.Fatalf("Failed to write package.json: %v", err)
+	}
+	outFile := filepath.Join(tmpDir, "sbom.json")
+
+	err := generate([]string{"--require-lockfile", "-o", outFile, "-d", tmpDir})
+	var exitErr *exitError
+	if !errors.As(err, &exitErr) || exitErr.code != exitPolicy {
+		t.Fatalf("Expected policy exit without a lockfile, got %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "package-lock.json"), []byte(`{"lockfileVersion": 3}`), 0644); err != nil {
+		t.Fatalf("Failed to write package-lock.json: %v", err)
+	}
+	if err := generate([]string{"--require-lockfile", "-o", outFile, "-d", tmpDir}); err != nil {
+		t.Errorf("Expected success with a lockfile, got %v", err)
+	}
+}
+
+func TestGenerate_MaxComponentsPerFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	var requirements strings.Builder
+	for i := 0; i < 50; i++ {
+		fmt.Fprintf(&requirements, "package-%d==1.0.0\n", i)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "requirements.txt"), []byte(requirements.String()), 0644); err != nil {
+		t.Fatalf("Failed to write requirements.txt: %v", err)
+	}
+	outFile := filepath.Join(tmpDir, "sbom.json")
+
+	if err := generate([]string{"--max-components-per-file", "10", "-o", outFile, "-d", tmpDir}); err != nil {
+		t.Fatalf("Failed to generate: %v", err)
+	}
+	doc, err := sbom.LoadFile(outFile)
+	if err != nil {
+		t.Fatalf("Failed to load SBOM: %v", err)
+	}
+	if len(doc.Components) != 10 {
+		t.Errorf("Expected 10 components, got %d", len(doc.Components))
+	}
+	if len(doc.Annotations) != 1 || doc.Annotations[0].EventType != "truncated" ||
+		!strings.Contains(doc.Annotations[0].Summary, "40 dropped") {
+		t.Errorf("Expected a truncation annotation, got %+v", doc.Annotations)
+	}
+
+	if _, err := parseGenArgs([]string{"--max-components-per-file", "-1"}); err == nil {
+		t.Error("Expected an error for a negative limit")
+	}
+}
+
+func TestParseGenArgs_InfersFormat(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{nil, "json"},
+		{[]string{"-o", "sbom.yaml"}, "yaml"},
+		{[]string{"-o", "sbom.yml"}, "yaml"},
+		{[]string{"-o", "sbom.md"}, "markdown"},
+		{[]string{"-o", "sbom.spdx"}, "spdx"},
+		{[]string{"-o", "sbom.cdx.json"}, "cyclonedx"},
+		{[]string{"-o", "sbom.json"}, "json"},
+		{[]string{"-o", "sbom.txt"}, "json"},
+		{[]string{"-f", "auto", "-o", "sbom.md"}, "markdown"},
+		{[]string{"-f", "json", "-o", "sbom.yaml"}, "json"},
+	}
+
+	for _, tt := range tests {
+		opts, err := parseGenArgs(append(tt.args, "-d", t.TempDir()))
+		if err != nil {
+			t.Fatalf("parseGenArgs(%v): %v", tt.args, err)
+		}
+		if opts.outputFormat != tt.expected {
+			t.Errorf("parseGenArgs(%v): expected format %s, got %s", tt.args, tt.expected, opts.outputFormat)
+		}
+	}
+
+	if _, err := parseGenArgs([]string{"-f", "pdf", "-d", t.TempDir()}); err == nil || !strings.Contains(err.Error(), "unknown format") {
+		t.Errorf("Expected an unknown format to be rejected, got %v", err)
+	}
+}
+
+func TestGenerate_CSVStreamsToFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "requirements.txt"), []byte("requests==2.28.0\nflask==2.2.0\n"), 0644); err != nil {
+		t.Fatalf("Failed to write requirements.txt: %v", err)
+	}
+	outFile := filepath.Join(tmpDir, "sbom.csv")
+	if err := generate([]string{"--output-mode", "0600", "-o", outFile, "-d", tmpDir}); err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+
+	data, err := os.ReadFile(outFile)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	if !strings.HasPrefix(string(data), "name,version,supplier,license,purl,cpe") {
+		t.Errorf("Expected CSV inferred from the .csv extension, got %q", data)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 3 {
+		t.Errorf("Expected a header and 2 rows, got %d lines", lines)
+	}
+	if runtime.GOOS != "windows" {
+		if info, err := os.Stat(outFile); err != nil || info.Mode().Perm() != 0600 {
+			t.Errorf("Expected the streamed file to get mode 0600, got %v, %v", info.Mode().Perm(), err)
+		}
+	}
+}
+
+func TestGenerate_SplitBySupplier(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "requirements.txt"), []byte("requests==2.28.0\nflask==2.2.0\n"), 0644); err != nil {
+		t.Fatalf("Failed to write requirements.txt: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "package.json"), []byte(`{"dependencies": {"express": "4.18.2"}}`), 0644); err != nil {
+		t.Fatalf("Failed to write package.json: %v", err)
+	}
+	outDir := filepath.Join(tmpDir, "sboms")
+	if err := generate([]string{"--split-by", "supplier", "--output-dir", outDir, "-f", "json", "-d", tmpDir}); err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+
+	entries, err := os.ReadDir(outDir)
+	if err != nil {
+		t.Fatalf("Failed to read output directory: %v", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if expected := []string{"index.json", "npm.json", "pypi.json"}; !reflect.DeepEqual(names, expected) {
+		t.Fatalf("Expected files %v, got %v", expected, names)
+	}
+
+	data, err := os.ReadFile(filepath.Join(outDir, "index.json"))
+	if err != nil {
+		t.Fatalf("Failed to read index: %v", err)
+	}
+	var index splitIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		t.Fatalf("Failed to parse index: %v", err)
+	}
+	if len(index.Documents) != 2 || index.Documents[1].Key != "pypi" || index.Documents[1].Components != 2 {
+		t.Errorf("Expected npm and pypi documents with 2 pypi components, got %+v", index.Documents)
+	}
+	if index.Documents[0].SerialNumber == index.Documents[1].SerialNumber {
+		t.Error("Expected each document to get its own serial number")
+	}
+
+	var doc sbom.SBOM
+	data, err = os.ReadFile(filepath.Join(outDir, "pypi.json"))
+	if err != nil {
+		t.Fatalf("Failed to read pypi.json: %v", err)
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("Failed to parse pypi.json: %v", err)
+	}
+	if len(doc.Components) != 2 || doc.SerialNumber != index.Documents[1].SerialNumber {
+		t.Errorf("Expected a standalone document with the 2 PyPI components, got %+v", doc)
+	}
+
+	if err := generate([]string{"--split-by", "supplier", "-d", tmpDir}); err == nil {
+		t.Error("Expected error for --split-by without --output-dir")
+	}
+}
+
+func TestGenerate_Deterministic(t *testing.T) {
+	tmpDir := t.TempDir()
+	pkg := `{"dependencies": {"express": "4.18.2", "lodash": "4.17.21", "chalk": "5.3.0", "debug": "4.3.4"}}`
+	if err := os.WriteFile(filepath.Join(tmpDir, "package.json"), []byte(pkg), 0644); err != nil {
+		t.Fatalf("Failed to write package.json: %v", err)
+	}
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+
+	var outputs []string
+	for _, name := range []string{"first.json", "second.json"} {
+		out := filepath.Join(tmpDir, name)
+		if err := generate([]string{"--deterministic", "-o", out, "-d", tmpDir}); err != nil {
+			t.Fatalf("Failed to generate: %v", err)
+		}
+		data, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatalf("Failed to read output: %v", err)
+		}
+		outputs = append(outputs, string(data))
+	}
+	if outputs[0] != outputs[1] {
+		t.Errorf("Expected identical deterministic output:\n%s\n---\n%s", outputs[0], outputs[1])
+	}
+
+	doc, err := sbom.LoadJSON([]byte(outputs[0]))
+	if err != nil {
+		t.Fatalf("Failed to load output: %v", err)
+	}
+	if !strings.HasPrefix(doc.SerialNumber, "urn:uuid:") || doc.Created.Unix() != 1700000000 {
+		t.Errorf("Expected a urn:uuid serial and SOURCE_DATE_EPOCH timestamp, got %s at %v", doc.SerialNumber, doc.Created)
+	}
+
+	other := filepath.Join(tmpDir, "random.json")
+	if err := generate([]string{"-o", other, "-d", tmpDir}); err != nil {
+		t.Fatalf("Failed to generate: %v", err)
+	}
+	random, err := sbom.LoadFile(other)
+	if err != nil {
+		t.Fatalf("Failed to load output: %v", err)
+	}
+	if random.SerialNumber == doc.SerialNumber {
+		t.Error("Expected a random serial number without --deterministic")
+	}
+}
+
+func TestGenerate_EncryptDecrypt(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "requirements.txt"), []byte("requests==2.28.0\n"), 0644); err != nil {
+		t.Fatalf("Failed to write requirements.txt: %v", err)
+	}
+	t.Setenv("SBOM_KEY", "correct horse battery staple")
+	encrypted := filepath.Join(tmpDir, "sbom.json.enc")
+
+	if err := generate([]string{"--encrypt", "--passphrase-env", "SBOM_KEY", "-o", encrypted, "-d", tmpDir}); err != nil {
+		t.Fatalf("Failed to generate: %v", err)
+	}
+	data, err := os.ReadFile(encrypted)
+	if err != nil {
+		t.Fatalf("Failed to read encrypted SBOM: %v", err)
+	}
+	if bytes.Contains(data, []byte("requests")) {
+		t.Error("Expected the written SBOM to be encrypted")
+	}
+
+	decrypted := filepath.Join(tmpDir, "sbom.json")
+	if err := decrypt([]string{"--passphrase-env", "SBOM_KEY", "-o", decrypted, encrypted}); err != nil {
+		t.Fatalf("Failed to decrypt: %v", err)
+	}
+	plaintext, err := os.ReadFile(decrypted)
+	if err != nil {
+		t.Fatalf("Failed to read decrypted SBOM: %v", err)
+	}
+	doc, err := sbom.LoadJSON(plaintext)
+	if err != nil || len(doc.Components) != 1 || doc.Components[0].Name != "requests" {
+		t.Errorf("Expected the decrypted SBOM to list requests, got %v (%v)", doc, err)
+	}
+
+	t.Setenv("SBOM_KEY", "wrong passphrase")
+	if err := decrypt([]string{"--passphrase-env", "SBOM_KEY", "-o", decrypted, encrypted}); !errors.Is(err, encrypt.ErrDecrypt) {
+		t.Errorf("Expected a wrong passphrase to fail, got %v", err)
+	}
+
+	if _, err := parseGenArgs([]string{"-d", tmpDir, "--encrypt", "--passphrase-env", "SBOM_KEY"}); err == nil {
+		t.Error("Expected --encrypt without an output file to fail")
+	}
+}
+
+func TestGithubAnnotation(t *testing.T) {
+	tests := []struct {
+		name     string
+		level    string
+		file     string
+		line     int
+		message  string
+		expected string
+	}{
+		{"file and line", "error", "requirements.txt", 3, "flask is not approved", "::error file=requirements.txt,line=3::flask is not approved"},
+		{"file only", "warning", "go.mod", 0, "check license", "::warning file=go.mod::check license"},
+		{"no file", "error", "", 7, "policy failed", "::error::policy failed"},
+		{"escaping", "error", "a,b:c.json", 1, "100%\nbad", "::error file=a%2Cb%3Ac.json,line=1::100%25%0Abad"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := githubAnnotation(tt.level, tt.file, tt.line, tt.message)
+			if got != tt.expected {
+				t.Errorf("Expected '%s', got '%s'", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestWriteComponentsJSON(t *testing.T) {
+	tmpDir := t.TempDir()
+	requirements := "flask==2.0.0\nrequests==2.28.0\nnumpy>=1.20.0\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, "requirements.txt"), []byte(requirements), 0644); err != nil {
+		t.Fatalf("Failed to write requirements.txt: %v", err)
+	}
+
+	components, err := analyzer.NewProjectAnalyzer().AnalyzeDir(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to analyze: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := writeComponentsJSON(&buf, components); err != nil {
+		t.Fatalf("Failed to write JSON: %v", err)
+	}
+
+	var decoded []sbom.Component
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Output is not a JSON component list: %v", err)
+	}
+	if len(decoded) != 3 {
+		t.Errorf("Expected 3 components, got %d", len(decoded))
+	}
+
+	buf.Reset()
+	if err := writeComponentsJSON(&buf, nil); err != nil {
+		t.Fatalf("Failed to write JSON: %v", err)
+	}
+	if got := bytes.TrimSpace(buf.Bytes()); string(got) != "[]" {
+		t.Errorf("Expected empty array for no components, got '%s'", got)
+	}
+}
+
+func TestAnalyze_LicenseConflictsRejectsJSON(t *testing.T) {
+	for _, mode := range []string{"--json", "--tree"} {
+		err := analyze([]string{"--dir", t.TempDir(), "--license-conflicts", mode})
+		if err == nil || !strings.Contains(err.Error(), "--license-conflicts") {
+			t.Errorf("Expected --license-conflicts with %s to be rejected, got %v", mode, err)
+		}
+	}
+}
+
+func TestConvert_RoundTrip(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	doc := sbom.New("test-app", "1.0.0", "serial-001")
+	doc.AddComponent(sbom.Component{Name: "lodash", Version: "4.17.21", Supplier: "npm", PURL: "pkg:npm/lodash@4.17.21", License: "MIT"})
+	doc.AddComponent(sbom.Component{Name: "requests", Version: "2.28.0", Supplier: "pypi", PURL: "pkg:pypi/requests@2.28.0",
+		Hashes: []sbom.Hash{{Algorithm: "SHA-256", Value: "abc123"}}})
+	doc.AddRelationship("pkg:npm/lodash@4.17.21", "pkg:pypi/requests@2.28.0", sbom.DependsOn)
+
+	output, err := formatter.NewJSONFormatter().Format(doc)
+	if err != nil {
+		t.Fatalf("Failed to format: %v", err)
+	}
+	jsonIn := filepath.Join(tmpDir, "in.json")
+	if err := os.WriteFile(jsonIn, []byte(output), 0644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+
+	yamlOut := filepath.Join(tmpDir, "sbom.yaml")
+	if err := convert([]string{"--to", "yaml", "-o", yamlOut, jsonIn}); err != nil {
+		t.Fatalf("Failed to convert json to yaml: %v", err)
+	}
+	// The YAML file has a .yaml extension, so the input format is detected.
+	jsonOut := filepath.Join(tmpDir, "out.json")
+	if err := convert([]string{"--to", "json", "-o", jsonOut, yamlOut}); err != nil {
+		t.Fatalf("Failed to convert yaml to json: %v", err)
+	}
+
+	result, err := sbom.LoadFile(jsonOut)
+	if err != nil {
+		t.Fatalf("Failed to load converted SBOM: %v", err)
+	}
+	if !reflect.DeepEqual(result.Components, doc.Components) {
+		t.Errorf("Components changed in round trip:\nexpected %+v\ngot      %+v", doc.Components, result.Components)
+	}
+	if !reflect.DeepEqual(result.Relationships, doc.Relationships) {
+		t.Errorf("Relationships changed in round trip: %v", result.Relationships)
+	}
+}
+
+func TestConvert_JSONLStream(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	const count = 10000
+	var stream strings.Builder
+	for i := 0; i < count; i++ {
+		fmt.Fprintf(&stream, `{"name":"pkg-%d","version":"1.0.%d","purl":"pkg:npm/pkg-%d@1.0.%d"}`+"\n", i, i, i, i)
+	}
+	input := filepath.Join(tmpDir, "vendor.ndjson")
+	if err := os.WriteFile(input, []byte(stream.String()), 0644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+
+	jsonlOut := filepath.Join(tmpDir, "out.jsonl")
+	if err := convert([]string{"--to", "jsonl", "-o", jsonlOut, input}); err != nil {
+		t.Fatalf("Failed to convert to jsonl: %v", err)
+	}
+	f, err := os.Open(jsonlOut)
+	if err != nil {
+		t.Fatalf("Failed to open output: %v", err)
+	}
+	defer f.Close()
+	streamed := 0
+	if err := sbom.ReadJSONL(f, func(sbom.Component) error { streamed++; return nil }); err != nil {
+		t.Fatalf("Failed to read converted stream: %v", err)
+	}
+	if streamed != count {
+		t.Errorf("Expected %d streamed components, got %d", count, streamed)
+	}
+
+	jsonOut := filepath.Join(tmpDir, "out.json")
+	if err := convert([]string{"--to", "json", "-o", jsonOut, input}); err != nil {
+		t.Fatalf("Failed to convert to json: %v", err)
+	}
+	doc, err := sbom.LoadFile(jsonOut)
+	if err != nil {
+		t.Fatalf("Failed to load converted SBOM: %v", err)
+	}
+	if len(doc.Components) != count {
+		t.Errorf("Expected %d components, got %d", count, len(doc.Components))
+	}
+	if doc.Name != "vendor" {
+		t.Errorf("Expected the SBOM to be named after the input file, got '%s'", doc.Name)
+	}
+}
+
+func TestConvert_RequiresTarget(t *testing.T) {
+	if err := convert([]string{"in.json"}); err == nil {
+		t.Error("Expected error without --to")
+	}
+	if err := convert([]string{"--from", "spdx", "--to", "json", "in.spdx"}); err == nil {
+		t.Error("Expected error for unsupported input format")
+	}
+
+	input := filepath.Join(t.TempDir(), "in.json")
+	if err := os.WriteFile(input, []byte(`{"name": "app", "components": []}`), 0644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+	if err := convert([]string{"--to", "pdf", input}); err == nil || !strings.Contains(err.Error(), "unknown format") {
+		t.Errorf("Expected an unknown --to format to be rejected, got %v", err)
+	}
+	if err := writeSBOM(sbom.New("app", "", ""), "pdf", ""); err == nil {
+		t.Error("Expected writeSBOM not to fall back to JSON for an unknown format")
+	}
+}
+
+func TestNormalize_Idempotent(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	doc := sbom.New("vendor-app", "1.0.0", "serial-001")
+	doc.Components = []sbom.Component{
+		{Name: "requests", Version: "2.28.0", Supplier: "pypi", License: "apache-2.0"},
+		{Name: "lodash", Version: "4.17.21", PURL: "pkg:npm/lodash@4.17.21", License: "mit"},
+		{Name: "requests", Version: "2.28.0", PURL: "pkg:pypi/requests@2.28.0"},
+	}
+	doc.Relationships = []sbom.Relationship{
+		{RefA: "pkg:pypi/requests@2.28.0", RefB: "pkg:npm/lodash@4.17.21", Relationship: sbom.DependsOn},
+		{RefA: "pkg:pypi/requests@2.28.0", RefB: "pkg:npm/lodash@4.17.21", Relationship: sbom.DependsOn},
+	}
+	output, err := formatter.NewJSONFormatter().Format(doc)
+	if err != nil {
+		t.Fatalf("Failed to format: %v", err)
+	}
+	messy := filepath.Join(tmpDir, "messy.json")
+	if err := os.WriteFile(messy, []byte(output), 0644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+
+	once := filepath.Join(tmpDir, "once.json")
+	if err := normalize([]string{"-o", once, messy}); err != nil {
+		t.Fatalf("Failed to normalize: %v", err)
+	}
+	twice := filepath.Join(tmpDir, "twice.json")
+	if err := normalize([]string{"-o", twice, once}); err != nil {
+		t.Fatalf("Failed to normalize again: %v", err)
+	}
+
+	first, _ := os.ReadFile(once)
+	second, _ := os.ReadFile(twice)
+	if string(first) != string(second) {
+		t.Errorf("Expected normalize to be idempotent:\n%s\n---\n%s", first, second)
+	}
+
+	result, err := sbom.LoadFile(once)
+	if err != nil {
+		t.Fatalf("Failed to load normalized SBOM: %v", err)
+	}
+	if len(result.Components) != 2 {
+		t.Fatalf("Expected 2 components after merging, got %d", len(result.Components))
+	}
+	if result.Components[0].Name != "lodash" || result.Components[0].License != "MIT" {
+		t.Errorf("Expected lodash with MIT first, got %+v", result.Components[0])
+	}
+	if result.Components[1].License != "Apache-2.0" || result.Components[1].Supplier != "pypi" {
+		t.Errorf("Expected merged requests component, got %+v", result.Components[1])
+	}
+	if len(result.Relationships) != 1 {
+		t.Errorf("Expected 1 relationship, got %d", len(result.Relationships))
+	}
+}
+
+func TestNormalize_RequiresInput(t *testing.T) {
+	if err := normalize(nil); err == nil {
+		t.Error("Expected error without an input file")
+	}
+}
+
+func TestParseGenArgs_ConfigThenFlags(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := `format: markdown
+output: sbom.md
+exclude:
+  - "internal-*"
+max_files: 100
+group_by: license
+enrich: true
+enrich_concurrency: 8
+enrich_rate: 2.5
+osv: true
+osv_batch_size: 200
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, ".sbomgen.yaml"), []byte(cfg), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	opts, err := parseGenArgs([]string{"-d", tmpDir})
+	if err != nil {
+		t.Fatalf("Failed to parse args: %v", err)
+	}
+	if opts.outputFormat != "markdown" || opts.outputFile != filepath.Join(tmpDir, "sbom.md") {
+		t.Errorf("Expected config defaults, got format '%s' output '%s'", opts.outputFormat, opts.outputFile)
+	}
+	if opts.maxFiles != 100 || opts.groupBy != formatter.GroupByLicense {
+		t.Errorf("Expected config max_files and group_by, got %d '%s'", opts.maxFiles, opts.groupBy)
+	}
+	if !opts.enrich || opts.enrichWorkers != 8 || opts.enrichRate != 2.5 || !opts.osv || opts.osvBatchSize != 200 {
+		t.Errorf("Expected config enrichment settings, got %+v", opts)
+	}
+
+	opts, err = parseGenArgs([]string{"-d", tmpDir, "-f", "json", "-o", "out.json", "--max-files", "0", "--exclude-package", "test-*",
+		"--enrich-concurrency", "2", "--enrich-rate", "0", "--batch-size", "50"})
+	if err != nil {
+		t.Fatalf("Failed to parse args: %v", err)
+	}
+	if opts.outputFormat != "json" || opts.outputFile != "out.json" || opts.maxFiles != 0 {
+		t.Errorf("Expected flags to override config, got %+v", opts)
+	}
+	if opts.enrichWorkers != 2 || opts.enrichRate != 0 || opts.osvBatchSize != 50 {
+		t.Errorf("Expected enrichment flags to override config, got %+v", opts)
+	}
+	if opts.groupBy != "" {
+		t.Errorf("Expected config group_by to be ignored for json output, got '%s'", opts.groupBy)
+	}
+	if !reflect.DeepEqual(opts.excludes, []string{"internal-*", "test-*"}) {
+		t.Errorf("Expected config and flag excludes combined, got %v", opts.excludes)
+	}
+}
+
+func TestParseGenArgs_Profile(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := `format: markdown
+max_files: 100
+profiles:
+  release:
+    format: spdx
+    output: release.spdx
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, ".sbomgen.yaml"), []byte(cfg), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	opts, err := parseGenArgs([]string{"-d", tmpDir, "--profile", "release"})
+	if err != nil {
+		t.Fatalf("Failed to parse args: %v", err)
+	}
+	if opts.outputFormat != "spdx" || opts.outputFile != filepath.Join(tmpDir, "release.spdx") || opts.maxFiles != 100 {
+		t.Errorf("Expected profile settings over config defaults, got format '%s' output '%s' max files %d", opts.outputFormat, opts.outputFile, opts.maxFiles)
+	}
+	if opts.projectDir != tmpDir {
+		t.Errorf("Expected the profile name not to be taken as the project directory, got '%s'", opts.projectDir)
+	}
+
+	opts, err = parseGenArgs([]string{"--profile", "release", "-d", tmpDir, "-f", "json", "-o", "out.json"})
+	if err != nil {
+		t.Fatalf("Failed to parse args: %v", err)
+	}
+	if opts.outputFormat != "json" || opts.outputFile != "out.json" {
+		t.Errorf("Expected flags to override the profile, got format '%s' output '%s'", opts.outputFormat, opts.outputFile)
+	}
+
+	if _, err := parseGenArgs([]string{"-d", tmpDir, "--profile", "audit"}); err == nil || !strings.Contains(err.Error(), `unknown profile "audit"`) {
+		t.Errorf("Expected an unknown profile error, got %v", err)
+	}
+	if _, err := parseGenArgs([]string{"-d", t.TempDir(), "--profile", "release"}); err == nil {
+		t.Error("Expected --profile without a config file to fail")
+	}
+}
+
+// captureGenerate runs generate with args and returns what it wrote to
+// stdout and stderr.
+func captureGenerate(t *testing.T, args []string) (string, string, error) {
+	t.Helper()
+	dir := t.TempDir()
+	stdout, err := os.Create(filepath.Join(dir, "stdout"))
+	if err != nil {
+		t.Fatalf("Failed to create stdout file: %v", err)
+	}
+	stderr, err := os.Create(filepath.Join(dir, "stderr"))
+	if err != nil {
+		t.Fatalf("Failed to create stderr file: %v", err)
+	}
+	oldStdout, oldStderr, oldLogs := os.Stdout, os.Stderr, logs
+	os.Stdout, os.Stderr = stdout, stderr
+	defer func() {
+		os.Stdout, os.Stderr, logs = oldStdout, oldStderr, oldLogs
+	}()
+
+	err = generate(args)
+	os.Stdout, os.Stderr = oldStdout, oldStderr
+	stdout.Close()
+	stderr.Close()
+	out, _ := os.ReadFile(stdout.Name())
+	errOut, _ := os.ReadFile(stderr.Name())
+	return string(out), string(errOut), err
+}
+
+// jsonLogLines decodes the JSON log lines in stderr, failing on any line
+// that is not JSON.
+func jsonLogLines(t *testing.T, stderr string) []map[string]any {
+	t.Helper()
+	var entries []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(stderr), "\n") {
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Expected each log line to be JSON, got %q: %v", line, err)
+		}
+		if _, ok := entry["level"].(string); !ok {
+			t.Errorf("Expected a level in %q", line)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestGenerate_GithubAnnotationsToStderr(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "requirements.txt"), []byte("requests==2.28.0\n"), 0644); err != nil {
+		t.Fatalf("Failed to write requirements.txt: %v", err)
+	}
+
+	stdout, stderr, err := captureGenerate(t, []string{"-d", tmpDir, "--fail-on-missing-license", "--github-annotations"})
+	if err == nil {
+		t.Fatal("Expected the unlicensed dependency to fail")
+	}
+	if strings.Contains(stdout, "::error") {
+		t.Errorf("Expected no workflow commands mixed into the SBOM on stdout, got %q", stdout)
+	}
+	if !strings.Contains(stderr, "::error file=") {
+		t.Errorf("Expected a workflow command on stderr, got %q", stderr)
+	}
+}
+
+func TestGenerate_LogFormatJSON(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, ".sbomgen.yaml"), []byte("format: json\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	baseline := filepath.Join(tmpDir, "sbom.json")
+
+	stdout, stderr, err := captureGenerate(t, []string{"--log-format", "json", "-o", baseline, "-d", tmpDir})
+	if err != nil {
+		t.Fatalf("generate failed: %v", err)
+	}
+	if stdout != "" {
+		t.Errorf("Expected no log output on stdout, got %q", stdout)
+	}
+	levels := make(map[string]int)
+	for _, entry := range jsonLogLines(t, stderr) {
+		levels[entry["level"].(string)]++
+	}
+	if levels["INFO"] == 0 || levels["WARN"] == 0 {
+		t.Errorf("Expected info and warning lines for an empty project, got %v", levels)
+	}
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "requirements.txt"), []byte("requests==2.28.0\n"), 0644); err != nil {
+		t.Fatalf("Failed to write requirements.txt: %v", err)
+	}
+	reports := make(map[string]bool)
+	for _, args := range [][]string{{"--dry-run", "--baseline", baseline}, {"--fail-on-missing-license"}} {
+		args = append([]string{"--log-format", "json", "-o", filepath.Join(tmpDir, "new.json"), "-d", tmpDir}, args...)
+		_, stderr, err := captureGenerate(t, args)
+		if err == nil {
+			t.Errorf("Expected the new, unlicensed dependency to fail %v", args)
+		}
+		for _, entry := range jsonLogLines(t, stderr) {
+			msg, _ := entry["msg"].(string)
+			switch {
+			case strings.HasPrefix(msg, "Dry run"):
+				reports["dry run"] = true
+			case strings.HasPrefix(msg, "New dependencies not in baseline"):
+				reports["baseline"] = entry["components"] != nil
+			case msg == "Components without a license":
+				reports["license"] = entry["components"] != nil
+			}
+		}
+	}
+	for _, report := range []string{"dry run", "baseline", "license"} {
+		if !reports[report] {
+			t.Errorf("Expected the %s report as a JSON log line", report)
+		}
+	}
+
+	if _, err := parseGenArgs([]string{"--log-format", "xml"}); err == nil {
+		t.Error("Expected error for an unknown log format")
+	}
+}
+
+func TestParseGenArgs_ExpandsPaths(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("PROJECT_ROOT", tmpDir)
+
+	opts, err := parseGenArgs([]string{"-d", "$PROJECT_ROOT", "-o", "${PROJECT_ROOT}/sbom.json"})
+	if err != nil {
+		t.Fatalf("Failed to parse args: %v", err)
+	}
+	if opts.projectDir != tmpDir || opts.outputFile != filepath.Join(tmpDir, "sbom.json") {
+		t.Errorf("Expected paths under %s, got dir '%s' and output '%s'", tmpDir, opts.projectDir, opts.outputFile)
+	}
+
+	if _, err := parseGenArgs([]string{"-d", "$SBOMGEN_TEST_UNSET"}); err == nil {
+		t.Error("Expected an error for an unset variable")
+	}
+}
+
+func TestParseGenArgs_SpecVersion(t *testing.T) {
+	dir := t.TempDir()
+
+	opts, err := parseGenArgs([]string{"-d", dir, "-f", "spdx", "--spec-version", "2.3"})
+	if err != nil {
+		t.Fatalf("Failed to parse args: %v", err)
+	}
+	if opts.specVersion != "2.3" {
+		t.Errorf("Expected spec version 2.3, got '%s'", opts.specVersion)
+	}
+
+	if _, err := parseGenArgs([]string{"-d", dir, "-f", "spdx", "--spec-version", "1.5"}); err == nil {
+		t.Error("Expected error for a CycloneDX version with SPDX output")
+	}
+	if _, err := parseGenArgs([]string{"-d", dir, "--spec-version", "2.3"}); err == nil {
+		t.Error("Expected error for --spec-version with JSON output")
+	}
+}
+
+func TestParseGenArgs_Outdated(t *testing.T) {
+	dir := t.TempDir()
+
+	opts, err := parseGenArgs([]string{"-d", dir, "--latest-versions", "latest.json"})
+	if err != nil {
+		t.Fatalf("Failed to parse args: %v", err)
+	}
+	if !opts.outdated || opts.latestFile != "latest.json" {
+		t.Errorf("Expected --latest-versions to enable --outdated, got %+v", opts)
+	}
+
+	if _, err := parseGenArgs([]string{"-d", dir, "--outdated"}); err == nil {
+		t.Error("Expected error for --outdated without a source of latest versions")
+	}
+}
+
+func TestParseGenArgs_BatchSize(t *testing.T) {
+	opts, err := parseGenArgs([]string{"--osv", "--dir", t.TempDir()})
+	if err != nil {
+		t.Fatalf("Failed to parse args: %v", err)
+	}
+	if !opts.osv || opts.osvBatchSize != 1000 {
+		t.Errorf("Expected --osv with the default batch size, got %v %d", opts.osv, opts.osvBatchSize)
+	}
+	for _, size := range []string{"0", "1001", "many"} {
+		if _, err := parseGenArgs([]string{"--osv", "--batch-size", size, "--dir", t.TempDir()}); err == nil {
+			t.Errorf("Expected --batch-size %s to be rejected", size)
+		}
+	}
+}
+
+func TestLatestVersions_File(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "latest.json")
+	if err := os.WriteFile(file, []byte(`{"express": "4.19.2"}`), 0644); err != nil {
+		t.Fatalf("Failed to write latest versions: %v", err)
+	}
+
+	gen := sbom.New("app", "1.0.0", "")
+	gen.AddComponent(sbom.Component{Name: "express", Version: "^4.18.0", Supplier: "npm"})
+	latest, err := latestVersions(genOptions{latestFile: file}, gen)
+	if err != nil {
+		t.Fatalf("Failed to load latest versions: %v", err)
+	}
+	outdated := gen.OutdatedAgainst(latest)
+	if len(outdated) != 1 || outdated[0].Latest != "4.19.2" {
+		t.Errorf("Expected express to be outdated, got %+v", outdated)
+	}
+}
+
+func TestCheckLicensePolicy(t *testing.T) {
+	doc := sbom.New("test-app", "1.0.0", "serial-001")
+	doc.AddComponent(sbom.Component{Name: "dual", Version: "1.0.0", License: "AGPL-3.0-only OR MIT"})
+	doc.AddComponent(sbom.Component{Name: "unlicensed", Version: "1.0.0"})
+
+	if err := checkLicensePolicy(doc, []string{"AGPL-3.0-only"}, false); err != nil {
+		t.Errorf("Expected dual-licensed component to pass, got %v", err)
+	}
+
+	doc.AddComponent(sbom.Component{Name: "copyleft", Version: "2.0.0", License: "AGPL-3.0-only"})
+	err := checkLicensePolicy(doc, []string{"AGPL-3.0-only"}, false)
+	var exitErr *exitError
+	if !errors.As(err, &exitErr) || exitErr.code != exitPolicy {
+		t.Errorf("Expected policy exit error, got %v", err)
+	}
+}
+
+func TestCheckMissingLicenses(t *testing.T) {
+	doc := sbom.New("test-app", "1.0.0", "serial-001")
+	doc.AddComponent(sbom.Component{Name: "lodash", Version: "4.17.21", License: "MIT", PURL: "pkg:npm/lodash@4.17.21"})
+
+	if err := checkMissingLicenses(doc, nil, false); err != nil {
+		t.Errorf("Expected licensed components to pass, got %v", err)
+	}
+
+	doc.AddComponent(sbom.Component{Name: "internal-ui", Version: "0.1.0", PURL: "pkg:npm/internal-ui@0.1.0"})
+	doc.AddComponent(sbom.Component{Name: "mystery", Version: "1.0.0", License: "NOASSERTION"})
+
+	err := checkMissingLicenses(doc, nil, false)
+	var exitErr *exitError
+	if !errors.As(err, &exitErr) || exitErr.code != exitPolicy {
+		t.Errorf("Expected policy exit error, got %v", err)
+	}
+
+	allowlist := filepath.Join(t.TempDir(), "allowlist.txt")
+	content := "# first-party packages are unlicensed\npkg:npm/internal-*\n\nmystery\n"
+	if err := os.WriteFile(allowlist, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write allowlist: %v", err)
+	}
+	allow, err := loadPatterns(allowlist)
+	if err != nil {
+		t.Fatalf("Failed to load allowlist: %v", err)
+	}
+	if !reflect.DeepEqual(allow, []string{"pkg:npm/internal-*", "mystery"}) {
+		t.Errorf("Unexpected allowlist %v", allow)
+	}
+	if err := checkMissingLicenses(doc, allow, false); err != nil {
+		t.Errorf("Expected allowlisted components to pass, got %v", err)
+	}
+}
+
+func TestWriteComponentTable(t *testing.T) {
+	purl := "pkg:npm/%40babel/plugin-transform-runtime@7.23.2"
+	components := []sbom.Component{
+		{Name: "@babel/plugin-transform-runtime", Version: "7.23.2", Supplier: "npm", PURL: purl},
+	}
+
+	var compact bytes.Buffer
+	writeComponentTable(&compact, components, false)
+	if strings.Contains(compact.String(), purl) {
+		t.Errorf("Expected compact output to truncate the PURL, got:\n%s", compact.String())
+	}
+	if !strings.Contains(compact.String(), "pkg:npm/%...") {
+		t.Errorf("Expected PURL truncated once to 12 characters, got:\n%s", compact.String())
+	}
+
+	var wide bytes.Buffer
+	writeComponentTable(&wide, components, true)
+	if !strings.Contains(wide.String(), purl) {
+		t.Errorf("Expected wide output to show the complete PURL, got:\n%s", wide.String())
+	}
+	if !strings.Contains(wide.String(), "@babel/plugin-transform-runtime ") {
+		t.Errorf("Expected wide output to show the complete name, got:\n%s", wide.String())
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+
+	"github.com/hallucinaut/sbomgen/pkg/formatter"
+	"github.com/hallucinaut/sbomgen/pkg/sbom"
+)
+
+// splitModes lists the values accepted by --split-by.
+var splitModes = []string{"supplier", "root"}
+
+// splitIndexFile is the name of the file --split-by writes next to the
+// documents, listing them.
+const splitIndexFile = "index.json"
+
+// unsafeFileChars matches runs of characters kept out of split file names.
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// splitEntry describes one document in the --split-by index.
+type splitEntry struct {
+	Key          string `json:"key"`
+	File         string `json:"file"`
+	SerialNumber string `json:"serialNumber"`
+	Components   int    `json:"components"`
+}
+
+// splitIndex is the content of the --split-by index file.
+type splitIndex struct {
+	SplitBy   string       `json:"splitBy"`
+	Name      string       `json:"name"`
+	Format    string       `json:"format"`
+	Documents []splitEntry `json:"documents"`
+}
+
+// splitKey returns the function that assigns components to documents for
+// mode: by supplier, or by the directory of the manifest they came from,
+// relative to the project directory dir.
+func splitKey(mode, dir string) func(sbom.Component) string {
+	if mode == "supplier" {
+		return func(comp sbom.Component) string {
+			if comp.Supplier == "" {
+				return "unknown"
+			}
+			return comp.Supplier
+		}
+	}
+	return func(comp sbom.Component) string {
+		if comp.Metadata.SourceFile == "" {
+			return "."
+		}
+		manifestDir := filepath.Dir(comp.Metadata.SourceFile)
+		if rel, err := filepath.Rel(dir, manifestDir); err == nil {
+			manifestDir = rel
+		}
+		return filepath.ToSlash(manifestDir)
+	}
+}
+
+// splitFileName turns a part key into a file name with the extension ext,
+// numbering names that are already taken.
+func splitFileName(key, ext string, taken map[string]bool) string {
+	base := unsafeFileChars.ReplaceAllString(key, "-")
+	if base == "." || base == "" {
+		base = "project"
+	}
+	name := base + ext
+	for n := 2; taken[name]; n++ {
+		name = base + "-" + strconv.Itoa(n) + ext
+	}
+	taken[name] = true
+	return name
+}
+
+// writeSplit writes doc into opts.outputDir as one document per part,
+// formatted by instance, followed by an index file listing them. It
+// returns the number of documents written.
+func writeSplit(instance formatter.Formatter, doc *sbom.SBOM, opts genOptions, projectDir string) (int, error) {
+	dir := opts.outputDir
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return 0, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	index := splitIndex{SplitBy: opts.splitBy, Name: doc.Name, Format: instance.Name(), Documents: []splitEntry{}}
+	ext := formatter.Extension(formatter.Format(opts.outputFormat))
+	taken := map[string]bool{splitIndexFile: true}
+	for _, part := range doc.Split(splitKey(opts.splitBy, projectDir)) {
+		name := splitFileName(part.Key, ext, taken)
+		file := filepath.Join(dir, name)
+		if streamer, ok := instance.(formatter.StreamFormatter); ok {
+			if err := streamOutput(streamer, part.SBOM, file, opts.outputMode); err != nil {
+				return 0, err
+			}
+		} else {
+			output, err := instance.Format(part.SBOM)
+			if err != nil {
+				return 0, fmt.Errorf("failed to format output: %w", err)
+			}
+			if err := writeOutputFile(file, []byte(output), opts.outputMode); err != nil {
+				return 0, err
+			}
+		}
+		index.Documents = append(index.Documents, splitEntry{
+			Key:          part.Key,
+			File:         name,
+			SerialNumber: part.SBOM.SerialNumber,
+			Components:   len(part.SBOM.Components),
+		})
+	}
+
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode split index: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, splitIndexFile), append(data, '\n'), 0644); err != nil {
+		return 0, fmt.Errorf("failed to write split index: %w", err)
+	}
+	return len(index.Documents), nil
+}
@@ -2,4 +2,8 @@ module github.com/hallucinaut/sbomgen
 
 go 1.21
 
-require gopkg.in/yaml.v3 v3.0.1
+require (
+	github.com/santhosh-tekuri/jsonschema/v5 v5.3.1
+	golang.org/x/mod v0.14.0
+	gopkg.in/yaml.v3 v3.0.1
+)
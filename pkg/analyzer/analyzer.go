@@ -3,12 +3,18 @@ package analyzer
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	pathpkg "path"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/hallucinaut/sbomgen/pkg/sbom"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/semver"
 )
 
 // Analyzer interface for extracting dependencies from different package managers.
@@ -18,9 +24,45 @@ type Analyzer interface {
 	Analyze(path string) ([]sbom.Component, error)
 }
 
+// RootAnalyzer is implemented by analyzers that can describe the project
+// itself from its manifest, in addition to its dependencies.
+type RootAnalyzer interface {
+	Analyzer
+	AnalyzeRoot(path string) (*sbom.Component, error)
+}
+
+// DefaultMaxFiles is the number of files a ProjectAnalyzer will visit before
+// giving up on a directory tree.
+const DefaultMaxFiles = 1000000
+
+// DefaultMaxComponentsPerFile is the number of components a single
+// analyzer result may contribute before it is truncated.
+const DefaultMaxComponentsPerFile = 100000
+
+// ErrTooManyFiles is returned when a directory walk exceeds MaxFiles.
+var ErrTooManyFiles = errors.New("too many files")
+
+// ErrNoLockfile is returned when RequireLockfile is set and a manifest
+// that only declares version ranges has no lockfile.
+var ErrNoLockfile = errors.New("no lockfile")
+
 // ProjectAnalyzer analyzes various project types and extracts dependencies.
 type ProjectAnalyzer struct {
 	analyzers []Analyzer
+	// MaxFiles caps the number of files visited during a directory walk.
+	// Zero or less means no limit.
+	MaxFiles int
+	// MaxComponentsPerFile caps the components kept from one analyzer
+	// result, guarding against corrupt or adversarial manifests. Zero or
+	// less means no limit.
+	MaxComponentsPerFile int
+	// RequireLockfile makes an analysis fail with ErrNoLockfile when a
+	// manifest handled by a LockfileAnalyzer has no lockfile next to it.
+	RequireLockfile bool
+	// Monorepo merges the packages of separate package.json files that do
+	// not form workspaces: dependencies between them become relationships
+	// and shared external dependencies are listed once.
+	Monorepo bool
 }
 
 // NewProjectAnalyzer creates a new project analyzer with all available analyzers.
@@ -32,42 +74,470 @@ func NewProjectAnalyzer() *ProjectAnalyzer {
 			NewGoAnalyzer(),
 			NewCargoAnalyzer(),
 			NewMavenAnalyzer(),
+			NewBazelAnalyzer(),
+			NewSwiftPMAnalyzer(),
+			NewPubAnalyzer(),
+			NewTerraformAnalyzer(),
+			NewHelmAnalyzer(),
+			NewToolchainAnalyzer(),
 		},
+		MaxFiles:             DefaultMaxFiles,
+		MaxComponentsPerFile: DefaultMaxComponentsPerFile,
+	}
+}
+
+// Names returns the names of the registered analyzers.
+func (p *ProjectAnalyzer) Names() []string {
+	names := make([]string, len(p.analyzers))
+	for i, analyzer := range p.analyzers {
+		names[i] = analyzer.Name()
+	}
+	return names
+}
+
+// FilterAnalyzers restricts the registered analyzers to those named in
+// allow, or removes those named in skip. At most one of the lists may be
+// non-empty, and every name must belong to a registered analyzer.
+func (p *ProjectAnalyzer) FilterAnalyzers(allow, skip []string) error {
+	if len(allow) > 0 && len(skip) > 0 {
+		return fmt.Errorf("cannot both select and skip analyzers")
 	}
+
+	known := make(map[string]bool)
+	for _, name := range p.Names() {
+		known[name] = true
+	}
+	selected := make(map[string]bool)
+	for _, name := range append(append([]string(nil), allow...), skip...) {
+		if !known[name] {
+			return fmt.Errorf("unknown analyzer: %s (available: %s)", name, strings.Join(p.Names(), ", "))
+		}
+		selected[name] = true
+	}
+
+	if len(selected) == 0 {
+		return nil
+	}
+	keep := len(allow) > 0
+	var filtered []Analyzer
+	for _, analyzer := range p.analyzers {
+		if selected[analyzer.Name()] == keep {
+			filtered = append(filtered, analyzer)
+		}
+	}
+	p.analyzers = filtered
+	return nil
+}
+
+// Result holds the components and relationships discovered by an analysis.
+type Result struct {
+	Components    []sbom.Component
+	Relationships []sbom.Relationship
+	// Manifests lists further manifest files consumed while producing the
+	// result. The directory walk does not analyze them again.
+	Manifests []string
+	// Scanned records every manifest an analyzer consumed, in the order
+	// they were analyzed.
+	Scanned []ManifestRecord
+	// Warnings lists the paths the directory walk could not read, such as
+	// directories without read permission or dangling symlinks. The walk
+	// continues past them, so the result may be incomplete.
+	Warnings []error
+}
+
+// Incomplete returns the walk warnings and the errors of failed manifests
+// joined into one error, one per line, or nil if everything was read.
+func (r *Result) Incomplete() error {
+	errs := append([]error(nil), r.Warnings...)
+	for _, record := range r.Scanned {
+		if record.Error != "" {
+			errs = append(errs, fmt.Errorf("%s (%s): %s", record.Path, record.Analyzer, record.Error))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ManifestRecord describes a manifest consumed during an analysis and how
+// many components it contributed. Error is set when the analyzer failed on
+// it, in which case its components are missing from the result.
+type ManifestRecord struct {
+	Analyzer       string `json:"analyzer"`
+	Path           string `json:"path"`
+	ComponentCount int    `json:"componentCount"`
+	// Truncated is the number of components dropped because the result
+	// exceeded MaxComponentsPerFile.
+	Truncated int    `json:"truncated,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ResultAnalyzer is implemented by analyzers that report relationships or
+// read several manifests at once, such as workspace roots.
+type ResultAnalyzer interface {
+	Analyzer
+	AnalyzeResult(path string) (*Result, error)
+}
+
+// ContentAnalyzer is implemented by analyzers that can analyze a manifest
+// held in memory rather than on disk, as AnalyzeArchive does with archive
+// entries. data is the manifest itself and dir maps the base names of the
+// other files read from its directory, such as lockfiles, to their
+// contents.
+type ContentAnalyzer interface {
+	Analyzer
+	AnalyzeContent(path string, data []byte, dir map[string][]byte) (*Result, error)
+}
+
+// RootContentAnalyzer is the in-memory counterpart of RootAnalyzer.
+type RootContentAnalyzer interface {
+	Analyzer
+	AnalyzeRootContent(path string, data []byte, dir map[string][]byte) (*sbom.Component, error)
+}
+
+// LockfileAnalyzer is implemented by analyzers whose manifests can declare
+// version ranges, so that a strict analysis can insist on the lockfile
+// that pins them.
+type LockfileAnalyzer interface {
+	Analyzer
+	HasLockfile(manifest string) bool
 }
 
 // AnalyzeDir scans a directory and extracts all dependencies.
 func (p *ProjectAnalyzer) AnalyzeDir(dir string) ([]sbom.Component, error) {
-	var allComponents []sbom.Component
+	result, err := p.AnalyzeProject(dir)
+	if err != nil {
+		return nil, err
+	}
+	return result.Components, nil
+}
 
-	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
+// manifestMatch pairs a manifest file with the analyzer that handles it.
+type manifestMatch struct {
+	path     string
+	analyzer Analyzer
+}
+
+// AnalyzeProject scans a directory and extracts all dependencies along with
+// any relationships the analyzers report. Manifests are analyzed shallowest
+// first so that workspace roots can claim their members' manifests.
+func (p *ProjectAnalyzer) AnalyzeProject(dir string) (*Result, error) {
+	return p.analyzeMatching(dir, nil)
+}
+
+// analyzeMatching implements AnalyzeProject, analyzing only the manifests
+// accepted by keep when it is non-nil. Each file goes to the first analyzer
+// that accepts it, and of several files whose paths differ only in case,
+// as a case-sensitive checkout of a case-insensitive tree may hold, only
+// the first is analyzed.
+func (p *ProjectAnalyzer) analyzeMatching(dir string, keep func(path string) bool) (*Result, error) {
+	var matches []manifestMatch
+	seen := make(map[string]bool)
+
+	result := &Result{}
+	files := 0
+	err := walkFiles(dir, func(path string) error {
+		files++
+		if p.MaxFiles > 0 && files > p.MaxFiles {
+			return fmt.Errorf("%w: more than %d files under %s", ErrTooManyFiles, p.MaxFiles, dir)
+		}
+		if keep != nil && !keep(path) {
 			return nil
 		}
-		if info.IsDir() {
-			if info.Name() == "node_modules" || info.Name() == "vendor" ||
-				info.Name() == ".git" || info.Name() == "dist" || info.Name() == "build" {
-				return filepath.SkipDir
-			}
+		if seen[manifestKey(path)] {
+			return nil
 		}
-
 		for _, analyzer := range p.analyzers {
 			if analyzer.ShouldAnalyze(path) {
-				components, err := analyzer.Analyze(path)
-				if err != nil {
-					continue
-				}
-				allComponents = append(allComponents, components...)
+				matches = append(matches, manifestMatch{path: path, analyzer: analyzer})
+				seen[manifestKey(path)] = true
+				break
 			}
 		}
 		return nil
+	}, func(err error) {
+		result.Warnings = append(result.Warnings, err)
 	})
 
 	if err != nil {
 		return nil, fmt.Errorf("directory walk failed: %w", err)
 	}
 
-	return allComponents, nil
+	sort.SliceStable(matches, func(i, j int) bool {
+		return pathDepth(matches[i].path) < pathDepth(matches[j].path)
+	})
+
+	claimed := make(map[string]bool)
+	var unlocked []string
+	for _, match := range matches {
+		if claimed[manifestKey(match.path)] {
+			continue
+		}
+
+		name := match.analyzer.Name()
+		if locker, ok := match.analyzer.(LockfileAnalyzer); ok && p.RequireLockfile && !locker.HasLockfile(match.path) {
+			result.Scanned = append(result.Scanned, failedRecord(name, match.path, ErrNoLockfile))
+			unlocked = append(unlocked, filepath.ToSlash(match.path))
+			continue
+		}
+		if resultAnalyzer, ok := match.analyzer.(ResultAnalyzer); ok {
+			r, err := resultAnalyzer.AnalyzeResult(match.path)
+			if err != nil {
+				result.Scanned = append(result.Scanned, failedRecord(name, match.path, err))
+				continue
+			}
+			p.addResult(result, claimed, name, match.path, r, func(path string) bool {
+				_, err := os.Stat(path)
+				return err == nil
+			})
+			continue
+		}
+
+		components, err := match.analyzer.Analyze(match.path)
+		if err != nil {
+			result.Scanned = append(result.Scanned, failedRecord(name, match.path, err))
+			continue
+		}
+		components, dropped := p.capComponents(components)
+		components = withSourceFile(components, match.path)
+		result.Components = append(result.Components, components...)
+		result.Scanned = append(result.Scanned, ManifestRecord{
+			Analyzer:       name,
+			Path:           filepath.ToSlash(match.path),
+			ComponentCount: len(components),
+			Truncated:      dropped,
+		})
+	}
+
+	return p.finishResult(result, unlocked, os.ReadFile)
+}
+
+// addResult merges r, the result of analyzing path with the analyzer named
+// name, into result and claims the further manifests r consumed, so they
+// are not analyzed again. exists reports whether a claimed manifest is
+// present, in which case it is recorded as scanned too.
+func (p *ProjectAnalyzer) addResult(result *Result, claimed map[string]bool, name, path string, r *Result, exists func(path string) bool) {
+	components, dropped := p.capComponents(r.Components)
+	components = withSourceFile(components, path)
+	result.Components = append(result.Components, components...)
+	result.Relationships = append(result.Relationships, r.Relationships...)
+	record := manifestRecord(name, path, components)
+	record.Truncated = dropped
+	result.Scanned = append(result.Scanned, record)
+	for _, manifest := range r.Manifests {
+		manifest = filepath.Clean(manifest)
+		key := manifestKey(manifest)
+		if claimed[key] || key == manifestKey(path) {
+			continue
+		}
+		claimed[key] = true
+		if exists(manifest) {
+			result.Scanned = append(result.Scanned, manifestRecord(name, manifest, components))
+		}
+	}
+}
+
+// finishResult fails for the manifests in unlocked, which lack the lockfile
+// RequireLockfile asks for, and otherwise completes result with the steps
+// that look across manifests. readFile reads the files those steps
+// consult, such as license texts.
+func (p *ProjectAnalyzer) finishResult(result *Result, unlocked []string, readFile func(path string) ([]byte, error)) (*Result, error) {
+	if len(unlocked) > 0 {
+		return nil, fmt.Errorf("%w for %s", ErrNoLockfile, strings.Join(unlocked, ", "))
+	}
+	if p.Monorepo {
+		aggregateMonorepo(result, readFile)
+	}
+	markDeclaredLicenses(result.Components)
+	inferLicenses(result.Components, readFile)
+	return result, nil
+}
+
+// hasSibling reports whether the directory of manifest holds a file named
+// one of names, ignoring case.
+func hasSibling(manifest string, names ...string) bool {
+	for _, name := range names {
+		if _, err := os.Stat(findManifest(filepath.Dir(manifest), name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// capComponents truncates components to MaxComponentsPerFile, returning
+// the kept components and how many were dropped. The kept slice is copied
+// so that the dropped ones can be garbage collected.
+func (p *ProjectAnalyzer) capComponents(components []sbom.Component) ([]sbom.Component, int) {
+	if p.MaxComponentsPerFile <= 0 || len(components) <= p.MaxComponentsPerFile {
+		return components, 0
+	}
+	kept := append([]sbom.Component(nil), components[:p.MaxComponentsPerFile]...)
+	return kept, len(components) - p.MaxComponentsPerFile
+}
+
+// manifestKey identifies a manifest path independently of its case.
+func manifestKey(path string) string {
+	return strings.ToLower(filepath.Clean(path))
+}
+
+// manifestRecord describes path as consumed by analyzer, counting the
+// components that name it as their source file.
+func manifestRecord(analyzer, path string, components []sbom.Component) ManifestRecord {
+	record := ManifestRecord{Analyzer: analyzer, Path: filepath.ToSlash(path)}
+	for _, comp := range components {
+		if comp.Metadata.SourceFile == record.Path {
+			record.ComponentCount++
+		}
+	}
+	return record
+}
+
+func failedRecord(analyzer, path string, err error) ManifestRecord {
+	return ManifestRecord{Analyzer: analyzer, Path: filepath.ToSlash(path), Error: err.Error()}
+}
+
+// withSourceFile records path as the source of components that do not
+// already name the manifest they were declared in. Source files always use
+// forward slashes so generated SBOMs do not depend on the host OS.
+func withSourceFile(components []sbom.Component, path string) []sbom.Component {
+	for i := range components {
+		if components[i].Metadata.SourceFile == "" {
+			components[i].Metadata.SourceFile = path
+		}
+		components[i].Metadata.SourceFile = filepath.ToSlash(components[i].Metadata.SourceFile)
+	}
+	return components
+}
+
+// markDeclaredLicenses records LicenseSourceDeclared on components whose
+// analyzer read a license without saying where it came from.
+func markDeclaredLicenses(components []sbom.Component) {
+	for i := range components {
+		if components[i].License != "" && components[i].Metadata.LicenseSource == "" {
+			components[i].Metadata.LicenseSource = sbom.LicenseSourceDeclared
+		}
+	}
+}
+
+// isExactVersion reports whether v is a plain version rather than a
+// constraint such as "~> 5.0", ">= 1.2, < 2.0", "^1.2" or "1.x".
+func isExactVersion(v string) bool {
+	if v == "" || strings.ContainsAny(v, "<>=~!^*, ") {
+		return false
+	}
+	for _, part := range strings.Split(v, ".") {
+		if part == "x" || part == "X" {
+			return false
+		}
+	}
+	return true
+}
+
+func pathDepth(path string) int {
+	return strings.Count(filepath.Clean(path), string(filepath.Separator))
+}
+
+// DetectRoot describes the project in dir using the first top-level manifest
+// whose analyzer implements RootAnalyzer. It returns nil if none is found.
+func (p *ProjectAnalyzer) DetectRoot(dir string) *sbom.Component {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, file.Name())
+		for _, analyzer := range p.analyzers {
+			rootAnalyzer, ok := analyzer.(RootAnalyzer)
+			if !ok || !rootAnalyzer.ShouldAnalyze(path) {
+				continue
+			}
+			root, err := rootAnalyzer.AnalyzeRoot(path)
+			if err == nil && root != nil {
+				if root.License != "" {
+					root.Metadata.LicenseSource = sbom.LicenseSourceDeclared
+				}
+				return root
+			}
+		}
+	}
+	return nil
+}
+
+// isManifest reports whether the file name of path is one of names. Names
+// are compared case-insensitively, as case-insensitive file systems let a
+// manifest be stored under any casing.
+func isManifest(path string, names ...string) bool {
+	base := filepath.Base(path)
+	for _, name := range names {
+		if strings.EqualFold(base, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// findManifest returns the path of the file in dir named name, ignoring
+// case, or filepath.Join(dir, name) if there is no such file.
+func findManifest(dir, name string) string {
+	entries, err := os.ReadDir(dir)
+	if err == nil {
+		for _, entry := range entries {
+			if !entry.IsDir() && strings.EqualFold(entry.Name(), name) {
+				return filepath.Join(dir, entry.Name())
+			}
+		}
+	}
+	return filepath.Join(dir, name)
+}
+
+// dirFile returns the name and contents of the file in dir named name,
+// ignoring case, where dir maps base names to contents as passed to
+// ContentAnalyzer.
+func dirFile(dir map[string][]byte, name string) (string, []byte, bool) {
+	if data, ok := dir[name]; ok {
+		return name, data, true
+	}
+	for base, data := range dir {
+		if strings.EqualFold(base, name) {
+			return base, data, true
+		}
+	}
+	return "", nil, false
+}
+
+// contentManifest returns the path of the file named name, ignoring case,
+// next to the in-memory manifest at path, whether or not it is in dir.
+func contentManifest(path string, dir map[string][]byte, name string) string {
+	if base, _, ok := dirFile(dir, name); ok {
+		name = base
+	}
+	return filepath.Join(filepath.Dir(path), name)
+}
+
+// contentReader returns a function reading the manifest at path, held in
+// data, and the files of its directory, held in dir, as os.ReadFile would.
+func contentReader(path string, data []byte, dir map[string][]byte) func(string) ([]byte, error) {
+	return func(name string) ([]byte, error) {
+		if name == path {
+			return data, nil
+		}
+		if filepath.Dir(name) == filepath.Dir(path) {
+			if _, content, ok := dirFile(dir, filepath.Base(name)); ok {
+				return content, nil
+			}
+		}
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+}
+
+// componentsResult wraps the components an analyzer returned in a Result.
+func componentsResult(components []sbom.Component, err error) (*Result, error) {
+	if err != nil {
+		return nil, err
+	}
+	return &Result{Components: components}, nil
 }
 
 // DetectProjectType detects the type of project in a directory.
@@ -80,16 +550,26 @@ func DetectProjectType(dir string) string {
 	for _, file := range files {
 		name := file.Name()
 		switch {
-		case name == "package.json":
+		case isManifest(name, "package.json"):
 			return "npm"
-		case name == "requirements.txt" || name == "setup.py" || name == "pyproject.toml":
+		case isManifest(name, "requirements.txt", "setup.py", "pyproject.toml"):
 			return "pypi"
-		case name == "go.mod":
+		case isManifest(name, "go.mod"):
 			return "go"
-		case name == "Cargo.toml":
+		case isManifest(name, "Cargo.toml"):
 			return "cargo"
-		case name == "pom.xml":
+		case isManifest(name, "pom.xml"):
 			return "maven"
+		case isManifest(name, "MODULE.bazel", "WORKSPACE", "WORKSPACE.bazel"):
+			return "bazel"
+		case isManifest(name, "Package.swift", "Package.resolved"):
+			return "swift"
+		case isManifest(name, "pubspec.yaml", "pubspec.lock"):
+			return "pub"
+		case isManifest(name, ".terraform.lock.hcl") || isTerraformFile(name):
+			return "terraform"
+		case isManifest(name, "Chart.yaml"):
+			return "helm"
 		}
 	}
 	return "unknown"
@@ -107,26 +587,137 @@ func (a *NPMAnalyzer) Name() string {
 }
 
 func (a *NPMAnalyzer) ShouldAnalyze(path string) bool {
-	return filepath.Base(path) == "package.json"
+	return isManifest(path, "package.json")
 }
 
+// HasLockfile reports whether an npm, Yarn or pnpm lockfile sits next to
+// the package.json.
+func (a *NPMAnalyzer) HasLockfile(manifest string) bool {
+	return hasSibling(manifest, npmLockfiles...)
+}
+
+// npmLockfiles lists the lockfiles that pin the dependencies of a
+// package.json.
+var npmLockfiles = []string{"package-lock.json", "npm-shrinkwrap.json", "yarn.lock", "pnpm-lock.yaml"}
+
 func (a *NPMAnalyzer) Analyze(path string) ([]sbom.Component, error) {
-	var pkg struct {
-		Name        string            `json:"name"`
-		Version     string            `json:"version"`
-		Dependencies map[string]string `json:"dependencies"`
-		DevDeps      map[string]string `json:"devDependencies"`
+	pkg, err := readNPMPackage(path)
+	if err != nil {
+		return nil, err
 	}
 
-	data, err := os.ReadFile(path)
+	return npmComponents(pkg), nil
+}
+
+// AnalyzeContent analyzes a package.json held in memory. Workspace members
+// are not resolved, as that needs the directory tree; each member's
+// package.json is analyzed on its own instead.
+func (a *NPMAnalyzer) AnalyzeContent(path string, data []byte, dir map[string][]byte) (*Result, error) {
+	pkg, err := loadNPMPackage(contentReader(path, data, dir), path)
 	if err != nil {
 		return nil, err
 	}
+	return &Result{Components: npmComponents(pkg)}, nil
+}
+
+func (a *NPMAnalyzer) AnalyzeRoot(path string) (*sbom.Component, error) {
+	pkg, err := readNPMPackage(path)
+	if err != nil {
+		return nil, err
+	}
+	return npmRoot(pkg)
+}
 
+func (a *NPMAnalyzer) AnalyzeRootContent(path string, data []byte, dir map[string][]byte) (*sbom.Component, error) {
+	pkg, err := loadNPMPackage(contentReader(path, data, dir), path)
+	if err != nil {
+		return nil, err
+	}
+	return npmRoot(pkg)
+}
+
+// npmRoot describes the package itself.
+func npmRoot(pkg *npmPackage) (*sbom.Component, error) {
+	if pkg.Name == "" {
+		return nil, fmt.Errorf("no name in %s", pkg.path)
+	}
+
+	return &sbom.Component{
+		Name:     pkg.Name,
+		Version:  pkg.Version,
+		Supplier: "npm",
+		License:  pkg.license(),
+		PURL:     npmPURL(pkg.Name, pkg.Version),
+	}, nil
+}
+
+// npmPackage is the subset of package.json that the npm analyzer reads.
+type npmPackage struct {
+	Name         string            `json:"name"`
+	Version      string            `json:"version"`
+	Dependencies map[string]string `json:"dependencies"`
+	DevDeps      map[string]string `json:"devDependencies"`
+	OptionalDeps map[string]string `json:"optionalDependencies"`
+	Workspaces   json.RawMessage   `json:"workspaces"`
+	License      json.RawMessage   `json:"license"`
+	Licenses     json.RawMessage   `json:"licenses"`
+
+	path       string
+	registries npmRegistries
+}
+
+// license returns the package's license as an SPDX expression. Besides the
+// current string form, it accepts the deprecated {"type": ...} object and
+// the "licenses" array of strings or objects.
+func (pkg *npmPackage) license() string {
+	var licenses []string
+	for _, raw := range []json.RawMessage{pkg.License, pkg.Licenses} {
+		if len(raw) == 0 {
+			continue
+		}
+		var entries []json.RawMessage
+		if err := json.Unmarshal(raw, &entries); err != nil {
+			entries = []json.RawMessage{raw}
+		}
+		for _, entry := range entries {
+			var id string
+			if err := json.Unmarshal(entry, &id); err != nil {
+				var legacy struct {
+					Type string `json:"type"`
+				}
+				if json.Unmarshal(entry, &legacy) != nil {
+					continue
+				}
+				id = legacy.Type
+			}
+			licenses = append(licenses, id)
+		}
+	}
+	return sbom.LicenseExpression(licenses...)
+}
+
+func readNPMPackage(path string) (*npmPackage, error) {
+	return loadNPMPackage(os.ReadFile, path)
+}
+
+// loadNPMPackage reads the package.json at path, and the .npmrc next to
+// it, using readFile.
+func loadNPMPackage(readFile func(path string) ([]byte, error), path string) (*npmPackage, error) {
+	data, err := readFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var pkg npmPackage
 	if err := json.Unmarshal(data, &pkg); err != nil {
 		return nil, err
 	}
+	pkg.path = path
+	pkg.registries = readNPMRegistries(readFile, filepath.Dir(path))
+	return &pkg, nil
+}
 
+func npmComponents(pkg *npmPackage) []sbom.Component {
 	var components []sbom.Component
 
 	for name, version := range pkg.Dependencies {
@@ -134,7 +725,11 @@ func (a *NPMAnalyzer) Analyze(path string) ([]sbom.Component, error) {
 			Name:     name,
 			Version:  version,
 			Supplier: "npm",
-			PURL:     fmt.Sprintf("pkg:npm/%s@%s", name, version),
+			PURL:     pkg.registries.purl(name, version),
+			Direct:   true,
+			Metadata: sbom.Metadata{
+				SourceFile: pkg.path,
+			},
 		})
 	}
 
@@ -143,14 +738,40 @@ func (a *NPMAnalyzer) Analyze(path string) ([]sbom.Component, error) {
 			Name:     name,
 			Version:  version,
 			Supplier: "npm",
-			PURL:     fmt.Sprintf("pkg:npm/%s@%s", name, version),
+			PURL:     pkg.registries.purl(name, version),
+			Direct:   true,
+			Scope:    sbom.ScopeDev,
 			Metadata: sbom.Metadata{
 				Description: "development dependency",
+				SourceFile:  pkg.path,
 			},
 		})
 	}
 
-	return components, nil
+	for name, version := range pkg.OptionalDeps {
+		components = append(components, sbom.Component{
+			Name:     name,
+			Version:  version,
+			Supplier: "npm",
+			PURL:     pkg.registries.purl(name, version),
+			Direct:   true,
+			Scope:    sbom.ScopeOptional,
+			Metadata: sbom.Metadata{
+				SourceFile: pkg.path,
+			},
+		})
+	}
+
+	for i := range components {
+		comp := &components[i]
+		if vcsURL, ref, ok := npmGitRef(comp.Version); ok {
+			comp.Version = ref
+			comp.PURL = gitPURL("npm", comp.Name, vcsURL)
+			comp.Metadata.Description = sbom.GitDependency
+		}
+	}
+
+	return components
 }
 
 // PyPIAnalyzer analyzes Python projects.
@@ -165,7 +786,37 @@ func (a *PyPIAnalyzer) Name() string {
 }
 
 func (a *PyPIAnalyzer) ShouldAnalyze(path string) bool {
-	return filepath.Base(path) == "requirements.txt"
+	return isManifest(path, "requirements.txt")
+}
+
+// HasLockfile reports whether a Poetry, Pipenv, uv or PDM lockfile sits next
+// to the requirements file, or the file pins every requirement with == as
+// pip-compile output does.
+func (a *PyPIAnalyzer) HasLockfile(manifest string) bool {
+	if hasSibling(manifest, pypiLockfiles...) {
+		return true
+	}
+	data, err := os.ReadFile(manifest)
+	return err == nil && pinnedRequirements(data)
+}
+
+// pypiLockfiles lists the lockfiles that pin the requirements of a Python
+// project.
+var pypiLockfiles = []string{"poetry.lock", "Pipfile.lock", "uv.lock", "pdm.lock"}
+
+// pinnedRequirements reports whether a requirements file pins every
+// requirement with ==.
+func pinnedRequirements(data []byte) bool {
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "-") {
+			continue
+		}
+		if !strings.Contains(line, "==") {
+			return false
+		}
+	}
+	return true
 }
 
 func (a *PyPIAnalyzer) Analyze(path string) ([]sbom.Component, error) {
@@ -173,13 +824,49 @@ func (a *PyPIAnalyzer) Analyze(path string) ([]sbom.Component, error) {
 	if err != nil {
 		return nil, err
 	}
+	return parseRequirements(path, data)
+}
 
+// AnalyzeContent analyzes a requirements file held in memory.
+func (a *PyPIAnalyzer) AnalyzeContent(path string, data []byte, dir map[string][]byte) (*Result, error) {
+	return componentsResult(parseRequirements(path, data))
+}
+
+// parseRequirements reads the requirements of a requirements.txt.
+func parseRequirements(path string, data []byte) ([]sbom.Component, error) {
 	var components []sbom.Component
 	lines := strings.Split(string(data), "\n")
 
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" || strings.HasPrefix(line, "#") {
+	for lineNo := 0; lineNo < len(lines); lineNo++ {
+		start := lineNo
+		line := strings.TrimSpace(lines[lineNo])
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		// A trailing backslash continues the requirement on the next line,
+		// as hash-pinned requirements usually do.
+		for strings.HasSuffix(line, "\\") && lineNo+1 < len(lines) {
+			lineNo++
+			line = strings.TrimSuffix(line, "\\") + " " + strings.TrimSpace(lines[lineNo])
+		}
+		line, hashes := requirementHashes(strings.TrimSuffix(line, "\\"))
+		if line == "" {
+			continue
+		}
+
+		if name, vcsURL, ref, ok := pipGitRequirement(line); ok {
+			components = append(components, sbom.Component{
+				Name:     name,
+				Version:  ref,
+				Supplier: "pypi",
+				PURL:     gitPURL("pypi", name, vcsURL),
+				Direct:   requirementDirect(lines, lineNo+1),
+				Metadata: sbom.Metadata{
+					Description: sbom.GitDependency,
+					SourceFile:  path,
+					SourceLine:  start + 1,
+				},
+			})
 			continue
 		}
 
@@ -199,7 +886,13 @@ func (a *PyPIAnalyzer) Analyze(path string) ([]sbom.Component, error) {
 				Name:     name,
 				Version:  version,
 				Supplier: "pypi",
-				PURL:     fmt.Sprintf("pkg:pypi/%s@%s", name, version),
+				PURL:     sbom.PURL("pypi", name, version),
+				Hashes:   hashes,
+				Direct:   requirementDirect(lines, lineNo+1),
+				Metadata: sbom.Metadata{
+					SourceFile: path,
+					SourceLine: start + 1,
+				},
 			})
 		}
 	}
@@ -207,6 +900,69 @@ func (a *PyPIAnalyzer) Analyze(path string) ([]sbom.Component, error) {
 	return components, nil
 }
 
+// requirementDirect reports whether the requirement ending before line
+// next is one the project declares. pip-compile output also pins
+// transitive requirements, annotating each with the packages that pulled
+// it in ("# via requests", or a "# via" list on the following lines);
+// requirements annotated only with other packages are transitive, while
+// those from a requirements file ("-r requirements.in") or a project file
+// ("app (pyproject.toml)") are direct. Unannotated requirements are taken
+// to be declared.
+func requirementDirect(lines []string, next int) bool {
+	var via []string
+	list := false
+	for ; next < len(lines); next++ {
+		line := strings.TrimSpace(lines[next])
+		comment, ok := strings.CutPrefix(line, "#")
+		if !ok {
+			break
+		}
+		comment = strings.TrimSpace(comment)
+		switch {
+		case comment == "via":
+			list = true
+		case strings.HasPrefix(comment, "via "):
+			via = append(via, strings.TrimSpace(strings.TrimPrefix(comment, "via ")))
+		case list && comment != "":
+			via = append(via, comment)
+		}
+	}
+	if len(via) == 0 {
+		return true
+	}
+	for _, source := range via {
+		if strings.HasPrefix(source, "-r") || strings.Contains(source, "(") {
+			return true
+		}
+	}
+	return false
+}
+
+// requirementHashes removes pip --hash options, as written by
+// pip-compile --generate-hashes or hashin, from a requirement line and
+// returns them as hashes.
+func requirementHashes(line string) (string, []sbom.Hash) {
+	var kept []string
+	var hashes []sbom.Hash
+	fields := strings.Fields(line)
+	for i := 0; i < len(fields); i++ {
+		value, ok := strings.CutPrefix(fields[i], "--hash=")
+		if !ok && fields[i] == "--hash" && i+1 < len(fields) {
+			i++
+			value, ok = fields[i], true
+		}
+		if !ok {
+			kept = append(kept, fields[i])
+			continue
+		}
+		algorithm, digest, found := strings.Cut(value, ":")
+		if name := sbom.CanonicalHashAlgorithm(algorithm); found && name != "" && digest != "" {
+			hashes = append(hashes, sbom.Hash{Algorithm: name, Value: digest})
+		}
+	}
+	return strings.Join(kept, " "), hashes
+}
+
 // GoAnalyzer analyzes Go projects.
 type GoAnalyzer struct{}
 
@@ -219,7 +975,7 @@ func (a *GoAnalyzer) Name() string {
 }
 
 func (a *GoAnalyzer) ShouldAnalyze(path string) bool {
-	return filepath.Base(path) == "go.mod"
+	return isManifest(path, "go.mod")
 }
 
 func (a *GoAnalyzer) Analyze(path string) ([]sbom.Component, error) {
@@ -227,30 +983,116 @@ func (a *GoAnalyzer) Analyze(path string) ([]sbom.Component, error) {
 	if err != nil {
 		return nil, err
 	}
+	return parseGoMod(path, data)
+}
 
-	var components []sbom.Component
-	lines := strings.Split(string(data), "\n")
+// AnalyzeContent analyzes a go.mod held in memory.
+func (a *GoAnalyzer) AnalyzeContent(path string, data []byte, dir map[string][]byte) (*Result, error) {
+	return componentsResult(parseGoMod(path, data))
+}
 
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if strings.HasPrefix(line, "require ") || (line != "" && strings.Contains(line, " ")) {
-			parts := strings.Fields(line)
-			if len(parts) >= 2 {
-				name := strings.TrimSpace(parts[0])
-				version := strings.TrimSpace(parts[1])
-				components = append(components, sbom.Component{
-					Name:     filepath.Base(name),
-					Version:  version,
-					Supplier: "go",
-					PURL:     fmt.Sprintf("pkg:go/%s@%s", filepath.Base(name), version),
-				})
+// parseGoMod reads the requirements of a go.mod.
+func parseGoMod(path string, data []byte) ([]sbom.Component, error) {
+	mod, err := modfile.Parse(path, data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse go.mod: %w", err)
+	}
+
+	// Modules replaced by a directory are part of the same repository and
+	// are first-party code rather than external dependencies.
+	local := make(map[string]string)
+	for _, r := range mod.Replace {
+		if modfile.IsDirectoryPath(r.New.Path) {
+			local[r.Old.Path] = r.New.Path
+		}
+	}
+
+	// Components are attributed to the module that requires them, so that
+	// sibling modules pinning different versions stay distinguishable.
+	owner := path
+	if mod.Module != nil {
+		owner = mod.Module.Mod.Path
+	}
+
+	var components []sbom.Component
+	seen := make(map[string]int)
+	for _, req := range mod.Require {
+		name := req.Mod.Path
+		if mod.Module != nil && name == mod.Module.Mod.Path {
+			continue
+		}
+		// A module listed twice resolves to the higher version, as in the
+		// go command.
+		if i, ok := seen[name]; ok {
+			if semver.Compare(req.Mod.Version, components[i].Version) > 0 {
+				components[i].Version = req.Mod.Version
+				components[i].PURL = sbom.PURL("go", name, req.Mod.Version)
 			}
+			components[i].Direct = components[i].Direct || !req.Indirect
+			continue
+		}
+		seen[name] = len(components)
+
+		comp := sbom.Component{
+			Name:     pathpkg.Base(name),
+			Version:  req.Mod.Version,
+			Supplier: "go",
+			PURL:     sbom.PURL("go", name, req.Mod.Version),
+			Direct:   !req.Indirect,
+			Metadata: sbom.Metadata{
+				SourceFile: path,
+				Module:     owner,
+			},
+		}
+		if req.Syntax != nil {
+			comp.Metadata.SourceLine = req.Syntax.Start.Line
 		}
+		if dir, ok := local[name]; ok {
+			comp.Scope = sbom.ScopeInternal
+			comp.Metadata.SourceURL = dir
+		}
+		components = append(components, comp)
+	}
+
+	if stdlib, ok := goStdlib(mod, path); ok {
+		stdlib.Metadata.Module = owner
+		components = append(components, stdlib)
 	}
 
 	return components, nil
 }
 
+// goStdlib returns a component for the Go standard library, versioned by the
+// toolchain directive or, failing that, the go directive, so that stdlib
+// vulnerabilities can be matched against the SBOM.
+func goStdlib(mod *modfile.File, path string) (sbom.Component, bool) {
+	var version string
+	var syntax *modfile.Line
+	switch {
+	case mod.Toolchain != nil:
+		version, syntax = strings.TrimPrefix(mod.Toolchain.Name, "go"), mod.Toolchain.Syntax
+	case mod.Go != nil:
+		version, syntax = mod.Go.Version, mod.Go.Syntax
+	default:
+		return sbom.Component{}, false
+	}
+
+	comp := sbom.Component{
+		Name:     "stdlib",
+		Version:  version,
+		Supplier: "go",
+		PURL:     sbom.PURL("go", "stdlib", version),
+		Direct:   true,
+		Metadata: sbom.Metadata{
+			SourceFile: path,
+		},
+	}
+	if syntax != nil {
+		comp.Metadata.SourceLine = syntax.Start.Line
+	}
+	return comp, true
+}
+
 // CargoAnalyzer analyzes Rust projects.
 type CargoAnalyzer struct{}
 
@@ -262,8 +1104,24 @@ func (a *CargoAnalyzer) Name() string {
 	return "cargo"
 }
 
+// HasLockfile reports whether a Cargo.lock sits next to the Cargo.toml or,
+// for workspace members, next to the Cargo.toml of an enclosing crate.
+func (a *CargoAnalyzer) HasLockfile(manifest string) bool {
+	for dir := filepath.Dir(manifest); ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(findManifest(dir, "Cargo.toml")); err != nil {
+			return false
+		}
+		if _, err := os.Stat(findManifest(dir, "Cargo.lock")); err == nil {
+			return true
+		}
+		if parent := filepath.Dir(dir); parent == dir {
+			return false
+		}
+	}
+}
+
 func (a *CargoAnalyzer) ShouldAnalyze(path string) bool {
-	return filepath.Base(path) == "Cargo.toml"
+	return isManifest(path, "Cargo.toml")
 }
 
 func (a *CargoAnalyzer) Analyze(path string) ([]sbom.Component, error) {
@@ -271,19 +1129,35 @@ func (a *CargoAnalyzer) Analyze(path string) ([]sbom.Component, error) {
 	if err != nil {
 		return nil, err
 	}
+	return parseCargoToml(path, data)
+}
+
+// AnalyzeContent analyzes a Cargo.toml held in memory.
+func (a *CargoAnalyzer) AnalyzeContent(path string, data []byte, dir map[string][]byte) (*Result, error) {
+	return componentsResult(parseCargoToml(path, data))
+}
 
+// parseCargoToml reads the dependency tables of a Cargo.toml.
+func parseCargoToml(path string, data []byte) ([]sbom.Component, error) {
 	var components []sbom.Component
 	lines := strings.Split(string(data), "\n")
+	var scope sbom.Scope
 	inDependencies := false
 
-	for _, line := range lines {
+	for lineNo, line := range lines {
 		line = strings.TrimSpace(line)
-		if strings.HasPrefix(line, "[dependencies]") {
-			inDependencies = true
-			continue
-		}
 		if strings.HasPrefix(line, "[") {
-			inDependencies = false
+			inDependencies = true
+			switch line {
+			case "[dependencies]":
+				scope = ""
+			case "[dev-dependencies]":
+				scope = sbom.ScopeDev
+			case "[build-dependencies]":
+				scope = sbom.ScopeBuild
+			default:
+				inDependencies = false
+			}
 			continue
 		}
 		if inDependencies && line != "" && !strings.HasPrefix(line, "#") {
@@ -291,17 +1165,45 @@ func (a *CargoAnalyzer) Analyze(path string) ([]sbom.Component, error) {
 			if len(parts) == 2 {
 				name := strings.TrimSpace(parts[0])
 				versionPart := strings.TrimSpace(parts[1])
-				if strings.HasPrefix(versionPart, "{") {
+				if vcsURL, ref, ok := cargoGitRef(versionPart); ok && strings.HasPrefix(versionPart, "{") {
+					depScope := scope
+					if scope == "" && cargoOptional(versionPart) {
+						depScope = sbom.ScopeOptional
+					}
+					components = append(components, sbom.Component{
+						Name:     name,
+						Version:  ref,
+						Supplier: "cargo",
+						PURL:     gitPURL("cargo", name, vcsURL),
+						Metadata: sbom.Metadata{
+							Description: sbom.GitDependency,
+							SourceFile:  path,
+							SourceLine:  lineNo + 1,
+						},
+						Scope:  depScope,
+						Direct: true,
+					})
+				} else if strings.HasPrefix(versionPart, "{") {
 					versionStart := strings.Index(versionPart, `"`)
 					if versionStart >= 0 {
 						versionEnd := strings.Index(versionPart[versionStart+1:], `"`)
 						if versionEnd >= 0 {
 							version := versionPart[versionStart+1 : versionStart+versionEnd+1]
+							depScope := scope
+							if scope == "" && cargoOptional(versionPart) {
+								depScope = sbom.ScopeOptional
+							}
 							components = append(components, sbom.Component{
 								Name:     name,
 								Version:  version,
 								Supplier: "cargo",
-								PURL:     fmt.Sprintf("pkg:cargo/%s@%s", name, version),
+								PURL:     sbom.PURL("cargo", name, version),
+								Metadata: sbom.Metadata{
+									SourceFile: path,
+									SourceLine: lineNo + 1,
+								},
+								Scope:  depScope,
+								Direct: true,
 							})
 						}
 					}
@@ -311,7 +1213,13 @@ func (a *CargoAnalyzer) Analyze(path string) ([]sbom.Component, error) {
 						Name:     name,
 						Version:  version,
 						Supplier: "cargo",
-						PURL:     fmt.Sprintf("pkg:cargo/%s@%s", name, version),
+						PURL:     sbom.PURL("cargo", name, version),
+						Metadata: sbom.Metadata{
+							SourceFile: path,
+							SourceLine: lineNo + 1,
+						},
+						Scope:  scope,
+						Direct: true,
 					})
 				}
 			}
@@ -321,6 +1229,104 @@ func (a *CargoAnalyzer) Analyze(path string) ([]sbom.Component, error) {
 	return components, nil
 }
 
+// cargoOptional reports whether an inline dependency table such as
+// { version = "1", optional = true } declares an optional dependency.
+func cargoOptional(table string) bool {
+	for _, field := range strings.Split(strings.Trim(table, "{}"), ",") {
+		key, value, ok := strings.Cut(field, "=")
+		if ok && strings.TrimSpace(key) == "optional" && strings.TrimSpace(value) == "true" {
+			return true
+		}
+	}
+	return false
+}
+
+// AnalyzeRoot reads the [package] section of Cargo.toml to describe the
+// crate itself.
+func (a *CargoAnalyzer) AnalyzeRoot(path string) (*sbom.Component, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseCargoPackage(path, data)
+}
+
+func (a *CargoAnalyzer) AnalyzeRootContent(path string, data []byte, dir map[string][]byte) (*sbom.Component, error) {
+	return parseCargoPackage(path, data)
+}
+
+// parseCargoPackage describes the crate declared by a Cargo.toml.
+func parseCargoPackage(path string, data []byte) (*sbom.Component, error) {
+	fields := make(map[string]string)
+	var authors []string
+	lines := strings.Split(string(data), "\n")
+	inPackage := false
+
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(line, "[") {
+			inPackage = line == "[package]"
+			continue
+		}
+		if !inPackage || line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		key := strings.TrimSpace(parts[0])
+		value := strings.TrimSpace(parts[1])
+
+		if key == "authors" {
+			// Arrays may span several lines until the closing bracket.
+			for !strings.Contains(value, "]") && i+1 < len(lines) {
+				i++
+				value += strings.TrimSpace(lines[i])
+			}
+			authors = parseTOMLStringArray(value)
+			continue
+		}
+		fields[key] = strings.Trim(value, `"`)
+	}
+
+	if fields["name"] == "" {
+		return nil, fmt.Errorf("no [package] name in %s", path)
+	}
+
+	return &sbom.Component{
+		Name:     fields["name"],
+		Version:  fields["version"],
+		Supplier: "cargo",
+		License:  sbom.LicenseExpression(fields["license"]),
+		PURL:     sbom.PURL("cargo", fields["name"], fields["version"]),
+		Metadata: sbom.Metadata{
+			Author:      strings.Join(authors, ", "),
+			Description: fields["description"],
+			HomepageURL: fields["homepage"],
+			SourceURL:   fields["repository"],
+		},
+	}, nil
+}
+
+// parseTOMLStringArray extracts the quoted strings of a TOML array value.
+func parseTOMLStringArray(value string) []string {
+	var result []string
+	for {
+		start := strings.Index(value, `"`)
+		if start < 0 {
+			return result
+		}
+		end := strings.Index(value[start+1:], `"`)
+		if end < 0 {
+			return result
+		}
+		result = append(result, value[start+1:start+1+end])
+		value = value[start+end+2:]
+	}
+}
+
 // MavenAnalyzer analyzes Java/Maven projects.
 type MavenAnalyzer struct{}
 
@@ -333,7 +1339,7 @@ func (a *MavenAnalyzer) Name() string {
 }
 
 func (a *MavenAnalyzer) ShouldAnalyze(path string) bool {
-	return filepath.Base(path) == "pom.xml"
+	return isManifest(path, "pom.xml")
 }
 
 func (a *MavenAnalyzer) Analyze(path string) ([]sbom.Component, error) {
@@ -345,40 +1351,100 @@ func (a *MavenAnalyzer) Analyze(path string) ([]sbom.Component, error) {
 	return parsePomXML(string(data)), nil
 }
 
+// AnalyzeContent analyzes a pom.xml held in memory.
+func (a *MavenAnalyzer) AnalyzeContent(path string, data []byte, dir map[string][]byte) (*Result, error) {
+	return &Result{Components: parsePomXML(string(data))}, nil
+}
+
+// parsePomXML reads the <dependency> blocks of a pom.xml. Dependencies of
+// build plugins are build-time; those under <dependencyManagement> only pin
+// versions and are skipped, as are dependencies without a literal version.
 func parsePomXML(xmlContent string) []sbom.Component {
 	var components []sbom.Component
-	lines := strings.Split(xmlContent, "\n")
-	inDependencies := false
+	var dep map[string]string
+	depLine := 0
+	inBuild, inManagement, inExclusions := false, false, false
 
-	for _, line := range lines {
+	for lineNo, line := range strings.Split(xmlContent, "\n") {
 		line = strings.TrimSpace(line)
-		if strings.Contains(line, "<dependencies>") {
-			inDependencies = true
-			continue
+		switch {
+		case strings.Contains(line, "<build>"):
+			inBuild = true
+		case strings.Contains(line, "</build>"):
+			inBuild = false
+		case strings.Contains(line, "<dependencyManagement>"):
+			inManagement = true
+		case strings.Contains(line, "</dependencyManagement>"):
+			inManagement = false
 		}
-		if strings.Contains(line, "</dependencies>") {
-			inDependencies = false
+		if strings.Contains(line, "<dependency>") {
+			dep = make(map[string]string)
+			depLine = lineNo + 1
+		}
+		if dep == nil {
 			continue
 		}
-		if inDependencies {
-			if strings.Contains(line, "<artifactId>") && strings.Contains(line, "</artifactId>") {
-				artifactId := extractTag(line, "artifactId")
-				version := extractTag(line, "version")
-				if artifactId != "" && version != "" {
-					components = append(components, sbom.Component{
-						Name:     artifactId,
-						Version:  version,
-						Supplier: "maven",
-						PURL:     fmt.Sprintf("pkg:maven/%s@%s", artifactId, version),
-					})
+		if strings.Contains(line, "<exclusions>") {
+			inExclusions = true
+		}
+		if !inExclusions {
+			for _, tag := range []string{"groupId", "artifactId", "version", "scope", "optional"} {
+				if value := extractTag(line, tag); value != "" {
+					dep[tag] = value
 				}
 			}
 		}
+		if strings.Contains(line, "</exclusions>") {
+			inExclusions = false
+		}
+		if !strings.Contains(line, "</dependency>") {
+			continue
+		}
+
+		artifactId, version := dep["artifactId"], dep["version"]
+		if !inManagement && artifactId != "" && version != "" && !strings.Contains(version, "${") {
+			name := artifactId
+			if dep["groupId"] != "" {
+				name = dep["groupId"] + "/" + artifactId
+			}
+			scope := mavenScope(dep["scope"], dep["optional"] == "true")
+			if inBuild {
+				scope = sbom.ScopeBuild
+			}
+			components = append(components, sbom.Component{
+				Name:     artifactId,
+				Version:  version,
+				Supplier: "maven",
+				PURL:     sbom.PURL("maven", name, version),
+				Scope:    scope,
+				Direct:   true,
+				Metadata: sbom.Metadata{
+					SourceLine: depLine,
+				},
+			})
+		}
+		dep = nil
 	}
 
 	return components
 }
 
+// mavenScope maps a Maven dependency scope onto a component scope. Provided
+// and system dependencies are supplied by the environment at build time and
+// are not packaged, so they count as build dependencies.
+func mavenScope(scope string, optional bool) sbom.Scope {
+	switch scope {
+	case "test":
+		return sbom.ScopeTest
+	case "provided", "system":
+		return sbom.ScopeBuild
+	}
+	if optional {
+		return sbom.ScopeOptional
+	}
+	return ""
+}
+
 func extractTag(line, tag string) string {
 	startTag := fmt.Sprintf("<%s>", tag)
 	endTag := fmt.Sprintf("</%s>", tag)
@@ -395,4 +1461,4 @@ func extractTag(line, tag string) string {
 	}
 
 	return line[start : start+end]
-}
\ No newline at end of file
+}
@@ -0,0 +1,364 @@
+package analyzer
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	pathpkg "path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hallucinaut/sbomgen/pkg/sbom"
+)
+
+// MaxArchiveEntrySize bounds the size of a single manifest read from an
+// archive, so that a malicious archive cannot exhaust memory.
+const MaxArchiveEntrySize = 64 << 20
+
+// ErrUnsupportedArchive is returned for archives that are not .tar, .tar.gz,
+// .tgz or .zip files.
+var ErrUnsupportedArchive = errors.New("unsupported archive format")
+
+// archiveSidecars lists files that analyzers consult next to a manifest,
+// such as lockfiles and license texts, and that are therefore read from
+// an archive along with the manifests themselves.
+var archiveSidecars = append(append(append([]string{"Cargo.lock", ".npmrc", "Package.swift"},
+	npmLockfiles...), pypiLockfiles...), licenseFileNames...)
+
+// AnalyzeArchive analyzes the project packed in a .tar, .tar.gz, .tgz or
+// .zip file without unpacking it. The archive is streamed and only the
+// manifests the analyzers accept, plus the lockfiles and license files
+// they consult, are read into memory; entries below skipped directories
+// such as node_modules are ignored, as in a directory walk. The entries
+// are then analyzed in memory by the analyzers implementing
+// ContentAnalyzer, so nothing is written to disk. npm workspaces are not
+// resolved: each member's package.json is analyzed on its own. Source
+// files in the result are reported as "<archive>!/<entry>". The returned
+// root describes the project when a top-level manifest names it, and is
+// nil otherwise.
+func (p *ProjectAnalyzer) AnalyzeArchive(archive string) (*Result, *sbom.Component, error) {
+	files := make(map[string][]byte)
+	entries := 0
+	err := readArchive(archive, func(name string, size int64, r io.Reader) error {
+		entries++
+		if p.MaxFiles > 0 && entries > p.MaxFiles {
+			return fmt.Errorf("%w: more than %d entries in %s", ErrTooManyFiles, p.MaxFiles, archive)
+		}
+		name, ok := archiveEntryPath(name)
+		if !ok || !p.wantsArchiveEntry(name) {
+			return nil
+		}
+		if size > MaxArchiveEntrySize {
+			return fmt.Errorf("archive entry %s is larger than %d bytes", name, MaxArchiveEntrySize)
+		}
+		data, err := io.ReadAll(io.LimitReader(r, MaxArchiveEntrySize+1))
+		if err != nil {
+			return fmt.Errorf("failed to read archive entry %s: %w", name, err)
+		}
+		if len(data) > MaxArchiveEntrySize {
+			return fmt.Errorf("archive entry %s is larger than %d bytes", name, MaxArchiveEntrySize)
+		}
+		files[name] = data
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tree := newArchiveTree(archive, files)
+	result, err := p.analyzeArchiveTree(tree)
+	if err != nil {
+		return nil, nil, err
+	}
+	return result, p.detectArchiveRoot(tree), nil
+}
+
+// archiveTree holds the entries read from an archive, keyed by their
+// cleaned slash-separated names and grouped by directory.
+type archiveTree struct {
+	// display prefixes entry names to form the paths reported for them.
+	display string
+	files   map[string][]byte
+	dirs    map[string]map[string][]byte
+}
+
+func newArchiveTree(archive string, files map[string][]byte) *archiveTree {
+	tree := &archiveTree{
+		display: filepath.ToSlash(archive) + "!/",
+		files:   files,
+		dirs:    make(map[string]map[string][]byte),
+	}
+	for name, data := range files {
+		dir := pathpkg.Dir(name)
+		if tree.dirs[dir] == nil {
+			tree.dirs[dir] = make(map[string][]byte)
+		}
+		tree.dirs[dir][pathpkg.Base(name)] = data
+	}
+	return tree
+}
+
+// path returns the path an entry is reported and analyzed under.
+func (t *archiveTree) path(name string) string {
+	return filepath.FromSlash(t.display + name)
+}
+
+// entry returns the entry name of a path returned by path.
+func (t *archiveTree) entry(path string) (string, bool) {
+	return strings.CutPrefix(filepath.ToSlash(path), t.display)
+}
+
+// readFile reads an entry by the path returned by path, as os.ReadFile
+// would.
+func (t *archiveTree) readFile(path string) ([]byte, error) {
+	if name, ok := t.entry(path); ok {
+		if data, ok := t.files[name]; ok {
+			return data, nil
+		}
+	}
+	return nil, &fs.PathError{Op: "open", Path: path, Err: fs.ErrNotExist}
+}
+
+// analyzeArchiveTree mirrors analyzeMatching for the entries of an
+// archive: manifests are analyzed shallowest first, by the first content
+// analyzer that accepts them, skipping case-insensitive duplicates and the
+// manifests an earlier result claimed.
+func (p *ProjectAnalyzer) analyzeArchiveTree(tree *archiveTree) (*Result, error) {
+	names := make([]string, 0, len(tree.files))
+	for name := range tree.files {
+		names = append(names, name)
+	}
+	// Sorting on \x00 in place of the separator visits entries in the order
+	// of a directory walk.
+	sort.Slice(names, func(i, j int) bool {
+		return strings.ReplaceAll(names[i], "/", "\x00") < strings.ReplaceAll(names[j], "/", "\x00")
+	})
+
+	var matches []manifestMatch
+	seen := make(map[string]bool)
+	for _, name := range names {
+		path := tree.path(name)
+		if seen[manifestKey(path)] {
+			continue
+		}
+		for _, analyzer := range p.analyzers {
+			if _, ok := analyzer.(ContentAnalyzer); ok && analyzer.ShouldAnalyze(path) {
+				matches = append(matches, manifestMatch{path: path, analyzer: analyzer})
+				seen[manifestKey(path)] = true
+				break
+			}
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return pathDepth(matches[i].path) < pathDepth(matches[j].path)
+	})
+
+	result := &Result{}
+	claimed := make(map[string]bool)
+	var unlocked []string
+	exists := func(path string) bool {
+		_, err := tree.readFile(path)
+		return err == nil
+	}
+	for _, match := range matches {
+		if claimed[manifestKey(match.path)] {
+			continue
+		}
+
+		name := match.analyzer.Name()
+		entry, _ := tree.entry(match.path)
+		if _, ok := match.analyzer.(LockfileAnalyzer); ok && p.RequireLockfile && !tree.hasLockfile(match.analyzer, entry) {
+			result.Scanned = append(result.Scanned, failedRecord(name, match.path, ErrNoLockfile))
+			unlocked = append(unlocked, filepath.ToSlash(match.path))
+			continue
+		}
+		r, err := match.analyzer.(ContentAnalyzer).AnalyzeContent(match.path, tree.files[entry], tree.dirs[pathpkg.Dir(entry)])
+		if err != nil {
+			result.Scanned = append(result.Scanned, failedRecord(name, match.path, err))
+			continue
+		}
+		p.addResult(result, claimed, name, match.path, r, exists)
+	}
+
+	return p.finishResult(result, unlocked, tree.readFile)
+}
+
+// hasLockfile is the in-memory counterpart of HasLockfile for the manifest
+// at entry, which analyzer handles.
+func (t *archiveTree) hasLockfile(analyzer Analyzer, entry string) bool {
+	dir := t.dirs[pathpkg.Dir(entry)]
+	hasFile := func(dir map[string][]byte, names ...string) bool {
+		for _, name := range names {
+			if _, _, ok := dirFile(dir, name); ok {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch analyzer.(type) {
+	case *NPMAnalyzer:
+		return hasFile(dir, npmLockfiles...)
+	case *PyPIAnalyzer:
+		return hasFile(dir, pypiLockfiles...) || pinnedRequirements(t.files[entry])
+	case *CargoAnalyzer:
+		for name := pathpkg.Dir(entry); hasFile(t.dirs[name], "Cargo.toml"); name = pathpkg.Dir(name) {
+			if hasFile(t.dirs[name], "Cargo.lock") {
+				return true
+			}
+			if name == "." {
+				break
+			}
+		}
+	}
+	return false
+}
+
+// detectArchiveRoot is the in-memory counterpart of DetectRoot. Source
+// archives usually wrap the tree in one top-level directory such as
+// "project-1.0/", in which case the manifests in that directory describe
+// the project.
+func (p *ProjectAnalyzer) detectArchiveRoot(tree *archiveTree) *sbom.Component {
+	top := "."
+	for name := range tree.files {
+		first, _, nested := strings.Cut(name, "/")
+		if !nested || (top != "." && first != top) {
+			top = "."
+			break
+		}
+		top = first
+	}
+
+	dir := tree.dirs[top]
+	bases := make([]string, 0, len(dir))
+	for base := range dir {
+		bases = append(bases, base)
+	}
+	sort.Strings(bases)
+
+	for _, base := range bases {
+		path := tree.path(pathpkg.Join(top, base))
+		for _, analyzer := range p.analyzers {
+			rootAnalyzer, ok := analyzer.(RootContentAnalyzer)
+			if !ok || !rootAnalyzer.ShouldAnalyze(path) {
+				continue
+			}
+			root, err := rootAnalyzer.AnalyzeRootContent(path, dir[base], dir)
+			if err == nil && root != nil {
+				if root.License != "" {
+					root.Metadata.LicenseSource = sbom.LicenseSourceDeclared
+				}
+				return root
+			}
+		}
+	}
+	return nil
+}
+
+// wantsArchiveEntry reports whether the archive entry at name, a cleaned
+// slash-separated path, should be read.
+func (p *ProjectAnalyzer) wantsArchiveEntry(name string) bool {
+	dirs := strings.Split(pathpkg.Dir(name), "/")
+	for _, dir := range dirs {
+		if skipDirs[dir] {
+			return false
+		}
+	}
+	if isManifest(name, archiveSidecars...) {
+		return true
+	}
+	for _, analyzer := range p.analyzers {
+		if analyzer.ShouldAnalyze(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// archiveEntryPath cleans the name of an archive entry, rejecting absolute
+// names and names that escape the archive root.
+func archiveEntryPath(name string) (string, bool) {
+	name = pathpkg.Clean(strings.ReplaceAll(name, "\\", "/"))
+	if name == "." || pathpkg.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+		return "", false
+	}
+	return name, true
+}
+
+// readArchive calls visit with the name, size and contents of every
+// regular file in archive.
+func readArchive(archive string, visit func(name string, size int64, r io.Reader) error) error {
+	lower := strings.ToLower(archive)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return readZip(archive, visit)
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		f, err := os.Open(archive)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", archive, err)
+		}
+		defer gz.Close()
+		return readTar(archive, gz, visit)
+	case strings.HasSuffix(lower, ".tar"):
+		f, err := os.Open(archive)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return readTar(archive, f, visit)
+	}
+	return fmt.Errorf("%w: %s (supported: .tar, .tar.gz, .tgz, .zip)", ErrUnsupportedArchive, archive)
+}
+
+func readTar(archive string, r io.Reader, visit func(name string, size int64, r io.Reader) error) error {
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", archive, err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := visit(header.Name, header.Size, tr); err != nil {
+			return err
+		}
+	}
+}
+
+func readZip(archive string, visit func(name string, size int64, r io.Reader) error) error {
+	zr, err := zip.OpenReader(archive)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", archive, err)
+	}
+	defer zr.Close()
+	for _, file := range zr.File {
+		if !file.Mode().IsRegular() {
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return fmt.Errorf("failed to read %s in %s: %w", file.Name, archive, err)
+		}
+		err = visit(file.Name, int64(file.UncompressedSize64), rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package analyzer
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hallucinaut/sbomgen/pkg/sbom"
+)
+
+const archiveGoMod = `module example.com/app
+
+go 1.21
+
+require github.com/pkg/errors v0.9.1
+`
+
+func writeTarGz(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Failed to create archive: %v", err)
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		header := &tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatalf("Failed to write tar header: %v", err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatalf("Failed to write tar entry: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("Failed to close tar: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("Failed to close gzip: %v", err)
+	}
+}
+
+func TestProjectAnalyzer_AnalyzeArchive_TarGz(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "project.tar.gz")
+	writeTarGz(t, archive, map[string]string{
+		"app/go.mod":           archiveGoMod,
+		"app/main.go":          "package main\n",
+		"app/package.json":     `{"name": "app", "version": "1.2.0"}`,
+		"app/web/package.json": `{"name": "web", "dependencies": {"express": "4.18.2"}}`,
+		"app/web/node_modules/left-pad/package.json": `{"dependencies": {"ignored": "1.0.0"}}`,
+		"../escape/package.json":                     `{"dependencies": {"escaped": "1.0.0"}}`,
+	})
+
+	result, root, err := NewProjectAnalyzer().AnalyzeArchive(archive)
+	if err != nil {
+		t.Fatalf("Failed to analyze archive: %v", err)
+	}
+	if root == nil || root.Name != "app" {
+		t.Errorf("Expected the root from the top-level directory's package.json, got %+v", root)
+	}
+
+	found := make(map[string]string)
+	for _, comp := range result.Components {
+		found[comp.Name] = comp.Metadata.SourceFile
+	}
+	if got := found["errors"]; got != filepath.ToSlash(archive)+"!/app/go.mod" {
+		t.Errorf("Expected errors from the archived go.mod, got source %q", got)
+	}
+	if _, ok := found["express"]; !ok {
+		t.Error("Expected express from the archived package.json")
+	}
+	for _, name := range []string{"ignored", "escaped"} {
+		if _, ok := found[name]; ok {
+			t.Errorf("Expected %s to be skipped", name)
+		}
+	}
+	for _, record := range result.Scanned {
+		if !strings.HasPrefix(record.Path, filepath.ToSlash(archive)+"!/") {
+			t.Errorf("Expected scanned paths inside the archive, got %s", record.Path)
+		}
+	}
+}
+
+func TestProjectAnalyzer_AnalyzeArchive_Zip(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "project.zip")
+	f, err := os.Create(archive)
+	if err != nil {
+		t.Fatalf("Failed to create archive: %v", err)
+	}
+	zw := zip.NewWriter(f)
+	w, err := zw.Create("package.json")
+	if err != nil {
+		t.Fatalf("Failed to add zip entry: %v", err)
+	}
+	if _, err := w.Write([]byte(`{"name": "app", "version": "2.0.0", "dependencies": {"lodash": "4.17.21"}}`)); err != nil {
+		t.Fatalf("Failed to write zip entry: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Failed to close zip: %v", err)
+	}
+	f.Close()
+
+	result, root, err := NewProjectAnalyzer().AnalyzeArchive(archive)
+	if err != nil {
+		t.Fatalf("Failed to analyze archive: %v", err)
+	}
+	if len(result.Components) != 1 || result.Components[0].Name != "lodash" {
+		t.Errorf("Expected lodash, got %+v", result.Components)
+	}
+	if root == nil || root.Name != "app" || root.Version != "2.0.0" {
+		t.Errorf("Expected the root from the archived package.json, got %+v", root)
+	}
+}
+
+func TestProjectAnalyzer_AnalyzeArchive_InMemory(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "project.tar.gz")
+	writeTarGz(t, archive, map[string]string{
+		"app/package.json":           `{"name": "app", "dependencies": {"util": "file:util"}}`,
+		"app/package-lock.json":      `{"lockfileVersion": 3}`,
+		"app/util/LICENSE":           apacheLicense,
+		"app/crates/Cargo.toml":      "[workspace]\nmembers = [\"core\"]\n",
+		"app/crates/Cargo.lock":      "version = 3\n",
+		"app/crates/core/Cargo.toml": "[package]\nname = \"core\"\n\n[dependencies]\nserde = \"1\"\n",
+	})
+
+	analyzer := NewProjectAnalyzer()
+	analyzer.RequireLockfile = true
+	result, _, err := analyzer.AnalyzeArchive(archive)
+	if err != nil {
+		t.Fatalf("Expected the workspace Cargo.lock to satisfy RequireLockfile, got %v", err)
+	}
+
+	var util *sbom.Component
+	for i := range result.Components {
+		if result.Components[i].Name == "util" {
+			util = &result.Components[i]
+		}
+	}
+	if util == nil {
+		t.Fatal("Expected util from the archived package.json")
+	}
+	if util.License != "Apache-2.0" {
+		t.Errorf("Expected the license inferred from the archived LICENSE, got '%s'", util.License)
+	}
+	if want := filepath.ToSlash(archive) + "!/app/util/LICENSE"; util.Metadata.LicenseFile != want {
+		t.Errorf("Expected license file %s, got %s", want, util.Metadata.LicenseFile)
+	}
+
+	writeTarGz(t, archive, map[string]string{
+		"app/requirements.txt": "requests>=2.0\n",
+	})
+	if _, _, err := analyzer.AnalyzeArchive(archive); !errors.Is(err, ErrNoLockfile) {
+		t.Errorf("Expected ErrNoLockfile for unpinned requirements, got %v", err)
+	}
+}
+
+func TestProjectAnalyzer_AnalyzeArchive_Unsupported(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "project.rar")
+	writeTestFile(t, archive, "not an archive")
+	if _, _, err := NewProjectAnalyzer().AnalyzeArchive(archive); !errors.Is(err, ErrUnsupportedArchive) {
+		t.Errorf("Expected ErrUnsupportedArchive, got %v", err)
+	}
+}
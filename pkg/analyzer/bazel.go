@@ -0,0 +1,222 @@
+package analyzer
+
+import (
+	"os"
+	pathpkg "path"
+	"regexp"
+	"strings"
+
+	"github.com/hallucinaut/sbomgen/pkg/sbom"
+)
+
+// BazelAnalyzer analyzes Bazel projects, reading bazel_dep calls from
+// MODULE.bazel (bzlmod) and http_archive/git_repository rules from the
+// legacy WORKSPACE file.
+type BazelAnalyzer struct{}
+
+func NewBazelAnalyzer() *BazelAnalyzer {
+	return &BazelAnalyzer{}
+}
+
+func (a *BazelAnalyzer) Name() string {
+	return "bazel"
+}
+
+func (a *BazelAnalyzer) ShouldAnalyze(path string) bool {
+	return isManifest(path, "MODULE.bazel", "WORKSPACE", "WORKSPACE.bazel")
+}
+
+func (a *BazelAnalyzer) Analyze(path string) ([]sbom.Component, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseBazelFile(path, data)
+}
+
+// AnalyzeContent analyzes a MODULE.bazel or WORKSPACE file held in memory.
+func (a *BazelAnalyzer) AnalyzeContent(path string, data []byte, dir map[string][]byte) (*Result, error) {
+	return componentsResult(parseBazelFile(path, data))
+}
+
+// parseBazelFile reads the dependencies declared by a MODULE.bazel or WORKSPACE file.
+func parseBazelFile(path string, data []byte) ([]sbom.Component, error) {
+	content := string(data)
+
+	var components []sbom.Component
+	if isManifest(path, "MODULE.bazel") {
+		for _, call := range starlarkCalls(content, "bazel_dep") {
+			name, version := call.args["name"], call.args["version"]
+			if name == "" {
+				continue
+			}
+			comp := sbom.Component{
+				Name:     name,
+				Version:  version,
+				Supplier: "bazel",
+				PURL:     sbom.PURL("bazel", name, version),
+				Direct:   true,
+				Metadata: sbom.Metadata{
+					SourceFile: path,
+					SourceLine: call.line,
+				},
+			}
+			if call.args["dev_dependency"] == "True" {
+				comp.Scope = sbom.ScopeDev
+			}
+			components = append(components, comp)
+		}
+		return components, nil
+	}
+
+	for _, call := range starlarkCalls(content, "http_archive") {
+		name := call.args["name"]
+		if name == "" {
+			continue
+		}
+		url := call.args["url"]
+		if url == "" && len(call.lists["urls"]) > 0 {
+			url = call.lists["urls"][0]
+		}
+		version := archiveVersion(name, call.args["strip_prefix"], url)
+		comp := sbom.Component{
+			Name:     name,
+			Version:  version,
+			Supplier: "bazel",
+			PURL:     archivePURL(name, version, url),
+			Direct:   true,
+			Metadata: sbom.Metadata{
+				SourceURL:  url,
+				SourceFile: path,
+				SourceLine: call.line,
+			},
+		}
+		if sha := call.args["sha256"]; sha != "" {
+			comp.Hashes = []sbom.Hash{{Algorithm: "SHA-256", Value: sha}}
+		}
+		components = append(components, comp)
+	}
+
+	for _, call := range starlarkCalls(content, "git_repository") {
+		name, remote := call.args["name"], call.args["remote"]
+		if name == "" {
+			continue
+		}
+		version := call.args["tag"]
+		if version == "" {
+			version = call.args["commit"]
+		}
+		components = append(components, sbom.Component{
+			Name:     name,
+			Version:  version,
+			Supplier: "bazel",
+			PURL:     archivePURL(name, version, remote),
+			Direct:   true,
+			Metadata: sbom.Metadata{
+				SourceURL:  remote,
+				SourceFile: path,
+				SourceLine: call.line,
+			},
+		})
+	}
+
+	return components, nil
+}
+
+// starlarkCall is a function call found in a Starlark file, with its
+// string and boolean keyword arguments and any string-list arguments.
+type starlarkCall struct {
+	line  int
+	args  map[string]string
+	lists map[string][]string
+}
+
+var (
+	starlarkScalarArg = regexp.MustCompile(`(\w+)\s*=\s*(?:"([^"]*)"|'([^']*)'|(True|False))`)
+	starlarkListArg   = regexp.MustCompile(`(\w+)\s*=\s*\[([^\]]*)\]`)
+	starlarkString    = regexp.MustCompile(`"([^"]*)"|'([^']*)'`)
+)
+
+// starlarkCalls finds every call to function in content. Arguments are
+// matched textually, which is sufficient for the literal values used in
+// MODULE.bazel and WORKSPACE files.
+func starlarkCalls(content, function string) []starlarkCall {
+	var calls []starlarkCall
+	pattern := regexp.MustCompile(`(?m)^[ \t]*` + regexp.QuoteMeta(function) + `\s*\(`)
+
+	for _, loc := range pattern.FindAllStringIndex(content, -1) {
+		body, ok := callBody(content[loc[1]:])
+		if !ok {
+			continue
+		}
+
+		call := starlarkCall{
+			line:  strings.Count(content[:loc[0]], "\n") + 1,
+			args:  make(map[string]string),
+			lists: make(map[string][]string),
+		}
+
+		for _, m := range starlarkListArg.FindAllStringSubmatch(body, -1) {
+			for _, item := range starlarkString.FindAllStringSubmatch(m[2], -1) {
+				call.lists[m[1]] = append(call.lists[m[1]], item[1]+item[2])
+			}
+		}
+		for _, m := range starlarkScalarArg.FindAllStringSubmatch(body, -1) {
+			if _, ok := call.args[m[1]]; !ok {
+				call.args[m[1]] = m[2] + m[3] + m[4]
+			}
+		}
+		calls = append(calls, call)
+	}
+	return calls
+}
+
+// callBody returns the text up to the parenthesis closing an open call.
+func callBody(s string) (string, bool) {
+	depth := 1
+	inString := byte(0)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case inString != 0:
+			if c == inString {
+				inString = 0
+			}
+		case c == '"' || c == '\'':
+			inString = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return s[:i], true
+			}
+		}
+	}
+	return "", false
+}
+
+var archiveVersionPattern = regexp.MustCompile(`v?(\d+(?:\.\d+)+(?:[-.][0-9A-Za-z]+)*)`)
+
+// archiveVersion makes a best-effort guess at an archive's version from its
+// strip_prefix or download URL.
+func archiveVersion(name, stripPrefix, url string) string {
+	for _, candidate := range []string{stripPrefix, pathpkg.Base(url)} {
+		candidate = strings.TrimSuffix(strings.TrimSuffix(candidate, ".tar.gz"), ".zip")
+		if m := archiveVersionPattern.FindStringSubmatch(candidate); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+var githubRepoPattern = regexp.MustCompile(`github\.com[/:]([^/]+)/([^/]+?)(?:\.git)?(?:/|$)`)
+
+// archivePURL builds a pkg:github PURL for GitHub sources and falls back to
+// pkg:generic with the download URL as a qualifier.
+func archivePURL(name, version, url string) string {
+	if m := githubRepoPattern.FindStringSubmatch(url); m != nil {
+		return sbom.PURL("github", strings.ToLower(m[1])+"/"+strings.ToLower(m[2]), version)
+	}
+	return sbom.PURLWithQualifiers("generic", name, version, map[string]string{"download_url": url})
+}
@@ -0,0 +1,139 @@
+package analyzer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBazelAnalyzer_Module(t *testing.T) {
+	analyzer := NewBazelAnalyzer()
+
+	moduleBazel := `module(
+    name = "my_project",
+    version = "1.0.0",
+)
+
+bazel_dep(name = "rules_go", version = "0.41.0")
+bazel_dep(name = "gazelle", version = "0.32.0", repo_name = "bazel_gazelle")
+bazel_dep(
+    name = "protobuf",
+    version = "21.7",
+)
+bazel_dep(name = "rules_testing", version = "0.4.0", dev_dependency = True)
+`
+
+	tmpDir, err := os.MkdirTemp("", "bazel-analyzer-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	path := filepath.Join(tmpDir, "MODULE.bazel")
+	if err := os.WriteFile(path, []byte(moduleBazel), 0644); err != nil {
+		t.Fatalf("Failed to write MODULE.bazel: %v", err)
+	}
+
+	if !analyzer.ShouldAnalyze(path) {
+		t.Fatal("Expected analyzer to handle MODULE.bazel")
+	}
+
+	components, err := analyzer.Analyze(path)
+	if err != nil {
+		t.Fatalf("Failed to analyze: %v", err)
+	}
+
+	if len(components) != 4 {
+		t.Fatalf("Expected 4 components, got %d", len(components))
+	}
+
+	expected := []struct {
+		name, version, purl, scope string
+		line                       int
+	}{
+		{"rules_go", "0.41.0", "pkg:bazel/rules_go@0.41.0", "", 6},
+		{"gazelle", "0.32.0", "pkg:bazel/gazelle@0.32.0", "", 7},
+		{"protobuf", "21.7", "pkg:bazel/protobuf@21.7", "", 8},
+		{"rules_testing", "0.4.0", "pkg:bazel/rules_testing@0.4.0", "dev", 12},
+	}
+	for i, want := range expected {
+		got := components[i]
+		if got.Name != want.name || got.Version != want.version || got.PURL != want.purl {
+			t.Errorf("Component %d: expected %s %s %s, got %s %s %s",
+				i, want.name, want.version, want.purl, got.Name, got.Version, got.PURL)
+		}
+		if string(got.Scope) != want.scope {
+			t.Errorf("Component %s: expected scope '%s', got '%s'", want.name, want.scope, got.Scope)
+		}
+		if got.Metadata.SourceLine != want.line {
+			t.Errorf("Component %s: expected line %d, got %d", want.name, want.line, got.Metadata.SourceLine)
+		}
+	}
+}
+
+func TestBazelAnalyzer_Workspace(t *testing.T) {
+	analyzer := NewBazelAnalyzer()
+
+	workspace := `load("@bazel_tools//tools/build_defs/repo:http.bzl", "http_archive")
+load("@bazel_tools//tools/build_defs/repo:git.bzl", "git_repository")
+
+http_archive(
+    name = "io_bazel_rules_go",
+    sha256 = "278b7ff5a826f3dc10f04feaf0b70d48b68748ccd512d7f98bf442077f043fe3",
+    urls = ["https://github.com/bazelbuild/rules_go/releases/download/v0.41.0/rules_go-v0.41.0.zip"],
+)
+
+git_repository(
+    name = "com_google_absl",
+    remote = "https://github.com/abseil/abseil-cpp.git",
+    tag = "20230125.3",
+)
+`
+
+	tmpDir, err := os.MkdirTemp("", "bazel-analyzer-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	path := filepath.Join(tmpDir, "WORKSPACE")
+	if err := os.WriteFile(path, []byte(workspace), 0644); err != nil {
+		t.Fatalf("Failed to write WORKSPACE: %v", err)
+	}
+
+	components, err := analyzer.Analyze(path)
+	if err != nil {
+		t.Fatalf("Failed to analyze: %v", err)
+	}
+
+	if len(components) != 2 {
+		t.Fatalf("Expected 2 components, got %d", len(components))
+	}
+	if components[0].PURL != "pkg:github/bazelbuild/rules_go@0.41.0" {
+		t.Errorf("Unexpected http_archive PURL '%s'", components[0].PURL)
+	}
+	if len(components[0].Hashes) != 1 || components[0].Hashes[0].Algorithm != "SHA-256" {
+		t.Errorf("Expected SHA-256 hash on http_archive, got %v", components[0].Hashes)
+	}
+	if components[1].PURL != "pkg:github/abseil/abseil-cpp@20230125.3" {
+		t.Errorf("Unexpected git_repository PURL '%s'", components[1].PURL)
+	}
+}
+
+func TestArchivePURL_EncodesDownloadURL(t *testing.T) {
+	got := archivePURL("zlib", "1.3.1", "https://mirror.example.com/get?file=zlib-1.3.1.tar.gz&sig=a+b")
+	want := "pkg:generic/zlib@1.3.1?download_url=https%3A%2F%2Fmirror.example.com%2Fget%3Ffile%3Dzlib-1.3.1.tar.gz%26sig%3Da%2Bb"
+	if got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+	if got := archivePURL("zlib", "1.3.1", ""); got != "pkg:generic/zlib@1.3.1" {
+		t.Errorf("Expected no qualifier without a URL, got %s", got)
+	}
+}
+
+func TestBazelAnalyzer_Name(t *testing.T) {
+	analyzer := NewBazelAnalyzer()
+	if analyzer.Name() != "bazel" {
+		t.Errorf("Expected name 'bazel', got '%s'", analyzer.Name())
+	}
+}
@@ -0,0 +1,184 @@
+package analyzer
+
+import (
+	"debug/buildinfo"
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"fmt"
+	pathpkg "path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/hallucinaut/sbomgen/pkg/sbom"
+	"golang.org/x/mod/modfile"
+)
+
+// BinaryAnalyzer describes a compiled ELF, Mach-O or PE executable rather
+// than a source tree. Go binaries report the modules recorded in their build
+// information; other binaries are identified by their SHA-256 and, when one
+// is found, a version string embedded next to the program's name.
+type BinaryAnalyzer struct{}
+
+func NewBinaryAnalyzer() *BinaryAnalyzer {
+	return &BinaryAnalyzer{}
+}
+
+func (a *BinaryAnalyzer) Name() string {
+	return "binary"
+}
+
+func (a *BinaryAnalyzer) ShouldAnalyze(path string) bool {
+	_, _, err := readOnlyData(path)
+	return err == nil
+}
+
+func (a *BinaryAnalyzer) Analyze(path string) ([]sbom.Component, error) {
+	if _, _, err := readOnlyData(path); err != nil {
+		return nil, err
+	}
+
+	info, err := buildinfo.ReadFile(path)
+	if err != nil {
+		// Not a Go binary: the executable itself is the only component.
+		return nil, nil
+	}
+
+	var components []sbom.Component
+	for _, dep := range info.Deps {
+		mod := dep
+		if dep.Replace != nil {
+			mod = dep.Replace
+		}
+		version := mod.Version
+		if version == "" {
+			version = dep.Version
+		}
+
+		comp := sbom.Component{
+			Name:     pathpkg.Base(dep.Path),
+			Version:  version,
+			Supplier: "go",
+			PURL:     sbom.PURL("go", dep.Path, version),
+			Metadata: sbom.Metadata{
+				SourceFile: path,
+			},
+		}
+		if strings.HasPrefix(mod.Sum, "h1:") {
+			comp.Metadata.Checksum = mod.Sum
+		}
+		if dep.Replace != nil {
+			comp.Metadata.SourceURL = dep.Replace.Path
+			if modfile.IsDirectoryPath(dep.Replace.Path) {
+				comp.Scope = sbom.ScopeInternal
+			}
+		}
+		components = append(components, comp)
+	}
+
+	// GoVersion may carry experiment suffixes, e.g. "go1.22.1 X:loopvar".
+	if fields := strings.Fields(info.GoVersion); len(fields) > 0 {
+		version := strings.TrimPrefix(fields[0], "go")
+		components = append(components, sbom.Component{
+			Name:     "stdlib",
+			Version:  version,
+			Supplier: "go",
+			PURL:     sbom.PURL("go", "stdlib", version),
+			Direct:   true,
+			Metadata: sbom.Metadata{
+				SourceFile: path,
+			},
+		})
+	}
+
+	return components, nil
+}
+
+// AnalyzeRoot describes the executable itself: the main module of a Go
+// binary, or the file name and any embedded version of another binary.
+func (a *BinaryAnalyzer) AnalyzeRoot(path string) (*sbom.Component, error) {
+	format, data, err := readOnlyData(path)
+	if err != nil {
+		return nil, err
+	}
+	digest, err := fileDigest(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash %s: %w", path, err)
+	}
+
+	root := &sbom.Component{
+		Hashes: []sbom.Hash{{Algorithm: "SHA-256", Value: digest}},
+		Metadata: sbom.Metadata{
+			Description: format + " executable",
+			SourceFile:  filepath.ToSlash(path),
+		},
+	}
+
+	if info, err := buildinfo.ReadFile(path); err == nil && info.Main.Path != "" {
+		root.Name = info.Main.Path
+		root.Version = info.Main.Version
+		root.Supplier = "go"
+		root.PURL = sbom.PURL("go", info.Main.Path, info.Main.Version)
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" {
+				root.Metadata.Revision = setting.Value
+			}
+		}
+		return root, nil
+	}
+
+	root.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	root.Version = embeddedVersion(root.Name, data)
+	return root, nil
+}
+
+// readOnlyData identifies an executable format and returns the section
+// holding its constant strings, or nil if it has none.
+func readOnlyData(path string) (string, []byte, error) {
+	var data []byte
+	if f, err := elf.Open(path); err == nil {
+		defer f.Close()
+		if s := f.Section(".rodata"); s != nil {
+			data, _ = s.Data()
+		}
+		return "ELF", data, nil
+	}
+	if f, err := macho.Open(path); err == nil {
+		defer f.Close()
+		if s := f.Section("__cstring"); s != nil {
+			data, _ = s.Data()
+		}
+		return "Mach-O", data, nil
+	}
+	if fat, err := macho.OpenFat(path); err == nil {
+		defer fat.Close()
+		if len(fat.Arches) > 0 {
+			if s := fat.Arches[0].Section("__cstring"); s != nil {
+				data, _ = s.Data()
+			}
+		}
+		return "Mach-O", data, nil
+	}
+	if f, err := pe.Open(path); err == nil {
+		defer f.Close()
+		if s := f.Section(".rdata"); s != nil {
+			data, _ = s.Data()
+		}
+		return "PE", data, nil
+	}
+	return "", nil, fmt.Errorf("%s is not an ELF, Mach-O or PE binary", path)
+}
+
+// embeddedVersion finds a version string such as "curl 8.4.0" or
+// "nginx/1.25.3" that follows the program's name in its constant data.
+func embeddedVersion(name string, data []byte) string {
+	if name == "" || len(data) == 0 {
+		return ""
+	}
+	re := regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(name) + `[ /-]v?(\d+\.\d+(?:\.\d+)?(?:[-+][0-9A-Za-z.]+)?)`)
+	if m := re.FindSubmatch(data); m != nil {
+		return string(m[1])
+	}
+	return ""
+}
@@ -0,0 +1,115 @@
+package analyzer
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestBinaryAnalyzer_GoBinary(t *testing.T) {
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go toolchain not available")
+	}
+
+	tmpDir := t.TempDir()
+	writeTestFile(t, filepath.Join(tmpDir, "go.mod"), `module example.com/hello
+
+go 1.21
+
+require example.com/greeting v0.0.0
+
+replace example.com/greeting => ./greeting
+`)
+	writeTestFile(t, filepath.Join(tmpDir, "main.go"), `package main
+
+import "example.com/greeting"
+
+func main() { println(greeting.Hello()) }
+`)
+	writeTestFile(t, filepath.Join(tmpDir, "greeting", "go.mod"), "module example.com/greeting\n\ngo 1.21\n")
+	writeTestFile(t, filepath.Join(tmpDir, "greeting", "greeting.go"), `package greeting
+
+func Hello() string { return "hello" }
+`)
+
+	binary := filepath.Join(tmpDir, "hello")
+	if runtime.GOOS == "windows" {
+		binary += ".exe"
+	}
+	build := exec.Command(goTool, "build", "-buildvcs=false", "-o", binary, ".")
+	build.Dir = tmpDir
+	build.Env = append(os.Environ(), "GOWORK=off", "GOPROXY=off", "GOFLAGS=-mod=mod", "GOTOOLCHAIN=local")
+	if out, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("Failed to build test binary: %v\n%s", err, out)
+	}
+
+	analyzer := NewBinaryAnalyzer()
+	if !analyzer.ShouldAnalyze(binary) {
+		t.Fatal("Expected the Go binary to be recognized")
+	}
+
+	components, err := analyzer.Analyze(binary)
+	if err != nil {
+		t.Fatalf("Failed to analyze: %v", err)
+	}
+	if len(components) != 2 {
+		t.Fatalf("Expected the replaced module and stdlib, got %d: %v", len(components), components)
+	}
+
+	greeting := components[0]
+	if greeting.Name != "greeting" || greeting.Scope != "internal" || greeting.Metadata.SourceURL != "./greeting" {
+		t.Errorf("Expected locally replaced module to be internal, got %+v", greeting)
+	}
+
+	stdlib := components[1]
+	if !strings.HasPrefix(stdlib.PURL, "pkg:golang/stdlib@1.") {
+		t.Errorf("Expected stdlib component from the toolchain version, got '%s'", stdlib.PURL)
+	}
+
+	root, err := analyzer.AnalyzeRoot(binary)
+	if err != nil {
+		t.Fatalf("Failed to analyze root: %v", err)
+	}
+	if root.Name != "example.com/hello" || root.Version != "(devel)" {
+		t.Errorf("Expected main module as root, got %s@%s", root.Name, root.Version)
+	}
+	if len(root.Hashes) != 1 || len(root.Hashes[0].Value) != 64 {
+		t.Errorf("Expected SHA-256 of the binary, got %v", root.Hashes)
+	}
+}
+
+func TestBinaryAnalyzer_NotBinary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "script.sh")
+	writeTestFile(t, path, "#!/bin/sh\necho hello\n")
+
+	analyzer := NewBinaryAnalyzer()
+	if analyzer.ShouldAnalyze(path) {
+		t.Error("Expected a shell script not to be treated as a binary")
+	}
+	if _, err := analyzer.Analyze(path); err == nil {
+		t.Error("Expected error for a non-binary file")
+	}
+}
+
+func TestEmbeddedVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected string
+	}{
+		{"curl", "\x00libcurl\x00curl 8.4.0 (x86_64-pc-linux-gnu)\x00", "8.4.0"},
+		{"nginx", "\x00nginx/1.25.3\x00", "1.25.3"},
+		{"tool", "\x00tool version v2.1\x00", ""},
+		{"tool", "\x00tool-v2.1.0-rc.1\x00", "2.1.0-rc.1"},
+	}
+
+	for _, tt := range tests {
+		if got := embeddedVersion(tt.name, []byte(tt.data)); got != tt.expected {
+			t.Errorf("Expected %s version '%s' from %q, got '%s'", tt.name, tt.expected, tt.data, got)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package analyzer
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// ChangeLister lists the files under dir that differ from a VCS revision,
+// as slash-separated paths relative to dir.
+type ChangeLister interface {
+	ChangedFiles(dir, ref string) ([]string, error)
+}
+
+// GitChangeLister lists changed files with git diff.
+type GitChangeLister struct{}
+
+func NewGitChangeLister() *GitChangeLister {
+	return &GitChangeLister{}
+}
+
+func (g *GitChangeLister) ChangedFiles(dir, ref string) ([]string, error) {
+	cmd := exec.Command("git", "-C", dir, "diff", "--name-only", "--relative", ref, "--")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git diff against %s failed: %w: %s", ref, err, strings.TrimSpace(stderr.String()))
+	}
+
+	var files []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			files = append(files, line)
+		}
+	}
+	return files, nil
+}
+
+// AnalyzeChanged analyzes only the manifests under dir that lister reports
+// as changed since ref, for SBOMs scoped to a pull request or commit range.
+func (p *ProjectAnalyzer) AnalyzeChanged(dir, ref string, lister ChangeLister) (*Result, error) {
+	files, err := lister.ChangedFiles(dir, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	changed := make(map[string]bool, len(files))
+	for _, file := range files {
+		changed[filepath.ToSlash(filepath.Clean(file))] = true
+	}
+
+	return p.analyzeMatching(dir, func(path string) bool {
+		rel, err := filepath.Rel(dir, path)
+		return err == nil && changed[filepath.ToSlash(rel)]
+	})
+}
@@ -0,0 +1,42 @@
+package analyzer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type fakeChangeLister struct {
+	files []string
+	ref   string
+}
+
+func (f *fakeChangeLister) ChangedFiles(dir, ref string) ([]string, error) {
+	f.ref = ref
+	return f.files, nil
+}
+
+func TestProjectAnalyzer_AnalyzeChanged(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "changed-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	writeTestFile(t, filepath.Join(tmpDir, "api", "requirements.txt"), "flask==2.0.0\n")
+	writeTestFile(t, filepath.Join(tmpDir, "worker", "requirements.txt"), "celery==5.3.0\n")
+	writeTestFile(t, filepath.Join(tmpDir, "web", "package.json"), `{"dependencies": {"react": "^18.0.0"}}`)
+
+	lister := &fakeChangeLister{files: []string{"worker/requirements.txt", "README.md", "deleted/go.mod"}}
+	result, err := NewProjectAnalyzer().AnalyzeChanged(tmpDir, "origin/main", lister)
+	if err != nil {
+		t.Fatalf("Failed to analyze: %v", err)
+	}
+
+	if lister.ref != "origin/main" {
+		t.Errorf("Expected ref 'origin/main' to be passed through, got '%s'", lister.ref)
+	}
+	if len(result.Components) != 1 || result.Components[0].Name != "celery" {
+		t.Errorf("Expected only the changed manifest's components, got %v", result.Components)
+	}
+}
@@ -0,0 +1,71 @@
+package analyzer
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// Diagnosis describes how a registered analyzer applies to a project.
+type Diagnosis struct {
+	Analyzer string
+	// Manifests lists the manifests the analyzer consumed, relative to the
+	// project directory. It is empty when the analyzer has nothing to do.
+	Manifests []string
+	// Components is the number of components the manifests contributed.
+	Components int
+	// Lockfiles reports whether the analyzer's manifests can be pinned by
+	// a lockfile. Unlocked lists the manifests that have none.
+	Lockfiles bool
+	Unlocked  []string
+	// Issues lists problems found with the manifests, such as a missing
+	// lockfile or a manifest the analyzer failed to read.
+	Issues []string
+}
+
+// Diagnose analyzes dir as AnalyzeProject does and reports, for every
+// registered analyzer in order, which manifests it found, whether they
+// have lockfiles and what went wrong. It is meant to check a project
+// before a scan rather than to produce an SBOM. Paths the walk could not
+// read are returned as warnings.
+func (p *ProjectAnalyzer) Diagnose(dir string) ([]Diagnosis, []error, error) {
+	result, err := p.AnalyzeProject(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	diagnoses := make([]Diagnosis, len(p.analyzers))
+	index := make(map[string]int, len(p.analyzers))
+	for i, analyzer := range p.analyzers {
+		_, locks := analyzer.(LockfileAnalyzer)
+		diagnoses[i] = Diagnosis{Analyzer: analyzer.Name(), Lockfiles: locks}
+		index[analyzer.Name()] = i
+	}
+
+	for _, record := range result.Scanned {
+		i, ok := index[record.Analyzer]
+		if !ok {
+			continue
+		}
+		d := &diagnoses[i]
+		path := filepath.FromSlash(record.Path)
+		display := path
+		if rel, err := filepath.Rel(dir, path); err == nil {
+			display = filepath.ToSlash(rel)
+		}
+		d.Manifests = append(d.Manifests, display)
+		d.Components += record.ComponentCount
+
+		if record.Error != "" {
+			d.Issues = append(d.Issues, fmt.Sprintf("%s could not be analyzed: %s", display, record.Error))
+			continue
+		}
+		if locker, ok := p.analyzers[i].(LockfileAnalyzer); ok && !locker.HasLockfile(path) {
+			d.Unlocked = append(d.Unlocked, display)
+			d.Issues = append(d.Issues, fmt.Sprintf("%s has no lockfile, so only declared version ranges are known", display))
+		}
+		if record.Truncated > 0 {
+			d.Issues = append(d.Issues, fmt.Sprintf("%s has more than %d components", display, record.ComponentCount))
+		}
+	}
+	return diagnoses, result.Warnings, nil
+}
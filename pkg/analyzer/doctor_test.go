@@ -0,0 +1,44 @@
+package analyzer
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiagnose_MissingLockfile(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTestFile(t, filepath.Join(tmpDir, "package.json"), `{"name": "app", "dependencies": {"express": "^4.18.0"}}`)
+	writeTestFile(t, filepath.Join(tmpDir, "api", "requirements.txt"), "requests==2.31.0\n")
+	writeTestFile(t, filepath.Join(tmpDir, "api", "poetry.lock"), "")
+
+	diagnoses, _, err := NewProjectAnalyzer().Diagnose(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to diagnose: %v", err)
+	}
+	byName := make(map[string]Diagnosis)
+	for _, d := range diagnoses {
+		byName[d.Analyzer] = d
+	}
+	if len(diagnoses) != len(NewProjectAnalyzer().Names()) {
+		t.Errorf("Expected a diagnosis per analyzer, got %d", len(diagnoses))
+	}
+
+	npm := byName["npm"]
+	if len(npm.Manifests) != 1 || npm.Manifests[0] != "package.json" || npm.Components != 1 {
+		t.Errorf("Expected package.json with 1 component, got %+v", npm)
+	}
+	if !npm.Lockfiles || len(npm.Unlocked) != 1 {
+		t.Errorf("Expected package.json to be flagged as unlocked, got %+v", npm)
+	}
+	if len(npm.Issues) != 1 || !strings.Contains(npm.Issues[0], "no lockfile") {
+		t.Errorf("Expected a missing lockfile issue, got %v", npm.Issues)
+	}
+
+	if pypi := byName["pypi"]; len(pypi.Manifests) != 1 || len(pypi.Unlocked) != 0 || len(pypi.Issues) != 0 {
+		t.Errorf("Expected api/requirements.txt to be locked by poetry.lock, got %+v", pypi)
+	}
+	if golang := byName["go"]; len(golang.Manifests) != 0 || golang.Lockfiles {
+		t.Errorf("Expected no Go manifests and no lockfile check, got %+v", golang)
+	}
+}
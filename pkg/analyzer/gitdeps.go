@@ -0,0 +1,134 @@
+package analyzer
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/hallucinaut/sbomgen/pkg/sbom"
+)
+
+// npmGitHosts maps the host shorthands of npm dependency specs onto the
+// repository URL prefix they stand for.
+var npmGitHosts = map[string]string{
+	"github:":    "https://github.com/",
+	"gitlab:":    "https://gitlab.com/",
+	"bitbucket:": "https://bitbucket.org/",
+	"gist:":      "https://gist.github.com/",
+}
+
+// npmGitHubShorthand matches the "org/repo" form npm resolves on GitHub.
+// Local paths such as "./lib" start with "." or "/" and do not match.
+var npmGitHubShorthand = regexp.MustCompile(`^[A-Za-z0-9_-][A-Za-z0-9_.-]*/[A-Za-z0-9_.-]+(#.*)?$`)
+
+// npmGitRef reports whether the npm dependency spec installs from git, as
+// in "github:org/repo#main" or "git+https://host/repo.git#v1.2.0". It
+// returns the repository as a package URL vcs_url, such as
+// "git+https://github.com/org/repo@main", and the ref after "#", which is
+// empty for the default branch.
+func npmGitRef(spec string) (vcsURL, ref string, ok bool) {
+	repo, ref, _ := strings.Cut(spec, "#")
+	switch {
+	case strings.HasPrefix(repo, "git+"), strings.HasPrefix(repo, "git://"):
+		vcsURL = repo
+	case npmGitHubShorthand.MatchString(spec):
+		vcsURL = "git+https://github.com/" + repo
+	default:
+		for prefix, host := range npmGitHosts {
+			if path, found := strings.CutPrefix(repo, prefix); found {
+				vcsURL = "git+" + host + path
+			}
+		}
+	}
+	if vcsURL == "" {
+		return "", "", false
+	}
+	return withGitRef(vcsURL, ref), ref, true
+}
+
+// withGitRef appends ref to a vcs_url, as "<url>@<ref>".
+func withGitRef(vcsURL, ref string) string {
+	if ref == "" {
+		return vcsURL
+	}
+	return vcsURL + "@" + ref
+}
+
+// gitPURL returns the package URL of a dependency installed from vcsURL.
+// It has no version, which would claim a registry release, and records
+// the repository in the vcs_url qualifier instead.
+func gitPURL(ecosystem, name, vcsURL string) string {
+	return sbom.PURLWithQualifiers(ecosystem, name, "", map[string]string{"vcs_url": vcsURL})
+}
+
+// pipGitRequirement parses a requirement installed from git, either a
+// direct reference ("name @ git+https://host/repo.git@main") or a bare or
+// editable URL naming the project in its #egg fragment ("-e
+// git+https://host/repo.git@v1.0#egg=name"). It returns the project
+// name, the repository as a package URL vcs_url and the ref after the
+// repository path, which is empty for the default branch.
+func pipGitRequirement(line string) (name, vcsURL, ref string, ok bool) {
+	line = strings.TrimSpace(line)
+	for _, option := range []string{"-e ", "--editable "} {
+		line = strings.TrimSpace(strings.TrimPrefix(line, option))
+	}
+	link := line
+	if before, after, found := strings.Cut(line, " @ "); found {
+		name, link = strings.TrimSpace(before), strings.TrimSpace(after)
+	}
+	if !strings.HasPrefix(link, "git+") {
+		return "", "", "", false
+	}
+
+	link, fragment, _ := strings.Cut(link, "#")
+	if name == "" {
+		for _, param := range strings.Split(fragment, "&") {
+			if egg, found := strings.CutPrefix(param, "egg="); found {
+				name = egg
+			}
+		}
+	}
+	// Keep the name only, without extras or a version.
+	if i := strings.IndexAny(name, "[=<>!~ ;"); i >= 0 {
+		name = name[:i]
+	}
+	if name == "" {
+		return "", "", "", false
+	}
+
+	// The ref follows the last "@" of the path, not one in the authority
+	// as in git+ssh://git@github.com/org/repo.git.
+	if _, rest, found := strings.Cut(link, "://"); found {
+		if _, path, found := strings.Cut(rest, "/"); found {
+			if i := strings.LastIndex(path, "@"); i >= 0 {
+				ref = path[i+1:]
+			}
+		}
+	}
+	return name, link, ref, true
+}
+
+// cargoGitRef reports whether an inline Cargo dependency table such as
+// { git = "https://host/repo", branch = "main" } installs from git. It
+// returns the repository as a package URL vcs_url and its branch, tag or
+// rev, which is empty for the default branch.
+func cargoGitRef(table string) (vcsURL, ref string, ok bool) {
+	fields := make(map[string]string)
+	for _, field := range strings.Split(strings.Trim(table, "{}"), ",") {
+		if key, value, ok := strings.Cut(field, "="); ok {
+			fields[strings.TrimSpace(key)] = strings.Trim(strings.TrimSpace(value), `"'`)
+		}
+	}
+	repo, ok := fields["git"]
+	if !ok {
+		return "", "", false
+	}
+	if !strings.HasPrefix(repo, "git+") && !strings.HasPrefix(repo, "git://") {
+		repo = "git+" + repo
+	}
+	for _, key := range []string{"branch", "tag", "rev"} {
+		if ref = fields[key]; ref != "" {
+			break
+		}
+	}
+	return withGitRef(repo, ref), ref, true
+}
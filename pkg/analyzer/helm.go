@@ -0,0 +1,185 @@
+package analyzer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hallucinaut/sbomgen/pkg/sbom"
+	"gopkg.in/yaml.v3"
+)
+
+// HelmAnalyzer analyzes Helm chart dependencies declared in Chart.yaml,
+// using the versions pinned in Chart.lock when it exists.
+type HelmAnalyzer struct{}
+
+func NewHelmAnalyzer() *HelmAnalyzer {
+	return &HelmAnalyzer{}
+}
+
+func (a *HelmAnalyzer) Name() string {
+	return "helm"
+}
+
+func (a *HelmAnalyzer) ShouldAnalyze(path string) bool {
+	return isManifest(path, "Chart.yaml", "Chart.lock")
+}
+
+type helmDependency struct {
+	Name       string `yaml:"name"`
+	Version    string `yaml:"version"`
+	Repository string `yaml:"repository"`
+}
+
+type helmChart struct {
+	Name         string           `yaml:"name"`
+	Version      string           `yaml:"version"`
+	Description  string           `yaml:"description"`
+	Home         string           `yaml:"home"`
+	Dependencies []helmDependency `yaml:"dependencies"`
+}
+
+type helmLock struct {
+	Dependencies []helmDependency `yaml:"dependencies"`
+	Digest       string           `yaml:"digest"`
+}
+
+func (a *HelmAnalyzer) Analyze(path string) ([]sbom.Component, error) {
+	result, err := a.AnalyzeResult(path)
+	if err != nil {
+		return nil, err
+	}
+	return result.Components, nil
+}
+
+// AnalyzeResult reads the chart's Chart.yaml and Chart.lock together and
+// claims both, so the chart is analyzed once whichever file is seen first.
+func (a *HelmAnalyzer) AnalyzeResult(path string) (*Result, error) {
+	dir := filepath.Dir(path)
+	return helmDependencies(findManifest(dir, "Chart.yaml"), findManifest(dir, "Chart.lock"), os.ReadFile)
+}
+
+// AnalyzeContent analyzes a chart held in memory, as AnalyzeResult does on
+// disk.
+func (a *HelmAnalyzer) AnalyzeContent(path string, data []byte, dir map[string][]byte) (*Result, error) {
+	chartPath := contentManifest(path, dir, "Chart.yaml")
+	lockPath := contentManifest(path, dir, "Chart.lock")
+	return helmDependencies(chartPath, lockPath, contentReader(path, data, dir))
+}
+
+// helmDependencies reads the dependencies of a chart from its Chart.yaml
+// and Chart.lock, using readFile. Either may be missing.
+func helmDependencies(chartPath, lockPath string, readFile func(path string) ([]byte, error)) (*Result, error) {
+	var chart helmChart
+	if err := readYAML(readFile, chartPath, &chart); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	var lock helmLock
+	if err := readYAML(readFile, lockPath, &lock); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	locked := make(map[string]helmDependency)
+	for _, dep := range lock.Dependencies {
+		locked[dep.Name+"\x00"+dep.Repository] = dep
+	}
+
+	deps := chart.Dependencies
+	source := chartPath
+	if len(deps) == 0 {
+		deps, source = lock.Dependencies, lockPath
+	}
+
+	result := &Result{Manifests: []string{chartPath, lockPath}}
+	for _, dep := range deps {
+		if dep.Name == "" {
+			continue
+		}
+		if pinned, ok := locked[dep.Name+"\x00"+dep.Repository]; ok {
+			dep.Version = pinned.Version
+		}
+		result.Components = append(result.Components, helmComponent(dep, source))
+	}
+	return result, nil
+}
+
+// AnalyzeRoot describes the chart itself. The Chart.lock digest, which
+// identifies the resolved dependency set, is recorded as its revision.
+func (a *HelmAnalyzer) AnalyzeRoot(path string) (*sbom.Component, error) {
+	return helmRoot(path, findManifest(filepath.Dir(path), "Chart.lock"), os.ReadFile)
+}
+
+func (a *HelmAnalyzer) AnalyzeRootContent(path string, data []byte, dir map[string][]byte) (*sbom.Component, error) {
+	return helmRoot(path, contentManifest(path, dir, "Chart.lock"), contentReader(path, data, dir))
+}
+
+// helmRoot describes the chart whose Chart.yaml is at path, using readFile.
+func helmRoot(path, lockPath string, readFile func(path string) ([]byte, error)) (*sbom.Component, error) {
+	if !isManifest(path, "Chart.yaml") {
+		return nil, fmt.Errorf("not a chart: %s", path)
+	}
+	var chart helmChart
+	if err := readYAML(readFile, path, &chart); err != nil {
+		return nil, err
+	}
+	if chart.Name == "" {
+		return nil, fmt.Errorf("no name in %s", path)
+	}
+
+	root := &sbom.Component{
+		Name:     chart.Name,
+		Version:  chart.Version,
+		Supplier: "helm",
+		PURL:     sbom.PURL("helm", chart.Name, chart.Version),
+		Metadata: sbom.Metadata{
+			Description: chart.Description,
+			HomepageURL: chart.Home,
+		},
+	}
+	var lock helmLock
+	if err := readYAML(readFile, lockPath, &lock); err == nil {
+		root.Metadata.Revision = lock.Digest
+	}
+	return root, nil
+}
+
+// helmComponent builds a component for a chart dependency. Subcharts
+// referenced with file:// are part of the same repository and are tagged
+// internal; other repositories are recorded as the PURL's repository_url.
+func helmComponent(dep helmDependency, path string) sbom.Component {
+	version := ""
+	if isExactVersion(dep.Version) {
+		version = dep.Version
+	}
+	comp := sbom.Component{
+		Name:     dep.Name,
+		Version:  dep.Version,
+		Supplier: "helm",
+		Direct:   true,
+		Metadata: sbom.Metadata{
+			SourceURL:  dep.Repository,
+			SourceFile: path,
+		},
+	}
+	qualifiers := make(map[string]string)
+	switch {
+	case strings.HasPrefix(dep.Repository, "file://"):
+		comp.Scope = sbom.ScopeInternal
+	case strings.Contains(dep.Repository, "://"):
+		qualifiers["repository_url"] = dep.Repository
+	}
+	comp.PURL = sbom.PURLWithQualifiers("helm", dep.Name, version, qualifiers)
+	return comp
+}
+
+func readYAML(readFile func(path string) ([]byte, error), path string, v interface{}) error {
+	data, err := readFile(path)
+	if err != nil {
+		return err
+	}
+	if err := yaml.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package analyzer
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestHelmAnalyzer(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTestFile(t, filepath.Join(tmpDir, "Chart.yaml"), `apiVersion: v2
+name: storefront
+version: 1.4.0
+description: Storefront web application
+dependencies:
+  - name: postgresql
+    version: "12.x.x"
+    repository: https://charts.bitnami.com/bitnami
+  - name: common
+    version: 0.1.0
+    repository: file://../common
+`)
+	writeTestFile(t, filepath.Join(tmpDir, "Chart.lock"), `dependencies:
+- name: postgresql
+  repository: https://charts.bitnami.com/bitnami
+  version: 12.12.10
+- name: common
+  repository: file://../common
+  version: 0.1.0
+digest: sha256:4f2c8e5a0d1b3c6e7f8091a2b3c4d5e6f708192a3b4c5d6e7f8091a2b3c4d5e6
+generated: "2024-01-15T10:00:00Z"
+`)
+
+	p := NewProjectAnalyzer()
+	if err := p.FilterAnalyzers([]string{"helm"}, nil); err != nil {
+		t.Fatalf("Failed to filter analyzers: %v", err)
+	}
+	components, err := p.AnalyzeDir(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to analyze: %v", err)
+	}
+
+	if len(components) != 2 {
+		t.Fatalf("Expected 2 subchart dependencies, got %d: %v", len(components), components)
+	}
+
+	postgres := components[0]
+	if postgres.Version != "12.12.10" {
+		t.Errorf("Expected version pinned by Chart.lock, got '%s'", postgres.Version)
+	}
+	if postgres.PURL != "pkg:helm/postgresql@12.12.10?repository_url=https%3A%2F%2Fcharts.bitnami.com%2Fbitnami" {
+		t.Errorf("Unexpected PURL '%s'", postgres.PURL)
+	}
+	if !postgres.Direct || postgres.Supplier != "helm" {
+		t.Errorf("Expected a direct helm dependency, got %+v", postgres)
+	}
+
+	common := components[1]
+	if common.Scope != "internal" || common.PURL != "pkg:helm/common@0.1.0" {
+		t.Errorf("Expected local subchart to be internal, got scope '%s' PURL '%s'", common.Scope, common.PURL)
+	}
+
+	root := p.DetectRoot(tmpDir)
+	if root == nil || root.PURL != "pkg:helm/storefront@1.4.0" {
+		t.Fatalf("Expected the chart as root, got %+v", root)
+	}
+	if root.Metadata.Revision != "sha256:4f2c8e5a0d1b3c6e7f8091a2b3c4d5e6f708192a3b4c5d6e7f8091a2b3c4d5e6" {
+		t.Errorf("Expected Chart.lock digest as root revision, got '%s'", root.Metadata.Revision)
+	}
+}
+
+func TestHelmAnalyzer_Unlocked(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Chart.yaml")
+	writeTestFile(t, path, `name: api
+version: 0.2.0
+dependencies:
+  - name: redis
+    version: ~17.3.0
+    repository: oci://registry-1.docker.io/bitnamicharts
+`)
+
+	components, err := NewHelmAnalyzer().Analyze(path)
+	if err != nil {
+		t.Fatalf("Failed to analyze: %v", err)
+	}
+	if len(components) != 1 {
+		t.Fatalf("Expected 1 component, got %d", len(components))
+	}
+	if components[0].Version != "~17.3.0" || components[0].PURL != "pkg:helm/redis?repository_url=oci%3A%2F%2Fregistry-1.docker.io%2Fbitnamicharts" {
+		t.Errorf("Expected an unversioned PURL for a range, got %s %s", components[0].Version, components[0].PURL)
+	}
+}
+
+func TestHelmAnalyzer_Name(t *testing.T) {
+	analyzer := NewHelmAnalyzer()
+	if analyzer.Name() != "helm" {
+		t.Errorf("Expected name 'helm', got '%s'", analyzer.Name())
+	}
+}
@@ -0,0 +1,164 @@
+package analyzer
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/hallucinaut/sbomgen/pkg/sbom"
+)
+
+// licenseFileNames lists the file names checked, in order, for the license
+// text of a local or vendored package.
+var licenseFileNames = []string{
+	"LICENSE", "LICENSE.md", "LICENSE.txt", "LICENCE", "LICENCE.md", "LICENCE.txt", "COPYING",
+}
+
+// licenseHeadLines is how many non-empty lines at the top of a license
+// file are searched for a license title.
+const licenseHeadLines = 5
+
+// licenseSignatures identifies common licenses by phrases from their text.
+// A signature matches when the first lines of the text contain its title,
+// the text contains every phrase and none of the excluded ones. Copyleft
+// licenses are identified by their title alone: their texts refer to each
+// other, the MPL to the GPL family and the GPL to the AGPL, so phrases
+// from the body would misidentify them.
+var licenseSignatures = []struct {
+	id      string
+	title   string
+	phrases []string
+	exclude []string
+}{
+	{id: "Apache-2.0", phrases: []string{"apache license", "version 2.0"}},
+	{id: "AGPL-3.0-only", title: "gnu affero general public license version 3"},
+	{id: "LGPL-3.0-only", title: "gnu lesser general public license version 3"},
+	{id: "LGPL-2.1-only", title: "gnu lesser general public license version 2.1"},
+	{id: "GPL-3.0-only", title: "gnu general public license version 3"},
+	{id: "GPL-2.0-only", title: "gnu general public license version 2"},
+	{id: "MPL-2.0", title: "mozilla public license version 2.0"},
+	{id: "Unlicense", phrases: []string{"free and unencumbered software released into the public domain"}},
+	{id: "ISC", phrases: []string{"permission to use, copy, modify, and/or distribute this software for any purpose"}},
+	{id: "MIT", phrases: []string{"permission is hereby granted, free of charge", "the above copyright notice and this permission notice shall be included"}},
+	{id: "BSD-3-Clause", phrases: []string{"redistribution and use in source and binary forms", "neither the name"}},
+	{id: "BSD-2-Clause", phrases: []string{"redistribution and use in source and binary forms"}, exclude: []string{"neither the name"}},
+}
+
+// ClassifyLicenseText returns the SPDX identifier of the license whose
+// text best matches text, or "" if it is not recognised. Matching is a
+// keyword heuristic, so the result is a best guess.
+func ClassifyLicenseText(text string) string {
+	var head []string
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) != "" && len(head) < licenseHeadLines {
+			head = append(head, line)
+		}
+	}
+	title := normalizeLicenseText(strings.Join(head, " "))
+	text = normalizeLicenseText(text)
+	for _, sig := range licenseSignatures {
+		if strings.Contains(title, sig.title) && containsAll(text, sig.phrases) && !containsAny(text, sig.exclude) {
+			return sig.id
+		}
+	}
+	return ""
+}
+
+// normalizeLicenseText lower-cases text and collapses its whitespace, so
+// phrases match regardless of how the text is wrapped.
+func normalizeLicenseText(text string) string {
+	return strings.ToLower(strings.Join(strings.Fields(text), " "))
+}
+
+func containsAll(text string, phrases []string) bool {
+	for _, phrase := range phrases {
+		if !strings.Contains(text, phrase) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsAny(text string, phrases []string) bool {
+	for _, phrase := range phrases {
+		if strings.Contains(text, phrase) {
+			return true
+		}
+	}
+	return false
+}
+
+// inferLicenses fills in the license of local and vendored components that
+// their manifest left blank, by classifying the license file in the
+// package directory, read with readFile. Inferred licenses are marked as
+// such and record the file they were guessed from in Metadata.LicenseFile.
+func inferLicenses(components []sbom.Component, readFile func(path string) ([]byte, error)) {
+	for i := range components {
+		comp := &components[i]
+		if comp.License != "" {
+			continue
+		}
+		dir := localPackageDir(*comp)
+		if dir == "" {
+			continue
+		}
+		for _, name := range licenseFileNames {
+			path := filepath.Join(dir, name)
+			data, err := readFile(path)
+			if err != nil {
+				continue
+			}
+			if id := ClassifyLicenseText(string(data)); id != "" {
+				comp.License = id
+				comp.Metadata.LicenseFile = filepath.ToSlash(path)
+				comp.Metadata.LicenseSource = sbom.LicenseSourceInferred
+			}
+			break
+		}
+	}
+}
+
+// localPackageDir returns the directory holding the sources of comp when
+// they live in the scanned tree: npm "file:" dependencies, directories
+// that replace a Go module or back an internal component, and the vendor
+// directory a Go module would have next to its go.mod. It returns ""
+// otherwise.
+func localPackageDir(comp sbom.Component) string {
+	if comp.Metadata.SourceFile == "" {
+		return ""
+	}
+	base := filepath.Dir(filepath.FromSlash(comp.Metadata.SourceFile))
+
+	if dir, ok := strings.CutPrefix(comp.Version, "file:"); ok {
+		return filepath.Join(base, filepath.FromSlash(dir))
+	}
+	if comp.Scope == sbom.ScopeInternal && comp.Metadata.SourceURL != "" {
+		dir := strings.TrimPrefix(comp.Metadata.SourceURL, "file://")
+		if !strings.Contains(dir, "://") {
+			if filepath.IsAbs(dir) {
+				return dir
+			}
+			return filepath.Join(base, filepath.FromSlash(dir))
+		}
+	}
+	if comp.Supplier == "go" {
+		if module, ok := goModulePath(comp.PURL); ok {
+			return filepath.Join(base, "vendor", filepath.FromSlash(module))
+		}
+	}
+	return ""
+}
+
+// goModulePath extracts the module path from a Go PURL such as
+// pkg:golang/github.com/pkg/errors@v0.9.1.
+func goModulePath(purl string) (string, bool) {
+	rest, ok := strings.CutPrefix(purl, "pkg:")
+	if !ok {
+		return "", false
+	}
+	_, rest, ok = strings.Cut(rest, "/")
+	if !ok {
+		return "", false
+	}
+	module, _, _ := strings.Cut(rest, "@")
+	return module, module != ""
+}
@@ -0,0 +1,177 @@
+package analyzer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hallucinaut/sbomgen/pkg/sbom"
+)
+
+const mitLicense = `MIT License
+
+Copyright (c) 2023 Example Authors
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+`
+
+const apacheLicense = `
+                                 Apache License
+                           Version 2.0, January 2004
+                        http://www.apache.org/licenses/
+
+   TERMS AND CONDITIONS FOR USE, REPRODUCTION, AND DISTRIBUTION
+
+   1. Definitions.
+
+      "License" shall mean the terms and conditions for use, reproduction,
+      and distribution as defined by Sections 1 through 9 of this document.
+`
+
+func TestClassifyLicenseText(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{"mit", mitLicense, "MIT"},
+		{"apache", apacheLicense, "Apache-2.0"},
+		{"bsd-3", "Redistribution and use in source and binary forms, with or without modification, are permitted.\nNeither the name of the copyright holder nor the names of its contributors may be used", "BSD-3-Clause"},
+		{"bsd-2", "Redistribution and use in source and binary forms, with or without modification, are permitted.", "BSD-2-Clause"},
+		{"lgpl", "GNU LESSER GENERAL PUBLIC LICENSE\nVersion 3, 29 June 2007", "LGPL-3.0-only"},
+		{"unknown", "All rights reserved.", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyLicenseText(tt.text); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestClassifyLicenseText_OfficialTexts classifies the full official license
+// texts in testdata/licenses, each named after its SPDX identifier. Several
+// of them mention other licenses in their body.
+func TestClassifyLicenseText_OfficialTexts(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "licenses", "*.txt"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("Expected license texts in testdata/licenses, got %v, %v", files, err)
+	}
+	for _, file := range files {
+		expected := strings.TrimSuffix(filepath.Base(file), ".txt")
+		t.Run(expected, func(t *testing.T) {
+			text, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatalf("Failed to read %s: %v", file, err)
+			}
+			if got := ClassifyLicenseText(string(text)); got != expected {
+				t.Errorf("Expected %q, got %q", expected, got)
+			}
+		})
+	}
+}
+
+func TestProjectAnalyzer_InfersLocalLicenses(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTestFile(t, filepath.Join(tmpDir, "go.mod"), `module example.com/app
+
+go 1.21
+
+require (
+	example.com/lib v0.0.0
+	github.com/pkg/errors v0.9.1
+	github.com/google/uuid v1.6.0
+)
+
+replace example.com/lib => ./lib
+`)
+	writeTestFile(t, filepath.Join(tmpDir, "lib", "LICENSE"), mitLicense)
+	writeTestFile(t, filepath.Join(tmpDir, "vendor", "github.com", "pkg", "errors", "LICENSE.txt"), apacheLicense)
+	writeTestFile(t, filepath.Join(tmpDir, "web", "package.json"), `{"dependencies": {"shared": "file:../shared", "express": "4.18.2"}}`)
+	writeTestFile(t, filepath.Join(tmpDir, "shared", "LICENSE.md"), apacheLicense)
+
+	result, err := NewProjectAnalyzer().AnalyzeProject(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to analyze project: %v", err)
+	}
+
+	expected := map[string]struct{ license, source string }{
+		"lib":     {"MIT", "lib/LICENSE"},
+		"errors":  {"Apache-2.0", "vendor/github.com/pkg/errors/LICENSE.txt"},
+		"shared":  {"Apache-2.0", "shared/LICENSE.md"},
+		"uuid":    {"", ""},
+		"express": {"", ""},
+	}
+	for _, comp := range result.Components {
+		want, ok := expected[comp.Name]
+		if !ok {
+			continue
+		}
+		delete(expected, comp.Name)
+		if comp.License != want.license {
+			t.Errorf("%s: expected license %q, got %q", comp.Name, want.license, comp.License)
+		}
+		source := ""
+		if comp.Metadata.LicenseFile != "" {
+			rel, err := filepath.Rel(tmpDir, filepath.FromSlash(comp.Metadata.LicenseFile))
+			if err != nil {
+				t.Fatalf("Failed to relativize %s: %v", comp.Metadata.LicenseFile, err)
+			}
+			source = filepath.ToSlash(rel)
+		}
+		if source != want.source {
+			t.Errorf("%s: expected license file %q, got %q", comp.Name, want.source, source)
+		}
+		if want.license != "" && comp.Metadata.LicenseSource != sbom.LicenseSourceInferred {
+			t.Errorf("%s: expected license source inferred, got %q", comp.Name, comp.Metadata.LicenseSource)
+		}
+	}
+	for name := range expected {
+		t.Errorf("Expected a component named %s", name)
+	}
+}
+
+func TestAnalyzeProject_LicenseSources(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTestFile(t, filepath.Join(tmpDir, "package.json"), `{
+		"name": "app",
+		"version": "1.0.0",
+		"license": "ISC",
+		"workspaces": ["packages/*"],
+		"dependencies": {"local-lib": "file:./local-lib"}
+	}`)
+	writeTestFile(t, filepath.Join(tmpDir, "packages", "ui", "package.json"), `{"name": "@app/ui", "version": "1.0.0", "license": "MIT"}`)
+	writeTestFile(t, filepath.Join(tmpDir, "local-lib", "LICENSE"), mitLicense)
+
+	pa := NewProjectAnalyzer()
+	result, err := pa.AnalyzeProject(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to analyze project: %v", err)
+	}
+	sources := make(map[string]sbom.LicenseSource)
+	for _, comp := range result.Components {
+		sources[comp.Name] = comp.Metadata.LicenseSource
+	}
+	if sources["@app/ui"] != sbom.LicenseSourceDeclared {
+		t.Errorf("Expected declared license source for @app/ui, got %q", sources["@app/ui"])
+	}
+	if sources["local-lib"] != sbom.LicenseSourceInferred {
+		t.Errorf("Expected inferred license source for local-lib, got %q", sources["local-lib"])
+	}
+
+	root := pa.DetectRoot(tmpDir)
+	if root == nil || root.Metadata.LicenseSource != sbom.LicenseSourceDeclared {
+		t.Errorf("Expected the root's license to be declared, got %+v", root)
+	}
+}
@@ -0,0 +1,305 @@
+package analyzer
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Where an IntegrityMismatch was found.
+const (
+	IntegritySourceCache       = "npm cache"
+	IntegritySourceNodeModules = "node_modules"
+)
+
+// IntegrityMismatch describes an installed or cached npm package whose
+// content no longer matches the integrity recorded in the lockfile.
+type IntegrityMismatch struct {
+	Package  string
+	Version  string
+	Lockfile string
+	Source   string
+	Expected string
+	Actual   string
+}
+
+// lockedIntegrity is an integrity hash recorded in a lockfile. key is where
+// the package is installed, relative to the project root.
+type lockedIntegrity struct {
+	key       string
+	name      string
+	version   string
+	integrity string
+}
+
+// integrityLockfiles lists the lockfiles VerifyIntegrity reads, in order of
+// preference: npm-shrinkwrap.json wins over package-lock.json, as with npm.
+var integrityLockfiles = []string{"npm-shrinkwrap.json", "package-lock.json", "yarn.lock"}
+
+// sriAlgorithm is a Subresource Integrity hash algorithm.
+type sriAlgorithm struct {
+	name string
+	new  func() hash.Hash
+}
+
+// sriAlgorithms lists the algorithms npm writes, strongest first.
+var sriAlgorithms = []sriAlgorithm{
+	{"sha512", sha512.New},
+	{"sha384", sha512.New384},
+	{"sha256", sha256.New},
+	{"sha1", sha1.New},
+}
+
+// VerifyIntegrity checks the integrity hashes recorded by the npm or Yarn
+// lockfile at the root of project against what is actually present: the
+// tarballs in cache, the root of an npm cache directory (usually ~/.npm)
+// whose _cacache store addresses tarballs by their digest, and the packages
+// installed in project's node_modules, as recorded by npm's hidden lockfile
+// or the _integrity field of the installed package.json. A project without
+// a lockfile, and packages that are neither cached nor installed, are
+// skipped, so only content that is actually present can fail.
+func VerifyIntegrity(project, cache fs.FS) ([]IntegrityMismatch, error) {
+	lockfile, locked, err := readLockedIntegrity(project)
+	if err != nil || lockfile == "" {
+		return nil, err
+	}
+	installed := installedIntegrity(project)
+
+	var mismatches []IntegrityMismatch
+	for _, entry := range locked {
+		algo, digest, ok := parseSRI(entry.integrity)
+		if !ok {
+			continue
+		}
+		mismatch := IntegrityMismatch{
+			Package:  entry.name,
+			Version:  entry.version,
+			Lockfile: lockfile,
+			Expected: entry.integrity,
+		}
+
+		if f, err := cache.Open(cacachePath(algo.name, digest)); err == nil {
+			h := algo.new()
+			_, err = io.Copy(h, f)
+			f.Close()
+			if err != nil {
+				return nil, fmt.Errorf("failed to read cached tarball for %s: %w", entry.key, err)
+			}
+			if sum := h.Sum(nil); !bytes.Equal(sum, digest) {
+				mismatch.Source = IntegritySourceCache
+				mismatch.Actual = algo.name + "-" + base64.StdEncoding.EncodeToString(sum)
+				mismatches = append(mismatches, mismatch)
+				continue
+			}
+		}
+
+		if actual := installed(entry); actual != "" && !sameSRI(entry.integrity, actual) {
+			mismatch.Source = IntegritySourceNodeModules
+			mismatch.Actual = actual
+			mismatches = append(mismatches, mismatch)
+		}
+	}
+
+	return mismatches, nil
+}
+
+// readLockedIntegrity returns the first lockfile found in project and the
+// integrity hashes it records, sorted by install location.
+func readLockedIntegrity(project fs.FS) (string, []lockedIntegrity, error) {
+	for _, name := range integrityLockfiles {
+		data, err := fs.ReadFile(project, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return "", nil, err
+		}
+
+		var locked []lockedIntegrity
+		if name == "yarn.lock" {
+			locked = parseYarnIntegrity(data)
+		} else if locked, err = parsePackageLockIntegrity(data); err != nil {
+			return "", nil, fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+		sort.Slice(locked, func(i, j int) bool { return locked[i].key < locked[j].key })
+		return name, locked, nil
+	}
+	return "", nil, nil
+}
+
+// packageLockEntries holds the packages section of a package-lock.json,
+// npm-shrinkwrap.json or node_modules/.package-lock.json.
+type packageLockEntries struct {
+	Packages map[string]struct {
+		Name      string `json:"name"`
+		Version   string `json:"version"`
+		Integrity string `json:"integrity"`
+	} `json:"packages"`
+}
+
+// parsePackageLockIntegrity returns the integrity-bearing entries of an npm
+// lockfile.
+func parsePackageLockIntegrity(data []byte) ([]lockedIntegrity, error) {
+	var lock packageLockEntries
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, err
+	}
+
+	var locked []lockedIntegrity
+	for key, entry := range lock.Packages {
+		if entry.Integrity == "" {
+			continue
+		}
+		name := entry.Name
+		if name == "" {
+			name = key
+			if i := strings.LastIndex(key, "node_modules/"); i >= 0 {
+				name = key[i+len("node_modules/"):]
+			}
+		}
+		locked = append(locked, lockedIntegrity{key: key, name: name, version: entry.Version, integrity: entry.Integrity})
+	}
+	return locked, nil
+}
+
+// parseYarnIntegrity returns the integrity-bearing entries of a Yarn classic
+// yarn.lock. Yarn hoists packages, so each is expected directly under the
+// top-level node_modules.
+func parseYarnIntegrity(data []byte) []lockedIntegrity {
+	var locked []lockedIntegrity
+	var current *lockedIntegrity
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if !strings.HasPrefix(line, " ") {
+			// A header such as `"left-pad@^1.3.0", left-pad@~1.3:`.
+			spec := strings.Trim(strings.TrimSpace(strings.Split(strings.TrimSuffix(trimmed, ":"), ",")[0]), `"`)
+			name := spec
+			if i := strings.LastIndex(spec, "@"); i > 0 {
+				name = spec[:i]
+			}
+			locked = append(locked, lockedIntegrity{key: "node_modules/" + name, name: name})
+			current = &locked[len(locked)-1]
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		if key, value, ok := strings.Cut(trimmed, " "); ok {
+			value = strings.Trim(value, `"`)
+			switch key {
+			case "version":
+				current.version = value
+			case "integrity":
+				current.integrity = value
+			}
+		}
+	}
+
+	kept := locked[:0]
+	for _, entry := range locked {
+		if entry.integrity != "" {
+			kept = append(kept, entry)
+		}
+	}
+	return kept
+}
+
+// installedIntegrity returns a lookup of the integrity npm recorded when it
+// installed a package into project's node_modules. It reads the hidden
+// lockfile npm 7 and later keep in node_modules, falling back to the
+// _integrity field older npm versions wrote to each installed package.json.
+// The lookup returns an empty string when the package is not installed at
+// the locked version or nothing was recorded.
+func installedIntegrity(project fs.FS) func(lockedIntegrity) string {
+	var hidden packageLockEntries
+	if data, err := fs.ReadFile(project, "node_modules/.package-lock.json"); err == nil {
+		_ = json.Unmarshal(data, &hidden)
+	}
+
+	return func(entry lockedIntegrity) string {
+		if installed, ok := hidden.Packages[entry.key]; ok && installed.Version == entry.version {
+			return installed.Integrity
+		}
+		data, err := fs.ReadFile(project, path.Join(entry.key, "package.json"))
+		if err != nil {
+			return ""
+		}
+		var manifest struct {
+			Version   string `json:"version"`
+			Integrity string `json:"_integrity"`
+		}
+		if json.Unmarshal(data, &manifest) != nil || manifest.Version != entry.version {
+			return ""
+		}
+		return manifest.Integrity
+	}
+}
+
+// sameSRI reports whether two SRI strings agree on the strongest algorithm
+// they share. Strings without a common algorithm cannot be compared and are
+// treated as agreeing.
+func sameSRI(a, b string) bool {
+	digestsA, digestsB := sriDigests(a), sriDigests(b)
+	for _, algo := range sriAlgorithms {
+		da, okA := digestsA[algo.name]
+		db, okB := digestsB[algo.name]
+		if okA && okB {
+			return bytes.Equal(da, db)
+		}
+	}
+	return true
+}
+
+// parseSRI picks the strongest supported hash from an SRI string, which may
+// list several space-separated "<algo>-<base64>" values.
+func parseSRI(sri string) (sriAlgorithm, []byte, bool) {
+	values := sriDigests(sri)
+	for _, algo := range sriAlgorithms {
+		if digest, found := values[algo.name]; found && len(digest) == algo.new().Size() {
+			return algo, digest, true
+		}
+	}
+	return sriAlgorithm{}, nil, false
+}
+
+// sriDigests decodes the "<algo>-<base64>" values of an SRI string by
+// algorithm.
+func sriDigests(sri string) map[string][]byte {
+	values := make(map[string][]byte)
+	for _, field := range strings.Fields(sri) {
+		name, encoded, found := strings.Cut(field, "-")
+		if !found {
+			continue
+		}
+		if decoded, err := base64.StdEncoding.DecodeString(encoded); err == nil {
+			values[name] = decoded
+		}
+	}
+	return values
+}
+
+// cacachePath returns where npm's content-addressable cache stores content
+// with the given digest, relative to the cache root.
+func cacachePath(algo string, digest []byte) string {
+	h := hex.EncodeToString(digest)
+	return path.Join("_cacache", "content-v2", algo, h[:2], h[2:4], h[4:])
+}
@@ -0,0 +1,150 @@
+package analyzer
+
+import (
+	"crypto/sha512"
+	"encoding/base64"
+	"testing"
+	"testing/fstest"
+)
+
+func testSRI(content string) string {
+	sum := sha512.Sum512([]byte(content))
+	return "sha512-" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func TestVerifyIntegrity(t *testing.T) {
+	goodTarball := []byte("lodash tarball contents")
+	tamperedTarball := []byte("left-pad tarball contents")
+
+	goodSum := sha512.Sum512(goodTarball)
+	expectedSum := sha512.Sum512([]byte("original left-pad contents"))
+	goodSRI := "sha512-" + base64.StdEncoding.EncodeToString(goodSum[:])
+	tamperedSRI := "sha512-" + base64.StdEncoding.EncodeToString(expectedSum[:])
+
+	cache := fstest.MapFS{
+		cacachePath("sha512", goodSum[:]):     {Data: goodTarball},
+		cacachePath("sha512", expectedSum[:]): {Data: tamperedTarball},
+	}
+
+	packageLock := `{
+  "name": "app",
+  "lockfileVersion": 3,
+  "packages": {
+    "": {"name": "app", "version": "1.0.0"},
+    "node_modules/lodash": {"version": "4.17.21", "integrity": "` + goodSRI + `"},
+    "node_modules/left-pad": {"version": "1.3.0", "integrity": "` + tamperedSRI + `"},
+    "node_modules/uncached": {"version": "0.1.0", "integrity": "` + testSRI("not in cache") + `"}
+  }
+}`
+	project := fstest.MapFS{"package-lock.json": {Data: []byte(packageLock)}}
+
+	mismatches, err := VerifyIntegrity(project, cache)
+	if err != nil {
+		t.Fatalf("Failed to verify: %v", err)
+	}
+
+	if len(mismatches) != 1 {
+		t.Fatalf("Expected 1 mismatch, got %d: %v", len(mismatches), mismatches)
+	}
+	m := mismatches[0]
+	if m.Package != "left-pad" || m.Version != "1.3.0" {
+		t.Errorf("Expected left-pad@1.3.0 mismatch, got %s@%s", m.Package, m.Version)
+	}
+	if m.Expected != tamperedSRI {
+		t.Errorf("Expected recorded integrity '%s', got '%s'", tamperedSRI, m.Expected)
+	}
+	if m.Actual == m.Expected {
+		t.Error("Expected recomputed integrity to differ")
+	}
+	if m.Lockfile != "package-lock.json" || m.Source != IntegritySourceCache {
+		t.Errorf("Expected a cache mismatch against package-lock.json, got %s in %s", m.Source, m.Lockfile)
+	}
+}
+
+func TestVerifyIntegrity_NodeModules(t *testing.T) {
+	packageLock := `{
+  "lockfileVersion": 3,
+  "packages": {
+    "node_modules/lodash": {"version": "4.17.21", "integrity": "` + testSRI("lodash") + `"},
+    "node_modules/left-pad": {"version": "1.3.0", "integrity": "` + testSRI("left-pad") + `"},
+    "node_modules/debug": {"version": "4.3.4", "integrity": "` + testSRI("debug") + `"},
+    "node_modules/ms": {"version": "2.1.3", "integrity": "` + testSRI("ms") + `"}
+  }
+}`
+	hidden := `{
+  "lockfileVersion": 3,
+  "packages": {
+    "node_modules/lodash": {"version": "4.17.21", "integrity": "` + testSRI("lodash") + `"},
+    "node_modules/left-pad": {"version": "1.3.0", "integrity": "` + testSRI("tampered left-pad") + `"},
+    "node_modules/ms": {"version": "2.0.0", "integrity": "` + testSRI("ms 2.0.0") + `"}
+  }
+}`
+	project := fstest.MapFS{
+		"package-lock.json":               {Data: []byte(packageLock)},
+		"node_modules/.package-lock.json": {Data: []byte(hidden)},
+		"node_modules/debug/package.json": {Data: []byte(`{"name": "debug", "version": "4.3.4", "_integrity": "` + testSRI("tampered debug") + `"}`)},
+	}
+
+	mismatches, err := VerifyIntegrity(project, fstest.MapFS{})
+	if err != nil {
+		t.Fatalf("Failed to verify: %v", err)
+	}
+
+	found := make(map[string]IntegrityMismatch)
+	for _, m := range mismatches {
+		found[m.Package] = m
+	}
+	if len(mismatches) != 2 {
+		t.Fatalf("Expected left-pad and debug to mismatch, got %v", mismatches)
+	}
+	if m, ok := found["left-pad"]; !ok || m.Source != IntegritySourceNodeModules || m.Actual != testSRI("tampered left-pad") {
+		t.Errorf("Expected left-pad to mismatch the hidden lockfile, got %+v", m)
+	}
+	if _, ok := found["debug"]; !ok {
+		t.Error("Expected debug to mismatch its installed _integrity")
+	}
+	if _, ok := found["ms"]; ok {
+		t.Error("Expected a different installed version not to be compared")
+	}
+}
+
+func TestVerifyIntegrity_YarnLock(t *testing.T) {
+	yarnLock := `# THIS IS AN AUTOGENERATED FILE. DO NOT EDIT THIS FILE DIRECTLY.
+# yarn lockfile v1
+
+
+"@babel/code-frame@^7.0.0":
+  version "7.22.13"
+  resolved "https://registry.yarnpkg.com/@babel/code-frame/-/code-frame-7.22.13.tgz"
+  integrity ` + testSRI("code-frame") + `
+
+left-pad@^1.3.0, left-pad@~1.3:
+  version "1.3.0"
+  resolved "https://registry.yarnpkg.com/left-pad/-/left-pad-1.3.0.tgz"
+  integrity ` + testSRI("left-pad") + `
+`
+	project := fstest.MapFS{
+		"yarn.lock": {Data: []byte(yarnLock)},
+		"node_modules/@babel/code-frame/package.json": {Data: []byte(`{"version": "7.22.13", "_integrity": "` + testSRI("tampered") + `"}`)},
+		"node_modules/left-pad/package.json":          {Data: []byte(`{"version": "1.3.0", "_integrity": "` + testSRI("left-pad") + `"}`)},
+	}
+
+	mismatches, err := VerifyIntegrity(project, fstest.MapFS{})
+	if err != nil {
+		t.Fatalf("Failed to verify: %v", err)
+	}
+	if len(mismatches) != 1 {
+		t.Fatalf("Expected 1 mismatch, got %v", mismatches)
+	}
+	if m := mismatches[0]; m.Package != "@babel/code-frame" || m.Version != "7.22.13" || m.Lockfile != "yarn.lock" {
+		t.Errorf("Expected @babel/code-frame@7.22.13 from yarn.lock, got %+v", m)
+	}
+}
+
+func TestVerifyIntegrity_NoLockfile(t *testing.T) {
+	project := fstest.MapFS{"package.json": {Data: []byte(`{"name": "app"}`)}}
+	mismatches, err := VerifyIntegrity(project, fstest.MapFS{})
+	if err != nil || len(mismatches) != 0 {
+		t.Errorf("Expected a project without a lockfile to pass, got %v, %v", mismatches, err)
+	}
+}
@@ -0,0 +1,129 @@
+package analyzer
+
+import (
+	"path/filepath"
+	"sort"
+
+	"github.com/hallucinaut/sbomgen/pkg/sbom"
+)
+
+// aggregateMonorepo merges the results of the separate package.json files
+// of a JavaScript monorepo that does not declare workspaces. The shallowest
+// package.json is taken to describe the repository itself, provided no
+// other sits at the same depth; every other named package becomes an
+// internal component. Dependencies on those packages are reported as
+// relationships instead of external components, and external dependencies
+// declared by several packages are listed once, keeping a runtime scope
+// over a development or optional one. The package.json files are read
+// with readFile.
+func aggregateMonorepo(result *Result, readFile func(path string) ([]byte, error)) {
+	var manifests []string
+	for _, record := range result.Scanned {
+		if record.Analyzer == "npm" && record.Error == "" && isManifest(record.Path, "package.json") {
+			manifests = append(manifests, record.Path)
+		}
+	}
+	if len(manifests) < 2 {
+		return
+	}
+	sort.SliceStable(manifests, func(i, j int) bool {
+		return pathDepth(filepath.FromSlash(manifests[i])) < pathDepth(filepath.FromSlash(manifests[j]))
+	})
+	root := ""
+	if pathDepth(filepath.FromSlash(manifests[0])) < pathDepth(filepath.FromSlash(manifests[1])) {
+		root = manifests[0]
+	}
+
+	locals := make(map[string]*npmPackage)
+	declaredBy := make(map[string]*npmPackage)
+	for _, manifest := range manifests {
+		pkg, err := loadNPMPackage(readFile, filepath.FromSlash(manifest))
+		if err != nil {
+			continue
+		}
+		declaredBy[manifest] = pkg
+		if pkg.Name != "" && locals[pkg.Name] == nil {
+			locals[pkg.Name] = pkg
+		}
+	}
+
+	seen := make(map[string]int)
+	var components []sbom.Component
+	for _, comp := range result.Components {
+		if comp.Supplier == "npm" && comp.Scope != sbom.ScopeInternal {
+			if local := locals[comp.Name]; local != nil {
+				if from := declaredBy[comp.Metadata.SourceFile]; from != nil && from != local {
+					result.Relationships = append(result.Relationships, sbom.Relationship{
+						RefA:         npmPURL(from.Name, from.Version),
+						RefB:         npmPURL(local.Name, local.Version),
+						Relationship: sbom.DependsOn,
+					})
+				}
+				continue
+			}
+		}
+		if comp.Supplier == "npm" && comp.PURL != "" {
+			if i, ok := seen[comp.PURL]; ok {
+				preferRuntime(&components[i], comp)
+				continue
+			}
+			seen[comp.PURL] = len(components)
+		}
+		components = append(components, comp)
+	}
+
+	names := make([]string, 0, len(locals))
+	for name := range locals {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		pkg := locals[name]
+		purl := npmPURL(pkg.Name, pkg.Version)
+		if filepath.ToSlash(pkg.path) == root {
+			continue
+		}
+		if _, ok := seen[purl]; ok {
+			continue
+		}
+		seen[purl] = len(components)
+		components = append(components, npmMemberComponent(pkg))
+	}
+	result.Components = components
+
+	sort.SliceStable(result.Relationships, func(i, j int) bool {
+		if result.Relationships[i].RefA != result.Relationships[j].RefA {
+			return result.Relationships[i].RefA < result.Relationships[j].RefA
+		}
+		return result.Relationships[i].RefB < result.Relationships[j].RefB
+	})
+}
+
+// npmMemberComponent describes a first-party package of a workspace or
+// monorepo.
+func npmMemberComponent(pkg *npmPackage) sbom.Component {
+	return sbom.Component{
+		Name:     pkg.Name,
+		Version:  pkg.Version,
+		Supplier: "npm",
+		License:  pkg.license(),
+		PURL:     npmPURL(pkg.Name, pkg.Version),
+		Scope:    sbom.ScopeInternal,
+		Metadata: sbom.Metadata{
+			SourceFile: filepath.ToSlash(pkg.path),
+		},
+	}
+}
+
+// preferRuntime gives kept the scope of its duplicate dup when dup is needed
+// at runtime and kept is not. A duplicate declared directly also marks kept
+// as direct.
+func preferRuntime(kept *sbom.Component, dup sbom.Component) {
+	kept.Direct = kept.Direct || dup.Direct
+	if kept.Scope.Effective() == sbom.ScopeRuntime || dup.Scope.Effective() != sbom.ScopeRuntime {
+		return
+	}
+	kept.Scope = dup.Scope
+	kept.Metadata.Description = dup.Metadata.Description
+	kept.Metadata.SourceFile = dup.Metadata.SourceFile
+}
@@ -0,0 +1,84 @@
+package analyzer
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/hallucinaut/sbomgen/pkg/sbom"
+)
+
+func TestProjectAnalyzer_Monorepo(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTestFile(t, filepath.Join(tmpDir, "package.json"), `{
+		"name": "acme-monorepo",
+		"version": "1.0.0",
+		"dependencies": {"lodash": "4.17.21"}
+	}`)
+	writeTestFile(t, filepath.Join(tmpDir, "services", "api", "package.json"), `{
+		"name": "@acme/api",
+		"version": "2.0.0",
+		"dependencies": {"lodash": "4.17.21", "express": "4.18.0", "@acme/utils": "^1.0.0"}
+	}`)
+	writeTestFile(t, filepath.Join(tmpDir, "libs", "utils", "package.json"), `{
+		"name": "@acme/utils",
+		"version": "1.0.0",
+		"license": "MIT",
+		"devDependencies": {"lodash": "4.17.21"}
+	}`)
+
+	pa := NewProjectAnalyzer()
+	pa.Monorepo = true
+	result, err := pa.AnalyzeProject(tmpDir)
+	if err != nil {
+		t.Fatalf("AnalyzeProject failed: %v", err)
+	}
+
+	byPURL := make(map[string][]sbom.Component)
+	for _, comp := range result.Components {
+		byPURL[comp.PURL] = append(byPURL[comp.PURL], comp)
+	}
+	if len(result.Components) != 4 {
+		t.Errorf("Expected lodash, express, @acme/api and @acme/utils, got %+v", result.Components)
+	}
+
+	lodash := byPURL["pkg:npm/lodash@4.17.21"]
+	if len(lodash) != 1 {
+		t.Fatalf("Expected lodash to be listed once, got %d", len(lodash))
+	}
+	if lodash[0].Scope != "" {
+		t.Errorf("Expected the runtime use of lodash to win, got scope '%s'", lodash[0].Scope)
+	}
+	if len(byPURL["pkg:npm/acme-monorepo@1.0.0"]) != 0 {
+		t.Errorf("Expected the repository root package not to be a component")
+	}
+	for _, purl := range []string{"pkg:npm/%40acme/api@2.0.0", "pkg:npm/%40acme/utils@1.0.0"} {
+		if comps := byPURL[purl]; len(comps) != 1 || comps[0].Scope != sbom.ScopeInternal {
+			t.Errorf("Expected one internal component %s, got %+v", purl, comps)
+		}
+	}
+	if utils := byPURL["pkg:npm/%40acme/utils@1.0.0"]; len(utils) == 1 && utils[0].License != "MIT" {
+		t.Errorf("Expected @acme/utils license MIT, got '%s'", utils[0].License)
+	}
+
+	if len(result.Relationships) != 1 {
+		t.Fatalf("Expected 1 relationship, got %v", result.Relationships)
+	}
+	rel := result.Relationships[0]
+	if rel.RefA != "pkg:npm/%40acme/api@2.0.0" || rel.RefB != "pkg:npm/%40acme/utils@1.0.0" || rel.Relationship != sbom.DependsOn {
+		t.Errorf("Unexpected relationship %+v", rel)
+	}
+}
+
+func TestProjectAnalyzer_MonorepoOff(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTestFile(t, filepath.Join(tmpDir, "a", "package.json"), `{"name": "a", "dependencies": {"lodash": "4.17.21"}}`)
+	writeTestFile(t, filepath.Join(tmpDir, "b", "package.json"), `{"name": "b", "dependencies": {"lodash": "4.17.21", "a": "1.0.0"}}`)
+
+	result, err := NewProjectAnalyzer().AnalyzeProject(tmpDir)
+	if err != nil {
+		t.Fatalf("AnalyzeProject failed: %v", err)
+	}
+	if len(result.Components) != 3 || len(result.Relationships) != 0 {
+		t.Errorf("Expected packages to be analyzed separately without --monorepo, got %+v and %v", result.Components, result.Relationships)
+	}
+}
@@ -0,0 +1,90 @@
+package analyzer
+
+import (
+	"bufio"
+	"bytes"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/hallucinaut/sbomgen/pkg/sbom"
+)
+
+// defaultNPMRegistry is the registry npm installs from unless configured
+// otherwise. Packages from it carry no repository_url qualifier.
+const defaultNPMRegistry = "https://registry.npmjs.org"
+
+// npmRegistries holds the registries configured in a project's .npmrc: the
+// default under "" and scoped registries under their "@scope".
+type npmRegistries map[string]string
+
+// readNPMRegistries reads the registry settings from the .npmrc in dir
+// using readFile. A missing or unreadable file means every package comes
+// from the default registry.
+func readNPMRegistries(readFile func(path string) ([]byte, error), dir string) npmRegistries {
+	data, err := readFile(filepath.Join(dir, ".npmrc"))
+	if err != nil {
+		return nil
+	}
+	return parseNPMRegistries(data)
+}
+
+// parseNPMRegistries parses the registry settings of an .npmrc.
+// Credentials embedded in a registry URL are dropped, so they never end up
+// in a PURL.
+func parseNPMRegistries(data []byte) npmRegistries {
+	registries := make(npmRegistries)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+
+		scope := ""
+		if key != "registry" {
+			var found bool
+			scope, found = strings.CutSuffix(key, ":registry")
+			if !found || !strings.HasPrefix(scope, "@") {
+				continue
+			}
+		}
+		if registry := cleanRegistryURL(value); registry != "" {
+			registries[scope] = registry
+		}
+	}
+	return registries
+}
+
+// cleanRegistryURL strips credentials and a trailing slash from a registry
+// URL, returning "" for values that are not absolute URLs, such as ones
+// still holding an unexpanded ${VAR}.
+func cleanRegistryURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme == "" || u.Host == "" || strings.Contains(raw, "${") {
+		return ""
+	}
+	u.User = nil
+	return strings.TrimSuffix(u.String(), "/")
+}
+
+// purl returns the package URL of an npm dependency, qualified with the
+// registry it is installed from when that is not the public npm registry.
+func (r npmRegistries) purl(name, version string) string {
+	registry := r[""]
+	if scope, _, ok := strings.Cut(name, "/"); ok && strings.HasPrefix(scope, "@") {
+		if scoped, ok := r[scope]; ok {
+			registry = scoped
+		}
+	}
+	if registry == defaultNPMRegistry {
+		registry = ""
+	}
+	return sbom.PURLWithQualifiers("npm", name, version, map[string]string{"repository_url": registry})
+}
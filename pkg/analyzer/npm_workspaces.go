@@ -0,0 +1,160 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hallucinaut/sbomgen/pkg/sbom"
+)
+
+// AnalyzeResult analyzes a package.json and, when it declares workspaces,
+// every workspace member as well. Dependencies on other members are
+// reported as relationships instead of external components, and external
+// dependencies shared between members are listed once.
+func (a *NPMAnalyzer) AnalyzeResult(path string) (*Result, error) {
+	root, err := readNPMPackage(path)
+	if err != nil {
+		return nil, err
+	}
+
+	patterns, err := workspacePatterns(root.Workspaces)
+	if err != nil {
+		return nil, fmt.Errorf("invalid workspaces in %s: %w", path, err)
+	}
+	if len(patterns) == 0 {
+		return &Result{Components: npmComponents(root)}, nil
+	}
+
+	memberPaths, err := resolveWorkspaces(filepath.Dir(path), patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	packages := []*npmPackage{root}
+	members := make(map[string]*npmPackage)
+	result := &Result{}
+	for _, memberPath := range memberPaths {
+		member, err := readNPMPackage(memberPath)
+		if err != nil || member.Name == "" {
+			continue
+		}
+		if member.registries == nil {
+			// npm reads the .npmrc at the workspace root for every member.
+			member.registries = root.registries
+		}
+		packages = append(packages, member)
+		members[member.Name] = member
+		result.Manifests = append(result.Manifests, memberPath)
+	}
+
+	memberNames := make([]string, 0, len(members))
+	for name := range members {
+		memberNames = append(memberNames, name)
+	}
+	sort.Strings(memberNames)
+	for _, name := range memberNames {
+		result.Components = append(result.Components, npmMemberComponent(members[name]))
+	}
+
+	seen := make(map[string]int)
+	for _, pkg := range packages {
+		for _, comp := range npmComponents(pkg) {
+			if member, ok := members[comp.Name]; ok {
+				result.Relationships = append(result.Relationships, sbom.Relationship{
+					RefA:         npmPURL(pkg.Name, pkg.Version),
+					RefB:         npmPURL(member.Name, member.Version),
+					Relationship: sbom.DependsOn,
+				})
+				continue
+			}
+
+			if i, ok := seen[comp.PURL]; ok {
+				// A runtime dependency anywhere in the workspace outranks
+				// the same package used only for development elsewhere.
+				if comp.Metadata.Description == "" {
+					result.Components[i].Metadata.Description = ""
+				}
+				continue
+			}
+			seen[comp.PURL] = len(result.Components)
+			result.Components = append(result.Components, comp)
+		}
+	}
+
+	sort.Slice(result.Relationships, func(i, j int) bool {
+		if result.Relationships[i].RefA != result.Relationships[j].RefA {
+			return result.Relationships[i].RefA < result.Relationships[j].RefA
+		}
+		return result.Relationships[i].RefB < result.Relationships[j].RefB
+	})
+
+	return result, nil
+}
+
+func npmPURL(name, version string) string {
+	return sbom.PURL("npm", name, version)
+}
+
+// workspacePatterns decodes the workspaces field, which npm and pnpm write
+// as an array and yarn classic as an object with a packages array.
+func workspacePatterns(raw json.RawMessage) ([]string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+
+	var patterns []string
+	if err := json.Unmarshal(raw, &patterns); err == nil {
+		return patterns, nil
+	}
+
+	var yarn struct {
+		Packages []string `json:"packages"`
+	}
+	if err := json.Unmarshal(raw, &yarn); err != nil {
+		return nil, err
+	}
+	return yarn.Packages, nil
+}
+
+// resolveWorkspaces expands workspace globs relative to rootDir into member
+// package.json paths. Patterns prefixed with "!" exclude matches, and "**"
+// is treated as a single path segment.
+func resolveWorkspaces(rootDir string, patterns []string) ([]string, error) {
+	included := make(map[string]bool)
+	excluded := make(map[string]bool)
+
+	for _, pattern := range patterns {
+		negate := strings.HasPrefix(pattern, "!")
+		pattern = strings.TrimPrefix(pattern, "!")
+		pattern = strings.ReplaceAll(pattern, "**", "*")
+
+		dirs, err := filepath.Glob(filepath.Join(rootDir, filepath.FromSlash(pattern)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid workspace pattern %q: %w", pattern, err)
+		}
+		for _, dir := range dirs {
+			manifest := findManifest(dir, "package.json")
+			if _, err := os.Stat(manifest); err != nil {
+				continue
+			}
+			if negate {
+				excluded[manifest] = true
+			} else {
+				included[manifest] = true
+			}
+		}
+	}
+
+	var manifests []string
+	for manifest := range included {
+		if !excluded[manifest] {
+			manifests = append(manifests, manifest)
+		}
+	}
+	sort.Strings(manifests)
+	return manifests, nil
+}
@@ -0,0 +1,92 @@
+package analyzer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
+
+func TestNPMAnalyzer_Workspaces(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "npm-workspaces-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	writeTestFile(t, filepath.Join(tmpDir, "package.json"), `{
+		"name": "acme-monorepo",
+		"version": "1.0.0",
+		"workspaces": ["packages/*"],
+		"devDependencies": {
+			"typescript": "5.0.0"
+		}
+	}`)
+	writeTestFile(t, filepath.Join(tmpDir, "packages", "app", "package.json"), `{
+		"name": "@acme/app",
+		"version": "1.2.0",
+		"dependencies": {
+			"@acme/utils": "workspace:*",
+			"lodash": "4.17.21"
+		}
+	}`)
+	writeTestFile(t, filepath.Join(tmpDir, "packages", "utils", "package.json"), `{
+		"name": "@acme/utils",
+		"version": "0.4.0",
+		"dependencies": {
+			"lodash": "4.17.21"
+		}
+	}`)
+
+	result, err := NewProjectAnalyzer().AnalyzeProject(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to analyze: %v", err)
+	}
+
+	counts := make(map[string]int)
+	for _, comp := range result.Components {
+		counts[comp.Name]++
+		if comp.Version == "workspace:*" {
+			t.Errorf("Expected workspace reference not to be an external component: %s", comp.PURL)
+		}
+	}
+	if counts["lodash"] != 1 {
+		t.Errorf("Expected shared lodash dependency once, got %d", counts["lodash"])
+	}
+	if counts["typescript"] != 1 {
+		t.Errorf("Expected root typescript dependency, got %d", counts["typescript"])
+	}
+	if counts["@acme/app"] != 1 || counts["@acme/utils"] != 1 {
+		t.Errorf("Expected each workspace member listed once, got %v", counts)
+	}
+	if len(result.Components) != 4 {
+		t.Errorf("Expected 4 components, got %d", len(result.Components))
+	}
+
+	if len(result.Relationships) != 1 {
+		t.Fatalf("Expected 1 relationship, got %d", len(result.Relationships))
+	}
+	rel := result.Relationships[0]
+	if rel.RefA != "pkg:npm/%40acme/app@1.2.0" || rel.RefB != "pkg:npm/%40acme/utils@0.4.0" {
+		t.Errorf("Unexpected relationship %s -> %s", rel.RefA, rel.RefB)
+	}
+}
+
+func TestWorkspacePatterns_YarnObject(t *testing.T) {
+	patterns, err := workspacePatterns([]byte(`{"packages": ["apps/*", "libs/*"]}`))
+	if err != nil {
+		t.Fatalf("Failed to parse workspaces: %v", err)
+	}
+	if len(patterns) != 2 || patterns[0] != "apps/*" {
+		t.Errorf("Unexpected patterns %v", patterns)
+	}
+}
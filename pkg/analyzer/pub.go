@@ -0,0 +1,103 @@
+package analyzer
+
+import (
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/hallucinaut/sbomgen/pkg/sbom"
+	"gopkg.in/yaml.v3"
+)
+
+// PubAnalyzer analyzes Dart and Flutter projects via pubspec.lock.
+type PubAnalyzer struct{}
+
+func NewPubAnalyzer() *PubAnalyzer {
+	return &PubAnalyzer{}
+}
+
+func (a *PubAnalyzer) Name() string {
+	return "pub"
+}
+
+func (a *PubAnalyzer) ShouldAnalyze(path string) bool {
+	return isManifest(path, "pubspec.lock")
+}
+
+func (a *PubAnalyzer) Analyze(path string) ([]sbom.Component, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parsePubspecLock(path, data)
+}
+
+// AnalyzeContent analyzes a pubspec.lock held in memory.
+func (a *PubAnalyzer) AnalyzeContent(path string, data []byte, dir map[string][]byte) (*Result, error) {
+	return componentsResult(parsePubspecLock(path, data))
+}
+
+// parsePubspecLock reads the packages of a pubspec.lock.
+func parsePubspecLock(path string, data []byte) ([]sbom.Component, error) {
+	var lock struct {
+		Packages map[string]struct {
+			Dependency  string      `yaml:"dependency"`
+			Description interface{} `yaml:"description"`
+			Source      string      `yaml:"source"`
+			Version     string      `yaml:"version"`
+		} `yaml:"packages"`
+	}
+	if err := yaml.Unmarshal(data, &lock); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(lock.Packages))
+	for name := range lock.Packages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var components []sbom.Component
+	for _, name := range names {
+		pkg := lock.Packages[name]
+		description, _ := pkg.Description.(map[string]interface{})
+
+		comp := sbom.Component{
+			Name:     name,
+			Version:  pkg.Version,
+			Supplier: "pub",
+			PURL:     sbom.PURL("pub", name, pkg.Version),
+			Direct:   strings.HasPrefix(pkg.Dependency, "direct"),
+			Metadata: sbom.Metadata{
+				SourceFile: path,
+			},
+		}
+		if pkg.Dependency == "direct dev" {
+			comp.Scope = sbom.ScopeDev
+		}
+
+		switch pkg.Source {
+		case "hosted":
+			comp.Metadata.SourceURL = stringField(description, "url")
+			if sha := stringField(description, "sha256"); sha != "" {
+				comp.Hashes = []sbom.Hash{{Algorithm: "SHA-256", Value: sha}}
+			}
+		case "git":
+			comp.Metadata.SourceURL = stringField(description, "url")
+			comp.Metadata.Revision = stringField(description, "resolved-ref")
+		case "path":
+			comp.Metadata.SourceURL = stringField(description, "path")
+		}
+
+		components = append(components, comp)
+	}
+
+	return components, nil
+}
+
+func stringField(m map[string]interface{}, key string) string {
+	if v, ok := m[key].(string); ok {
+		return v
+	}
+	return ""
+}
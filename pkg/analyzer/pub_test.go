@@ -0,0 +1,112 @@
+package analyzer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPubAnalyzer(t *testing.T) {
+	analyzer := NewPubAnalyzer()
+
+	pubspecLock := `# Generated by pub
+# See https://dart.dev/tools/pub/glossary#lockfile
+packages:
+  async:
+    dependency: transitive
+    description:
+      name: async
+      sha256: "947bfcf187f74dbc5e146c9eb9c0f10c9f8b30743e341481c1e2ed3ecc18c20c"
+      url: "https://pub.dev"
+    source: hosted
+    version: "2.11.0"
+  http:
+    dependency: "direct main"
+    description:
+      name: http
+      sha256: "759d1a329847dd0f39226c688d3e06a6b8679668e350e2891a6474f8b4bb8525"
+      url: "https://pub.dev"
+    source: hosted
+    version: "1.1.0"
+  lints:
+    dependency: "direct dev"
+    description:
+      name: lints
+      sha256: "0a217c6c989d21039f1498c3ed9f3ed71b354e69873f13a8dfc3c9fe76f1b452"
+      url: "https://pub.dev"
+    source: hosted
+    version: "2.1.1"
+  shared_widgets:
+    dependency: "direct main"
+    description:
+      path: "."
+      ref: main
+      resolved-ref: "3f2a1c9d8e7b6a5f4e3d2c1b0a9f8e7d6c5b4a39"
+      url: "https://github.com/acme/shared_widgets.git"
+    source: git
+    version: "0.3.0"
+sdks:
+  dart: ">=3.0.0 <4.0.0"
+`
+
+	tmpDir, err := os.MkdirTemp("", "pub-analyzer-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	path := filepath.Join(tmpDir, "pubspec.lock")
+	if err := os.WriteFile(path, []byte(pubspecLock), 0644); err != nil {
+		t.Fatalf("Failed to write pubspec.lock: %v", err)
+	}
+
+	components, err := analyzer.Analyze(path)
+	if err != nil {
+		t.Fatalf("Failed to analyze: %v", err)
+	}
+
+	if len(components) != 4 {
+		t.Fatalf("Expected 4 components, got %d", len(components))
+	}
+
+	byName := make(map[string]int)
+	for i, comp := range components {
+		byName[comp.Name] = i
+	}
+
+	async := components[byName["async"]]
+	if async.Direct {
+		t.Error("Expected async to be transitive")
+	}
+	if async.PURL != "pkg:pub/async@2.11.0" {
+		t.Errorf("Unexpected PURL '%s'", async.PURL)
+	}
+	if len(async.Hashes) != 1 || async.Hashes[0].Algorithm != "SHA-256" {
+		t.Errorf("Expected SHA-256 hash for hosted package, got %v", async.Hashes)
+	}
+
+	http := components[byName["http"]]
+	if !http.Direct || http.Scope != "" {
+		t.Errorf("Expected http to be a direct runtime dependency, got direct=%v scope='%s'", http.Direct, http.Scope)
+	}
+
+	lints := components[byName["lints"]]
+	if !lints.Direct || lints.Scope != "dev" {
+		t.Errorf("Expected lints to be a direct dev dependency, got direct=%v scope='%s'", lints.Direct, lints.Scope)
+	}
+
+	git := components[byName["shared_widgets"]]
+	if git.Metadata.SourceURL != "https://github.com/acme/shared_widgets.git" {
+		t.Errorf("Unexpected git source URL '%s'", git.Metadata.SourceURL)
+	}
+	if git.Metadata.Revision != "3f2a1c9d8e7b6a5f4e3d2c1b0a9f8e7d6c5b4a39" {
+		t.Errorf("Expected resolved-ref as revision, got '%s'", git.Metadata.Revision)
+	}
+}
+
+func TestPubAnalyzer_Name(t *testing.T) {
+	analyzer := NewPubAnalyzer()
+	if analyzer.Name() != "pub" {
+		t.Errorf("Expected name 'pub', got '%s'", analyzer.Name())
+	}
+}
@@ -0,0 +1,75 @@
+package analyzer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// SourceHash returns a Merkle-style SHA-256 fingerprint of the files under
+// dir. Each file is hashed individually, and the result hashes the sorted
+// list of slash-separated relative paths and file digests, so it is stable
+// across walk order and operating systems. Directories skipped by the
+// analyzer walk and any paths in exclude are left out.
+func SourceHash(dir string, exclude ...string) (string, error) {
+	excluded := make(map[string]bool)
+	for _, path := range exclude {
+		if abs, err := filepath.Abs(path); err == nil {
+			excluded[abs] = true
+		}
+	}
+
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve directory path: %w", err)
+	}
+
+	var entries []string
+	err = walkFiles(root, func(path string) error {
+		if excluded[path] {
+			return nil
+		}
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			return nil
+		}
+		digest, err := fileDigest(path)
+		if err != nil {
+			return fmt.Errorf("failed to hash %s: %w", path, err)
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, filepath.ToSlash(rel)+"\x00"+digest)
+		return nil
+	}, nil)
+	if err != nil {
+		return "", err
+	}
+
+	sort.Strings(entries)
+	h := sha256.New()
+	for _, entry := range entries {
+		io.WriteString(h, entry+"\n")
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func fileDigest(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,55 @@
+package analyzer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSourceHash(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "source-hash-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	writeTestFile(t, filepath.Join(tmpDir, "main.go"), "package main\n")
+	writeTestFile(t, filepath.Join(tmpDir, "pkg", "lib.go"), "package pkg\n")
+	writeTestFile(t, filepath.Join(tmpDir, "node_modules", "dep", "index.js"), "module.exports = {}\n")
+
+	first, err := SourceHash(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to hash: %v", err)
+	}
+	second, err := SourceHash(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to hash: %v", err)
+	}
+	if first != second {
+		t.Errorf("Expected deterministic hash, got '%s' and '%s'", first, second)
+	}
+	if len(first) != 64 {
+		t.Errorf("Expected hex SHA-256, got '%s'", first)
+	}
+
+	writeTestFile(t, filepath.Join(tmpDir, "node_modules", "dep", "extra.js"), "")
+	if skipped, _ := SourceHash(tmpDir); skipped != first {
+		t.Error("Expected files in skipped directories not to affect the hash")
+	}
+
+	output := filepath.Join(tmpDir, "sbom.json")
+	writeTestFile(t, output, "{}")
+	if excluded, _ := SourceHash(tmpDir, output); excluded != first {
+		t.Error("Expected excluded output file not to affect the hash")
+	}
+
+	writeTestFile(t, filepath.Join(tmpDir, "pkg", "new.go"), "package pkg\n")
+	added, _ := SourceHash(tmpDir, output)
+	if added == first {
+		t.Error("Expected adding a file to change the hash")
+	}
+	again, _ := SourceHash(tmpDir, output)
+	if added != again {
+		t.Error("Expected hash after adding a file to be deterministic")
+	}
+}
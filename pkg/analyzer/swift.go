@@ -0,0 +1,168 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/hallucinaut/sbomgen/pkg/sbom"
+)
+
+// SwiftPMAnalyzer analyzes Swift Package Manager projects via the pinned
+// versions in Package.resolved. The packages that the Package.swift next
+// to it declares are marked direct; without a Package.swift, as for Xcode
+// projects, which pins are direct is unknown and all of them are.
+type SwiftPMAnalyzer struct{}
+
+var (
+	swiftPackageDecl = regexp.MustCompile(`\.package\s*\(([^)]*)`)
+	swiftPackageURL  = regexp.MustCompile(`\burl:\s*"([^"]+)"`)
+	swiftPackageID   = regexp.MustCompile(`\bid:\s*"([^"]+)"`)
+)
+
+func NewSwiftPMAnalyzer() *SwiftPMAnalyzer {
+	return &SwiftPMAnalyzer{}
+}
+
+func (a *SwiftPMAnalyzer) Name() string {
+	return "swift"
+}
+
+func (a *SwiftPMAnalyzer) ShouldAnalyze(path string) bool {
+	return isManifest(path, "Package.resolved")
+}
+
+// swiftPin is a resolved package. Version 2 and 3 files use identity and
+// location; version 1 files use package and repositoryURL.
+type swiftPin struct {
+	Identity      string `json:"identity"`
+	Location      string `json:"location"`
+	Package       string `json:"package"`
+	RepositoryURL string `json:"repositoryURL"`
+	State         struct {
+		Branch   string `json:"branch"`
+		Revision string `json:"revision"`
+		Version  string `json:"version"`
+	} `json:"state"`
+}
+
+func (a *SwiftPMAnalyzer) Analyze(path string) ([]sbom.Component, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := os.ReadFile(findManifest(filepath.Dir(path), "Package.swift"))
+	if err != nil {
+		manifest = nil
+	}
+	return parsePackageResolved(path, data, manifest)
+}
+
+// AnalyzeContent analyzes a Package.resolved held in memory.
+func (a *SwiftPMAnalyzer) AnalyzeContent(path string, data []byte, dir map[string][]byte) (*Result, error) {
+	_, manifest, _ := dirFile(dir, "Package.swift")
+	return componentsResult(parsePackageResolved(path, data, manifest))
+}
+
+// parsePackageResolved reads the pins of a Package.resolved. manifest is
+// the Package.swift of the package, or nil if there is none.
+func parsePackageResolved(path string, data, manifest []byte) ([]sbom.Component, error) {
+	var resolved struct {
+		Pins   []swiftPin `json:"pins"`
+		Object struct {
+			Pins []swiftPin `json:"pins"`
+		} `json:"object"`
+	}
+	if err := json.Unmarshal(data, &resolved); err != nil {
+		return nil, err
+	}
+
+	pins := resolved.Pins
+	if len(pins) == 0 {
+		pins = resolved.Object.Pins
+	}
+
+	declared := swiftDeclaredPackages(manifest)
+
+	var components []sbom.Component
+	for _, pin := range pins {
+		name := pin.Identity
+		if name == "" {
+			name = strings.ToLower(pin.Package)
+		}
+		location := pin.Location
+		if location == "" {
+			location = pin.RepositoryURL
+		}
+		if name == "" {
+			continue
+		}
+
+		version := pin.State.Version
+		if version == "" {
+			version = pin.State.Revision
+		}
+
+		components = append(components, sbom.Component{
+			Name:     name,
+			Version:  version,
+			Supplier: "swift",
+			PURL:     swiftPURL(name, version, location),
+			Direct:   declared == nil || declared[name] || declared[swiftRepository(location)],
+			Metadata: sbom.Metadata{
+				SourceURL:  location,
+				Revision:   pin.State.Revision,
+				SourceFile: path,
+			},
+		})
+	}
+
+	return components, nil
+}
+
+// swiftDeclaredPackages returns the registry identities and repositories,
+// as normalized by swiftRepository, of the .package dependencies that the
+// Package.swift in manifest declares. It returns nil for a nil manifest.
+func swiftDeclaredPackages(manifest []byte) map[string]bool {
+	if manifest == nil {
+		return nil
+	}
+	declared := make(map[string]bool)
+	for _, decl := range swiftPackageDecl.FindAllStringSubmatch(string(manifest), -1) {
+		if m := swiftPackageURL.FindStringSubmatch(decl[1]); m != nil {
+			declared[swiftRepository(m[1])] = true
+		}
+		if m := swiftPackageID.FindStringSubmatch(decl[1]); m != nil {
+			declared[strings.ToLower(m[1])] = true
+		}
+	}
+	return declared
+}
+
+// swiftRepository normalizes a repository location so that the HTTPS and
+// SSH forms of a URL, with or without .git, compare equal, e.g.
+// "github.com/apple/swift-nio".
+func swiftRepository(location string) string {
+	repo := strings.ToLower(strings.TrimSuffix(strings.TrimSuffix(location, "/"), ".git"))
+	repo = strings.TrimPrefix(repo, "https://")
+	repo = strings.TrimPrefix(repo, "http://")
+	repo = strings.TrimPrefix(repo, "ssh://")
+	repo = strings.TrimPrefix(repo, "git@")
+	return strings.Replace(repo, ":", "/", 1)
+}
+
+// swiftPURL builds a pkg:swift PURL whose namespace is the repository host
+// and owner, e.g. pkg:swift/github.com/apple/swift-nio@2.58.0.
+func swiftPURL(name, version, location string) string {
+	namespace := strings.TrimSuffix(location, ".git")
+	namespace = strings.TrimPrefix(namespace, "https://")
+	namespace = strings.TrimPrefix(namespace, "http://")
+	namespace = strings.TrimPrefix(namespace, "git@")
+	namespace = strings.Replace(namespace, ":", "/", 1)
+	if i := strings.LastIndex(namespace, "/"); i >= 0 {
+		return sbom.PURL("swift", namespace[:i]+"/"+namespace[i+1:], version)
+	}
+	return sbom.PURL("swift", name, version)
+}
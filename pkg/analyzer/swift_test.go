@@ -0,0 +1,106 @@
+package analyzer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSwiftPMAnalyzer(t *testing.T) {
+	analyzer := NewSwiftPMAnalyzer()
+
+	packageResolved := `{
+  "originHash" : "a3c2b5e1",
+  "pins" : [
+    {
+      "identity" : "swift-argument-parser",
+      "kind" : "remoteSourceControl",
+      "location" : "https://github.com/apple/swift-argument-parser.git",
+      "state" : {
+        "revision" : "fee6933f37fde9a5e12a1e4aeaa93fe60116ff2a",
+        "version" : "1.2.2"
+      }
+    },
+    {
+      "identity" : "swift-nio",
+      "kind" : "remoteSourceControl",
+      "location" : "https://github.com/apple/swift-nio.git",
+      "state" : {
+        "branch" : "main",
+        "revision" : "6213ba7a06febe8fef60563a4a7d26a4085783cf"
+      }
+    }
+  ],
+  "version" : 2
+}`
+
+	tmpDir, err := os.MkdirTemp("", "swift-analyzer-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	path := filepath.Join(tmpDir, "Package.resolved")
+	if err := os.WriteFile(path, []byte(packageResolved), 0644); err != nil {
+		t.Fatalf("Failed to write Package.resolved: %v", err)
+	}
+
+	components, err := analyzer.Analyze(path)
+	if err != nil {
+		t.Fatalf("Failed to analyze: %v", err)
+	}
+
+	if len(components) != 2 {
+		t.Fatalf("Expected 2 components, got %d", len(components))
+	}
+
+	parser := components[0]
+	if parser.PURL != "pkg:swift/github.com/apple/swift-argument-parser@1.2.2" {
+		t.Errorf("Unexpected PURL '%s'", parser.PURL)
+	}
+	if parser.Metadata.Revision != "fee6933f37fde9a5e12a1e4aeaa93fe60116ff2a" {
+		t.Errorf("Expected revision in metadata, got '%s'", parser.Metadata.Revision)
+	}
+	if parser.Metadata.SourceURL != "https://github.com/apple/swift-argument-parser.git" {
+		t.Errorf("Unexpected source URL '%s'", parser.Metadata.SourceURL)
+	}
+
+	nio := components[1]
+	if nio.Version != "6213ba7a06febe8fef60563a4a7d26a4085783cf" {
+		t.Errorf("Expected branch pin to fall back to revision, got '%s'", nio.Version)
+	}
+	if !parser.Direct || !nio.Direct {
+		t.Error("Expected every pin to be direct without a Package.swift")
+	}
+
+	packageSwift := `// swift-tools-version:5.9
+import PackageDescription
+
+let package = Package(
+    name: "Tool",
+    dependencies: [
+        .package(url: "git@github.com:apple/swift-argument-parser", .upToNextMajor(from: "1.2.0")),
+    ],
+    targets: [
+        .binaryTarget(name: "NIO", url: "https://github.com/apple/swift-nio.git", checksum: "abc"),
+    ]
+)
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, "Package.swift"), []byte(packageSwift), 0644); err != nil {
+		t.Fatalf("Failed to write Package.swift: %v", err)
+	}
+	components, err = analyzer.Analyze(path)
+	if err != nil {
+		t.Fatalf("Failed to analyze: %v", err)
+	}
+	if !components[0].Direct || components[1].Direct {
+		t.Errorf("Expected only the package Package.swift declares to be direct, got %v, %v", components[0].Direct, components[1].Direct)
+	}
+}
+
+func TestSwiftPMAnalyzer_Name(t *testing.T) {
+	analyzer := NewSwiftPMAnalyzer()
+	if analyzer.Name() != "swift" {
+		t.Errorf("Expected name 'swift', got '%s'", analyzer.Name())
+	}
+}
@@ -0,0 +1,302 @@
+package analyzer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hallucinaut/sbomgen/pkg/sbom"
+)
+
+// defaultTerraformRegistry is the host of provider sources such as
+// "hashicorp/aws" that do not name one.
+const defaultTerraformRegistry = "registry.terraform.io"
+
+var (
+	tfProviderBlock = regexp.MustCompile(`^provider\s+"([^"]+)"\s*\{`)
+	tfVersionAttr   = regexp.MustCompile(`^version\s*=\s*"([^"]*)"`)
+	tfQuoted        = regexp.MustCompile(`"([^"]*)"`)
+	tfRequiredBlock = regexp.MustCompile(`required_providers\s*\{`)
+	tfObjectEntry   = regexp.MustCompile(`([A-Za-z_][\w-]*)\s*=\s*\{([^}]*)\}`)
+	tfStringEntry   = regexp.MustCompile(`([A-Za-z_][\w-]*)\s*=\s*"([^"]*)"`)
+	tfAttrInObject  = regexp.MustCompile(`(source|version)\s*=\s*"([^"]*)"`)
+)
+
+// TerraformAnalyzer analyzes Terraform providers. Pinned versions and hashes
+// come from .terraform.lock.hcl; without a lock file, the version
+// constraints of required_providers blocks in *.tf files are reported.
+// Providers the module lists in required_providers are direct; the lock
+// file also pins those of child modules, which are not. A module without
+// required_providers blocks leaves directness unknown, and all of its
+// providers are kept as direct.
+type TerraformAnalyzer struct{}
+
+func NewTerraformAnalyzer() *TerraformAnalyzer {
+	return &TerraformAnalyzer{}
+}
+
+func (a *TerraformAnalyzer) Name() string {
+	return "terraform"
+}
+
+func (a *TerraformAnalyzer) ShouldAnalyze(path string) bool {
+	return isManifest(path, ".terraform.lock.hcl") || isTerraformFile(path)
+}
+
+func (a *TerraformAnalyzer) Analyze(path string) ([]sbom.Component, error) {
+	result, err := a.AnalyzeResult(path)
+	if err != nil {
+		return nil, err
+	}
+	return result.Components, nil
+}
+
+// AnalyzeResult analyzes a whole Terraform module at once, claiming all of
+// its files. The lock file, when there is one, supersedes the *.tf files
+// next to it; otherwise every *.tf file in the directory is read.
+func (a *TerraformAnalyzer) AnalyzeResult(path string) (*Result, error) {
+	dir := filepath.Dir(path)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var tfFiles []string
+	for _, entry := range entries {
+		if !entry.IsDir() && isTerraformFile(entry.Name()) {
+			tfFiles = append(tfFiles, filepath.Join(dir, entry.Name()))
+		}
+	}
+	return terraformModule(tfFiles, findManifest(dir, ".terraform.lock.hcl"), os.ReadFile)
+}
+
+// AnalyzeContent analyzes a Terraform module held in memory, as
+// AnalyzeResult does on disk.
+func (a *TerraformAnalyzer) AnalyzeContent(path string, data []byte, dir map[string][]byte) (*Result, error) {
+	var tfFiles []string
+	for name := range dir {
+		if isTerraformFile(name) {
+			tfFiles = append(tfFiles, filepath.Join(filepath.Dir(path), name))
+		}
+	}
+	sort.Strings(tfFiles)
+	return terraformModule(tfFiles, contentManifest(path, dir, ".terraform.lock.hcl"), contentReader(path, data, dir))
+}
+
+// terraformModule reads the lock file at lockPath, or the tfFiles when
+// there is none, using readFile.
+func terraformModule(tfFiles []string, lockPath string, readFile func(path string) ([]byte, error)) (*Result, error) {
+	var required []sbom.Component
+	seen := make(map[string]bool)
+	for _, file := range tfFiles {
+		data, err := readFile(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, comp := range parseRequiredProviders(file, data) {
+			if !seen[comp.Name] {
+				seen[comp.Name] = true
+				required = append(required, comp)
+			}
+		}
+	}
+
+	data, err := readFile(lockPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &Result{Components: required, Manifests: tfFiles}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	components, err := parseTerraformLock(lockPath, data)
+	if err != nil {
+		return nil, err
+	}
+	for i := range components {
+		components[i].Direct = len(seen) == 0 || seen[components[i].Name]
+	}
+	return &Result{Components: components, Manifests: append(tfFiles, lockPath)}, nil
+}
+
+// isTerraformFile reports whether path is a Terraform configuration file.
+func isTerraformFile(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".tf")
+}
+
+// parseTerraformLock reads the provider blocks of a dependency lock file.
+func parseTerraformLock(path string, data []byte) ([]sbom.Component, error) {
+	var components []sbom.Component
+	var source, version string
+	var hashes []sbom.Hash
+	inProvider, inHashes := false, false
+	lineNo, start := 0, 0
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(stripHCLComment(scanner.Text()))
+
+		if !inProvider {
+			if m := tfProviderBlock.FindStringSubmatch(line); m != nil {
+				inProvider, source, version, hashes, start = true, m[1], "", nil, lineNo
+			}
+			continue
+		}
+
+		switch {
+		case inHashes || strings.HasPrefix(line, "hashes"):
+			if inHashes || strings.Contains(line, "[") {
+				for _, m := range tfQuoted.FindAllStringSubmatch(line, -1) {
+					if hash, ok := terraformHash(m[1]); ok {
+						hashes = append(hashes, hash)
+					}
+				}
+				inHashes = !strings.Contains(line, "]")
+			}
+		case tfVersionAttr.MatchString(line):
+			version = tfVersionAttr.FindStringSubmatch(line)[1]
+		case line == "}":
+			comp := terraformProvider(source, version, path)
+			comp.Hashes = hashes
+			comp.Metadata.SourceLine = start
+			components = append(components, comp)
+			inProvider = false
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	sort.SliceStable(components, func(i, j int) bool {
+		return components[i].Name < components[j].Name
+	})
+	return components, nil
+}
+
+// parseRequiredProviders reads the required_providers blocks of a *.tf file.
+// Entries are either objects with source and version attributes or, in the
+// legacy syntax, a bare version constraint.
+func parseRequiredProviders(path string, data []byte) []sbom.Component {
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		lines = append(lines, stripHCLComment(line))
+	}
+	text := strings.Join(lines, "\n")
+
+	var components []sbom.Component
+	for _, loc := range tfRequiredBlock.FindAllStringIndex(text, -1) {
+		body := hclBlockBody(text[loc[1]:])
+		line := strings.Count(text[:loc[0]], "\n") + 1
+
+		for _, m := range tfObjectEntry.FindAllStringSubmatch(body, -1) {
+			var source, version string
+			for _, attr := range tfAttrInObject.FindAllStringSubmatch(m[2], -1) {
+				if attr[1] == "source" {
+					source = attr[2]
+				} else {
+					version = attr[2]
+				}
+			}
+			if source == "" {
+				source = "hashicorp/" + m[1]
+			}
+			comp := terraformProvider(source, version, path)
+			comp.Direct = true
+			comp.Metadata.SourceLine = line
+			components = append(components, comp)
+		}
+		for _, m := range tfStringEntry.FindAllStringSubmatch(tfObjectEntry.ReplaceAllString(body, ""), -1) {
+			comp := terraformProvider("hashicorp/"+m[1], m[2], path)
+			comp.Direct = true
+			comp.Metadata.SourceLine = line
+			components = append(components, comp)
+		}
+	}
+	return components
+}
+
+// terraformProvider builds a provider component from a source address such
+// as "hashicorp/aws" or "registry.terraform.io/hashicorp/aws", with a PURL
+// of the form pkg:terraform/<registry>/<namespace>/<type>@<version>. version
+// may be a constraint when read from a *.tf file, in which case the PURL is
+// left unversioned.
+func terraformProvider(source, version, path string) sbom.Component {
+	address := strings.ToLower(source)
+	if strings.Count(address, "/") < 2 {
+		address = defaultTerraformRegistry + "/" + address
+	}
+	name := address[strings.Index(address, "/")+1:]
+
+	purl := sbom.PURL("terraform", address, "")
+	if isExactVersion(version) {
+		purl = sbom.PURL("terraform", address, version)
+	}
+	return sbom.Component{
+		Name:     name,
+		Version:  version,
+		Supplier: "terraform",
+		PURL:     purl,
+		Metadata: sbom.Metadata{
+			SourceURL:  "https://" + address,
+			SourceFile: path,
+		},
+	}
+}
+
+// terraformHash converts a zh: lock file checksum, the hex SHA-256 of a
+// release zip, into a SHA-256 hash. h1: checksums are a hash over the
+// hashes of the files in the package, not a SHA-256 of anything a consumer
+// could download, and are skipped.
+func terraformHash(value string) (sbom.Hash, bool) {
+	digest, ok := strings.CutPrefix(value, "zh:")
+	if !ok {
+		return sbom.Hash{}, false
+	}
+	if _, err := hex.DecodeString(digest); err != nil || len(digest) != 64 {
+		return sbom.Hash{}, false
+	}
+	return sbom.Hash{Algorithm: "SHA-256", Value: strings.ToLower(digest)}, true
+}
+
+// hclBlockBody returns the text of a block whose opening brace has already
+// been consumed, up to its matching closing brace.
+func hclBlockBody(text string) string {
+	depth := 1
+	for i, r := range text {
+		switch r {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return text[:i]
+			}
+		}
+	}
+	return text
+}
+
+// stripHCLComment removes a trailing # or // comment from line, ignoring
+// comment markers inside quoted strings.
+func stripHCLComment(line string) string {
+	inString := false
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '"' && (i == 0 || line[i-1] != '\\'):
+			inString = !inString
+		case inString:
+		case line[i] == '#':
+			return line[:i]
+		case line[i] == '/' && i+1 < len(line) && line[i+1] == '/':
+			return line[:i]
+		}
+	}
+	return line
+}
@@ -0,0 +1,129 @@
+package analyzer
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+const terraformLock = `# This file is maintained automatically by "terraform init".
+# Manual edits may be lost in future updates.
+
+provider "registry.terraform.io/hashicorp/aws" {
+  version     = "5.31.0"
+  constraints = "~> 5.0"
+  hashes = [
+    "h1:ltxyuBWIy9cq0kIKDJH1jeWJy/y7XJLjS4QrsQK4plA=",
+    "zh:0cdb9c2083bf0902442384f7309367791e4640581652dda456f2d6d7abf0de8d",
+  ]
+}
+
+provider "registry.opentofu.org/integrations/github" {
+  version = "5.42.0"
+  hashes  = ["zh:1111111111111111111111111111111111111111111111111111111111111111"]
+}
+`
+
+const terraformMain = `terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws" # pinned in the lock file
+      version = "~> 5.0"
+    }
+    random = { source = "hashicorp/random", version = "3.5.1" }
+    null   = ">= 3.0"
+  }
+}
+
+resource "random_pet" "name" {}
+`
+
+func TestTerraformAnalyzer_LockFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTestFile(t, filepath.Join(tmpDir, ".terraform.lock.hcl"), terraformLock)
+	writeTestFile(t, filepath.Join(tmpDir, "main.tf"), terraformMain)
+
+	p := NewProjectAnalyzer()
+	if err := p.FilterAnalyzers([]string{"terraform"}, nil); err != nil {
+		t.Fatalf("Failed to filter analyzers: %v", err)
+	}
+	components, err := p.AnalyzeDir(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to analyze: %v", err)
+	}
+
+	if len(components) != 2 {
+		t.Fatalf("Expected the lock file to supersede main.tf with 2 components, got %d: %v", len(components), components)
+	}
+
+	aws := components[0]
+	if aws.Name != "hashicorp/aws" || aws.Version != "5.31.0" {
+		t.Errorf("Unexpected provider %s@%s", aws.Name, aws.Version)
+	}
+	if aws.PURL != "pkg:terraform/registry.terraform.io/hashicorp/aws@5.31.0" {
+		t.Errorf("Unexpected PURL '%s'", aws.PURL)
+	}
+	if aws.Metadata.SourceLine != 4 {
+		t.Errorf("Expected source line 4, got %d", aws.Metadata.SourceLine)
+	}
+	if len(aws.Hashes) != 1 {
+		t.Fatalf("Expected only the zh hash, got %v", aws.Hashes)
+	}
+	if aws.Hashes[0].Algorithm != "SHA-256" || aws.Hashes[0].Value != "0cdb9c2083bf0902442384f7309367791e4640581652dda456f2d6d7abf0de8d" {
+		t.Errorf("Expected zh hash as SHA-256, got %v", aws.Hashes[0])
+	}
+
+	github := components[1]
+	if github.PURL != "pkg:terraform/registry.opentofu.org/integrations/github@5.42.0" || len(github.Hashes) != 1 {
+		t.Errorf("Unexpected provider %s with hashes %v", github.PURL, github.Hashes)
+	}
+	if !aws.Direct || github.Direct {
+		t.Errorf("Expected only the provider main.tf requires to be direct, got aws %v, github %v", aws.Direct, github.Direct)
+	}
+}
+
+func TestTerraformAnalyzer_RequiredProviders(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "main.tf")
+	writeTestFile(t, path, terraformMain)
+	writeTestFile(t, filepath.Join(tmpDir, "versions.tf"), `terraform {
+  required_providers {
+    aws = { source = "hashicorp/aws" }
+  }
+}
+`)
+
+	components, err := NewTerraformAnalyzer().Analyze(path)
+	if err != nil {
+		t.Fatalf("Failed to analyze: %v", err)
+	}
+
+	if len(components) != 3 {
+		t.Fatalf("Expected 3 providers across both files, got %d: %v", len(components), components)
+	}
+
+	expected := map[string]string{
+		"hashicorp/aws":    "pkg:terraform/registry.terraform.io/hashicorp/aws",
+		"hashicorp/random": "pkg:terraform/registry.terraform.io/hashicorp/random@3.5.1",
+		"hashicorp/null":   "pkg:terraform/registry.terraform.io/hashicorp/null",
+	}
+	for _, comp := range components {
+		if comp.PURL != expected[comp.Name] {
+			t.Errorf("Expected %s to have PURL '%s', got '%s'", comp.Name, expected[comp.Name], comp.PURL)
+		}
+	}
+	if components[0].Version != "~> 5.0" {
+		t.Errorf("Expected version constraint to be kept, got '%s'", components[0].Version)
+	}
+	for _, comp := range components {
+		if !comp.Direct {
+			t.Errorf("Expected required provider %s to be direct", comp.Name)
+		}
+	}
+}
+
+func TestTerraformAnalyzer_Name(t *testing.T) {
+	analyzer := NewTerraformAnalyzer()
+	if analyzer.Name() != "terraform" {
+		t.Errorf("Expected name 'terraform', got '%s'", analyzer.Name())
+	}
+}
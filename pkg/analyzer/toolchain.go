@@ -0,0 +1,105 @@
+package analyzer
+
+import (
+	"os"
+	"strings"
+
+	"github.com/hallucinaut/sbomgen/pkg/sbom"
+)
+
+// asdfToolNames maps asdf plugin names onto the runtime names used for the
+// other version files, so that .nvmrc and .tool-versions agree.
+var asdfToolNames = map[string]string{
+	"nodejs": "node",
+}
+
+// ToolchainAnalyzer records the language runtimes a project pins in
+// .nvmrc, .python-version, .ruby-version and asdf's .tool-versions.
+type ToolchainAnalyzer struct{}
+
+func NewToolchainAnalyzer() *ToolchainAnalyzer {
+	return &ToolchainAnalyzer{}
+}
+
+func (a *ToolchainAnalyzer) Name() string {
+	return "toolchain"
+}
+
+func (a *ToolchainAnalyzer) ShouldAnalyze(path string) bool {
+	return isManifest(path, ".nvmrc", ".python-version", ".ruby-version", ".tool-versions")
+}
+
+func (a *ToolchainAnalyzer) Analyze(path string) ([]sbom.Component, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseToolVersions(path, data)
+}
+
+// AnalyzeContent analyzes a version file held in memory.
+func (a *ToolchainAnalyzer) AnalyzeContent(path string, data []byte, dir map[string][]byte) (*Result, error) {
+	return componentsResult(parseToolVersions(path, data))
+}
+
+// parseToolVersions reads the runtime versions pinned by a version file.
+func parseToolVersions(path string, data []byte) ([]sbom.Component, error) {
+	var components []sbom.Component
+	add := func(tool, version string) {
+		if !isToolchainVersion(version) {
+			return
+		}
+		components = append(components, sbom.Component{
+			Name:     tool,
+			Version:  version,
+			Supplier: "toolchain",
+			PURL:     sbom.PURL("generic", tool, version),
+			Scope:    sbom.ScopeToolchain,
+			Direct:   true,
+			Metadata: sbom.Metadata{
+				SourceFile: path,
+			},
+		})
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch {
+		case isManifest(path, ".tool-versions"):
+			// "nodejs 18.17.0" or, with fallbacks, "python 3.11.4 3.10.12".
+			tool := fields[0]
+			if name, ok := asdfToolNames[tool]; ok {
+				tool = name
+			}
+			for _, version := range fields[1:] {
+				add(tool, version)
+			}
+		case isManifest(path, ".nvmrc"):
+			add("node", strings.TrimPrefix(fields[0], "v"))
+		case isManifest(path, ".ruby-version"):
+			add("ruby", strings.TrimPrefix(fields[0], "ruby-"))
+		default:
+			// pyenv allows one version per line, the first taking
+			// precedence.
+			add("python", fields[0])
+		}
+	}
+	return components, nil
+}
+
+// isToolchainVersion reports whether version names a concrete release,
+// rejecting aliases such as "system", "lts/hydrogen" and asdf's "ref:" and
+// "path:" forms.
+func isToolchainVersion(version string) bool {
+	if version == "" || version[0] < '0' || version[0] > '9' {
+		return false
+	}
+	return !strings.ContainsAny(version, ":/")
+}
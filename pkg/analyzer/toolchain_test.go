@@ -0,0 +1,66 @@
+package analyzer
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestToolchainAnalyzer(t *testing.T) {
+	analyzer := NewToolchainAnalyzer()
+
+	tests := []struct {
+		file     string
+		content  string
+		expected []string
+	}{
+		{".nvmrc", "v18.17.0\n", []string{"pkg:generic/node@18.17.0"}},
+		{".nvmrc", "lts/hydrogen\n", nil},
+		{".python-version", "3.11.4\n3.10.12\n", []string{"pkg:generic/python@3.11.4", "pkg:generic/python@3.10.12"}},
+		{".python-version", "system\n", nil},
+		{".ruby-version", "ruby-3.2.2\n", []string{"pkg:generic/ruby@3.2.2"}},
+		{".tool-versions", `# asdf
+nodejs 18.17.0
+python 3.11.4 3.10.12  # fallback
+golang ref:master
+terraform 1.5.7
+`, []string{
+			"pkg:generic/node@18.17.0",
+			"pkg:generic/python@3.11.4",
+			"pkg:generic/python@3.10.12",
+			"pkg:generic/terraform@1.5.7",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			writeTestFile(t, path, tt.content)
+
+			if !analyzer.ShouldAnalyze(path) {
+				t.Fatalf("Expected %s to be analyzed", tt.file)
+			}
+			components, err := analyzer.Analyze(path)
+			if err != nil {
+				t.Fatalf("Failed to analyze %s: %v", tt.file, err)
+			}
+			if len(components) != len(tt.expected) {
+				t.Fatalf("Expected %d components, got %+v", len(tt.expected), components)
+			}
+			for i, comp := range components {
+				if comp.PURL != tt.expected[i] {
+					t.Errorf("Expected PURL %s, got %s", tt.expected[i], comp.PURL)
+				}
+				if string(comp.Scope) != "runtime-toolchain" || !comp.Direct {
+					t.Errorf("Expected a direct runtime-toolchain component, got %+v", comp)
+				}
+			}
+		})
+	}
+}
+
+func TestToolchainAnalyzer_ShouldAnalyze(t *testing.T) {
+	analyzer := NewToolchainAnalyzer()
+	if analyzer.ShouldAnalyze("/project/.nvmrc.bak") || analyzer.ShouldAnalyze("/project/package.json") {
+		t.Error("Expected only toolchain version files to be analyzed")
+	}
+}
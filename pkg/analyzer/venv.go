@@ -0,0 +1,272 @@
+package analyzer
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hallucinaut/sbomgen/pkg/sbom"
+)
+
+// VenvAnalyzer reads the packages installed in a Python virtual environment
+// from their site-packages/*.dist-info/METADATA files. Unlike requirements
+// files, these record the resolved version of every installed package and,
+// through Requires-Dist, the edges between them. It only runs on request,
+// as it describes what is installed rather than what the project declares.
+type VenvAnalyzer struct{}
+
+func NewVenvAnalyzer() *VenvAnalyzer {
+	return &VenvAnalyzer{}
+}
+
+func (a *VenvAnalyzer) Name() string {
+	return "venv"
+}
+
+// distInfo is the subset of a METADATA file that the venv analyzer reads.
+type distInfo struct {
+	name     string
+	version  string
+	license  string
+	requires []string
+	path     string
+}
+
+// requirementName matches the project name at the start of a Requires-Dist
+// value such as "idna<4,>=2.5" or "charset-normalizer (<4,>=2)".
+var requirementName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*`)
+
+// pypiSeparators matches the runs of characters that PEP 503 treats as
+// equivalent in project names.
+var pypiSeparators = regexp.MustCompile(`[-_.]+`)
+
+// normalizePyPIName returns the PEP 503 normalized form of a project name.
+func normalizePyPIName(name string) string {
+	return strings.ToLower(pypiSeparators.ReplaceAllString(name, "-"))
+}
+
+// AnalyzeResult describes the packages installed in the virtual environment
+// at venv, which may also be a site-packages directory itself. Every
+// installed package becomes a component, and each Requires-Dist entry that
+// names another installed package becomes a depends_on relationship.
+// Requirements that only apply to an extra are left out.
+func (a *VenvAnalyzer) AnalyzeResult(venv string) (*Result, error) {
+	dirs, err := sitePackages(venv)
+	if err != nil {
+		return nil, err
+	}
+
+	installed := make(map[string]*distInfo)
+	var names []string
+	for _, dir := range dirs {
+		metadata, err := filepath.Glob(filepath.Join(dir, "*.dist-info", "METADATA"))
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range metadata {
+			info, err := readDistInfo(path)
+			if err != nil {
+				return nil, err
+			}
+			key := normalizePyPIName(info.name)
+			if info.name == "" || installed[key] != nil {
+				continue
+			}
+			installed[key] = info
+			names = append(names, key)
+		}
+	}
+	sort.Strings(names)
+
+	result := &Result{}
+	for _, key := range names {
+		info := installed[key]
+		result.Components = append(result.Components, sbom.Component{
+			Name:     info.name,
+			Version:  info.version,
+			Supplier: "pypi",
+			License:  info.license,
+			PURL:     sbom.PURL("pypi", info.name, info.version),
+			Metadata: sbom.Metadata{
+				SourceFile: filepath.ToSlash(info.path),
+			},
+		})
+		seen := make(map[string]bool)
+		for _, req := range info.requires {
+			dep := installed[normalizePyPIName(req)]
+			if dep == nil || dep == info || seen[dep.name] {
+				continue
+			}
+			seen[dep.name] = true
+			result.Relationships = append(result.Relationships, sbom.Relationship{
+				RefA:         sbom.PURL("pypi", info.name, info.version),
+				RefB:         sbom.PURL("pypi", dep.name, dep.version),
+				Relationship: sbom.DependsOn,
+			})
+		}
+	}
+	markDeclaredLicenses(result.Components)
+	result.Scanned = append(result.Scanned, ManifestRecord{
+		Analyzer:       a.Name(),
+		Path:           filepath.ToSlash(venv),
+		ComponentCount: len(result.Components),
+	})
+	return result, nil
+}
+
+// sitePackages returns the site-packages directories of the virtual
+// environment at venv: lib/pythonX.Y/site-packages on Unix and
+// Lib/site-packages on Windows, or venv itself if it holds dist-info
+// directories.
+func sitePackages(venv string) ([]string, error) {
+	if info, err := os.Stat(venv); err != nil {
+		return nil, err
+	} else if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", venv)
+	}
+	if own, _ := filepath.Glob(filepath.Join(venv, "*.dist-info")); len(own) > 0 {
+		return []string{venv}, nil
+	}
+
+	var dirs []string
+	for _, pattern := range []string{
+		filepath.Join(venv, "lib", "python*", "site-packages"),
+		filepath.Join(venv, "lib64", "python*", "site-packages"),
+		filepath.Join(venv, "Lib", "site-packages"),
+	} {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		for _, match := range matches {
+			if resolved, err := filepath.EvalSymlinks(match); err == nil && !containsPath(dirs, resolved) {
+				dirs = append(dirs, resolved)
+			}
+		}
+	}
+	if len(dirs) == 0 {
+		return nil, fmt.Errorf("no site-packages directory found in %s", venv)
+	}
+	return dirs, nil
+}
+
+func containsPath(paths []string, path string) bool {
+	for _, p := range paths {
+		if p == path {
+			return true
+		}
+	}
+	return false
+}
+
+// readDistInfo parses the header section of a core metadata file. As with
+// PyPI enrichment, the free-form License field is only used when it is
+// short, since some packages embed the full license text; a PEP 639
+// License-Expression takes precedence.
+func readDistInfo(path string) (*distInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info := &distInfo{path: path}
+	var license, expression string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			break
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.ToLower(key) {
+		case "name":
+			info.name = value
+		case "version":
+			info.version = value
+		case "license":
+			if license == "" {
+				license = value
+			}
+		case "license-expression":
+			expression = value
+		case "requires-dist":
+			requirement, marker, _ := strings.Cut(value, ";")
+			if strings.Contains(marker, "extra") {
+				continue
+			}
+			if name := requirementName.FindString(strings.TrimSpace(requirement)); name != "" {
+				info.requires = append(info.requires, name)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	switch {
+	case expression != "":
+		info.license = expression
+	case license != "" && !strings.EqualFold(license, "UNKNOWN") && len(license) <= 64:
+		info.license = sbom.LicenseExpression(license)
+	}
+	return info, nil
+}
+
+// Supersede replaces the components of r that other also describes, as
+// identified by supplier and name, with those of other, and adds the rest
+// of other's components, relationships, scanned records and warnings. A superseded
+// component passes its scope and direct flag on to its replacement. It is
+// used to let resolved data, such as an installed virtual environment,
+// take precedence over declared ranges.
+func (r *Result) Supersede(other *Result) {
+	replaced := make(map[string]sbom.Component)
+	for _, comp := range r.Components {
+		replaced[componentIdentity(comp)] = comp
+	}
+
+	superseded := make(map[string]bool)
+	for i := range other.Components {
+		comp := &other.Components[i]
+		key := componentIdentity(*comp)
+		if old, ok := replaced[key]; ok {
+			superseded[key] = true
+			comp.Direct = comp.Direct || old.Direct
+			if comp.Scope == "" {
+				comp.Scope = old.Scope
+			}
+		}
+	}
+
+	kept := r.Components[:0]
+	for _, comp := range r.Components {
+		if !superseded[componentIdentity(comp)] {
+			kept = append(kept, comp)
+		}
+	}
+	r.Components = append(kept, other.Components...)
+	r.Relationships = append(r.Relationships, other.Relationships...)
+	r.Scanned = append(r.Scanned, other.Scanned...)
+	r.Warnings = append(r.Warnings, other.Warnings...)
+}
+
+// componentIdentity identifies a package independently of its version,
+// using the PEP 503 name for PyPI packages.
+func componentIdentity(comp sbom.Component) string {
+	if comp.Supplier == "pypi" {
+		return "pypi/" + normalizePyPIName(comp.Name)
+	}
+	return comp.Supplier + "/" + comp.Name
+}
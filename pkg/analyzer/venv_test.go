@@ -0,0 +1,82 @@
+package analyzer
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/hallucinaut/sbomgen/pkg/sbom"
+)
+
+func TestVenvAnalyzer_AnalyzeResult(t *testing.T) {
+	venv := t.TempDir()
+	site := filepath.Join(venv, "lib", "python3.11", "site-packages")
+	writeTestFile(t, filepath.Join(site, "requests-2.31.0.dist-info", "METADATA"), `Metadata-Version: 2.1
+Name: requests
+Version: 2.31.0
+License: Apache 2.0
+Requires-Dist: charset-normalizer (<4,>=2)
+Requires-Dist: idna<4,>=2.5
+Requires-Dist: PySocks!=1.5.7,>=1.5.6 ; extra == 'socks'
+
+Requests is an elegant and simple HTTP library.
+Requires-Dist: not-a-header
+`)
+	writeTestFile(t, filepath.Join(site, "charset_normalizer-3.3.2.dist-info", "METADATA"), `Metadata-Version: 2.1
+Name: charset-normalizer
+Version: 3.3.2
+License-Expression: MIT
+`)
+
+	result, err := NewVenvAnalyzer().AnalyzeResult(venv)
+	if err != nil {
+		t.Fatalf("Failed to analyze virtualenv: %v", err)
+	}
+	if len(result.Components) != 2 {
+		t.Fatalf("Expected 2 components, got %+v", result.Components)
+	}
+	if comp := result.Components[1]; comp.PURL != "pkg:pypi/requests@2.31.0" || comp.License != "Apache 2.0" {
+		t.Errorf("Expected requests@2.31.0 under its License field, got %+v", comp)
+	}
+	if comp := result.Components[0]; comp.PURL != "pkg:pypi/charset-normalizer@3.3.2" || comp.License != "MIT" {
+		t.Errorf("Expected charset-normalizer@3.3.2 under MIT, got %+v", comp)
+	}
+
+	expected := []sbom.Relationship{{
+		RefA:         "pkg:pypi/requests@2.31.0",
+		RefB:         "pkg:pypi/charset-normalizer@3.3.2",
+		Relationship: sbom.DependsOn,
+	}}
+	if len(result.Relationships) != 1 || result.Relationships[0] != expected[0] {
+		t.Errorf("Expected %+v, got %+v", expected, result.Relationships)
+	}
+}
+
+func TestVenvAnalyzer_NoSitePackages(t *testing.T) {
+	if _, err := NewVenvAnalyzer().AnalyzeResult(t.TempDir()); err == nil {
+		t.Error("Expected an error for a directory without site-packages")
+	}
+}
+
+func TestResult_Supersede(t *testing.T) {
+	declared := &Result{Components: []sbom.Component{
+		{Name: "Requests", Version: ">=2.28", Supplier: "pypi", Scope: sbom.ScopeDev},
+		{Name: "flask", Version: "2.3.0", Supplier: "pypi"},
+		{Name: "requests", Version: "1.0.0", Supplier: "npm"},
+	}}
+	installed := &Result{Components: []sbom.Component{
+		{Name: "requests", Version: "2.31.0", Supplier: "pypi"},
+	}}
+
+	declared.Supersede(installed)
+	if len(declared.Components) != 3 {
+		t.Fatalf("Expected 3 components, got %+v", declared.Components)
+	}
+	for _, comp := range declared.Components {
+		if comp.Supplier == "pypi" && comp.Name == "Requests" {
+			t.Error("Expected the declared range to be superseded")
+		}
+	}
+	if last := declared.Components[2]; last.Version != "2.31.0" || last.Scope != sbom.ScopeDev {
+		t.Errorf("Expected the installed version to keep the declared scope, got %+v", last)
+	}
+}
@@ -0,0 +1,98 @@
+package analyzer
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// skipDirs lists directory names that hold installed dependencies, VCS data
+// or build output rather than project manifests.
+var skipDirs = map[string]bool{
+	"node_modules": true,
+	"vendor":       true,
+	".git":         true,
+	"dist":         true,
+	"build":        true,
+	".terraform":   true,
+}
+
+// walkFiles calls visit for every file below dir, skipping skipDirs.
+// Symlinked directories are followed, but each resolved directory is read at
+// most once, so symlink cycles terminate. Windows junctions are reported as
+// irregular files and are never descended into. Unreadable entries, such as
+// directories without read permission or dangling symlinks, are skipped and
+// passed to warn if it is non-nil.
+//
+// Only dir itself and symlinked directories are resolved with
+// EvalSymlinks: below a resolved directory, a real subdirectory resolves to
+// the joined path, which saves a system call per path component on every
+// directory of deep trees.
+func walkFiles(dir string, visit func(path string) error, warn func(err error)) error {
+	visited := make(map[string]bool)
+
+	var walk func(path, resolved string) error
+	walk = func(path, resolved string) error {
+		if resolved == "" {
+			var err error
+			if resolved, err = filepath.EvalSymlinks(path); err != nil {
+				if warn != nil {
+					warn(err)
+				}
+				return nil
+			}
+		}
+		if visited[resolved] {
+			return nil
+		}
+		visited[resolved] = true
+
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			if warn != nil {
+				warn(err)
+			}
+			return nil
+		}
+
+		for _, entry := range entries {
+			name := entry.Name()
+			child := filepath.Join(path, name)
+
+			if entry.IsDir() {
+				if skipDirs[name] {
+					continue
+				}
+				if err := walk(child, filepath.Join(resolved, name)); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if entry.Type()&os.ModeSymlink != 0 {
+				info, err := os.Stat(child)
+				if err != nil {
+					if warn != nil {
+						warn(err)
+					}
+					continue
+				}
+				if info.IsDir() {
+					if skipDirs[name] {
+						continue
+					}
+					if err := walk(child, ""); err != nil {
+						return err
+					}
+					continue
+				}
+			}
+
+			if err := visit(child); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return walk(dir, "")
+}
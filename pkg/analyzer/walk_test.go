@@ -0,0 +1,257 @@
+package analyzer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAnalyzeDir_SymlinkCycle(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "walk-cycle-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	writeTestFile(t, filepath.Join(tmpDir, "requirements.txt"), "flask==2.0.0\n")
+	if err := os.MkdirAll(filepath.Join(tmpDir, "sub"), 0755); err != nil {
+		t.Fatalf("Failed to create sub dir: %v", err)
+	}
+	if err := os.Symlink(tmpDir, filepath.Join(tmpDir, "sub", "loop")); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+
+	done := make(chan int, 1)
+	go func() {
+		components, _ := NewProjectAnalyzer().AnalyzeDir(tmpDir)
+		done <- len(components)
+	}()
+
+	select {
+	case count := <-done:
+		if count != 1 {
+			t.Errorf("Expected 1 component, got %d", count)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Directory walk did not terminate on a symlink cycle")
+	}
+}
+
+func TestAnalyzeDir_FollowsSymlinkedDir(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "walk-link-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	target := filepath.Join(tmpDir, "shared")
+	project := filepath.Join(tmpDir, "project")
+	writeTestFile(t, filepath.Join(target, "requirements.txt"), "requests==2.28.0\n")
+	if err := os.MkdirAll(project, 0755); err != nil {
+		t.Fatalf("Failed to create project dir: %v", err)
+	}
+	if err := os.Symlink(target, filepath.Join(project, "shared")); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+
+	components, err := NewProjectAnalyzer().AnalyzeDir(project)
+	if err != nil {
+		t.Fatalf("Failed to analyze: %v", err)
+	}
+	if len(components) != 1 || components[0].Name != "requests" {
+		t.Fatalf("Expected requests from symlinked dir, got %v", components)
+	}
+	if strings.Contains(components[0].Metadata.SourceFile, `\`) {
+		t.Errorf("Expected forward slashes in source file, got '%s'", components[0].Metadata.SourceFile)
+	}
+}
+
+func TestAnalyzeDir_MaxFiles(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "walk-max-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	for i := 0; i < 20; i++ {
+		writeTestFile(t, filepath.Join(tmpDir, fmt.Sprintf("file%02d.txt", i)), "")
+	}
+
+	analyzer := NewProjectAnalyzer()
+	analyzer.MaxFiles = 10
+	if _, err := analyzer.AnalyzeDir(tmpDir); !errors.Is(err, ErrTooManyFiles) {
+		t.Errorf("Expected ErrTooManyFiles, got %v", err)
+	}
+
+	analyzer.MaxFiles = 20
+	if _, err := analyzer.AnalyzeDir(tmpDir); err != nil {
+		t.Errorf("Expected walk within cap to succeed, got %v", err)
+	}
+}
+
+func TestAnalyzeProject_ReportsUnreadablePaths(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeTestFile(t, filepath.Join(tmpDir, "requirements.txt"), "flask==2.0.0\n")
+	writeTestFile(t, filepath.Join(tmpDir, "svc", "go.mod"), "module example.com/svc\n\nrequire (\n")
+	dangling := filepath.Join(tmpDir, "web", "package.json")
+	if err := os.MkdirAll(filepath.Dir(dangling), 0755); err != nil {
+		t.Fatalf("Failed to create web dir: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(tmpDir, "missing.json"), dangling); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+
+	result, err := NewProjectAnalyzer().AnalyzeProject(tmpDir)
+	if err != nil {
+		t.Fatalf("Expected the scan to continue, got %v", err)
+	}
+	if len(result.Components) != 1 {
+		t.Errorf("Expected the readable requirements.txt to be analyzed, got %+v", result.Components)
+	}
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0].Error(), dangling) {
+		t.Errorf("Expected a warning for %s, got %v", dangling, result.Warnings)
+	}
+
+	incomplete := result.Incomplete()
+	if incomplete == nil {
+		t.Fatal("Expected the result to be reported as incomplete")
+	}
+	for _, want := range []string{dangling, filepath.ToSlash(filepath.Join(tmpDir, "svc", "go.mod"))} {
+		if !strings.Contains(incomplete.Error(), want) {
+			t.Errorf("Expected %s to be reported, got %v", want, incomplete)
+		}
+	}
+}
+
+func TestAnalyzeProject_ReportsUnreadableDir(t *testing.T) {
+	if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+		t.Skip("directory permissions are not enforced for this user")
+	}
+	tmpDir := t.TempDir()
+	locked := filepath.Join(tmpDir, "locked")
+	writeTestFile(t, filepath.Join(locked, "requirements.txt"), "flask==2.0.0\n")
+	if err := os.Chmod(locked, 0); err != nil {
+		t.Fatalf("Failed to lock dir: %v", err)
+	}
+	defer os.Chmod(locked, 0755)
+
+	result, err := NewProjectAnalyzer().AnalyzeProject(tmpDir)
+	if err != nil {
+		t.Fatalf("Expected the scan to continue, got %v", err)
+	}
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0].Error(), locked) {
+		t.Errorf("Expected a warning for %s, got %v", locked, result.Warnings)
+	}
+}
+
+func TestResult_IncompleteNil(t *testing.T) {
+	result := &Result{Scanned: []ManifestRecord{{Analyzer: "npm", Path: "package.json", ComponentCount: 1}}}
+	if err := result.Incomplete(); err != nil {
+		t.Errorf("Expected a complete result, got %v", err)
+	}
+}
+
+// buildHeavyTree creates a monorepo-like tree below dir: packages with
+// deeply nested source directories, each beside a node_modules holding
+// many installed packages.
+func buildHeavyTree(tb testing.TB, dir string, packages, depth, installed int) {
+	tb.Helper()
+	mkfile := func(path string) {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			tb.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("{}"), 0644); err != nil {
+			tb.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+	for i := 0; i < packages; i++ {
+		pkg := filepath.Join(dir, "packages", fmt.Sprintf("pkg-%d", i))
+		mkfile(filepath.Join(pkg, "package.json"))
+		src := filepath.Join(pkg, "src")
+		for d := 0; d < depth; d++ {
+			src = filepath.Join(src, fmt.Sprintf("level-%d", d))
+			mkfile(filepath.Join(src, "index.js"))
+		}
+		for j := 0; j < installed; j++ {
+			mkfile(filepath.Join(pkg, "node_modules", fmt.Sprintf("dep-%d", j), "package.json"))
+		}
+	}
+}
+
+func collectFiles(tb testing.TB, dir string) []string {
+	tb.Helper()
+	var files []string
+	err := walkFiles(dir, func(path string) error {
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	}, func(err error) {
+		tb.Errorf("Unexpected walk warning: %v", err)
+	})
+	if err != nil {
+		tb.Fatalf("walkFiles failed: %v", err)
+	}
+	sort.Strings(files)
+	return files
+}
+
+func BenchmarkWalkFiles(b *testing.B) {
+	dir := b.TempDir()
+	buildHeavyTree(b, dir, 200, 12, 20)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		collectFiles(b, dir)
+	}
+}
+
+func TestWalkFiles_SkipsAndSymlinks(t *testing.T) {
+	tmpDir := t.TempDir()
+	project := filepath.Join(tmpDir, "project")
+	ext := filepath.Join(tmpDir, "ext")
+	for _, path := range []string{
+		filepath.Join(project, "requirements.txt"),
+		filepath.Join(project, "a", "b", "c", "go.mod"),
+		filepath.Join(project, "node_modules", "x", "package.json"),
+		filepath.Join(project, "a", "node_modules", "y", "package.json"),
+		filepath.Join(project, "a", "vendor", "z", "go.mod"),
+		filepath.Join(ext, "lib", "Cargo.toml"),
+		filepath.Join(ext, "notes.txt"),
+	} {
+		writeTestFile(t, path, "")
+	}
+	for link, target := range map[string]string{
+		filepath.Join(project, "linked"):       ext,
+		filepath.Join(project, "a", "loop"):    project,
+		filepath.Join(project, "a", "twin"):    filepath.Join(project, "a", "b"),
+		filepath.Join(project, "deps"):         filepath.Join(project, "node_modules"),
+		filepath.Join(project, "build"):        ext,
+		filepath.Join(project, "dist"):         filepath.Join(ext, "notes.txt"),
+		filepath.Join(project, "a", "b", "up"): filepath.Join(project, "a", "b", "c"),
+	} {
+		if err := os.Symlink(target, link); err != nil {
+			t.Skipf("Symlinks not supported: %v", err)
+		}
+	}
+
+	got := collectFiles(t, project)
+	want := []string{
+		"a/b/c/go.mod",
+		"deps/x/package.json",
+		"dist",
+		"linked/lib/Cargo.toml",
+		"linked/notes.txt",
+		"requirements.txt",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Expected files %v, got %v", want, got)
+	}
+}
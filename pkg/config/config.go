@@ -0,0 +1,236 @@
+// Package config loads sbomgen defaults from a .sbomgen.yaml file.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hallucinaut/sbomgen/pkg/enrich"
+	"github.com/hallucinaut/sbomgen/pkg/formatter"
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the name of the configuration file searched for by Find.
+const FileName = ".sbomgen.yaml"
+
+// Config holds defaults for the gen command. Explicit command-line flags
+// take precedence over every field.
+type Config struct {
+	Format        string        `yaml:"format"`
+	Output        string        `yaml:"output"`
+	Exclude       []string      `yaml:"exclude"`
+	MaxFiles      *int          `yaml:"max_files"`
+	GroupBy       string        `yaml:"group_by"`
+	LicensePolicy LicensePolicy `yaml:"license_policy"`
+	// PURLTypes overrides the package-url type used for an ecosystem, for
+	// example {deb: alpine}.
+	PURLTypes map[string]string `yaml:"purl_types"`
+	// Enrich, EnrichConcurrency, EnrichRate, OSV and OSVBatchSize are the
+	// defaults of --enrich, --enrich-concurrency, --enrich-rate, --osv and
+	// --batch-size.
+	Enrich            *bool    `yaml:"enrich"`
+	EnrichConcurrency *int     `yaml:"enrich_concurrency"`
+	EnrichRate        *float64 `yaml:"enrich_rate"`
+	OSV               *bool    `yaml:"osv"`
+	OSVBatchSize      *int     `yaml:"osv_batch_size"`
+	// Profiles holds named sets of settings, such as release or audit,
+	// that WithProfile layers over the top-level ones.
+	Profiles map[string]*Config `yaml:"profiles"`
+}
+
+// LicensePolicy lists licenses that must not appear in the generated SBOM.
+type LicensePolicy struct {
+	Deny []string `yaml:"deny"`
+}
+
+// Load reads and validates the configuration file at path. Environment
+// variables in the output path are expanded with ExpandPath, and a relative
+// output path is then resolved against the directory containing the file.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	var cfg Config
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", path, err)
+	}
+
+	if cfg.Output, err = resolveOutput(cfg.Output, path); err != nil {
+		return nil, fmt.Errorf("invalid %s: output: %w", path, err)
+	}
+	for name, profile := range cfg.Profiles {
+		if profile == nil {
+			continue
+		}
+		if profile.Output, err = resolveOutput(profile.Output, path); err != nil {
+			return nil, fmt.Errorf("invalid %s: profiles.%s.output: %w", path, name, err)
+		}
+	}
+	return &cfg, nil
+}
+
+// resolveOutput expands output and resolves it against the directory of
+// the configuration file at path.
+func resolveOutput(output, path string) (string, error) {
+	output, err := ExpandPath(output)
+	if err != nil {
+		return "", err
+	}
+	if output != "" && !filepath.IsAbs(output) {
+		output = filepath.Join(filepath.Dir(path), output)
+	}
+	return output, nil
+}
+
+// WithProfile returns the settings of c with the profile called name
+// layered over them. Settings the profile leaves unset keep their
+// top-level values, exclude patterns and denied licenses are combined,
+// and purl_types entries of the profile replace those with the same
+// ecosystem. It fails if c has no such profile.
+func (c *Config) WithProfile(name string) (*Config, error) {
+	profile, ok := c.Profiles[name]
+	if !ok {
+		names := make([]string, 0, len(c.Profiles))
+		for n := range c.Profiles {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		if len(names) == 0 {
+			return nil, fmt.Errorf("unknown profile %q: the config defines no profiles", name)
+		}
+		return nil, fmt.Errorf("unknown profile %q (available: %s)", name, strings.Join(names, ", "))
+	}
+	if profile == nil {
+		profile = &Config{}
+	}
+
+	merged := *c
+	merged.Profiles = nil
+	if profile.Format != "" {
+		merged.Format = profile.Format
+	}
+	if profile.Output != "" {
+		merged.Output = profile.Output
+	}
+	if profile.MaxFiles != nil {
+		merged.MaxFiles = profile.MaxFiles
+	}
+	if profile.GroupBy != "" {
+		merged.GroupBy = profile.GroupBy
+	}
+	if profile.Enrich != nil {
+		merged.Enrich = profile.Enrich
+	}
+	if profile.EnrichConcurrency != nil {
+		merged.EnrichConcurrency = profile.EnrichConcurrency
+	}
+	if profile.EnrichRate != nil {
+		merged.EnrichRate = profile.EnrichRate
+	}
+	if profile.OSV != nil {
+		merged.OSV = profile.OSV
+	}
+	if profile.OSVBatchSize != nil {
+		merged.OSVBatchSize = profile.OSVBatchSize
+	}
+	merged.Exclude = append(append([]string(nil), c.Exclude...), profile.Exclude...)
+	merged.LicensePolicy.Deny = append(append([]string(nil), c.LicensePolicy.Deny...), profile.LicensePolicy.Deny...)
+	if len(profile.PURLTypes) > 0 {
+		merged.PURLTypes = make(map[string]string, len(c.PURLTypes)+len(profile.PURLTypes))
+		for ecosystem, purlType := range c.PURLTypes {
+			merged.PURLTypes[ecosystem] = purlType
+		}
+		for ecosystem, purlType := range profile.PURLTypes {
+			merged.PURLTypes[ecosystem] = purlType
+		}
+	}
+	return &merged, nil
+}
+
+// Find looks for FileName in dir and each of its parents, returning the
+// loaded configuration and its path. It returns a nil Config and no error
+// when no file is found.
+func Find(dir string) (*Config, string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to resolve directory path: %w", err)
+	}
+
+	for {
+		candidate := filepath.Join(dir, FileName)
+		if _, err := os.Stat(candidate); err == nil {
+			cfg, err := Load(candidate)
+			return cfg, candidate, err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, "", nil
+		}
+		dir = parent
+	}
+}
+
+// Validate reports the first invalid setting in c.
+func (c *Config) Validate() error {
+	if format := formatter.Format(c.Format); format != "" && format != formatter.Auto && !format.IsValid() {
+		return fmt.Errorf("unknown format %q", c.Format)
+	}
+	for _, pattern := range c.Exclude {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid exclude pattern %q: %w", pattern, err)
+		}
+	}
+	if c.MaxFiles != nil && *c.MaxFiles < 0 {
+		return fmt.Errorf("max_files must not be negative")
+	}
+	if c.GroupBy != "" {
+		if _, err := formatter.ParseGroupBy(c.GroupBy); err != nil {
+			return err
+		}
+	}
+	if c.EnrichConcurrency != nil && *c.EnrichConcurrency < 1 {
+		return fmt.Errorf("enrich_concurrency must be a positive integer")
+	}
+	if c.EnrichRate != nil && *c.EnrichRate < 0 {
+		return fmt.Errorf("enrich_rate must not be negative")
+	}
+	if c.OSVBatchSize != nil && (*c.OSVBatchSize < 1 || *c.OSVBatchSize > enrich.DefaultOSVBatchSize) {
+		return fmt.Errorf("osv_batch_size must be between 1 and %d", enrich.DefaultOSVBatchSize)
+	}
+	for ecosystem, purlType := range c.PURLTypes {
+		if purlType == "" {
+			return fmt.Errorf("purl_types.%s is empty", ecosystem)
+		}
+	}
+	for _, license := range c.LicensePolicy.Deny {
+		if license == "" {
+			return fmt.Errorf("license_policy.deny contains an empty license")
+		}
+	}
+	for name, profile := range c.Profiles {
+		if profile == nil {
+			continue
+		}
+		if len(profile.Profiles) > 0 {
+			return fmt.Errorf("profiles.%s: profiles cannot be nested", name)
+		}
+		if err := profile.Validate(); err != nil {
+			return fmt.Errorf("profiles.%s: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, dir, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, FileName)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	return path
+}
+
+func TestFind_SearchesParents(t *testing.T) {
+	tmpDir := t.TempDir()
+	nested := filepath.Join(tmpDir, "services", "api")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatalf("Failed to create dir: %v", err)
+	}
+
+	path := writeConfig(t, tmpDir, `format: markdown
+output: reports/sbom.md
+exclude:
+  - "internal-*"
+max_files: 5000
+group_by: supplier
+license_policy:
+  deny:
+    - AGPL-3.0-only
+`)
+
+	cfg, found, err := Find(nested)
+	if err != nil {
+		t.Fatalf("Failed to find config: %v", err)
+	}
+	if found != path {
+		t.Errorf("Expected config at '%s', got '%s'", path, found)
+	}
+	if cfg.Format != "markdown" || cfg.GroupBy != "supplier" {
+		t.Errorf("Unexpected config %+v", cfg)
+	}
+	if cfg.MaxFiles == nil || *cfg.MaxFiles != 5000 {
+		t.Errorf("Expected max_files 5000, got %v", cfg.MaxFiles)
+	}
+	if cfg.Output != filepath.Join(tmpDir, "reports", "sbom.md") {
+		t.Errorf("Expected output relative to config file, got '%s'", cfg.Output)
+	}
+	if len(cfg.Exclude) != 1 || len(cfg.LicensePolicy.Deny) != 1 {
+		t.Errorf("Expected exclude and deny lists, got %+v", cfg)
+	}
+}
+
+func TestFind_NoConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg, found, err := Find(tmpDir)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cfg != nil && filepath.Dir(found) == tmpDir {
+		t.Errorf("Expected no config in empty directory, found '%s'", found)
+	}
+}
+
+func TestLoad_Validation(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"unknown format", "format: pdf\n"},
+		{"unknown field", "fromat: json\n"},
+		{"bad exclude glob", "exclude:\n  - \"[\"\n"},
+		{"negative max files", "max_files: -1\n"},
+		{"bad group by", "group_by: ecosystem\n"},
+		{"empty denied license", "license_policy:\n  deny:\n    - \"\"\n"},
+		{"zero enrich concurrency", "enrich_concurrency: 0\n"},
+		{"negative enrich rate", "enrich_rate: -1\n"},
+		{"oversized OSV batch", "osv_batch_size: 1001\n"},
+		{"invalid profile", "profiles:\n  release:\n    format: pdf\n"},
+		{"unknown profile field", "profiles:\n  release:\n    fromat: json\n"},
+		{"nested profiles", "profiles:\n  release:\n    profiles:\n      audit: {}\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeConfig(t, t.TempDir(), tt.content)
+			if _, err := Load(path); err == nil {
+				t.Error("Expected validation error")
+			}
+		})
+	}
+}
+
+func TestLoad_Empty(t *testing.T) {
+	path := writeConfig(t, t.TempDir(), "")
+	if _, err := Load(path); err != nil {
+		t.Errorf("Expected empty config to load, got %v", err)
+	}
+}
+
+func TestExpandPath(t *testing.T) {
+	t.Setenv("SBOMGEN_TEST_ROOT", "/work/monorepo")
+
+	tests := []struct {
+		input, expected string
+	}{
+		{"$SBOMGEN_TEST_ROOT/services", "/work/monorepo/services"},
+		{"${SBOMGEN_TEST_ROOT}-sbom.json", "/work/monorepo-sbom.json"},
+		{"reports/$$HOME.json", "reports/$HOME.json"},
+		{"plain/path", "plain/path"},
+	}
+	for _, tt := range tests {
+		got, err := ExpandPath(tt.input)
+		if err != nil {
+			t.Errorf("ExpandPath(%q) failed: %v", tt.input, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("ExpandPath(%q): expected %q, got %q", tt.input, tt.expected, got)
+		}
+	}
+
+	if _, err := ExpandPath("${SBOMGEN_TEST_UNSET}/app"); err == nil {
+		t.Error("Expected an error for an unset variable")
+	}
+}
+
+func TestLoad_ExpandsOutput(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("SBOMGEN_TEST_REPORTS", filepath.Join(tmpDir, "reports"))
+	path := writeConfig(t, tmpDir, "output: ${SBOMGEN_TEST_REPORTS}/sbom.json\n")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	if cfg.Output != filepath.Join(tmpDir, "reports", "sbom.json") {
+		t.Errorf("Expected expanded output path, got '%s'", cfg.Output)
+	}
+}
+
+func TestConfig_WithProfile(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := writeConfig(t, tmpDir, `format: json
+output: sbom.json
+exclude:
+  - "internal-*"
+max_files: 1000
+enrich_concurrency: 8
+purl_types:
+  deb: alpine
+profiles:
+  release:
+    format: spdx
+    osv: true
+    output: dist/sbom.spdx
+    exclude:
+      - "test-*"
+    license_policy:
+      deny:
+        - AGPL-3.0-only
+    purl_types:
+      go: go
+  dev:
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	release, err := cfg.WithProfile("release")
+	if err != nil {
+		t.Fatalf("Failed to select profile: %v", err)
+	}
+	if release.Format != "spdx" || release.Output != filepath.Join(tmpDir, "dist", "sbom.spdx") {
+		t.Errorf("Expected profile format and output, got '%s' '%s'", release.Format, release.Output)
+	}
+	if release.MaxFiles == nil || *release.MaxFiles != 1000 {
+		t.Errorf("Expected top-level max_files to be kept, got %v", release.MaxFiles)
+	}
+	if release.OSV == nil || !*release.OSV || release.EnrichConcurrency == nil || *release.EnrichConcurrency != 8 {
+		t.Errorf("Expected profile osv over top-level enrich_concurrency, got %v %v", release.OSV, release.EnrichConcurrency)
+	}
+	if len(release.Exclude) != 2 || release.Exclude[0] != "internal-*" || release.Exclude[1] != "test-*" {
+		t.Errorf("Expected excludes to be combined, got %v", release.Exclude)
+	}
+	if len(release.LicensePolicy.Deny) != 1 || release.PURLTypes["deb"] != "alpine" || release.PURLTypes["go"] != "go" {
+		t.Errorf("Unexpected layered settings %+v", release)
+	}
+	if len(cfg.Exclude) != 1 || len(cfg.PURLTypes) != 1 {
+		t.Errorf("Expected the top-level settings to be left unchanged, got %+v", cfg)
+	}
+
+	dev, err := cfg.WithProfile("dev")
+	if err != nil {
+		t.Fatalf("Failed to select empty profile: %v", err)
+	}
+	if dev.Format != "json" || dev.Output != filepath.Join(tmpDir, "sbom.json") {
+		t.Errorf("Expected an empty profile to keep the top-level settings, got %+v", dev)
+	}
+
+	if _, err := cfg.WithProfile("audit"); err == nil || !strings.Contains(err.Error(), "available: dev, release") {
+		t.Errorf("Expected an error listing the profiles, got %v", err)
+	}
+}
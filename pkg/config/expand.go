@@ -0,0 +1,29 @@
+package config
+
+import (
+	"fmt"
+	"os"
+)
+
+// ExpandPath replaces ${VAR} and $VAR in path with the values of environment
+// variables, for paths that reach sbomgen unexpanded, such as quoted
+// arguments or configuration values. "$$" stands for a literal "$". A
+// variable that is not set is an error rather than an empty string, so a
+// typo cannot silently point at the wrong directory.
+func ExpandPath(path string) (string, error) {
+	var missing string
+	expanded := os.Expand(path, func(name string) string {
+		if name == "$" {
+			return "$"
+		}
+		value, ok := os.LookupEnv(name)
+		if !ok && missing == "" {
+			missing = name
+		}
+		return value
+	})
+	if missing != "" {
+		return "", fmt.Errorf("environment variable %s in %q is not set", missing, path)
+	}
+	return expanded, nil
+}
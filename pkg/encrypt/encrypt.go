@@ -0,0 +1,127 @@
+// Package encrypt protects SBOM files at rest with a passphrase, using
+// AES-256-GCM and a PBKDF2-HMAC-SHA256 derived key.
+package encrypt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+)
+
+// magic starts every encrypted file. The trailing digit is the format
+// version.
+var magic = []byte("SBOMENC1")
+
+const (
+	saltSize  = 16
+	nonceSize = 12
+	keySize   = 32
+	// headerSize covers the magic, iteration count, salt and nonce.
+	headerSize = 8 + 4 + saltSize + nonceSize
+)
+
+// Overhead is the number of bytes Encrypt adds to the plaintext: the header
+// and the GCM authentication tag.
+const Overhead = headerSize + 16
+
+// DefaultIterations is the PBKDF2 work factor used by Encrypt.
+const DefaultIterations = 600000
+
+// ErrDecrypt is returned when data cannot be decrypted, because the
+// passphrase is wrong or the data was modified.
+var ErrDecrypt = errors.New("wrong passphrase or corrupted data")
+
+// IsEncrypted reports whether data starts with the encrypted file header.
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, magic)
+}
+
+// Encrypt encrypts plaintext with a key derived from passphrase. The
+// output is a header holding the KDF salt, iteration count and nonce,
+// followed by the ciphertext. The header is authenticated along with it.
+func Encrypt(plaintext, passphrase []byte) ([]byte, error) {
+	return encrypt(plaintext, passphrase, DefaultIterations)
+}
+
+func encrypt(plaintext, passphrase []byte, iterations uint32) ([]byte, error) {
+	if len(passphrase) == 0 {
+		return nil, fmt.Errorf("passphrase must not be empty")
+	}
+	header := make([]byte, headerSize)
+	copy(header, magic)
+	binary.BigEndian.PutUint32(header[8:12], iterations)
+	if _, err := rand.Read(header[12:]); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	salt, nonce := header[12:12+saltSize], header[12+saltSize:]
+
+	gcm, err := newGCM(passphrase, salt, iterations)
+	if err != nil {
+		return nil, err
+	}
+	return gcm.Seal(header, nonce, plaintext, header), nil
+}
+
+// Decrypt reverses Encrypt, returning ErrDecrypt if passphrase is wrong or
+// data was tampered with.
+func Decrypt(data, passphrase []byte) ([]byte, error) {
+	if !IsEncrypted(data) || len(data) < headerSize {
+		return nil, fmt.Errorf("not an encrypted SBOM")
+	}
+	header := data[:headerSize]
+	iterations := binary.BigEndian.Uint32(header[8:12])
+	if iterations == 0 {
+		return nil, ErrDecrypt
+	}
+	salt, nonce := header[12:12+saltSize], header[12+saltSize:]
+
+	gcm, err := newGCM(passphrase, salt, iterations)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, nonce, data[headerSize:], header)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
+
+func newGCM(passphrase, salt []byte, iterations uint32) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(pbkdf2(sha256.New, passphrase, salt, int(iterations), keySize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// pbkdf2 derives a key of keyLen bytes as specified in RFC 8018.
+func pbkdf2(h func() hash.Hash, password, salt []byte, iterations, keyLen int) []byte {
+	prf := hmac.New(h, password)
+	key := make([]byte, 0, keyLen)
+	var counter [4]byte
+	for block := uint32(1); len(key) < keyLen; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		binary.BigEndian.PutUint32(counter[:], block)
+		prf.Write(counter[:])
+		u := prf.Sum(nil)
+		t := append([]byte(nil), u...)
+		for i := 1; i < iterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		key = append(key, t...)
+	}
+	return key[:keyLen]
+}
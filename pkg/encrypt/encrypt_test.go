@@ -0,0 +1,74 @@
+package encrypt
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+func TestEncryptDecrypt_RoundTrip(t *testing.T) {
+	plaintext := []byte(`{"name": "app", "components": [{"name": "express"}]}`)
+
+	encrypted, err := encrypt(plaintext, []byte("correct horse"), 1000)
+	if err != nil {
+		t.Fatalf("Failed to encrypt: %v", err)
+	}
+	if !IsEncrypted(encrypted) || bytes.Contains(encrypted, []byte("express")) {
+		t.Error("Expected encrypted output with a header and no plaintext")
+	}
+
+	decrypted, err := Decrypt(encrypted, []byte("correct horse"))
+	if err != nil {
+		t.Fatalf("Failed to decrypt: %v", err)
+	}
+	if !bytes.Equal(decrypted, plaintext) {
+		t.Errorf("Expected %q, got %q", plaintext, decrypted)
+	}
+
+	again, err := encrypt(plaintext, []byte("correct horse"), 1000)
+	if err != nil {
+		t.Fatalf("Failed to encrypt: %v", err)
+	}
+	if bytes.Equal(again, encrypted) {
+		t.Error("Expected a fresh salt and nonce for every encryption")
+	}
+}
+
+func TestDecrypt_WrongPassphrase(t *testing.T) {
+	encrypted, err := encrypt([]byte("inventory"), []byte("correct horse"), 1000)
+	if err != nil {
+		t.Fatalf("Failed to encrypt: %v", err)
+	}
+
+	if _, err := Decrypt(encrypted, []byte("battery staple")); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("Expected ErrDecrypt for a wrong passphrase, got %v", err)
+	}
+
+	tampered := append([]byte(nil), encrypted...)
+	tampered[12] ^= 1
+	if _, err := Decrypt(tampered, []byte("correct horse")); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("Expected ErrDecrypt for a modified header, got %v", err)
+	}
+
+	if _, err := Decrypt([]byte(`{"name": "app"}`), []byte("correct horse")); err == nil {
+		t.Error("Expected error for data that is not encrypted")
+	}
+}
+
+func TestEncrypt_EmptyPassphrase(t *testing.T) {
+	if _, err := Encrypt([]byte("inventory"), nil); err == nil {
+		t.Error("Expected error for an empty passphrase")
+	}
+}
+
+func TestPBKDF2(t *testing.T) {
+	// RFC 7914, section 11.
+	key := pbkdf2(sha256.New, []byte("passwd"), []byte("salt"), 1, 64)
+	want := "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc" +
+		"49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783"
+	if got := hex.EncodeToString(key); got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
@@ -0,0 +1,143 @@
+package enrich
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/hallucinaut/sbomgen/pkg/sbom"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed cpe_dictionary.yaml
+var defaultCPEDictionary []byte
+
+// cpePairPattern matches a "vendor:product" pair as written in the CPE
+// dictionary: lower-case words without spaces or CPE wildcards.
+var cpePairPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._~-]*:[a-z0-9][a-z0-9._~-]*$`)
+
+// CPEDictionary maps packages to the vendor and product names the NVD CPE
+// dictionary files them under, which often differ from the package name:
+// pkg:pypi/jinja2 is cpe:2.3:a:palletsprojects:jinja. It is an Enricher
+// that sets CPEs offline.
+type CPEDictionary struct {
+	// entries maps "<purl type>/<namespace/name>", lower-cased, to
+	// "vendor:product".
+	entries map[string]string
+}
+
+var (
+	builtinCPEOnce sync.Once
+	builtinCPE     *CPEDictionary
+)
+
+// DefaultCPEDictionary returns the small dictionary bundled with sbomgen,
+// covering common packages. It is parsed once and shared, so it must not
+// be modified.
+func DefaultCPEDictionary() *CPEDictionary {
+	builtinCPEOnce.Do(func() {
+		d, err := parseCPEDictionary(defaultCPEDictionary)
+		if err != nil {
+			panic(fmt.Sprintf("invalid bundled CPE dictionary: %v", err))
+		}
+		builtinCPE = d
+	})
+	return builtinCPE
+}
+
+// LoadCPEDictionary reads a YAML or JSON file mapping versionless PURLs to
+// "vendor:product" pairs, such as pkg:npm/lodash: lodash:lodash. Entries
+// are added to those of the bundled dictionary, replacing any for the
+// same package.
+func LoadCPEDictionary(path string) (*CPEDictionary, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CPE dictionary: %w", err)
+	}
+	d, err := parseCPEDictionary(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CPE dictionary %s: %w", path, err)
+	}
+	for key, pair := range DefaultCPEDictionary().entries {
+		if _, ok := d.entries[key]; !ok {
+			d.entries[key] = pair
+		}
+	}
+	return d, nil
+}
+
+func parseCPEDictionary(data []byte) (*CPEDictionary, error) {
+	var raw map[string]string
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	d := &CPEDictionary{entries: make(map[string]string, len(raw))}
+	for purl, pair := range raw {
+		key, ok := cpeKey(purl)
+		if !ok {
+			return nil, fmt.Errorf("%q is not a package URL", purl)
+		}
+		if !cpePairPattern.MatchString(pair) {
+			return nil, fmt.Errorf("CPE for %s must be \"vendor:product\", got %q", purl, pair)
+		}
+		d.entries[key] = pair
+	}
+	return d, nil
+}
+
+// cpeKey returns the dictionary key for purl, ignoring its version,
+// qualifiers and subpath.
+func cpeKey(purl string) (string, bool) {
+	purlType, path, ok := splitPURL(purl)
+	if !ok {
+		return "", false
+	}
+	return purlType + "/" + strings.ToLower(path), true
+}
+
+// Resolve returns the CPE 2.3 name of the package purl at version, or ""
+// if the dictionary has no entry for it. Versions that are empty or a
+// range rather than a release become the "*" wildcard.
+func (d *CPEDictionary) Resolve(purl, version string) string {
+	key, ok := cpeKey(purl)
+	if !ok {
+		return ""
+	}
+	pair, ok := d.entries[key]
+	if !ok {
+		return ""
+	}
+	return "cpe:2.3:a:" + pair + ":" + cpeVersion(version) + ":*:*:*:*:*:*:*"
+}
+
+// Enrich sets the component's CPE if it has none and the dictionary knows
+// the package.
+func (d *CPEDictionary) Enrich(ctx context.Context, comp *sbom.Component) error {
+	setIfEmpty(&comp.CPE, d.Resolve(comp.PURL, comp.Version))
+	return nil
+}
+
+// cpeVersion renders version for the version field of a CPE 2.3 formatted
+// string. The "v" of Go module versions is dropped, as NVD does, and
+// characters with a meaning in CPE names are escaped.
+func cpeVersion(version string) string {
+	version = strings.TrimPrefix(version, "v")
+	if version == "" || strings.ContainsAny(version, "^~<>=*| ,") {
+		return "*"
+	}
+	var sb strings.Builder
+	for _, r := range version {
+		switch {
+		case r == '-' || r == '.' || r == '_',
+			'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9':
+		default:
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
@@ -0,0 +1,67 @@
+package enrich
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hallucinaut/sbomgen/pkg/sbom"
+)
+
+func TestCPEDictionary_Resolve(t *testing.T) {
+	dict := DefaultCPEDictionary()
+
+	tests := []struct {
+		purl, version, expected string
+	}{
+		{"pkg:pypi/jinja2@3.1.2", "3.1.2", "cpe:2.3:a:palletsprojects:jinja:3.1.2:*:*:*:*:*:*:*"},
+		{"pkg:pypi/Jinja2@3.1.2", "3.1.2", "cpe:2.3:a:palletsprojects:jinja:3.1.2:*:*:*:*:*:*:*"},
+		{"pkg:maven/org.apache.logging.log4j/log4j-core@2.14.1", "2.14.1", "cpe:2.3:a:apache:log4j:2.14.1:*:*:*:*:*:*:*"},
+		{"pkg:golang/github.com/gin-gonic/gin@v1.9.0", "v1.9.0", "cpe:2.3:a:gin-gonic:gin:1.9.0:*:*:*:*:*:*:*"},
+		{"pkg:npm/express@^4.18.0", "^4.18.0", "cpe:2.3:a:expressjs:express:*:*:*:*:*:*:*:*"},
+		{"pkg:npm/lodash@4.17.21+build:1", "4.17.21+build:1", `cpe:2.3:a:lodash:lodash:4.17.21\+build\:1:*:*:*:*:*:*:*`},
+		{"pkg:npm/left-pad@1.3.0", "1.3.0", ""},
+		{"not-a-purl", "1.0.0", ""},
+	}
+	for _, tt := range tests {
+		if got := dict.Resolve(tt.purl, tt.version); got != tt.expected {
+			t.Errorf("Resolve(%s): expected '%s', got '%s'", tt.purl, tt.expected, got)
+		}
+	}
+}
+
+func TestLoadCPEDictionary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cpes.yaml")
+	if err := os.WriteFile(path, []byte("pkg:npm/left-pad: left-pad_project:left-pad\npkg:npm/lodash: example:lodash\n"), 0644); err != nil {
+		t.Fatalf("Failed to write dictionary: %v", err)
+	}
+
+	dict, err := LoadCPEDictionary(path)
+	if err != nil {
+		t.Fatalf("Failed to load dictionary: %v", err)
+	}
+	comp := sbom.Component{Name: "left-pad", Version: "1.3.0", PURL: "pkg:npm/left-pad@1.3.0"}
+	if err := dict.Enrich(context.Background(), &comp); err != nil {
+		t.Fatalf("Failed to enrich: %v", err)
+	}
+	if comp.CPE != "cpe:2.3:a:left-pad_project:left-pad:1.3.0:*:*:*:*:*:*:*" {
+		t.Errorf("Expected CPE from the file, got '%s'", comp.CPE)
+	}
+	if got := dict.Resolve("pkg:npm/lodash@4.17.21", "4.17.21"); got != "cpe:2.3:a:example:lodash:4.17.21:*:*:*:*:*:*:*" {
+		t.Errorf("Expected the file to override the bundled entry, got '%s'", got)
+	}
+	if got := dict.Resolve("pkg:pypi/django@4.2.0", "4.2.0"); got == "" {
+		t.Error("Expected bundled entries to remain available")
+	}
+	if DefaultCPEDictionary().Resolve("pkg:npm/lodash@4.17.21", "4.17.21") != "cpe:2.3:a:lodash:lodash:4.17.21:*:*:*:*:*:*:*" {
+		t.Error("Expected the bundled dictionary to be left unchanged")
+	}
+
+	if err := os.WriteFile(path, []byte("pkg:npm/left-pad: left pad\n"), 0644); err != nil {
+		t.Fatalf("Failed to write dictionary: %v", err)
+	}
+	if _, err := LoadCPEDictionary(path); err == nil {
+		t.Error("Expected error for an entry that is not vendor:product")
+	}
+}
@@ -0,0 +1,83 @@
+// Package enrich fills in component metadata from package registries.
+package enrich
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/hallucinaut/sbomgen/pkg/sbom"
+)
+
+// Enricher adds metadata to a single component in place.
+type Enricher interface {
+	Enrich(ctx context.Context, comp *sbom.Component) error
+}
+
+// Options controls how Enrich schedules enricher calls.
+type Options struct {
+	// Concurrency is the maximum number of calls in flight. Values below
+	// one mean one.
+	Concurrency int
+	// Rate is the maximum number of calls started per second. Zero or less
+	// disables rate limiting.
+	Rate float64
+	// Clock is used by the rate limiter; nil means the system clock.
+	Clock Clock
+}
+
+// Enrich runs e over components with bounded concurrency and rate. Failures
+// for individual components do not stop the run and are returned joined
+// together, while cancelling ctx stops scheduling new work and returns
+// ctx.Err() once in-flight calls have finished.
+func Enrich(ctx context.Context, components []sbom.Component, e Enricher, opts Options) error {
+	concurrency := opts.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	var limiter *Limiter
+	if opts.Rate > 0 {
+		limiter = NewLimiter(opts.Rate, concurrency, opts.Clock)
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	slots := make(chan struct{}, concurrency)
+
+	for i := range components {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		if limiter != nil {
+			if err := limiter.Wait(ctx); err != nil {
+				<-slots
+				break
+			}
+		}
+
+		wg.Add(1)
+		go func(comp *sbom.Component) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			if err := e.Enrich(ctx, comp); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("%s: %w", comp.Name, err))
+				mu.Unlock()
+			}
+		}(&components[i])
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,114 @@
+package enrich
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hallucinaut/sbomgen/pkg/sbom"
+)
+
+type countingEnricher struct {
+	inFlight, maxInFlight, calls int32
+	fail                         string
+	block                        chan struct{}
+}
+
+func (e *countingEnricher) Enrich(ctx context.Context, comp *sbom.Component) error {
+	n := atomic.AddInt32(&e.inFlight, 1)
+	defer atomic.AddInt32(&e.inFlight, -1)
+	atomic.AddInt32(&e.calls, 1)
+	for {
+		max := atomic.LoadInt32(&e.maxInFlight)
+		if n <= max || atomic.CompareAndSwapInt32(&e.maxInFlight, max, n) {
+			break
+		}
+	}
+
+	if e.block != nil {
+		select {
+		case <-e.block:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	} else {
+		time.Sleep(time.Millisecond)
+	}
+
+	if comp.Name == e.fail {
+		return errors.New("registry unavailable")
+	}
+	comp.License = "MIT"
+	return nil
+}
+
+func testComponents(n int) []sbom.Component {
+	components := make([]sbom.Component, n)
+	for i := range components {
+		components[i] = sbom.Component{Name: string(rune('a' + i)), Supplier: "npm"}
+	}
+	return components
+}
+
+func TestEnrich_Concurrency(t *testing.T) {
+	components := testComponents(20)
+	enricher := &countingEnricher{fail: "c"}
+
+	err := Enrich(context.Background(), components, enricher, Options{Concurrency: 3})
+	if err == nil {
+		t.Error("Expected the failing component to be reported")
+	}
+
+	if enricher.calls != 20 {
+		t.Errorf("Expected 20 calls despite a failure, got %d", enricher.calls)
+	}
+	if enricher.maxInFlight > 3 {
+		t.Errorf("Expected at most 3 concurrent calls, got %d", enricher.maxInFlight)
+	}
+	if components[0].License != "MIT" {
+		t.Error("Expected components to be enriched in place")
+	}
+}
+
+func TestEnrich_RateLimited(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	enricher := &countingEnricher{}
+
+	if err := Enrich(context.Background(), testComponents(12), enricher, Options{Concurrency: 2, Rate: 4, Clock: clock}); err != nil {
+		t.Fatalf("Enrich failed: %v", err)
+	}
+
+	// A burst of two is allowed immediately; the other ten need 2.5s at 4/s.
+	if elapsed := clock.Now().Sub(time.Unix(0, 0)); elapsed < 2500*time.Millisecond {
+		t.Errorf("Expected rate limiting to take at least 2.5s of clock time, got %v", elapsed)
+	}
+}
+
+func TestEnrich_Cancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	enricher := &countingEnricher{block: make(chan struct{})}
+
+	var wg sync.WaitGroup
+	var err error
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		err = Enrich(ctx, testComponents(10), enricher, Options{Concurrency: 2})
+	}()
+
+	for atomic.LoadInt32(&enricher.inFlight) < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	wg.Wait()
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if enricher.calls != 2 {
+		t.Errorf("Expected no new work after cancellation, got %d calls", enricher.calls)
+	}
+}
@@ -0,0 +1,72 @@
+package enrich
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sync"
+
+	"github.com/hallucinaut/sbomgen/pkg/sbom"
+)
+
+// LatestVersions looks up the newest release of npm and PyPI components.
+// It is an Enricher so that lookups share Enrich's concurrency and rate
+// limits, but it records versions instead of changing the components.
+type LatestVersions struct {
+	fetcher  Fetcher
+	mu       sync.Mutex
+	versions map[string]string
+}
+
+func NewLatestVersions(fetcher Fetcher) *LatestVersions {
+	return &LatestVersions{fetcher: fetcher, versions: make(map[string]string)}
+}
+
+func (l *LatestVersions) Enrich(ctx context.Context, comp *sbom.Component) error {
+	var endpoint string
+	switch comp.Supplier {
+	case "npm":
+		endpoint = "https://registry.npmjs.org/" + url.PathEscape(comp.Name) + "/latest"
+	case "pypi":
+		endpoint = "https://pypi.org/pypi/" + url.PathEscape(comp.Name) + "/json"
+	default:
+		return nil
+	}
+	data, err := l.fetcher.Fetch(ctx, endpoint)
+	if err != nil {
+		return err
+	}
+
+	var release struct {
+		Version string `json:"version"`
+		Info    struct {
+			Version string `json:"version"`
+		} `json:"info"`
+	}
+	if err := json.Unmarshal(data, &release); err != nil {
+		return fmt.Errorf("failed to parse %s registry response: %w", comp.Supplier, err)
+	}
+	version := release.Version
+	if version == "" {
+		version = release.Info.Version
+	}
+	if version != "" {
+		l.mu.Lock()
+		l.versions[comp.Name] = version
+		l.mu.Unlock()
+	}
+	return nil
+}
+
+// Versions returns the latest versions found so far, keyed by component
+// name.
+func (l *LatestVersions) Versions() map[string]string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	versions := make(map[string]string, len(l.versions))
+	for name, version := range l.versions {
+		versions[name] = version
+	}
+	return versions
+}
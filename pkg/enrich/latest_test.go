@@ -0,0 +1,34 @@
+package enrich
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/hallucinaut/sbomgen/pkg/sbom"
+)
+
+func TestLatestVersions(t *testing.T) {
+	fetcher := fakeFetcher{
+		"https://registry.npmjs.org/express/latest": `{"name": "express", "version": "4.19.2"}`,
+		"https://pypi.org/pypi/requests/json":       `{"info": {"name": "requests", "version": "2.32.3"}}`,
+	}
+	components := []sbom.Component{
+		{Name: "express", Version: "^4.18.0", Supplier: "npm"},
+		{Name: "requests", Version: "2.28.0", Supplier: "pypi"},
+		{Name: "serde", Version: "1.0", Supplier: "cargo"},
+	}
+
+	latest := NewLatestVersions(fetcher)
+	if err := Enrich(context.Background(), components, latest, Options{Concurrency: 2}); err != nil {
+		t.Fatalf("Failed to look up latest versions: %v", err)
+	}
+
+	expected := map[string]string{"express": "4.19.2", "requests": "2.32.3"}
+	if got := latest.Versions(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+	if components[0].Version != "^4.18.0" {
+		t.Errorf("Expected components to be left alone, got version '%s'", components[0].Version)
+	}
+}
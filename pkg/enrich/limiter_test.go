@@ -0,0 +1,67 @@
+package enrich
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock advances only when slept on, recording when each caller wakes.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Sleep(ctx context.Context, d time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	return ctx.Err()
+}
+
+func TestLimiter_NeverExceedsRate(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	limiter := NewLimiter(5, 1, clock)
+
+	var starts []time.Time
+	for i := 0; i < 20; i++ {
+		if err := limiter.Wait(context.Background()); err != nil {
+			t.Fatalf("Wait failed: %v", err)
+		}
+		starts = append(starts, clock.Now())
+	}
+
+	// With a burst of one, any window of one second may hold at most five
+	// starts when the rate is five per second.
+	for i := range starts {
+		inWindow := 0
+		for j := i; j < len(starts) && starts[j].Sub(starts[i]) < time.Second; j++ {
+			inWindow++
+		}
+		if inWindow > 5 {
+			t.Fatalf("Expected at most 5 requests per second, got %d starting at %v", inWindow, starts[i].Sub(time.Unix(0, 0)))
+		}
+	}
+
+	if elapsed := starts[len(starts)-1].Sub(starts[0]); elapsed < 3800*time.Millisecond {
+		t.Errorf("Expected 20 requests at 5/s to span at least 3.8s, got %v", elapsed)
+	}
+}
+
+func TestLimiter_Cancelled(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	limiter := NewLimiter(1, 1, clock)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := limiter.Wait(ctx); err == nil {
+		t.Error("Expected error from cancelled context")
+	}
+}
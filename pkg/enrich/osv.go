@@ -0,0 +1,163 @@
+package enrich
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hallucinaut/sbomgen/pkg/sbom"
+)
+
+// OSVBatchURL is the OSV.dev batch query endpoint.
+const OSVBatchURL = "https://api.osv.dev/v1/querybatch"
+
+// DefaultOSVBatchSize is the most queries OSV accepts in one batch request.
+const DefaultOSVBatchSize = 1000
+
+// Poster sends a JSON request body to a URL and returns the response body.
+// Like Fetcher, it is an interface so that lookups can be tested offline.
+type Poster interface {
+	Post(ctx context.Context, url string, body []byte) ([]byte, error)
+}
+
+// OSVOptions controls how QueryOSV batches its requests.
+type OSVOptions struct {
+	// BatchSize is the number of PURLs per request. Values below one mean
+	// DefaultOSVBatchSize.
+	BatchSize int
+	// Concurrency is the maximum number of batch requests in flight.
+	// Values below one mean one.
+	Concurrency int
+	// URL overrides OSVBatchURL.
+	URL string
+}
+
+type osvQuery struct {
+	Package struct {
+		PURL string `json:"purl"`
+	} `json:"package"`
+}
+
+type osvBatchResponse struct {
+	Results []struct {
+		Vulns []struct {
+			ID string `json:"id"`
+		} `json:"vulns"`
+	} `json:"results"`
+}
+
+// QueryOSV looks up known vulnerabilities for every component with a
+// versioned PURL. The PURLs are sent in chunks of opts.BatchSize, with up to
+// opts.Concurrency chunks in flight, and OSV's results are mapped back to
+// components by their position in the chunk. A failed chunk does not stop
+// the others: the vulnerabilities found are returned, sorted by PURL and ID,
+// together with the chunk errors joined.
+func QueryOSV(ctx context.Context, poster Poster, components []sbom.Component, opts OSVOptions) ([]sbom.Vulnerability, error) {
+	batchSize := opts.BatchSize
+	if batchSize < 1 {
+		batchSize = DefaultOSVBatchSize
+	}
+	concurrency := opts.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	endpoint := opts.URL
+	if endpoint == "" {
+		endpoint = OSVBatchURL
+	}
+
+	var purls []string
+	seen := make(map[string]bool)
+	for _, comp := range components {
+		// OSV cannot match a PURL without a version.
+		if !strings.Contains(comp.PURL, "@") || seen[comp.PURL] {
+			continue
+		}
+		seen[comp.PURL] = true
+		purls = append(purls, comp.PURL)
+	}
+
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		vulns []sbom.Vulnerability
+		errs  []error
+	)
+	slots := make(chan struct{}, concurrency)
+
+	for start := 0; start < len(purls); start += batchSize {
+		end := start + batchSize
+		if end > len(purls) {
+			end = len(purls)
+		}
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(start int, batch []string) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			found, err := queryOSVBatch(ctx, poster, endpoint, batch)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("OSV batch of components %d-%d: %w", start+1, start+len(batch), err))
+				return
+			}
+			vulns = append(vulns, found...)
+		}(start, purls[start:end])
+	}
+	wg.Wait()
+
+	sort.Slice(vulns, func(i, j int) bool {
+		if vulns[i].Affects != vulns[j].Affects {
+			return vulns[i].Affects < vulns[j].Affects
+		}
+		return vulns[i].ID < vulns[j].ID
+	})
+	if err := ctx.Err(); err != nil {
+		return vulns, err
+	}
+	return vulns, errors.Join(errs...)
+}
+
+func queryOSVBatch(ctx context.Context, poster Poster, endpoint string, purls []string) ([]sbom.Vulnerability, error) {
+	queries := make([]osvQuery, len(purls))
+	for i, purl := range purls {
+		queries[i].Package.PURL = purl
+	}
+	body, err := json.Marshal(map[string][]osvQuery{"queries": queries})
+	if err != nil {
+		return nil, err
+	}
+	data, err := poster.Post(ctx, endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp osvBatchResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse OSV response: %w", err)
+	}
+	// Results are positional, so a short or long list cannot be trusted.
+	if len(resp.Results) != len(purls) {
+		return nil, fmt.Errorf("OSV returned %d results for %d queries", len(resp.Results), len(purls))
+	}
+
+	var vulns []sbom.Vulnerability
+	for i, result := range resp.Results {
+		for _, v := range result.Vulns {
+			vulns = append(vulns, sbom.Vulnerability{ID: v.ID, Affects: purls[i]})
+		}
+	}
+	return vulns, nil
+}
@@ -0,0 +1,121 @@
+package enrich
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hallucinaut/sbomgen/pkg/sbom"
+)
+
+// fakeOSV answers batch queries from a PURL-keyed table of vulnerability
+// IDs, failing any batch that contains a PURL in fail.
+type fakeOSV struct {
+	vulns map[string][]string
+	fail  map[string]bool
+
+	mu      sync.Mutex
+	batches [][]string
+}
+
+func (f *fakeOSV) Post(ctx context.Context, url string, body []byte) ([]byte, error) {
+	var req struct {
+		Queries []osvQuery `json:"queries"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, err
+	}
+
+	var purls, results []string
+	for _, q := range req.Queries {
+		purl := q.Package.PURL
+		purls = append(purls, purl)
+		var ids []string
+		for _, id := range f.vulns[purl] {
+			ids = append(ids, fmt.Sprintf(`{"id": %q}`, id))
+		}
+		results = append(results, `{"vulns": [`+strings.Join(ids, ",")+`]}`)
+	}
+	f.mu.Lock()
+	f.batches = append(f.batches, purls)
+	f.mu.Unlock()
+
+	for _, purl := range purls {
+		if f.fail[purl] {
+			return nil, errors.New("503 Service Unavailable")
+		}
+	}
+	return []byte(`{"results": [` + strings.Join(results, ",") + `]}`), nil
+}
+
+func TestQueryOSV_Batches(t *testing.T) {
+	osv := &fakeOSV{
+		vulns: map[string][]string{
+			"pkg:npm/lodash@4.17.20":   {"GHSA-35jh-r3h4-6jhm", "GHSA-29mw-wpgm-hmr9"},
+			"pkg:pypi/urllib3@1.26.0":  {"PYSEC-2021-59"},
+			"pkg:cargo/openssl@0.10.0": {"RUSTSEC-2023-0044"},
+		},
+		fail: map[string]bool{"pkg:cargo/openssl@0.10.0": true},
+	}
+	components := []sbom.Component{
+		{Name: "lodash", PURL: "pkg:npm/lodash@4.17.20"},
+		{Name: "express", PURL: "pkg:npm/express@4.18.2"},
+		{Name: "urllib3", PURL: "pkg:pypi/urllib3@1.26.0"},
+		{Name: "lodash", PURL: "pkg:npm/lodash@4.17.20"},
+		{Name: "ms", PURL: "pkg:npm/ms@2.1.3"},
+		{Name: "openssl", PURL: "pkg:cargo/openssl@0.10.0"},
+		{Name: "unversioned", PURL: "pkg:npm/unversioned"},
+	}
+
+	vulns, err := QueryOSV(context.Background(), osv, components, OSVOptions{BatchSize: 2, Concurrency: 2})
+	if err == nil || !strings.Contains(err.Error(), "components 5-5") {
+		t.Errorf("Expected the failed batch to be reported, got %v", err)
+	}
+
+	if len(osv.batches) != 3 {
+		t.Fatalf("Expected 3 batches, got %d: %v", len(osv.batches), osv.batches)
+	}
+	for _, batch := range osv.batches {
+		if len(batch) > 2 {
+			t.Errorf("Expected at most 2 queries per batch, got %v", batch)
+		}
+	}
+
+	expected := []sbom.Vulnerability{
+		{ID: "GHSA-29mw-wpgm-hmr9", Affects: "pkg:npm/lodash@4.17.20"},
+		{ID: "GHSA-35jh-r3h4-6jhm", Affects: "pkg:npm/lodash@4.17.20"},
+		{ID: "PYSEC-2021-59", Affects: "pkg:pypi/urllib3@1.26.0"},
+	}
+	if len(vulns) != len(expected) {
+		t.Fatalf("Expected %d vulnerabilities, got %+v", len(expected), vulns)
+	}
+	for i := range expected {
+		if vulns[i] != expected[i] {
+			t.Errorf("Expected %+v, got %+v", expected[i], vulns[i])
+		}
+	}
+}
+
+type shortOSV struct{}
+
+func (shortOSV) Post(ctx context.Context, url string, body []byte) ([]byte, error) {
+	return []byte(`{"results": [{"vulns": [{"id": "GHSA-xxxx"}]}]}`), nil
+}
+
+func TestQueryOSV_MismatchedResults(t *testing.T) {
+	components := []sbom.Component{
+		{Name: "a", PURL: "pkg:npm/a@1.0.0"},
+		{Name: "b", PURL: "pkg:npm/b@1.0.0"},
+	}
+	vulns, err := QueryOSV(context.Background(), shortOSV{}, components, OSVOptions{})
+	if err == nil {
+		t.Error("Expected an error when OSV returns fewer results than queries")
+	}
+	if len(vulns) != 0 {
+		t.Errorf("Expected no vulnerabilities from an unmatched batch, got %+v", vulns)
+	}
+}
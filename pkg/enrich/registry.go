@@ -0,0 +1,265 @@
+package enrich
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hallucinaut/sbomgen/pkg/sbom"
+)
+
+// ErrNotFound is returned by a Fetcher when the registry has no such entry.
+var ErrNotFound = errors.New("not found")
+
+// Fetcher retrieves the body of a registry URL. It is an interface so that
+// enrichment can be tested offline.
+type Fetcher interface {
+	Fetch(ctx context.Context, url string) ([]byte, error)
+}
+
+// DefaultTimeout bounds a whole HTTP exchange, from connecting to reading
+// the response body, so that an unresponsive server cannot hang a run.
+const DefaultTimeout = time.Minute
+
+// NewHTTPClient returns the HTTP client sbomgen talks to remote services
+// with. Unlike http.DefaultClient, it gives up after DefaultTimeout.
+func NewHTTPClient() *http.Client {
+	return &http.Client{Timeout: DefaultTimeout}
+}
+
+// HTTPFetcher fetches URLs over HTTP. It also implements Poster.
+type HTTPFetcher struct {
+	Client    *http.Client
+	UserAgent string
+}
+
+func NewHTTPFetcher() *HTTPFetcher {
+	return &HTTPFetcher{Client: NewHTTPClient(), UserAgent: "sbomgen"}
+}
+
+func (f *HTTPFetcher) Fetch(ctx context.Context, rawURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", f.UserAgent)
+
+	resp, err := f.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", rawURL, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func (f *HTTPFetcher) Post(ctx context.Context, rawURL string, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rawURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", f.UserAgent)
+
+	resp, err := f.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("POST %s: %s", rawURL, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// RegistryEnricher looks components up in the npm and PyPI registries and
+// fills in license, description and links that the manifest did not carry.
+// Fields that are already set are left alone, and components from other
+// ecosystems are skipped. Components pinned to an exact version are also
+// marked if that version is deprecated (npm) or yanked (PyPI).
+type RegistryEnricher struct {
+	fetcher Fetcher
+}
+
+func NewRegistryEnricher(fetcher Fetcher) *RegistryEnricher {
+	return &RegistryEnricher{fetcher: fetcher}
+}
+
+func (e *RegistryEnricher) Enrich(ctx context.Context, comp *sbom.Component) error {
+	// First-party packages are not published, and their names must not
+	// leak to public registries.
+	if comp.Scope == sbom.ScopeInternal {
+		return nil
+	}
+	switch comp.Supplier {
+	case "npm":
+		return e.enrichNPM(ctx, comp)
+	case "pypi":
+		return e.enrichPyPI(ctx, comp)
+	default:
+		return nil
+	}
+}
+
+func (e *RegistryEnricher) enrichNPM(ctx context.Context, comp *sbom.Component) error {
+	exact := exactVersion(comp.Version)
+	version := exact
+	if version == "" {
+		version = "latest"
+	}
+	data, err := e.fetcher.Fetch(ctx, "https://registry.npmjs.org/"+url.PathEscape(comp.Name)+"/"+url.PathEscape(version))
+	if err != nil {
+		return err
+	}
+
+	var manifest struct {
+		License     json.RawMessage `json:"license"`
+		Licenses    json.RawMessage `json:"licenses"`
+		Description string          `json:"description"`
+		Homepage    string          `json:"homepage"`
+		Repository  json.RawMessage `json:"repository"`
+		Deprecated  string          `json:"deprecated"`
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return fmt.Errorf("failed to parse npm registry response: %w", err)
+	}
+
+	// The deprecation of the latest release says nothing about a range.
+	if exact != "" && manifest.Deprecated != "" {
+		markDeprecated(comp, manifest.Deprecated)
+	}
+
+	setRegistryLicense(comp, npmLicense(manifest.License, manifest.Licenses))
+	setIfEmpty(&comp.Metadata.Description, manifest.Description)
+	setIfEmpty(&comp.Metadata.HomepageURL, manifest.Homepage)
+	setIfEmpty(&comp.Metadata.SourceURL, stringOrField(manifest.Repository, "url"))
+	return nil
+}
+
+func (e *RegistryEnricher) enrichPyPI(ctx context.Context, comp *sbom.Component) error {
+	endpoint := "https://pypi.org/pypi/" + url.PathEscape(comp.Name)
+	exact := exactVersion(comp.Version)
+	if exact != "" {
+		endpoint += "/" + url.PathEscape(exact)
+	}
+	data, err := e.fetcher.Fetch(ctx, endpoint+"/json")
+	if err != nil {
+		return err
+	}
+
+	var project struct {
+		Info struct {
+			License      string            `json:"license"`
+			Summary      string            `json:"summary"`
+			HomePage     string            `json:"home_page"`
+			ProjectURLs  map[string]string `json:"project_urls"`
+			Yanked       bool              `json:"yanked"`
+			YankedReason string            `json:"yanked_reason"`
+		} `json:"info"`
+	}
+	if err := json.Unmarshal(data, &project); err != nil {
+		return fmt.Errorf("failed to parse PyPI response: %w", err)
+	}
+
+	if exact != "" && project.Info.Yanked {
+		reason := "yanked"
+		if project.Info.YankedReason != "" {
+			reason += ": " + project.Info.YankedReason
+		}
+		markDeprecated(comp, reason)
+	}
+
+	// PyPI's free-form license field sometimes holds the full license
+	// text; only short values are taken as identifiers.
+	if license := strings.TrimSpace(project.Info.License); license != "" && !strings.Contains(license, "\n") && len(license) <= 64 {
+		setRegistryLicense(comp, sbom.LicenseExpression(license))
+	}
+	setIfEmpty(&comp.Metadata.Description, project.Info.Summary)
+	setIfEmpty(&comp.Metadata.HomepageURL, project.Info.HomePage)
+	setIfEmpty(&comp.Metadata.HomepageURL, project.Info.ProjectURLs["Homepage"])
+	setIfEmpty(&comp.Metadata.SourceURL, project.Info.ProjectURLs["Source"])
+	return nil
+}
+
+// exactVersion strips range operators such as ^, ~, >= and == from a
+// declared version, returning an empty string when nothing concrete is left.
+func exactVersion(version string) string {
+	version = strings.TrimLeft(strings.TrimSpace(version), "^~=<>! v")
+	if version == "" || strings.ContainsAny(version, "*xX| ,") {
+		return ""
+	}
+	return version
+}
+
+// stringOrField decodes raw as a string, or as an object and returns its
+// field key, as npm allows for both license and repository.
+func stringOrField(raw json.RawMessage, key string) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	var obj map[string]interface{}
+	if json.Unmarshal(raw, &obj) == nil {
+		if v, ok := obj[key].(string); ok {
+			return v
+		}
+	}
+	return ""
+}
+
+// npmLicense returns the license of an npm registry manifest as an SPDX
+// expression. Besides the current string form of "license", it accepts
+// the deprecated {"type": ...} object and the "licenses" array of strings
+// or objects, whose entries are alternatives joined with OR.
+func npmLicense(raws ...json.RawMessage) string {
+	var licenses []string
+	for _, raw := range raws {
+		var entries []json.RawMessage
+		if err := json.Unmarshal(raw, &entries); err != nil {
+			entries = []json.RawMessage{raw}
+		}
+		for _, entry := range entries {
+			licenses = append(licenses, stringOrField(entry, "type"))
+		}
+	}
+	return sbom.LicenseExpression(licenses...)
+}
+
+func markDeprecated(comp *sbom.Component, reason string) {
+	comp.Metadata.Deprecated = true
+	comp.Metadata.DeprecationReason = reason
+}
+
+func setIfEmpty(field *string, value string) {
+	if *field == "" {
+		*field = value
+	}
+}
+
+// setRegistryLicense sets a license fetched from a registry on comp if it
+// has none, recording the registry as its source.
+func setRegistryLicense(comp *sbom.Component, license string) {
+	if comp.License == "" && license != "" {
+		comp.License = license
+		comp.Metadata.LicenseSource = sbom.LicenseSourceRegistry
+	}
+}
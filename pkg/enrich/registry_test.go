@@ -0,0 +1,125 @@
+package enrich
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hallucinaut/sbomgen/pkg/sbom"
+)
+
+type fakeFetcher map[string]string
+
+func (f fakeFetcher) Fetch(ctx context.Context, url string) ([]byte, error) {
+	body, ok := f[url]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return []byte(body), nil
+}
+
+func TestRegistryEnricher(t *testing.T) {
+	fetcher := fakeFetcher{
+		"https://registry.npmjs.org/express/4.18.0": `{
+			"license": "MIT",
+			"description": "Fast, unopinionated, minimalist web framework",
+			"homepage": "http://expressjs.com/",
+			"repository": {"type": "git", "url": "git+https://github.com/expressjs/express.git"}
+		}`,
+		"https://pypi.org/pypi/requests/2.28.0/json": `{
+			"info": {
+				"license": "Apache 2.0",
+				"summary": "Python HTTP for Humans.",
+				"project_urls": {"Homepage": "https://requests.readthedocs.io", "Source": "https://github.com/psf/requests"}
+			}
+		}`,
+	}
+	enricher := NewRegistryEnricher(fetcher)
+
+	express := sbom.Component{Name: "express", Version: "^4.18.0", Supplier: "npm"}
+	if err := enricher.Enrich(context.Background(), &express); err != nil {
+		t.Fatalf("Failed to enrich npm component: %v", err)
+	}
+	if express.License != "MIT" || express.Metadata.HomepageURL != "http://expressjs.com/" {
+		t.Errorf("Unexpected npm enrichment %+v", express)
+	}
+	if express.Metadata.LicenseSource != sbom.LicenseSourceRegistry {
+		t.Errorf("Expected registry license source, got '%s'", express.Metadata.LicenseSource)
+	}
+	if express.Metadata.SourceURL != "git+https://github.com/expressjs/express.git" {
+		t.Errorf("Expected repository URL, got '%s'", express.Metadata.SourceURL)
+	}
+
+	requests := sbom.Component{Name: "requests", Version: "2.28.0", Supplier: "pypi", License: "Apache-2.0"}
+	if err := enricher.Enrich(context.Background(), &requests); err != nil {
+		t.Fatalf("Failed to enrich PyPI component: %v", err)
+	}
+	if requests.License != "Apache-2.0" || requests.Metadata.LicenseSource != "" {
+		t.Errorf("Expected existing license to be kept, got '%s' from '%s'", requests.License, requests.Metadata.LicenseSource)
+	}
+	if requests.Metadata.Description != "Python HTTP for Humans." || requests.Metadata.SourceURL != "https://github.com/psf/requests" {
+		t.Errorf("Unexpected PyPI enrichment %+v", requests.Metadata)
+	}
+
+	cargo := sbom.Component{Name: "serde", Version: "1.0", Supplier: "cargo"}
+	if err := enricher.Enrich(context.Background(), &cargo); err != nil {
+		t.Errorf("Expected unsupported ecosystems to be skipped, got %v", err)
+	}
+
+	// Not in the fake registry, so a lookup would fail with ErrNotFound.
+	internal := sbom.Component{Name: "@acme/ui", Version: "1.0.0", Supplier: "npm", Scope: sbom.ScopeInternal}
+	if err := enricher.Enrich(context.Background(), &internal); err != nil {
+		t.Errorf("Expected internal components not to be looked up, got %v", err)
+	}
+}
+
+func TestRegistryEnricher_NPMLicenses(t *testing.T) {
+	fetcher := fakeFetcher{
+		"https://registry.npmjs.org/dual/1.0.0": `{
+			"licenses": [{"type": "MIT", "url": "https://opensource.org/licenses/MIT"}, "Apache-2.0"]
+		}`,
+	}
+	comp := sbom.Component{Name: "dual", Version: "1.0.0", Supplier: "npm"}
+	if err := NewRegistryEnricher(fetcher).Enrich(context.Background(), &comp); err != nil {
+		t.Fatalf("Failed to enrich npm component: %v", err)
+	}
+	if comp.License != "MIT OR Apache-2.0" {
+		t.Errorf("Expected the licenses array joined with OR, got '%s'", comp.License)
+	}
+}
+
+func TestRegistryEnricher_Deprecated(t *testing.T) {
+	fetcher := fakeFetcher{
+		"https://registry.npmjs.org/request/2.88.2": `{
+			"license": "Apache-2.0",
+			"deprecated": "request has been deprecated, see https://github.com/request/request/issues/3142"
+		}`,
+		"https://registry.npmjs.org/left-pad/latest": `{"deprecated": "use String.prototype.padStart()"}`,
+		"https://pypi.org/pypi/urllib3/2.0.0/json": `{
+			"info": {"license": "MIT", "yanked": true, "yanked_reason": "Broken on Python 3.7"}
+		}`,
+		"https://pypi.org/pypi/requests/2.28.0/json": `{"info": {"license": "Apache 2.0", "yanked": false}}`,
+	}
+	enricher := NewRegistryEnricher(fetcher)
+
+	tests := []struct {
+		comp       sbom.Component
+		deprecated bool
+		reason     string
+	}{
+		{sbom.Component{Name: "request", Version: "2.88.2", Supplier: "npm"}, true,
+			"request has been deprecated, see https://github.com/request/request/issues/3142"},
+		{sbom.Component{Name: "left-pad", Version: "*", Supplier: "npm"}, false, ""},
+		{sbom.Component{Name: "urllib3", Version: "2.0.0", Supplier: "pypi"}, true, "yanked: Broken on Python 3.7"},
+		{sbom.Component{Name: "requests", Version: "2.28.0", Supplier: "pypi"}, false, ""},
+	}
+	for _, tt := range tests {
+		comp := tt.comp
+		if err := enricher.Enrich(context.Background(), &comp); err != nil {
+			t.Fatalf("Failed to enrich %s: %v", comp.Name, err)
+		}
+		if comp.Metadata.Deprecated != tt.deprecated || comp.Metadata.DeprecationReason != tt.reason {
+			t.Errorf("%s: expected deprecated=%v reason '%s', got %v '%s'",
+				comp.Name, tt.deprecated, tt.reason, comp.Metadata.Deprecated, comp.Metadata.DeprecationReason)
+		}
+	}
+}
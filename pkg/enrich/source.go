@@ -0,0 +1,152 @@
+package enrich
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"github.com/hallucinaut/sbomgen/pkg/sbom"
+)
+
+// SourceResolver returns the source URL for a package, given the
+// namespace and name of its PURL joined by "/" and unescaped, such as
+// "@babel/core" or "github.com/gin-gonic/gin". It returns "" when it cannot
+// tell.
+type SourceResolver func(path string) string
+
+// SourceLinker fills in links from a component's PURL without any network
+// access, for PURL types whose locations follow from the package name:
+// Metadata.SourceURL for types that name a code repository, such as Go
+// modules on GitHub, and Metadata.HomepageURL with the registry page for
+// registry types such as npm. A registry page is not the package's source
+// code, so it is not recorded as SourceURL, which SBOM formats report as a
+// VCS location. It is an Enricher, but cheap enough to call directly.
+type SourceLinker struct {
+	resolvers map[string]SourceResolver
+	pages     map[string]SourceResolver
+}
+
+// NewSourceLinker returns a linker that knows the GitHub and Go PURL types
+// as sources, and the npm, PyPI, Cargo, pub and Maven registry pages.
+func NewSourceLinker() *SourceLinker {
+	return &SourceLinker{
+		resolvers: map[string]SourceResolver{
+			"github": prefixResolver("https://github.com/"),
+			"golang": goSource,
+		},
+		pages: map[string]SourceResolver{
+			"npm":   prefixResolver("https://www.npmjs.com/package/"),
+			"pypi":  func(path string) string { return "https://pypi.org/project/" + path + "/" },
+			"cargo": prefixResolver("https://crates.io/crates/"),
+			"pub":   prefixResolver("https://pub.dev/packages/"),
+			"maven": prefixResolver("https://central.sonatype.com/artifact/"),
+		},
+	}
+}
+
+// Register sets the source resolver for a PURL type, replacing any
+// built-in one.
+func (l *SourceLinker) Register(purlType string, resolver SourceResolver) {
+	l.resolvers[purlType] = resolver
+}
+
+// RegisterPage sets the registry page resolver for a PURL type, replacing
+// any built-in one.
+func (l *SourceLinker) RegisterPage(purlType string, resolver SourceResolver) {
+	l.pages[purlType] = resolver
+}
+
+// Resolve returns the source URL for purl, or "" if there is no resolver
+// for its type or the resolver cannot tell.
+func (l *SourceLinker) Resolve(purl string) string {
+	return resolvePURL(l.resolvers, purl)
+}
+
+// Page returns the registry page for purl, or "" if there is no resolver
+// for its type or the resolver cannot tell.
+func (l *SourceLinker) Page(purl string) string {
+	return resolvePURL(l.pages, purl)
+}
+
+// Link sets the component's source URL and homepage if they are empty,
+// and reports whether it set either.
+func (l *SourceLinker) Link(comp *sbom.Component) bool {
+	linked := false
+	if comp.Metadata.SourceURL == "" {
+		comp.Metadata.SourceURL = l.Resolve(comp.PURL)
+		linked = comp.Metadata.SourceURL != ""
+	}
+	if comp.Metadata.HomepageURL == "" {
+		comp.Metadata.HomepageURL = l.Page(comp.PURL)
+		linked = linked || comp.Metadata.HomepageURL != ""
+	}
+	return linked
+}
+
+// Enrich links the component as Link does.
+func (l *SourceLinker) Enrich(ctx context.Context, comp *sbom.Component) error {
+	l.Link(comp)
+	return nil
+}
+
+func resolvePURL(resolvers map[string]SourceResolver, purl string) string {
+	purlType, path, ok := splitPURL(purl)
+	if !ok {
+		return ""
+	}
+	resolver, ok := resolvers[purlType]
+	if !ok {
+		return ""
+	}
+	return resolver(path)
+}
+
+// splitPURL returns the type and the unescaped namespace/name of a package
+// URL, dropping its version, qualifiers and subpath.
+func splitPURL(purl string) (purlType, path string, ok bool) {
+	rest, ok := strings.CutPrefix(purl, "pkg:")
+	if !ok {
+		return "", "", false
+	}
+	if i := strings.IndexAny(rest, "?#"); i >= 0 {
+		rest = rest[:i]
+	}
+	purlType, rest, ok = strings.Cut(rest, "/")
+	if !ok || rest == "" {
+		return "", "", false
+	}
+	// The version follows the last "@"; an "@" earlier in the path, as in
+	// unescaped npm scopes, is part of the name.
+	if i := strings.LastIndex(rest, "@"); i > 0 {
+		rest = rest[:i]
+	}
+	if path, err := url.PathUnescape(rest); err == nil {
+		rest = path
+	}
+	return strings.ToLower(purlType), rest, true
+}
+
+func prefixResolver(prefix string) SourceResolver {
+	return func(path string) string { return prefix + path }
+}
+
+// goHosts are the code hosts whose Go module paths start with an
+// owner/repository pair.
+var goHosts = map[string]bool{
+	"github.com":    true,
+	"gitlab.com":    true,
+	"bitbucket.org": true,
+}
+
+// goSource maps a Go module path onto its repository, for modules hosted on
+// well-known code hosts and the golang.org/x repositories.
+func goSource(path string) string {
+	parts := strings.Split(path, "/")
+	switch {
+	case goHosts[parts[0]] && len(parts) >= 3:
+		return "https://" + strings.Join(parts[:3], "/")
+	case parts[0] == "golang.org" && len(parts) >= 3 && parts[1] == "x":
+		return "https://go.googlesource.com/" + parts[2]
+	}
+	return ""
+}
@@ -0,0 +1,76 @@
+package enrich
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hallucinaut/sbomgen/pkg/sbom"
+)
+
+func TestSourceLinker_Resolve(t *testing.T) {
+	linker := NewSourceLinker()
+
+	tests := []struct {
+		purl, expected string
+	}{
+		{"pkg:npm/lodash@4.17.21", ""},
+		{"pkg:golang/github.com/gin-gonic/gin@v1.9.0", "https://github.com/gin-gonic/gin"},
+		{"pkg:golang/github.com/go-redis/redis/v8@v8.11.0", "https://github.com/go-redis/redis"},
+		{"pkg:golang/golang.org/x/sys@v0.15.0", "https://go.googlesource.com/sys"},
+		{"pkg:golang/gopkg.in/yaml.v3@v3.0.1", ""},
+		{"pkg:github/cli/cli@v2.40.0", "https://github.com/cli/cli"},
+		{"pkg:helm/postgresql@12.12.10?repository_url=https://charts.bitnami.com/bitnami", ""},
+		{"not-a-purl", ""},
+	}
+	for _, tt := range tests {
+		if got := linker.Resolve(tt.purl); got != tt.expected {
+			t.Errorf("Resolve(%s): expected '%s', got '%s'", tt.purl, tt.expected, got)
+		}
+	}
+}
+
+func TestSourceLinker_Page(t *testing.T) {
+	linker := NewSourceLinker()
+
+	tests := []struct {
+		purl, expected string
+	}{
+		{"pkg:npm/lodash@4.17.21", "https://www.npmjs.com/package/lodash"},
+		{"pkg:npm/%40babel/core@7.23.0", "https://www.npmjs.com/package/@babel/core"},
+		{"pkg:npm/@types/node", "https://www.npmjs.com/package/@types/node"},
+		{"pkg:pypi/requests@2.28.0", "https://pypi.org/project/requests/"},
+		{"pkg:golang/github.com/gin-gonic/gin@v1.9.0", ""},
+	}
+	for _, tt := range tests {
+		if got := linker.Page(tt.purl); got != tt.expected {
+			t.Errorf("Page(%s): expected '%s', got '%s'", tt.purl, tt.expected, got)
+		}
+	}
+
+	comp := sbom.Component{Name: "lodash", PURL: "pkg:npm/lodash@4.17.21"}
+	if !linker.Link(&comp) {
+		t.Error("Expected lodash to be linked")
+	}
+	if comp.Metadata.SourceURL != "" || comp.Metadata.HomepageURL != "https://www.npmjs.com/package/lodash" {
+		t.Errorf("Expected the npm page as homepage and no source URL, got %+v", comp.Metadata)
+	}
+}
+
+func TestSourceLinker_Register(t *testing.T) {
+	linker := NewSourceLinker()
+	linker.Register("helm", func(path string) string { return "https://artifacthub.io/packages/search?ts_query_web=" + path })
+
+	comp := sbom.Component{Name: "postgresql", PURL: "pkg:helm/postgresql@12.12.10"}
+	if err := linker.Enrich(context.Background(), &comp); err != nil {
+		t.Fatalf("Failed to link source: %v", err)
+	}
+	if comp.Metadata.SourceURL != "https://artifacthub.io/packages/search?ts_query_web=postgresql" {
+		t.Errorf("Expected the registered resolver to be used, got '%s'", comp.Metadata.SourceURL)
+	}
+
+	kept := sbom.Component{Name: "lodash", PURL: "pkg:npm/lodash@4.17.21", Metadata: sbom.Metadata{SourceURL: "https://github.com/lodash/lodash"}}
+	linker.Enrich(context.Background(), &kept)
+	if kept.Metadata.SourceURL != "https://github.com/lodash/lodash" {
+		t.Errorf("Expected an existing source URL to be kept, got '%s'", kept.Metadata.SourceURL)
+	}
+}
@@ -0,0 +1,78 @@
+package formatter
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/hallucinaut/sbomgen/pkg/sbom"
+)
+
+// csvColumns are the columns every CSV document has.
+var csvColumns = []string{"name", "version", "supplier", "license", "purl", "cpe"}
+
+// CSVFormatter formats the components of an SBOM as CSV, one row per
+// component, for import into spreadsheets. Fields are quoted as RFC 4180
+// requires, so license expressions and descriptions containing commas or
+// quotes stay in one cell, and empty fields are kept as empty cells.
+type CSVFormatter struct{}
+
+func NewCSVFormatter() *CSVFormatter {
+	return &CSVFormatter{}
+}
+
+func (f *CSVFormatter) Name() string {
+	return "csv"
+}
+
+func (f *CSVFormatter) Format(sbom *sbom.SBOM) (string, error) {
+	var sb strings.Builder
+	if err := f.FormatTo(&sb, sbom); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// FormatTo writes the rows to w as they are produced, so that SBOMs with
+// many components are never held in memory as text. Scope and direct
+// columns follow the standard ones when any component has a scope or is
+// marked direct.
+func (f *CSVFormatter) FormatTo(w io.Writer, doc *sbom.SBOM) error {
+	var scope, direct bool
+	for _, comp := range doc.Components {
+		scope = scope || comp.Scope != ""
+		direct = direct || comp.Direct
+	}
+
+	header := append([]string(nil), csvColumns...)
+	if scope {
+		header = append(header, "scope")
+	}
+	if direct {
+		header = append(header, "direct")
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	for _, comp := range doc.Components {
+		row := []string{comp.Name, comp.Version, comp.Supplier, comp.License, comp.PURL, comp.CPE}
+		if scope {
+			row = append(row, string(comp.Scope))
+		}
+		if direct {
+			row = append(row, strconv.FormatBool(comp.Direct))
+		}
+		if err := cw.Write(row); err != nil {
+			return fmt.Errorf("failed to write CSV: %w", err)
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package formatter
+
+import (
+	"encoding/csv"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hallucinaut/sbomgen/pkg/sbom"
+)
+
+func TestCSVFormatter_LicenseWithComma(t *testing.T) {
+	doc := sbom.New("app", "1.0.0", "")
+	doc.AddComponent(sbom.Component{Name: "dual", Version: "1.0.0", Supplier: "npm", License: "MIT, Apache-2.0", PURL: "pkg:npm/dual@1.0.0"})
+	doc.AddComponent(sbom.Component{Name: "quoted", Version: "2.0.0", License: `"BSD" style`})
+
+	var sb strings.Builder
+	if err := NewCSVFormatter().FormatTo(&sb, doc); err != nil {
+		t.Fatalf("Failed to format CSV: %v", err)
+	}
+	records, err := csv.NewReader(strings.NewReader(sb.String())).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to read CSV back: %v", err)
+	}
+	if len(records) != doc.Count()+1 {
+		t.Fatalf("Expected a header and %d rows, got %d", doc.Count(), len(records))
+	}
+	if !reflect.DeepEqual(records[0], csvColumns) {
+		t.Errorf("Expected header %v without optional columns, got %v", csvColumns, records[0])
+	}
+	if want := []string{"dual", "1.0.0", "npm", "MIT, Apache-2.0", "pkg:npm/dual@1.0.0", ""}; !reflect.DeepEqual(records[1], want) {
+		t.Errorf("Expected %v, got %v", want, records[1])
+	}
+	if records[2][3] != `"BSD" style` {
+		t.Errorf("Expected the quoted license to round-trip, got %q", records[2][3])
+	}
+
+	output, err := NewCSVFormatter().Format(doc)
+	if err != nil || output != sb.String() {
+		t.Errorf("Expected Format to match FormatTo, got %q, %v", output, err)
+	}
+}
+
+func TestCSVFormatter_NameWithComma(t *testing.T) {
+	doc := sbom.New("app", "1.0.0", "")
+	doc.AddComponent(sbom.Component{Name: `parser, "fast" edition`, Version: "0.3.1", Supplier: "cargo"})
+	doc.AddComponent(sbom.Component{Name: "bare"})
+
+	output, err := NewCSVFormatter().Format(doc)
+	if err != nil {
+		t.Fatalf("Failed to format CSV: %v", err)
+	}
+	records, err := csv.NewReader(strings.NewReader(output)).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to read CSV back: %v", err)
+	}
+	if len(records) != doc.Count()+1 {
+		t.Fatalf("Expected a header and %d rows, got %d", doc.Count(), len(records))
+	}
+	if records[1][0] != `parser, "fast" edition` {
+		t.Errorf("Expected the name to round-trip, got %q", records[1][0])
+	}
+	if want := []string{"bare", "", "", "", "", ""}; !reflect.DeepEqual(records[2], want) {
+		t.Errorf("Expected empty fields as empty cells %q, got %q", want, records[2])
+	}
+}
+
+func TestCSVFormatter_ScopeAndDirect(t *testing.T) {
+	doc := sbom.New("app", "1.0.0", "")
+	doc.AddComponent(sbom.Component{Name: "express", Version: "4.18.0", Direct: true})
+	doc.AddComponent(sbom.Component{Name: "jest", Version: "29.0.0", Scope: sbom.ScopeDev})
+
+	output, err := NewCSVFormatter().Format(doc)
+	if err != nil {
+		t.Fatalf("Failed to format CSV: %v", err)
+	}
+	records, err := csv.NewReader(strings.NewReader(output)).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to read CSV back: %v", err)
+	}
+	if header := records[0]; len(header) != 8 || header[6] != "scope" || header[7] != "direct" {
+		t.Errorf("Expected scope and direct columns, got %v", header)
+	}
+	if records[1][6] != "" || records[1][7] != "true" || records[2][6] != "dev" || records[2][7] != "false" {
+		t.Errorf("Unexpected optional columns %v, %v", records[1][6:], records[2][6:])
+	}
+}
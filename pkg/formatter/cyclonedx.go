@@ -0,0 +1,535 @@
+package formatter
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hallucinaut/sbomgen/pkg/sbom"
+	"github.com/hallucinaut/sbomgen/pkg/validator"
+)
+
+// cdxExternalReference is a CycloneDX component externalReference.
+type cdxExternalReference struct {
+	URL     string `json:"url"`
+	Type    string `json:"type"`
+	Comment string `json:"comment,omitempty"`
+}
+
+// cycloneDXExternalReferences returns the external references of comp.
+// CycloneDX has no provenance reference type, so build provenance, such as
+// a SLSA attestation, is filed as "attestation" in 1.5 and as "build-meta"
+// in 1.4, which lacks that type.
+func cycloneDXExternalReferences(comp sbom.Component, specVersion string) []cdxExternalReference {
+	var refs []cdxExternalReference
+	if comp.Metadata.HomepageURL != "" {
+		refs = append(refs, cdxExternalReference{URL: comp.Metadata.HomepageURL, Type: "website"})
+	}
+	if comp.Metadata.SourceURL != "" {
+		refs = append(refs, cdxExternalReference{URL: comp.Metadata.SourceURL, Type: "vcs"})
+	}
+	if comp.Metadata.ProvenanceURL != "" {
+		refType := "attestation"
+		if specVersion == "1.4" {
+			refType = "build-meta"
+		}
+		refs = append(refs, cdxExternalReference{URL: comp.Metadata.ProvenanceURL, Type: refType, Comment: "provenance"})
+	}
+	return refs
+}
+
+// cdxEvidence is the CycloneDX 1.5 component evidence.
+type cdxEvidence struct {
+	Occurrences []cdxOccurrence `json:"occurrences,omitempty"`
+}
+
+// cdxOccurrence is a location where a component was found. CycloneDX 1.5
+// has no field for the line within the file.
+type cdxOccurrence struct {
+	Location string `json:"location"`
+}
+
+// evidencedComponent is a component together with the places it was
+// found.
+type evidencedComponent struct {
+	Component sbom.Component
+	Evidence  *cdxEvidence
+}
+
+// cycloneDXOccurrences merges components that were found in several
+// manifests, identified by PURL or, without one, by name and version, into
+// one entry listing every manifest as an occurrence. Entries keep the order
+// in which components first appear. Occurrences need CycloneDX 1.5, so for
+// earlier versions components are merged but carry no evidence.
+func cycloneDXOccurrences(components []sbom.Component, specVersion string) []evidencedComponent {
+	var merged []evidencedComponent
+	index := make(map[string]int)
+	seen := make(map[string]bool)
+	for _, comp := range components {
+		key := comp.PURL
+		if key == "" {
+			key = comp.Name + "@" + comp.Version
+		}
+		i, ok := index[key]
+		if !ok {
+			i = len(merged)
+			index[key] = i
+			merged = append(merged, evidencedComponent{Component: comp})
+		}
+
+		location := comp.Metadata.SourceFile
+		if specVersion == "1.4" || location == "" || seen[key+"\x00"+location] {
+			continue
+		}
+		seen[key+"\x00"+location] = true
+		if merged[i].Evidence == nil {
+			merged[i].Evidence = &cdxEvidence{}
+		}
+		merged[i].Evidence.Occurrences = append(merged[i].Evidence.Occurrences, cdxOccurrence{Location: location})
+	}
+	return merged
+}
+
+// cdxBOM is a CycloneDX JSON document.
+type cdxBOM struct {
+	Schema          string             `json:"$schema"`
+	BOMFormat       string             `json:"bomFormat"`
+	SpecVersion     string             `json:"specVersion"`
+	SerialNumber    string             `json:"serialNumber"`
+	Version         int                `json:"version"`
+	Metadata        cdxMetadata        `json:"metadata"`
+	Components      []cdxComponent     `json:"components"`
+	Dependencies    []cdxDependency    `json:"dependencies,omitempty"`
+	Vulnerabilities []cdxVulnerability `json:"vulnerabilities,omitempty"`
+	Annotations     []cdxAnnotation    `json:"annotations,omitempty"`
+}
+
+// cdxComponent is a CycloneDX component.
+type cdxComponent struct {
+	Type               string                 `json:"type"`
+	BOMRef             string                 `json:"bom-ref,omitempty"`
+	Supplier           *cdxOrganization       `json:"supplier,omitempty"`
+	Author             string                 `json:"author,omitempty"`
+	Publisher          string                 `json:"publisher,omitempty"`
+	Name               string                 `json:"name"`
+	Version            string                 `json:"version,omitempty"`
+	Description        string                 `json:"description,omitempty"`
+	Scope              string                 `json:"scope,omitempty"`
+	Hashes             []cdxHash              `json:"hashes,omitempty"`
+	Licenses           []cdxLicenseChoice     `json:"licenses,omitempty"`
+	CPE                string                 `json:"cpe,omitempty"`
+	PURL               string                 `json:"purl,omitempty"`
+	ExternalReferences []cdxExternalReference `json:"externalReferences,omitempty"`
+	Properties         []cdxProperty          `json:"properties,omitempty"`
+	Evidence           *cdxEvidence           `json:"evidence,omitempty"`
+}
+
+// cdxOrganization is a CycloneDX organizational entity.
+type cdxOrganization struct {
+	Name string `json:"name"`
+}
+
+// cdxHash is a CycloneDX hash, with the digest in hex.
+type cdxHash struct {
+	Alg     string `json:"alg"`
+	Content string `json:"content"`
+}
+
+// cdxLicenseChoice is an entry of a CycloneDX licenses array: either a
+// single license or an SPDX expression.
+type cdxLicenseChoice struct {
+	License    *cdxLicense `json:"license,omitempty"`
+	Expression string      `json:"expression,omitempty"`
+}
+
+// cdxLicense is a license named by SPDX identifier or, for licenses SPDX
+// does not list, by name.
+type cdxLicense struct {
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
+// cdxProperty is a CycloneDX name-value property.
+type cdxProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// cdxMetadata is the CycloneDX BOM metadata.
+type cdxMetadata struct {
+	Timestamp string        `json:"timestamp,omitempty"`
+	Tools     any           `json:"tools,omitempty"`
+	Component *cdxComponent `json:"component,omitempty"`
+}
+
+// cdxTool is the legacy tool entry of CycloneDX 1.4, which 1.5 replaces
+// with a component.
+type cdxTool struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+// cdxTools is the tools object of CycloneDX 1.5.
+type cdxTools struct {
+	Components []cdxComponent `json:"components"`
+}
+
+// cdxDependency lists the components a component directly depends on.
+type cdxDependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn,omitempty"`
+}
+
+// cdxVulnerability is a known vulnerability of a component.
+type cdxVulnerability struct {
+	ID       string       `json:"id"`
+	Analysis *cdxAnalysis `json:"analysis,omitempty"`
+	Affects  []cdxAffect  `json:"affects"`
+}
+
+// cdxAnalysis is the triage state of a vulnerability.
+type cdxAnalysis struct {
+	State         string `json:"state,omitempty"`
+	Justification string `json:"justification,omitempty"`
+	Detail        string `json:"detail,omitempty"`
+}
+
+// cdxAffect references a component affected by a vulnerability.
+type cdxAffect struct {
+	Ref string `json:"ref"`
+}
+
+// cdxAnnotation is a CycloneDX 1.5 annotation.
+type cdxAnnotation struct {
+	Subjects  []string     `json:"subjects"`
+	Annotator cdxAnnotator `json:"annotator"`
+	Timestamp string       `json:"timestamp"`
+	Text      string       `json:"text"`
+}
+
+// cdxAnnotator names what created an annotation; sbomgen records itself.
+type cdxAnnotator struct {
+	Component *cdxComponent `json:"component"`
+}
+
+// cdxSerialPattern matches the serial number form CycloneDX requires.
+var cdxSerialPattern = regexp.MustCompile(`^urn:uuid:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+// cycloneDXSerialNumber returns doc's serial number in the urn:uuid form
+// CycloneDX requires. Serials already in that form, or bare UUIDs, are kept;
+// other serials are turned into a name-based UUID, so the same serial always
+// maps to the same one. Without a serial the document's content decides.
+func cycloneDXSerialNumber(doc *sbom.SBOM) string {
+	serial := strings.ToLower(doc.SerialNumber)
+	switch {
+	case serial == "":
+		return doc.ContentSerialNumber()
+	case cdxSerialPattern.MatchString(serial):
+		return serial
+	case cdxSerialPattern.MatchString("urn:uuid:" + serial):
+		return "urn:uuid:" + serial
+	}
+	return sbom.NameSerialNumber(doc.SerialNumber)
+}
+
+// toolComponent describes sbomgen itself, with the version that generated
+// doc when it is recorded.
+func toolComponent(doc *sbom.SBOM) cdxComponent {
+	return cdxComponent{Type: "application", Name: "sbomgen", Version: doc.ToolVersion}
+}
+
+// cycloneDXTools returns the metadata tools entry for doc in the form
+// specVersion expects.
+func cycloneDXTools(doc *sbom.SBOM, specVersion string) any {
+	tool := toolComponent(doc)
+	if specVersion == "1.4" {
+		return []cdxTool{{Name: tool.Name, Version: tool.Version}}
+	}
+	return cdxTools{Components: []cdxComponent{tool}}
+}
+
+// cycloneDXScope maps a component scope onto the CycloneDX one: optional
+// dependencies are "optional", dev, build and test dependencies, which
+// never ship, are "excluded", and the rest are "required". Components
+// without a scope are left to the CycloneDX default, which is required.
+func cycloneDXScope(scope sbom.Scope) string {
+	if scope == "" {
+		return ""
+	}
+	switch scope.Effective() {
+	case sbom.ScopeOptional:
+		return "optional"
+	case sbom.ScopeDev, sbom.ScopeBuild, sbom.ScopeTest:
+		return "excluded"
+	default:
+		return "required"
+	}
+}
+
+// cycloneDXLicenses converts an SPDX license expression into a CycloneDX
+// licenses array. A single SPDX identifier becomes a license id and any
+// other single license a license name; compound expressions are kept as
+// an expression.
+func cycloneDXLicenses(license string) []cdxLicenseChoice {
+	license = strings.TrimSpace(license)
+	if license == "" || license == "NOASSERTION" {
+		return nil
+	}
+	if strings.ContainsAny(license, " ()") {
+		return []cdxLicenseChoice{{Expression: license}}
+	}
+	if id, ok := validator.SPDXLicenseID(license); ok {
+		return []cdxLicenseChoice{{License: &cdxLicense{ID: id}}}
+	}
+	return []cdxLicenseChoice{{License: &cdxLicense{Name: license}}}
+}
+
+// cycloneDXHashes converts hashes into CycloneDX ones. CycloneDX wants hex
+// digests, so base64 digests such as npm integrity values are decoded, and
+// hashes with an algorithm or digest CycloneDX cannot represent are left
+// out.
+func cycloneDXHashes(hashes []sbom.Hash) []cdxHash {
+	var converted []cdxHash
+	for _, h := range hashes {
+		alg := sbom.CanonicalHashAlgorithm(h.Algorithm)
+		content := hexDigest(h.Value)
+		if alg == "" || content == "" {
+			continue
+		}
+		converted = append(converted, cdxHash{Alg: alg, Content: content})
+	}
+	return converted
+}
+
+// hexDigest returns value as a lower-case hex digest of a length CycloneDX
+// accepts, decoding base64 if needed, or "" if it is neither.
+func hexDigest(value string) string {
+	valid := func(n int) bool { return n == 16 || n == 20 || n == 32 || n == 48 || n == 64 }
+	if raw, err := hex.DecodeString(value); err == nil && valid(len(raw)) {
+		return strings.ToLower(value)
+	}
+	if raw, err := base64.StdEncoding.DecodeString(value); err == nil && valid(len(raw)) {
+		return hex.EncodeToString(raw)
+	}
+	return ""
+}
+
+// cycloneDXProperties returns the sbomgen-specific details of comp that
+// CycloneDX has no field for.
+func cycloneDXProperties(comp sbom.Component) []cdxProperty {
+	var props []cdxProperty
+	add := func(name, value string) {
+		if value != "" {
+			props = append(props, cdxProperty{Name: "sbomgen:" + name, Value: value})
+		}
+	}
+	for _, alias := range comp.ExternalRefs {
+		if alias != comp.PURL {
+			add("purl-alias", alias)
+		}
+	}
+	add("go-module", comp.Metadata.Module)
+	add("checksum", comp.Metadata.Checksum)
+	add("revision", comp.Metadata.Revision)
+	add("license-source", string(comp.Metadata.LicenseSource))
+	add("license-file", comp.Metadata.LicenseFile)
+	if comp.Metadata.Deprecated {
+		add("deprecated", "true")
+		add("deprecation-reason", comp.Metadata.DeprecationReason)
+	}
+	return props
+}
+
+// cycloneDXComponent converts comp into a CycloneDX component of type
+// componentType identified by ref.
+func cycloneDXComponent(comp sbom.Component, componentType, ref, specVersion string) cdxComponent {
+	c := cdxComponent{
+		Type:               componentType,
+		BOMRef:             ref,
+		Author:             comp.Metadata.Author,
+		Publisher:          comp.Metadata.Publisher,
+		Name:               comp.Name,
+		Version:            comp.Version,
+		Description:        comp.Metadata.Description,
+		Scope:              cycloneDXScope(comp.Scope),
+		Hashes:             cycloneDXHashes(comp.Hashes),
+		Licenses:           cycloneDXLicenses(comp.License),
+		CPE:                comp.CPE,
+		PURL:               comp.PURL,
+		ExternalReferences: cycloneDXExternalReferences(comp, specVersion),
+		Properties:         cycloneDXProperties(comp),
+	}
+	if comp.Supplier != "" {
+		c.Supplier = &cdxOrganization{Name: comp.Supplier}
+	}
+	return c
+}
+
+// cycloneDXMetadataComponent describes the scanned project as the subject
+// of the BOM, which consumers use to associate the BOM with a product. It
+// is derived from doc.Root only, so it is nil when there is no root or it
+// was dropped with --no-root-component.
+func cycloneDXMetadataComponent(doc *sbom.SBOM, specVersion string) *cdxComponent {
+	if doc.Root == nil {
+		return nil
+	}
+	ref := doc.Root.PURL
+	if ref == "" {
+		ref = doc.Root.Name
+	}
+	root := cycloneDXComponent(*doc.Root, "application", ref, specVersion)
+	root.Scope = ""
+	return &root
+}
+
+// cycloneDXBOM converts doc into a CycloneDX document of specVersion.
+// Components are identified by PURL, or by name where they have none, and
+// relationships, annotations and vulnerabilities are resolved against
+// those references; entries referring to components that are not in the
+// document are dropped.
+func cycloneDXBOM(doc *sbom.SBOM, specVersion string) cdxBOM {
+	bom := cdxBOM{
+		Schema:       "http://cyclonedx.org/schema/bom-" + specVersion + ".schema.json",
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  specVersion,
+		SerialNumber: cycloneDXSerialNumber(doc),
+		Version:      1,
+		Metadata: cdxMetadata{
+			Timestamp: doc.Created.UTC().Format("2006-01-02T15:04:05Z"),
+			Tools:     cycloneDXTools(doc, specVersion),
+			Component: cycloneDXMetadataComponent(doc, specVersion),
+		},
+		Components: []cdxComponent{},
+	}
+
+	// refs maps the names and PURLs relationships use onto bom-refs, which
+	// must be unique.
+	refs := make(map[string]string)
+	used := make(map[string]bool)
+	var order []string
+	register := func(comp sbom.Component, ref string) {
+		used[ref] = true
+		order = append(order, ref)
+		for _, key := range []string{comp.PURL, comp.Name} {
+			if _, ok := refs[key]; key != "" && !ok {
+				refs[key] = ref
+			}
+		}
+	}
+	rootRef := ""
+	if root := bom.Metadata.Component; root != nil {
+		rootRef = root.BOMRef
+		register(*doc.Root, rootRef)
+	}
+
+	entries := cycloneDXOccurrences(doc.Components, specVersion)
+	for _, entry := range entries {
+		comp := entry.Component
+		ref := comp.PURL
+		if ref == "" || used[ref] {
+			ref = comp.Name
+		}
+		if used[ref] {
+			ref = comp.Name + "@" + comp.Version
+		}
+		for n := 2; used[ref]; n++ {
+			ref = comp.Name + "@" + comp.Version + "#" + strconv.Itoa(n)
+		}
+		register(comp, ref)
+
+		c := cycloneDXComponent(comp, "library", ref, specVersion)
+		c.Evidence = entry.Evidence
+		bom.Components = append(bom.Components, c)
+	}
+
+	bom.Dependencies = cycloneDXDependencies(doc, entries, refs, order)
+	for _, vuln := range doc.Vulnerabilities {
+		ref, ok := refs[vuln.Affects]
+		if !ok {
+			continue
+		}
+		v := cdxVulnerability{ID: vuln.ID, Affects: []cdxAffect{{Ref: ref}}}
+		if vuln.Analysis.State != "" {
+			v.Analysis = &cdxAnalysis{
+				State:         vuln.Analysis.State,
+				Justification: vuln.Analysis.Justification,
+				Detail:        vuln.Analysis.Detail,
+			}
+		}
+		bom.Vulnerabilities = append(bom.Vulnerabilities, v)
+	}
+
+	if specVersion != "1.4" {
+		tool := toolComponent(doc)
+		for _, annotation := range doc.Annotations {
+			subjects := []string{}
+			if annotation.ComponentRef != "" {
+				ref, ok := refs[annotation.ComponentRef]
+				if !ok {
+					continue
+				}
+				subjects = append(subjects, ref)
+			} else if rootRef != "" {
+				subjects = append(subjects, rootRef)
+			}
+			bom.Annotations = append(bom.Annotations, cdxAnnotation{
+				Subjects:  subjects,
+				Annotator: cdxAnnotator{Component: &tool},
+				Timestamp: annotation.Time.UTC().Format("2006-01-02T15:04:05Z"),
+				Text:      annotation.Summary,
+			})
+		}
+	}
+	return bom
+}
+
+// cycloneDXDependencies builds the dependency graph from doc's
+// relationships and the dependencies recorded on its components. Once
+// there is any edge, every component is listed, those without
+// dependencies as empty elements, as CycloneDX asks.
+func cycloneDXDependencies(doc *sbom.SBOM, entries []evidencedComponent, refs map[string]string, order []string) []cdxDependency {
+	edges := make(map[string][]string)
+	seen := make(map[[2]string]bool)
+	add := func(from, to string) {
+		a, okA := refs[from]
+		b, okB := refs[to]
+		if !okA || !okB || a == b || seen[[2]string{a, b}] {
+			return
+		}
+		seen[[2]string{a, b}] = true
+		edges[a] = append(edges[a], b)
+	}
+
+	for _, rel := range doc.Relationships {
+		if rel.Relationship.CycloneDX() != "dependsOn" {
+			continue
+		}
+		if rel.Relationship == sbom.DependsOn {
+			add(rel.RefA, rel.RefB)
+		} else {
+			// The *_dependency_of types point from the dependency to
+			// the component that needs it.
+			add(rel.RefB, rel.RefA)
+		}
+	}
+	for _, entry := range entries {
+		from := entry.Component.PURL
+		if from == "" {
+			from = entry.Component.Name
+		}
+		for _, dep := range entry.Component.Dependencies {
+			add(from, dep)
+		}
+	}
+	if len(edges) == 0 {
+		return nil
+	}
+
+	deps := make([]cdxDependency, len(order))
+	for i, ref := range order {
+		deps[i] = cdxDependency{Ref: ref, DependsOn: edges[ref]}
+	}
+	return deps
+}
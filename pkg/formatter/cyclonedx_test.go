@@ -0,0 +1,106 @@
+package formatter
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/hallucinaut/sbomgen/pkg/sbom"
+)
+
+func TestCycloneDXExternalReferences_Provenance(t *testing.T) {
+	comp := sbom.Component{
+		Name: "express",
+		PURL: "pkg:npm/express@4.18.2",
+		Metadata: sbom.Metadata{
+			SourceURL:     "https://github.com/expressjs/express",
+			ProvenanceURL: "https://example.com/express.intoto.jsonl",
+		},
+	}
+
+	want := []cdxExternalReference{
+		{URL: "https://github.com/expressjs/express", Type: "vcs"},
+		{URL: "https://example.com/express.intoto.jsonl", Type: "attestation", Comment: "provenance"},
+	}
+	if got := cycloneDXExternalReferences(comp, "1.5"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+
+	if got := cycloneDXExternalReferences(comp, "1.4"); len(got) != 2 || got[1].Type != "build-meta" {
+		t.Errorf("Expected a build-meta reference for CycloneDX 1.4, got %+v", got)
+	}
+	if got := cycloneDXExternalReferences(sbom.Component{Name: "bare"}, "1.5"); got != nil {
+		t.Errorf("Expected no references, got %+v", got)
+	}
+}
+
+func TestCycloneDXProperties_Checksum(t *testing.T) {
+	comp := sbom.Component{
+		Name:     "errors",
+		PURL:     "pkg:golang/github.com/pkg/errors@v0.9.1",
+		Metadata: sbom.Metadata{Checksum: "h1:FEBLx1zS214owpjy7qsBeixbURkuhQAwrK5UwLGTwt4="},
+	}
+	want := []cdxProperty{{Name: "sbomgen:checksum", Value: "h1:FEBLx1zS214owpjy7qsBeixbURkuhQAwrK5UwLGTwt4="}}
+	if got := cycloneDXProperties(comp); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+}
+
+func TestCycloneDXOccurrences(t *testing.T) {
+	components := []sbom.Component{
+		{Name: "lodash", Version: "4.17.21", PURL: "pkg:npm/lodash@4.17.21", Metadata: sbom.Metadata{SourceFile: "package.json"}},
+		{Name: "express", Version: "4.18.2", PURL: "pkg:npm/express@4.18.2", Metadata: sbom.Metadata{SourceFile: "package.json"}},
+		{Name: "lodash", Version: "4.17.21", PURL: "pkg:npm/lodash@4.17.21", Metadata: sbom.Metadata{SourceFile: "packages/web/package.json"}},
+		{Name: "lodash", Version: "4.17.21", PURL: "pkg:npm/lodash@4.17.21", Metadata: sbom.Metadata{SourceFile: "package.json"}},
+		{Name: "vendored", Version: "1.0.0"},
+	}
+
+	merged := cycloneDXOccurrences(components, "1.5")
+	if len(merged) != 3 {
+		t.Fatalf("Expected 3 merged components, got %d", len(merged))
+	}
+	want := []cdxOccurrence{{Location: "package.json"}, {Location: "packages/web/package.json"}}
+	if merged[0].Component.Name != "lodash" || merged[0].Evidence == nil || !reflect.DeepEqual(merged[0].Evidence.Occurrences, want) {
+		t.Errorf("Expected lodash with occurrences %+v, got %+v", want, merged[0])
+	}
+	if merged[1].Evidence == nil || len(merged[1].Evidence.Occurrences) != 1 {
+		t.Errorf("Expected express with one occurrence, got %+v", merged[1].Evidence)
+	}
+	if merged[2].Evidence != nil {
+		t.Errorf("Expected no evidence without a source file, got %+v", merged[2].Evidence)
+	}
+
+	for _, entry := range cycloneDXOccurrences(components, "1.4") {
+		if entry.Evidence != nil {
+			t.Errorf("Expected no occurrences for CycloneDX 1.4, got %+v", entry.Evidence)
+		}
+	}
+}
+
+func TestCycloneDXMetadataComponent(t *testing.T) {
+	doc := sbom.New("my-app", "1.2.0", "serial-001")
+	doc.AddComponent(sbom.Component{Name: "lodash", Version: "4.17.21", PURL: "pkg:npm/lodash@4.17.21"})
+	if got := cycloneDXMetadataComponent(doc, "1.5"); got != nil {
+		t.Errorf("Expected no metadata component without a root, got %+v", got)
+	}
+
+	doc.SetRoot(sbom.Component{Name: "my-app", Version: "1.2.0", PURL: "pkg:npm/my-app@1.2.0"})
+	data, err := json.Marshal(cdxMetadata{Component: cycloneDXMetadataComponent(doc, "1.5")})
+	if err != nil {
+		t.Fatalf("Failed to marshal metadata: %v", err)
+	}
+	var metadata struct {
+		Component struct {
+			Type, Name, Version, PURL string
+		}
+	}
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		t.Fatalf("Failed to parse metadata: %v", err)
+	}
+	if metadata.Component.Name != "my-app" {
+		t.Errorf("Expected metadata.component.name my-app, got %q", metadata.Component.Name)
+	}
+	if metadata.Component.Type != "application" || metadata.Component.PURL != "pkg:npm/my-app@1.2.0" {
+		t.Errorf("Expected an application with the root PURL, got %+v", metadata.Component)
+	}
+}
@@ -4,6 +4,7 @@ package formatter
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"strings"
 
 	"github.com/hallucinaut/sbomgen/pkg/sbom"
@@ -15,11 +16,13 @@ type Format string
 
 const (
 	SPDX      Format = "spdx"
+	SPDXJSON  Format = "spdx-json"
 	CycloneDX Format = "cyclonedx"
 	JSON      Format = "json"
 	YAML      Format = "yaml"
 	Markdown  Format = "markdown"
 	Table     Format = "table"
+	CSV       Format = "csv"
 )
 
 // Formatter interface for serializing SBOMs.
@@ -28,19 +31,43 @@ type Formatter interface {
 	Format(sbom *sbom.SBOM) (string, error)
 }
 
+// StreamFormatter is implemented by formatters that can write their output
+// to w as they produce it instead of returning it as one string.
+type StreamFormatter interface {
+	Formatter
+	FormatTo(w io.Writer, sbom *sbom.SBOM) error
+}
+
 // JSONFormatter formats SBOM as JSON.
-type JSONFormatter struct{}
+type JSONFormatter struct {
+	compact bool
+}
 
 func NewJSONFormatter() *JSONFormatter {
 	return &JSONFormatter{}
 }
 
+// NewCompactJSONFormatter returns a JSON formatter that emits minified
+// output for machine consumption.
+func NewCompactJSONFormatter() *JSONFormatter {
+	return &JSONFormatter{compact: true}
+}
+
 func (f *JSONFormatter) Name() string {
 	return "json"
 }
 
 func (f *JSONFormatter) Format(sbom *sbom.SBOM) (string, error) {
-	data, err := json.MarshalIndent(sbom, "", "  ")
+	sbom, err := withNormalizedRelationships(sbom)
+	if err != nil {
+		return "", err
+	}
+	var data []byte
+	if f.compact {
+		data, err = json.Marshal(sbom)
+	} else {
+		data, err = json.MarshalIndent(sbom, "", "  ")
+	}
 	if err != nil {
 		return "", fmt.Errorf("failed to serialize to JSON: %w", err)
 	}
@@ -59,6 +86,10 @@ func (f *YAMLFormatter) Name() string {
 }
 
 func (f *YAMLFormatter) Format(sbom *sbom.SBOM) (string, error) {
+	sbom, err := withNormalizedRelationships(sbom)
+	if err != nil {
+		return "", err
+	}
 	data, err := yaml.Marshal(sbom)
 	if err != nil {
 		return "", fmt.Errorf("failed to serialize to YAML: %w", err)
@@ -67,12 +98,20 @@ func (f *YAMLFormatter) Format(sbom *sbom.SBOM) (string, error) {
 }
 
 // MarkdownFormatter formats SBOM as Markdown.
-type MarkdownFormatter struct{}
+type MarkdownFormatter struct {
+	groupBy GroupBy
+}
 
 func NewMarkdownFormatter() *MarkdownFormatter {
 	return &MarkdownFormatter{}
 }
 
+// NewGroupedMarkdownFormatter returns a Markdown formatter that emits one
+// component table per supplier or license instead of a single flat table.
+func NewGroupedMarkdownFormatter(groupBy GroupBy) *MarkdownFormatter {
+	return &MarkdownFormatter{groupBy: groupBy}
+}
+
 func (f *MarkdownFormatter) Name() string {
 	return "markdown"
 }
@@ -82,16 +121,30 @@ func (f *MarkdownFormatter) Format(sbom *sbom.SBOM) (string, error) {
 
 	sb.WriteString(fmt.Sprintf("# Software Bill of Materials\n\n"))
 	sb.WriteString(fmt.Sprintf("**Project:** %s v%s\n\n", sbom.Name, sbom.Version))
+	if sbom.Root != nil && sbom.Root.License != "" {
+		sb.WriteString(fmt.Sprintf("**License:** %s\n\n", sbom.Root.License))
+	}
 	sb.WriteString(fmt.Sprintf("**Created:** %s\n", sbom.Created.Format("2006-01-02 15:04:05 UTC")))
 	sb.WriteString(fmt.Sprintf("**Total Components:** %d\n\n", sbom.Count()))
+	if summary := licenseSourceSummary(sbom.Components); summary != "" {
+		sb.WriteString(fmt.Sprintf("**License Sources:** %s\n\n", summary))
+	}
 
 	sb.WriteString("## Components\n\n")
-	sb.WriteString("| # | Name | Version | Supplier | License |\n")
-	sb.WriteString("|---|------|---------|----------|---------|\n")
-
-	for i, comp := range sbom.Components {
-		sb.WriteString(fmt.Sprintf("| %d | %s | %s | %s | %s |\n",
-			i+1, comp.Name, comp.Version, comp.Supplier, comp.License))
+	if f.groupBy == "" {
+		writeMarkdownComponents(&sb, sbom.Components)
+	} else {
+		groups, err := groupComponentsBy(f.groupBy, sbom.Components)
+		if err != nil {
+			return "", err
+		}
+		for i, group := range groups {
+			if i > 0 {
+				sb.WriteString("\n")
+			}
+			sb.WriteString(fmt.Sprintf("### %s (%d)\n\n", group.Key, len(group.Components)))
+			writeMarkdownComponents(&sb, group.Components)
+		}
 	}
 
 	sb.WriteString("\n## Relationships\n\n")
@@ -101,14 +154,59 @@ func (f *MarkdownFormatter) Format(sbom *sbom.SBOM) (string, error) {
 		sb.WriteString("| Component A | Component B | Relationship |\n")
 		sb.WriteString("|-------------|-------------|--------------|\n")
 		for _, rel := range sbom.Relationships {
+			relType, err := normalizeRelationship(rel)
+			if err != nil {
+				return "", err
+			}
 			sb.WriteString(fmt.Sprintf("| %s | %s | %s |\n",
-				rel.RefA, rel.RefB, rel.Relationship))
+				rel.RefA, rel.RefB, relType))
 		}
 	}
 
 	return sb.String(), nil
 }
 
+func writeMarkdownComponents(sb *strings.Builder, components []sbom.Component) {
+	sb.WriteString("| # | Name | Version | Supplier | License |\n")
+	sb.WriteString("|---|------|---------|----------|---------|\n")
+
+	for i, comp := range components {
+		sb.WriteString(fmt.Sprintf("| %d | %s | %s | %s | %s |\n",
+			i+1, comp.Name, comp.Version, comp.Supplier, markdownLicense(comp)))
+	}
+}
+
+// markdownLicense returns the license of comp, followed by its source when
+// it was not declared by the component itself, so reviewers can tell which
+// licenses to double-check.
+func markdownLicense(comp sbom.Component) string {
+	source := comp.Metadata.LicenseSource
+	if comp.License == "" || source == "" || source == sbom.LicenseSourceDeclared {
+		return comp.License
+	}
+	return fmt.Sprintf("%s (%s)", comp.License, source)
+}
+
+// licenseSourceSummary counts the components of each license source, as in
+// "3 declared, 1 inferred", or returns "" when no source is recorded.
+func licenseSourceSummary(components []sbom.Component) string {
+	counts := make(map[sbom.LicenseSource]int)
+	for _, comp := range components {
+		if comp.License != "" && comp.Metadata.LicenseSource != "" {
+			counts[comp.Metadata.LicenseSource]++
+		}
+	}
+	var parts []string
+	for _, source := range []sbom.LicenseSource{
+		sbom.LicenseSourceDeclared, sbom.LicenseSourceInferred, sbom.LicenseSourceOverride, sbom.LicenseSourceRegistry,
+	} {
+		if counts[source] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[source], source))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
 // TableFormatter formats SBOM as ASCII table.
 type TableFormatter struct{}
 
@@ -142,10 +240,19 @@ func (f *TableFormatter) Format(sbom *sbom.SBOM) (string, error) {
 }
 
 // SPDXFormatter formats SBOM as SPDX.
-type SPDXFormatter struct{}
+type SPDXFormatter struct {
+	specVersion string
+	// DocumentNamespaceBase is the URL document namespaces are built
+	// under, DefaultDocumentNamespaceBase if empty.
+	DocumentNamespaceBase string
+	// OmitRelationships leaves out the dependency relationships,
+	// including those derived from component scopes. The DESCRIBES
+	// relationships SPDX requires are always written.
+	OmitRelationships bool
+}
 
 func NewSPDXFormatter() *SPDXFormatter {
-	return &SPDXFormatter{}
+	return &SPDXFormatter{specVersion: specVersions[SPDX][0]}
 }
 
 func (f *SPDXFormatter) Name() string {
@@ -153,40 +260,69 @@ func (f *SPDXFormatter) Name() string {
 }
 
 func (f *SPDXFormatter) Format(sbom *sbom.SBOM) (string, error) {
+	sbom, err := withNormalizedRelationships(sbom)
+	if err != nil {
+		return "", err
+	}
 	var sb strings.Builder
 
-	sb.WriteString("SPDXVersion: SPDX-2.2\n")
+	sb.WriteString(fmt.Sprintf("SPDXVersion: SPDX-%s\n", f.specVersion))
 	sb.WriteString("DataLicense: CC0-1.0\n")
 	sb.WriteString(fmt.Sprintf("SPDXID: SPDXRef-DOCUMENT\n"))
 	sb.WriteString(fmt.Sprintf("DocumentName: %s\n", sbom.Name))
-	sb.WriteString(fmt.Sprintf("DocumentNamespace: https://sbom.example.org/%s/%s\n", sbom.Name, sbom.Version))
-	sb.WriteString(fmt.Sprintf("Creator: Tool: sbomgen-%s\n", sbom.Version))
+	sb.WriteString(fmt.Sprintf("DocumentNamespace: %s\n", spdxDocumentNamespace(f.DocumentNamespaceBase, sbom)))
+	sb.WriteString(fmt.Sprintf("Creator: %s\n", spdxCreator(sbom)))
 	sb.WriteString(fmt.Sprintf("Created: %sZ\n", sbom.Created.UTC().Format("2006-01-02T15:04:05Z")))
 
 	sb.WriteString("\n## Packages\n\n")
+	if sbom.Root != nil {
+		f.writePackage(&sb, *sbom.Root, spdxRootID, "APPLICATION")
+	}
 	for i, comp := range sbom.Components {
-		sb.WriteString(fmt.Sprintf("PackageName: %s\n", comp.Name))
-		sb.WriteString(fmt.Sprintf("SPDXID: SPDXRef-Package-%d\n", i))
-		sb.WriteString(fmt.Sprintf("PackageVersion: %s\n", comp.Version))
-		sb.WriteString(fmt.Sprintf("PackageSupplier: PackageSupplier: %s\n", comp.Supplier))
-		if comp.License != "" {
-			sb.WriteString(fmt.Sprintf("PackageLicenseConcluded: %s\n", comp.License))
-		}
-		if comp.PURL != "" {
-			sb.WriteString(fmt.Sprintf("PackageDownloadLocation: %s\n", comp.PURL))
+		f.writePackage(&sb, comp, spdxPackageID(i), "LIBRARY")
+	}
+
+	if rels := spdxRelationships(sbom, f.OmitRelationships); len(rels) > 0 {
+		sb.WriteString("## Relationships\n\n")
+		for _, rel := range rels {
+			sb.WriteString(fmt.Sprintf("Relationship: %s %s %s\n", rel.Element, rel.Type, rel.Related))
 		}
-		sb.WriteString("FilesAnalyzed: false\n")
-		sb.WriteString("\n")
 	}
 
 	return sb.String(), nil
 }
 
+func (f *SPDXFormatter) writePackage(sb *strings.Builder, comp sbom.Component, id, purpose string) {
+	sb.WriteString(fmt.Sprintf("PackageName: %s\n", comp.Name))
+	sb.WriteString(fmt.Sprintf("SPDXID: %s\n", id))
+	sb.WriteString(fmt.Sprintf("PackageVersion: %s\n", comp.Version))
+	sb.WriteString(fmt.Sprintf("PackageSupplier: %s\n", spdxSupplier(comp.Supplier)))
+	if comp.License != "" {
+		sb.WriteString(fmt.Sprintf("PackageLicenseConcluded: %s\n", comp.License))
+	}
+	sb.WriteString(fmt.Sprintf("PackageDownloadLocation: %s\n", spdxDownloadLocation(comp)))
+	sb.WriteString("FilesAnalyzed: false\n")
+	for _, ref := range append([]string{comp.PURL}, comp.ExternalRefs...) {
+		if ref != "" {
+			sb.WriteString(fmt.Sprintf("ExternalRef: PACKAGE-MANAGER purl %s\n", ref))
+		}
+	}
+	if comp.CPE != "" {
+		sb.WriteString(fmt.Sprintf("ExternalRef: SECURITY cpe23Type %s\n", comp.CPE))
+	}
+	if f.specVersion != "2.2" {
+		sb.WriteString(fmt.Sprintf("PrimaryPackagePurpose: %s\n", purpose))
+	}
+	sb.WriteString("\n")
+}
+
 // CycloneDXFormatter formats SBOM as CycloneDX.
-type CycloneDXFormatter struct{}
+type CycloneDXFormatter struct {
+	specVersion string
+}
 
 func NewCycloneDXFormatter() *CycloneDXFormatter {
-	return &CycloneDXFormatter{}
+	return &CycloneDXFormatter{specVersion: specVersions[CycloneDX][0]}
 }
 
 func (f *CycloneDXFormatter) Name() string {
@@ -197,30 +333,142 @@ func (f *CycloneDXFormatter) Format(sbom *sbom.SBOM) (string, error) {
 	return f.FormatJSON(sbom)
 }
 
-// FormatJSON formats SBOM as CycloneDX JSON.
-// NOTE: This function is currently disabled due to forward reference issues with struct types.
+// FormatJSON formats SBOM as a CycloneDX JSON document of the formatter's
+// specification version.
 func (f *CycloneDXFormatter) FormatJSON(sbom *sbom.SBOM) (string, error) {
-	return "", fmt.Errorf("CycloneDX JSON formatting not yet implemented")
+	sbom, err := withNormalizedRelationships(sbom)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.MarshalIndent(cycloneDXBOM(sbom, f.specVersion), "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize to CycloneDX: %w", err)
+	}
+	return string(data), nil
 }
 
-// GetFormatter returns a formatter by name.
+// GetFormatter returns a formatter by name. Unknown formats fall back to
+// JSON, so callers taking a format from user input should check it with
+// ParseFormat first.
 func GetFormatter(format Format) Formatter {
-	switch format {
-	case JSON:
-		return NewJSONFormatter()
-	case YAML:
-		return NewYAMLFormatter()
-	case Markdown:
-		return NewMarkdownFormatter()
-	case Table:
-		return NewTableFormatter()
-	case SPDX:
-		return NewSPDXFormatter()
-	case CycloneDX:
-		return NewCycloneDXFormatter()
-	default:
-		return NewJSONFormatter()
+	for _, f := range formats {
+		if f.format == format {
+			return f.new()
+		}
+	}
+	return NewJSONFormatter()
+}
+
+// formats lists every supported output format with its constructor, in the
+// order they are presented to users.
+var formats = []struct {
+	format Format
+	new    func() Formatter
+}{
+	{JSON, func() Formatter { return NewJSONFormatter() }},
+	{YAML, func() Formatter { return NewYAMLFormatter() }},
+	{Markdown, func() Formatter { return NewMarkdownFormatter() }},
+	{Table, func() Formatter { return NewTableFormatter() }},
+	{CSV, func() Formatter { return NewCSVFormatter() }},
+	{SPDX, func() Formatter { return NewSPDXFormatter() }},
+	{SPDXJSON, func() Formatter { return NewSPDXJSONFormatter() }},
+	{CycloneDX, func() Formatter { return NewCycloneDXFormatter() }},
+}
+
+// ValidFormats returns the supported output formats.
+func ValidFormats() []Format {
+	valid := make([]Format, len(formats))
+	for i, f := range formats {
+		valid[i] = f.format
+	}
+	return valid
+}
+
+// IsValid reports whether f is a supported output format.
+func (f Format) IsValid() bool {
+	for _, valid := range formats {
+		if valid.format == f {
+			return true
+		}
+	}
+	return false
+}
+
+// FormatList returns the valid formats as a comma-separated list, e.g. for
+// help output.
+func FormatList() string {
+	names := make([]string, len(formats))
+	for i, f := range formats {
+		names[i] = string(f.format)
+	}
+	return strings.Join(names, ", ")
+}
+
+// ParseFormat validates an output format name.
+func ParseFormat(s string) (Format, error) {
+	if f := Format(s); f.IsValid() {
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown format: %q (supported: %s)", s, FormatList())
+}
+
+// normalizeRelationship returns the canonical type of rel, rejecting types
+// that no supported standard can represent.
+func normalizeRelationship(rel sbom.Relationship) (sbom.RelationshipType, error) {
+	relType, err := sbom.ParseRelationshipType(string(rel.Relationship))
+	if err != nil {
+		return "", fmt.Errorf("relationship %s -> %s: %w", rel.RefA, rel.RefB, err)
 	}
+	return relType, nil
+}
+
+// withNormalizedRelationships returns a shallow copy of doc whose
+// relationships carry canonical types, so serialized output never contains
+// spellings that SPDX or CycloneDX consumers would reject.
+func withNormalizedRelationships(doc *sbom.SBOM) (*sbom.SBOM, error) {
+	if len(doc.Relationships) == 0 {
+		return doc, nil
+	}
+	normalized := *doc
+	normalized.Relationships = make([]sbom.Relationship, len(doc.Relationships))
+	for i, rel := range doc.Relationships {
+		relType, err := normalizeRelationship(rel)
+		if err != nil {
+			return nil, err
+		}
+		rel.Relationship = relType
+		normalized.Relationships[i] = rel
+	}
+	return &normalized, nil
+}
+
+// WithHashPolicy returns a copy of doc whose component hashes have been
+// reduced with sbom.SelectHashes, so any formatter emits only the preferred
+// algorithm and, if omitWeak is set, no MD5 or SHA-1 hashes. doc is left
+// unchanged.
+func WithHashPolicy(doc *sbom.SBOM, preferred string, omitWeak bool) *sbom.SBOM {
+	selected := *doc
+	selected.Components = make([]sbom.Component, len(doc.Components))
+	for i, comp := range doc.Components {
+		comp.Hashes = sbom.SelectHashes(comp, preferred, omitWeak)
+		selected.Components[i] = comp
+	}
+	if doc.Root != nil {
+		root := *doc.Root
+		root.Hashes = sbom.SelectHashes(root, preferred, omitWeak)
+		selected.Root = &root
+	}
+	return &selected
+}
+
+// ComponentsOnly returns a copy of doc without relationships and
+// annotations, for consumers that cannot handle those sections. The
+// components, root and vulnerabilities are kept. doc is left unchanged.
+func ComponentsOnly(doc *sbom.SBOM) *sbom.SBOM {
+	stripped := *doc
+	stripped.Relationships = nil
+	stripped.Annotations = nil
+	return &stripped
 }
 
 func truncate(s string, max int) string {
@@ -228,4 +476,4 @@ func truncate(s string, max int) string {
 		return s
 	}
 	return s[:max-3] + "..."
-}
\ No newline at end of file
+}
@@ -1,10 +1,16 @@
 package formatter
 
 import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/hallucinaut/sbomgen/pkg/sbom"
+	"github.com/hallucinaut/sbomgen/pkg/validator"
 )
 
 func TestGetFormatter(t *testing.T) {
@@ -175,30 +181,140 @@ func TestSPDXFormatter(t *testing.T) {
 
 func TestCycloneDXFormatter(t *testing.T) {
 	sbomDoc := sbom.New("test-app", "1.0.0", "serial-001")
+	sbomDoc.Created = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	sbomDoc.SetRoot(sbom.Component{Name: "test-app", Version: "1.0.0", PURL: "pkg:npm/test-app@1.0.0"})
 	sbomDoc.AddComponent(sbom.Component{
 		Name:     "lib-a",
 		Version:  "1.0.0",
 		Supplier: "npm",
+		License:  "mit",
+		PURL:     "pkg:npm/lib-a@1.0.0",
+		Hashes:   []sbom.Hash{{Algorithm: "sha512", Value: "9fa2b0b4b1ab7edbb4cbd8e3abd6a974cd1a7b5afe4c2f1af8ecd5869cc3b5bd4a9ab3f1d4c3e1ed61f6b6ec7c2bb373f6ba3d5fbe9e74dbb6a3d6acd0e47329"}},
 	})
 	sbomDoc.AddComponent(sbom.Component{
 		Name:     "lib-b",
 		Version:  "2.0.0",
 		Supplier: "pypi",
+		License:  "MIT OR Apache-2.0",
+		PURL:     "pkg:pypi/lib-b@2.0.0",
+		Scope:    sbom.ScopeDev,
 	})
+	sbomDoc.AddRelationship("test-app", "pkg:npm/lib-a@1.0.0", sbom.DependsOn)
 
 	f := NewCycloneDXFormatter()
 	output, err := f.FormatJSON(sbomDoc)
-	
-	// CycloneDX formatting not yet implemented - expect error
 	if err != nil {
-		t.Logf("Expected error (not implemented yet): %v", err)
-		return
+		t.Fatalf("Failed to format CycloneDX: %v", err)
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal([]byte(output), &doc); err != nil {
+		t.Fatalf("Failed to parse CycloneDX output: %v", err)
+	}
+	if doc["bomFormat"] != "CycloneDX" {
+		t.Errorf("Expected bomFormat CycloneDX, got %v", doc["bomFormat"])
+	}
+	if doc["specVersion"] != "1.5" {
+		t.Errorf("Expected specVersion 1.5, got %v", doc["specVersion"])
+	}
+	serial, _ := doc["serialNumber"].(string)
+	if !regexp.MustCompile(`^urn:uuid:[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`).MatchString(serial) {
+		t.Errorf("Expected a urn:uuid serial number, got %q", serial)
+	}
+	if want := sbom.NameSerialNumber("serial-001"); serial != want {
+		t.Errorf("Expected serial number %s derived from the SBOM's, got %s", want, serial)
+	}
+	if doc["version"] != float64(1) {
+		t.Errorf("Expected version 1, got %v", doc["version"])
+	}
+
+	metadata, _ := doc["metadata"].(map[string]interface{})
+	if metadata["timestamp"] != "2024-01-02T03:04:05Z" {
+		t.Errorf("Expected the creation time as metadata.timestamp, got %v", metadata["timestamp"])
+	}
+	tools, _ := metadata["tools"].(map[string]interface{})
+	toolComponents, _ := tools["components"].([]interface{})
+	if len(toolComponents) != 1 || toolComponents[0].(map[string]interface{})["name"] != "sbomgen" {
+		t.Errorf("Expected sbomgen as the metadata tool, got %v", metadata["tools"])
+	}
+
+	components, _ := doc["components"].([]interface{})
+	if len(components) != 2 {
+		t.Fatalf("Expected 2 components, got %d", len(components))
+	}
+	libA := components[0].(map[string]interface{})
+	for field, want := range map[string]interface{}{"type": "library", "name": "lib-a", "version": "1.0.0", "purl": "pkg:npm/lib-a@1.0.0"} {
+		if libA[field] != want {
+			t.Errorf("Expected lib-a %s %v, got %v", field, want, libA[field])
+		}
+	}
+	licenses, _ := libA["licenses"].([]interface{})
+	if len(licenses) != 1 || licenses[0].(map[string]interface{})["license"].(map[string]interface{})["id"] != "MIT" {
+		t.Errorf("Expected lib-a to have license id MIT, got %v", libA["licenses"])
+	}
+	hashes, _ := libA["hashes"].([]interface{})
+	if len(hashes) != 1 || hashes[0].(map[string]interface{})["alg"] != "SHA-512" {
+		t.Errorf("Expected a SHA-512 hash for lib-a, got %v", libA["hashes"])
+	}
+	libB := components[1].(map[string]interface{})
+	licenses, _ = libB["licenses"].([]interface{})
+	if len(licenses) != 1 || licenses[0].(map[string]interface{})["expression"] != "MIT OR Apache-2.0" {
+		t.Errorf("Expected lib-b to have a license expression, got %v", libB["licenses"])
+	}
+	if libB["scope"] != "excluded" {
+		t.Errorf("Expected the dev dependency to be excluded, got %v", libB["scope"])
+	}
+
+	dependencies, _ := doc["dependencies"].([]interface{})
+	if len(dependencies) != 3 {
+		t.Fatalf("Expected a dependency entry for the root and each component, got %v", doc["dependencies"])
+	}
+	rootDeps := dependencies[0].(map[string]interface{})
+	if rootDeps["ref"] != "pkg:npm/test-app@1.0.0" || fmt.Sprint(rootDeps["dependsOn"]) != "[pkg:npm/lib-a@1.0.0]" {
+		t.Errorf("Expected the root to depend on lib-a, got %v", rootDeps)
+	}
+
+	violations, err := validator.Validate(validator.CycloneDX, []byte(output))
+	if err != nil {
+		t.Fatalf("Failed to validate CycloneDX output: %v", err)
+	}
+	if len(violations) > 0 {
+		t.Errorf("Expected the output to match the CycloneDX 1.5 schema, got %v", violations)
+	}
+}
+
+func TestCycloneDXFormatter_SpecVersion14(t *testing.T) {
+	sbomDoc := sbom.New("test-app", "1.0.0", "6f1c5b0e-4d2a-4a5e-9a53-0c3f1e2d7b8a")
+	sbomDoc.AddComponent(sbom.Component{Name: "lib-a", Version: "1.0.0", PURL: "pkg:npm/lib-a@1.0.0"})
+	sbomDoc.Annotations = append(sbomDoc.Annotations, sbom.Annotation{ComponentRef: "pkg:npm/lib-a@1.0.0", Summary: "checked"})
+
+	f, err := NewVersionedFormatter(CycloneDX, "1.4")
+	if err != nil {
+		t.Fatalf("Failed to create formatter: %v", err)
+	}
+	output, err := f.Format(sbomDoc)
+	if err != nil {
+		t.Fatalf("Failed to format CycloneDX: %v", err)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal([]byte(output), &doc); err != nil {
+		t.Fatalf("Failed to parse CycloneDX output: %v", err)
 	}
-	
-	if !strings.Contains(output, "CycloneDX") {
-		t.Errorf("Expected CycloneDX in output, got: %s", output)
+	if doc["specVersion"] != "1.4" {
+		t.Errorf("Expected specVersion 1.4, got %v", doc["specVersion"])
+	}
+	if doc["serialNumber"] != "urn:uuid:6f1c5b0e-4d2a-4a5e-9a53-0c3f1e2d7b8a" {
+		t.Errorf("Expected the UUID serial number as a URN, got %v", doc["serialNumber"])
+	}
+	metadata, _ := doc["metadata"].(map[string]interface{})
+	if _, ok := metadata["tools"].([]interface{}); !ok {
+		t.Errorf("Expected the legacy tools array for CycloneDX 1.4, got %v", metadata["tools"])
+	}
+	if _, ok := doc["annotations"]; ok {
+		t.Error("Expected no annotations for CycloneDX 1.4")
 	}
 }
+
 func TestJSONFormatter_EmptySBOM(t *testing.T) {
 	sbomDoc := sbom.New("test-app", "1.0.0", "serial-001")
 
@@ -311,4 +427,278 @@ func TestTableFormatter_EmptySBOM(t *testing.T) {
 	if !strings.Contains(output, "NAME") {
 		t.Error("Expected table header even with no components")
 	}
-}
\ No newline at end of file
+}
+
+func TestMarkdownFormatter_UnknownRelationship(t *testing.T) {
+	sbomDoc := sbom.New("test-app", "1.0.0", "serial-001")
+	sbomDoc.AddRelationship("ref-a", "ref-b", "uses")
+
+	f := NewMarkdownFormatter()
+	if _, err := f.Format(sbomDoc); err == nil {
+		t.Error("Expected error for unknown relationship type")
+	}
+}
+
+func TestJSONFormatter_NormalizesRelationships(t *testing.T) {
+	sbomDoc := sbom.New("test-app", "1.0.0", "serial-001")
+	sbomDoc.AddRelationship("ref-a", "ref-b", "DEPENDS_ON")
+
+	output, err := NewJSONFormatter().Format(sbomDoc)
+	if err != nil {
+		t.Fatalf("Failed to format: %v", err)
+	}
+	if !strings.Contains(output, `"relationship": "depends_on"`) {
+		t.Errorf("Expected canonical relationship type, got: %s", output)
+	}
+
+	sbomDoc.AddRelationship("ref-b", "ref-c", "uses")
+	if _, err := NewJSONFormatter().Format(sbomDoc); err == nil {
+		t.Error("Expected JSON formatter to reject unknown relationship type")
+	}
+	if _, err := NewYAMLFormatter().Format(sbomDoc); err == nil {
+		t.Error("Expected YAML formatter to reject unknown relationship type")
+	}
+}
+
+func TestCompactJSONFormatter(t *testing.T) {
+	sbomDoc := sbom.New("test-app", "1.0.0", "serial-001")
+	sbomDoc.AddComponent(sbom.Component{
+		Name:     "lib-a",
+		Version:  "1.0.0",
+		Supplier: "npm",
+		License:  "MIT",
+	})
+	sbomDoc.AddRelationship("ref-a", "ref-b", sbom.DependsOn)
+
+	compact, err := NewCompactJSONFormatter().Format(sbomDoc)
+	if err != nil {
+		t.Fatalf("Failed to format: %v", err)
+	}
+	if strings.Contains(compact, "\n") {
+		t.Error("Expected compact output to contain no newlines")
+	}
+
+	pretty, err := NewJSONFormatter().Format(sbomDoc)
+	if err != nil {
+		t.Fatalf("Failed to format: %v", err)
+	}
+	if len(compact) >= len(pretty) {
+		t.Errorf("Expected compact output to be smaller than pretty output")
+	}
+
+	var fromCompact, fromPretty map[string]interface{}
+	if err := json.Unmarshal([]byte(compact), &fromCompact); err != nil {
+		t.Fatalf("Failed to unmarshal compact output: %v", err)
+	}
+	if err := json.Unmarshal([]byte(pretty), &fromPretty); err != nil {
+		t.Fatalf("Failed to unmarshal pretty output: %v", err)
+	}
+	if !reflect.DeepEqual(fromCompact, fromPretty) {
+		t.Error("Expected compact and pretty output to unmarshal to the same structure")
+	}
+}
+
+func TestMarkdownFormatter_LicenseExpression(t *testing.T) {
+	sbomDoc := sbom.New("test-app", "1.0.0", "serial-001")
+	sbomDoc.SetRoot(sbom.Component{Name: "my-crate", Version: "0.3.1", License: sbom.LicenseExpression("MIT/Apache-2.0")})
+	sbomDoc.AddComponent(sbom.Component{Name: "serde", Version: "1.0.0", Supplier: "cargo", License: "MIT OR Apache-2.0"})
+
+	output, err := NewMarkdownFormatter().Format(sbomDoc)
+	if err != nil {
+		t.Fatalf("Failed to format: %v", err)
+	}
+
+	if !strings.Contains(output, "**License:** MIT OR Apache-2.0") {
+		t.Error("Expected root license expression in header")
+	}
+	if !strings.Contains(output, "| 1 | serde | 1.0.0 | cargo | MIT OR Apache-2.0 |") {
+		t.Error("Expected component license expression in table")
+	}
+}
+
+func TestMarkdownFormatter_LicenseSources(t *testing.T) {
+	sbomDoc := sbom.New("test-app", "1.0.0", "serial-001")
+	sbomDoc.AddComponent(sbom.Component{Name: "express", Version: "4.18.0", Supplier: "npm", License: "MIT",
+		Metadata: sbom.Metadata{LicenseSource: sbom.LicenseSourceDeclared}})
+	sbomDoc.AddComponent(sbom.Component{Name: "shared", Version: "1.0.0", Supplier: "npm", License: "Apache-2.0",
+		Metadata: sbom.Metadata{LicenseSource: sbom.LicenseSourceInferred}})
+	sbomDoc.AddComponent(sbom.Component{Name: "lodash", Version: "4.17.21", Supplier: "npm", License: "MIT",
+		Metadata: sbom.Metadata{LicenseSource: sbom.LicenseSourceRegistry}})
+
+	output, err := NewMarkdownFormatter().Format(sbomDoc)
+	if err != nil {
+		t.Fatalf("Failed to format: %v", err)
+	}
+	if !strings.Contains(output, "**License Sources:** 1 declared, 1 inferred, 1 registry") {
+		t.Errorf("Expected a license source summary, got:\n%s", output)
+	}
+	for _, row := range []string{
+		"| 1 | express | 4.18.0 | npm | MIT |",
+		"| 2 | shared | 1.0.0 | npm | Apache-2.0 (inferred) |",
+		"| 3 | lodash | 4.17.21 | npm | MIT (registry) |",
+	} {
+		if !strings.Contains(output, row) {
+			t.Errorf("Expected row %q, got:\n%s", row, output)
+		}
+	}
+}
+
+func TestMarkdownFormatter_GroupBySupplier(t *testing.T) {
+	sbomDoc := sbom.New("test-app", "1.0.0", "serial-001")
+	sbomDoc.AddComponent(sbom.Component{Name: "requests", Version: "2.28.0", Supplier: "pypi"})
+	sbomDoc.AddComponent(sbom.Component{Name: "express", Version: "4.18.0", Supplier: "npm"})
+	sbomDoc.AddComponent(sbom.Component{Name: "vendored", Version: "0.1.0"})
+	sbomDoc.AddComponent(sbom.Component{Name: "lodash", Version: "4.17.21", Supplier: "npm"})
+
+	output, err := NewGroupedMarkdownFormatter(GroupBySupplier).Format(sbomDoc)
+	if err != nil {
+		t.Fatalf("Failed to format: %v", err)
+	}
+
+	headers := []string{"### npm (2)", "### pypi (1)", "### Unknown (1)"}
+	last := -1
+	for _, header := range headers {
+		idx := strings.Index(output, header)
+		if idx < 0 {
+			t.Fatalf("Expected section header '%s' in output:\n%s", header, output)
+		}
+		if idx < last {
+			t.Errorf("Expected '%s' after the previous section", header)
+		}
+		last = idx
+	}
+	if !strings.Contains(output, "| 2 | lodash | 4.17.21 | npm |  |") {
+		t.Error("Expected numbering to restart within each group")
+	}
+	if strings.Count(output, "| # | Name |") != 3 {
+		t.Errorf("Expected one table per group, got %d", strings.Count(output, "| # | Name |"))
+	}
+}
+
+func TestParseGroupBy(t *testing.T) {
+	if g, err := ParseGroupBy("license"); err != nil || g != GroupByLicense {
+		t.Errorf("Expected GroupByLicense, got '%s' (%v)", g, err)
+	}
+	if _, err := ParseGroupBy("ecosystem"); err == nil {
+		t.Error("Expected error for unknown group-by field")
+	}
+}
+
+func TestJSONFormatter_VEX(t *testing.T) {
+	sbomDoc := sbom.New("test-app", "1.0.0", "serial-001")
+	sbomDoc.AddComponent(sbom.Component{Name: "lodash", Version: "4.17.20", PURL: "pkg:npm/lodash@4.17.20"})
+	sbomDoc.ApplyVEX(sbom.VEX{
+		"pkg:npm/lodash@4.17.20": {{ID: "CVE-2021-23337", State: "not_affected", Justification: "code_not_reachable"}},
+	})
+
+	output, err := NewJSONFormatter().Format(sbomDoc)
+	if err != nil {
+		t.Fatalf("Failed to format: %v", err)
+	}
+
+	var decoded struct {
+		Vulnerabilities []sbom.Vulnerability `json:"vulnerabilities"`
+	}
+	if err := json.Unmarshal([]byte(output), &decoded); err != nil {
+		t.Fatalf("Failed to parse output: %v", err)
+	}
+	if len(decoded.Vulnerabilities) != 1 || decoded.Vulnerabilities[0].Analysis.State != "not_affected" {
+		t.Errorf("Expected VEX state in output, got %+v", decoded.Vulnerabilities)
+	}
+}
+
+func TestWithHashPolicy(t *testing.T) {
+	sbomDoc := sbom.New("test-app", "1.0.0", "serial-001")
+	sbomDoc.AddComponent(sbom.Component{Name: "lib-a", Hashes: []sbom.Hash{
+		{Algorithm: "MD5", Value: "def456"},
+		{Algorithm: "SHA-256", Value: "abc123"},
+	}})
+	sbomDoc.AddComponent(sbom.Component{Name: "lib-b", Hashes: []sbom.Hash{{Algorithm: "SHA-1", Value: "0a1b"}}})
+
+	selected := WithHashPolicy(sbomDoc, "", true)
+	if len(selected.Components[0].Hashes) != 1 || selected.Components[0].Hashes[0].Algorithm != "SHA-256" {
+		t.Errorf("Expected only SHA-256 for lib-a, got %v", selected.Components[0].Hashes)
+	}
+	if len(selected.Components[1].Hashes) != 0 {
+		t.Errorf("Expected weak hash to be omitted for lib-b, got %v", selected.Components[1].Hashes)
+	}
+	if len(sbomDoc.Components[0].Hashes) != 2 {
+		t.Error("Expected the original SBOM to be left unchanged")
+	}
+
+	output, err := NewJSONFormatter().Format(WithHashPolicy(sbomDoc, "md5", false))
+	if err != nil {
+		t.Fatalf("Failed to format: %v", err)
+	}
+	if strings.Contains(output, "abc123") || !strings.Contains(output, "def456") {
+		t.Error("Expected only the preferred MD5 hash for lib-a in the output")
+	}
+}
+
+func TestValidFormats(t *testing.T) {
+	sbomDoc := sbom.New("test-app", "1.0.0", "serial-001")
+	sbomDoc.AddComponent(sbom.Component{Name: "lib-a", Version: "1.0.0", PURL: "pkg:npm/lib-a@1.0.0"})
+
+	formats := ValidFormats()
+	if len(formats) == 0 {
+		t.Fatal("Expected at least one valid format")
+	}
+	for _, format := range formats {
+		if !format.IsValid() {
+			t.Errorf("Expected %s to be valid", format)
+		}
+		f := GetFormatter(format)
+		if f.Name() != string(format) {
+			t.Errorf("Expected the %s formatter, got %s", format, f.Name())
+		}
+		if output, err := f.Format(sbomDoc); err != nil || output == "" {
+			t.Errorf("Expected %s to format the SBOM, got %q, %v", format, output, err)
+		}
+	}
+
+	for _, format := range []Format{"", "unknown", Auto} {
+		if format.IsValid() {
+			t.Errorf("Expected %q to be invalid", format)
+		}
+	}
+	if _, err := ParseFormat("pdf"); err == nil || !strings.Contains(err.Error(), FormatList()) {
+		t.Errorf("Expected an error listing the valid formats, got %v", err)
+	}
+}
+
+func TestComponentsOnly(t *testing.T) {
+	doc := sbom.New("web", "1.0.0", "serial-001")
+	doc.SetRoot(sbom.Component{Name: "web", Version: "1.0.0"})
+	doc.AddComponent(sbom.Component{Name: "express", Version: "4.18.2", PURL: "pkg:npm/express@4.18.2"})
+	doc.AddComponent(sbom.Component{Name: "jest", Version: "29.7.0", PURL: "pkg:npm/jest@29.7.0", Scope: sbom.ScopeDev})
+	doc.AddRelationship("web", "pkg:npm/express@4.18.2", sbom.DependsOn)
+	doc.Annotations = append(doc.Annotations, sbom.Annotation{ComponentRef: "pkg:npm/express@4.18.2", EventType: "license_override"})
+
+	output, err := NewJSONFormatter().Format(ComponentsOnly(doc))
+	if err != nil {
+		t.Fatalf("Failed to format: %v", err)
+	}
+	var parsed map[string]interface{}
+	if err := json.Unmarshal([]byte(output), &parsed); err != nil {
+		t.Fatalf("Failed to parse output: %v", err)
+	}
+	for _, key := range []string{"relationships", "annotations"} {
+		if _, ok := parsed[key]; ok {
+			t.Errorf("Expected no %s key in components-only output", key)
+		}
+	}
+	if components, _ := parsed["components"].([]interface{}); len(components) != 2 {
+		t.Errorf("Expected both components to be kept, got %v", parsed["components"])
+	}
+	if len(doc.Relationships) != 1 || len(doc.Annotations) != 1 {
+		t.Error("Expected the original SBOM to be left unchanged")
+	}
+
+	spdx, err := (&SPDXFormatter{specVersion: "2.3", OmitRelationships: true}).Format(ComponentsOnly(doc))
+	if err != nil {
+		t.Fatalf("Failed to format SPDX: %v", err)
+	}
+	if got := strings.Count(spdx, "Relationship:"); got != 1 || !strings.Contains(spdx, "Relationship: SPDXRef-DOCUMENT DESCRIBES SPDXRef-Root\n") {
+		t.Errorf("Expected only the DESCRIBES relationship, got:\n%s", spdx)
+	}
+}
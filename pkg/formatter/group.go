@@ -0,0 +1,73 @@
+package formatter
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hallucinaut/sbomgen/pkg/sbom"
+)
+
+// GroupBy selects the component field used to split output into sections.
+type GroupBy string
+
+const (
+	GroupBySupplier GroupBy = "supplier"
+	GroupByLicense  GroupBy = "license"
+)
+
+// unknownGroup collects components with no value for the grouping field.
+const unknownGroup = "Unknown"
+
+// componentGroup is a set of components sharing a supplier or license.
+type componentGroup struct {
+	Key        string
+	Components []sbom.Component
+}
+
+// ParseGroupBy validates a --group-by value.
+func ParseGroupBy(s string) (GroupBy, error) {
+	switch g := GroupBy(s); g {
+	case GroupBySupplier, GroupByLicense:
+		return g, nil
+	default:
+		return "", fmt.Errorf("unknown group-by field: %q (supported: supplier, license)", s)
+	}
+}
+
+// groupComponentsBy splits components into groups sorted by key, with the
+// Unknown group last. Components keep their original order within a group.
+func groupComponentsBy(groupBy GroupBy, components []sbom.Component) ([]componentGroup, error) {
+	var key func(sbom.Component) string
+	switch groupBy {
+	case GroupBySupplier:
+		key = func(c sbom.Component) string { return c.Supplier }
+	case GroupByLicense:
+		key = func(c sbom.Component) string { return c.License }
+	default:
+		return nil, fmt.Errorf("unknown group-by field: %q", groupBy)
+	}
+
+	index := make(map[string]int)
+	var groups []componentGroup
+	for _, comp := range components {
+		k := key(comp)
+		if k == "" {
+			k = unknownGroup
+		}
+		i, ok := index[k]
+		if !ok {
+			i = len(groups)
+			index[k] = i
+			groups = append(groups, componentGroup{Key: k})
+		}
+		groups[i].Components = append(groups[i].Components, comp)
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		if (groups[i].Key == unknownGroup) != (groups[j].Key == unknownGroup) {
+			return groups[j].Key == unknownGroup
+		}
+		return groups[i].Key < groups[j].Key
+	})
+	return groups, nil
+}
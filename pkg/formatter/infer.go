@@ -0,0 +1,49 @@
+package formatter
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Auto selects the output format from the output file name.
+const Auto Format = "auto"
+
+// extensionFormats maps output file suffixes to the format they imply.
+// Longer suffixes come first so that ".cdx.json" and ".spdx.json" win over
+// ".json".
+var extensionFormats = []struct {
+	suffix string
+	format Format
+}{
+	{".cdx.json", CycloneDX},
+	{".spdx.json", SPDXJSON},
+	{".json", JSON},
+	{".yaml", YAML},
+	{".yml", YAML},
+	{".md", Markdown},
+	{".spdx", SPDX},
+	{".csv", CSV},
+}
+
+// FormatForPath infers the output format from the extension of path. It
+// returns JSON for an empty path (stdout) and for unknown extensions.
+func FormatForPath(path string) Format {
+	name := strings.ToLower(filepath.Base(path))
+	for _, ext := range extensionFormats {
+		if strings.HasSuffix(name, ext.suffix) {
+			return ext.format
+		}
+	}
+	return JSON
+}
+
+// Extension returns the file suffix FormatForPath maps to format, such as
+// ".cdx.json" for CycloneDX, or ".txt" for formats that have none.
+func Extension(format Format) string {
+	for _, ext := range extensionFormats {
+		if ext.format == format {
+			return ext.suffix
+		}
+	}
+	return ".txt"
+}
@@ -0,0 +1,28 @@
+package formatter
+
+import "testing"
+
+func TestFormatForPath(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected Format
+	}{
+		{"", JSON},
+		{"sbom.json", JSON},
+		{"out/sbom.yaml", YAML},
+		{"sbom.yml", YAML},
+		{"SBOM.YML", YAML},
+		{"sbom.md", Markdown},
+		{"sbom.spdx", SPDX},
+		{"sbom.cdx.json", CycloneDX},
+		{"sbom.spdx.json", SPDXJSON},
+		{"sbom.txt", JSON},
+		{"sbom", JSON},
+	}
+
+	for _, tt := range tests {
+		if got := FormatForPath(tt.path); got != tt.expected {
+			t.Errorf("FormatForPath(%q): expected %s, got %s", tt.path, tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package formatter
+
+import (
+	"time"
+
+	"github.com/hallucinaut/sbomgen/pkg/sbom"
+)
+
+// MinimizeFor returns a copy of doc holding only the fields that format can
+// represent, so standards-based outputs carry no tool-specific extras. The
+// native json and yaml formats, and formats without a fixed field set, get
+// an unchanged copy. doc is left unchanged.
+func MinimizeFor(doc *sbom.SBOM, format Format) *sbom.SBOM {
+	minimized := *doc
+	var minimize func(sbom.Component) sbom.Component
+	switch format {
+	case SPDX, SPDXJSON:
+		minimize = minimizeSPDX
+		// SPDX 2 documents have no vulnerability section.
+		minimized.Vulnerabilities = nil
+	case CycloneDX:
+		minimize = minimizeCycloneDX
+	default:
+		minimized.Components = append([]sbom.Component(nil), doc.Components...)
+		return &minimized
+	}
+
+	minimized.Components = make([]sbom.Component, len(doc.Components))
+	for i, comp := range doc.Components {
+		minimized.Components[i] = minimize(comp)
+	}
+	if doc.Root != nil {
+		root := minimize(*doc.Root)
+		minimized.Root = &root
+	}
+	return &minimized
+}
+
+// minimizeSPDX keeps the fields of an SPDX 2 package, plus the scope and
+// dependencies the document relationships are derived from.
+func minimizeSPDX(comp sbom.Component) sbom.Component {
+	return sbom.Component{
+		Name:         comp.Name,
+		Version:      comp.Version,
+		Supplier:     comp.Supplier,
+		License:      comp.License,
+		PURL:         comp.PURL,
+		CPE:          comp.CPE,
+		Hashes:       comp.Hashes,
+		ExternalRefs: comp.ExternalRefs,
+		Scope:        comp.Scope,
+		Dependencies: comp.Dependencies,
+		Metadata: sbom.Metadata{
+			Author:      comp.Metadata.Author,
+			Description: comp.Metadata.Description,
+			HomepageURL: comp.Metadata.HomepageURL,
+			SourceURL:   comp.Metadata.SourceURL,
+		},
+	}
+}
+
+// minimizeCycloneDX drops the fields CycloneDX has no place for. Occurrences
+// record the manifest a component was found in but not the line.
+func minimizeCycloneDX(comp sbom.Component) sbom.Component {
+	comp.Direct = false
+	comp.Metadata.LastModified = time.Time{}
+	comp.Metadata.SourceLine = 0
+	return comp
+}
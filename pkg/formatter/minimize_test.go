@@ -0,0 +1,97 @@
+package formatter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hallucinaut/sbomgen/pkg/sbom"
+)
+
+func minimizeTestSBOM() *sbom.SBOM {
+	doc := sbom.New("test-app", "1.0.0", "serial-001")
+	doc.AddComponent(sbom.Component{
+		Name:     "lodash",
+		Version:  "4.17.21",
+		Supplier: "npm",
+		License:  "MIT",
+		PURL:     "pkg:npm/lodash@4.17.21",
+		CPE:      "cpe:2.3:a:lodash:lodash:4.17.21:*:*:*:*:*:*:*",
+		Hashes:   []sbom.Hash{{Algorithm: "SHA-256", Value: "abc123"}},
+		Scope:    "dev",
+		Direct:   true,
+		Metadata: sbom.Metadata{
+			Description: "Lodash modular utilities",
+			SourceFile:  "package.json",
+			SourceLine:  12,
+			Revision:    "deadbeef",
+		},
+		Dependencies: []string{"pkg:npm/other@1.0.0"},
+	})
+	doc.Vulnerabilities = []sbom.Vulnerability{{ID: "CVE-2021-23337", Affects: "pkg:npm/lodash@4.17.21"}}
+	return doc
+}
+
+func TestMinimizeFor_SPDX(t *testing.T) {
+	doc := minimizeTestSBOM()
+	minimized := MinimizeFor(doc, SPDX)
+
+	comp := minimized.Components[0]
+	if comp.Name != "lodash" || comp.License != "MIT" || comp.PURL == "" || comp.CPE == "" || len(comp.Hashes) != 1 {
+		t.Errorf("Expected SPDX package fields to be kept, got %+v", comp)
+	}
+	if comp.Metadata.Description != "Lodash modular utilities" {
+		t.Errorf("Expected description to be kept, got '%s'", comp.Metadata.Description)
+	}
+	if comp.Direct {
+		t.Errorf("Expected direct to be dropped, got %+v", comp)
+	}
+	if comp.Scope != "dev" || len(comp.Dependencies) != 1 {
+		t.Errorf("Expected scope and dependencies to be kept for relationships, got %+v", comp)
+	}
+	if comp.Metadata.SourceFile != "" || comp.Metadata.SourceLine != 0 || comp.Metadata.Revision != "" {
+		t.Errorf("Expected tool metadata to be dropped, got %+v", comp.Metadata)
+	}
+	if minimized.Vulnerabilities != nil {
+		t.Errorf("Expected vulnerabilities to be dropped, got %v", minimized.Vulnerabilities)
+	}
+
+	if doc.Components[0].Scope != "dev" || len(doc.Vulnerabilities) != 1 {
+		t.Error("Expected the original SBOM to be left unchanged")
+	}
+}
+
+func TestMinimizeFor_SPDXRelationships(t *testing.T) {
+	doc := minimizeTestSBOM()
+	doc.SetRoot(sbom.Component{Name: "test-app", Version: "1.0.0"})
+	doc.AddComponent(sbom.Component{Name: "other", Version: "1.0.0", PURL: "pkg:npm/other@1.0.0"})
+
+	output, err := NewSPDXFormatter().Format(MinimizeFor(doc, SPDX))
+	if err != nil {
+		t.Fatalf("Failed to format: %v", err)
+	}
+	for _, line := range []string{
+		"Relationship: SPDXRef-Package-0 DEV_DEPENDENCY_OF SPDXRef-Root",
+		"Relationship: SPDXRef-Package-0 DEPENDS_ON SPDXRef-Package-1",
+	} {
+		if !strings.Contains(output, line+"\n") {
+			t.Errorf("Expected line %q in minimized output:\n%s", line, output)
+		}
+	}
+}
+
+func TestMinimizeFor_OtherFormats(t *testing.T) {
+	doc := minimizeTestSBOM()
+
+	cdx := MinimizeFor(doc, CycloneDX).Components[0]
+	if cdx.Direct {
+		t.Error("Expected CycloneDX to drop direct")
+	}
+	if cdx.Scope != "dev" || cdx.CPE == "" || cdx.Metadata.SourceFile != "package.json" {
+		t.Errorf("Expected CycloneDX fields to be kept, got %+v", cdx)
+	}
+
+	native := MinimizeFor(doc, JSON).Components[0]
+	if !native.Direct || native.Scope != "dev" {
+		t.Errorf("Expected JSON to keep every field, got %+v", native)
+	}
+}
@@ -0,0 +1,197 @@
+package formatter
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/hallucinaut/sbomgen/pkg/sbom"
+)
+
+// spdxRootID identifies the package describing the project itself.
+const spdxRootID = "SPDXRef-Root"
+
+// DefaultDocumentNamespaceBase is the URL SPDX document namespaces are
+// built under unless another base is configured.
+const DefaultDocumentNamespaceBase = "https://spdx.org/spdxdocs"
+
+// spdxDocumentNamespace returns the namespace of doc under base, following
+// the SPDX recommendation of <base>/<document name>-<UUID>. The UUID is that
+// of the serial number, which is random for every gen run, so that builds
+// of the same version never share a namespace. Documents without a serial
+// number use their content serial number instead.
+func spdxDocumentNamespace(base string, doc *sbom.SBOM) string {
+	if base == "" {
+		base = DefaultDocumentNamespaceBase
+	}
+	id := doc.SerialNumber
+	if id == "" {
+		id = doc.ContentSerialNumber()
+	}
+	id = strings.TrimPrefix(id, "urn:uuid:")
+	return strings.TrimSuffix(base, "/") + "/" + url.PathEscape(doc.Name+"-"+id)
+}
+
+// ValidateDocumentNamespaceBase checks that base is an absolute http or
+// https URL without a query or fragment, as document namespaces must be.
+func ValidateDocumentNamespaceBase(base string) error {
+	u, err := url.Parse(base)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("document namespace base must be an absolute http(s) URL, got %q", base)
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("document namespace base must not have a query or fragment, got %q", base)
+	}
+	return nil
+}
+
+// spdxCreator returns the SPDX creator of doc: the sbomgen release that
+// generated it, when known.
+func spdxCreator(doc *sbom.SBOM) string {
+	if doc.ToolVersion == "" {
+		return "Tool: sbomgen"
+	}
+	return "Tool: sbomgen-" + doc.ToolVersion
+}
+
+// spdxPackageID returns the SPDX identifier of the i-th component.
+func spdxPackageID(i int) string {
+	return fmt.Sprintf("SPDXRef-Package-%d", i)
+}
+
+// spdxRelationship is an SPDX relationship between two elements, tagged
+// for the SPDX JSON serialization.
+type spdxRelationship struct {
+	Element string `json:"spdxElementId"`
+	Type    string `json:"relationshipType"`
+	Related string `json:"relatedSpdxElement"`
+}
+
+// scopeRelationship returns the relationship a component of scope has to
+// the project: dev, build and optional components are DEV_, BUILD_ and
+// OPTIONAL_DEPENDENCY_OF it. Other scopes imply no specific relationship.
+func scopeRelationship(scope sbom.Scope) (sbom.RelationshipType, bool) {
+	switch scope.Effective() {
+	case sbom.ScopeDev:
+		return sbom.DevDependencyOf, true
+	case sbom.ScopeBuild:
+		return sbom.BuildDependencyOf, true
+	case sbom.ScopeOptional:
+		return sbom.OptionalDependencyOf, true
+	}
+	return "", false
+}
+
+// spdxScopeRelationships relates every dev, build and optional component to
+// the root package. Without a root there is nothing to relate them to.
+func spdxScopeRelationships(doc *sbom.SBOM) []spdxRelationship {
+	if doc.Root == nil {
+		return nil
+	}
+	var rels []spdxRelationship
+	for i, comp := range doc.Components {
+		if relType, ok := scopeRelationship(comp.Scope); ok {
+			rels = append(rels, spdxRelationship{Element: spdxPackageID(i), Type: relType.SPDX(), Related: spdxRootID})
+		}
+	}
+	return rels
+}
+
+// spdxIDs maps the references relationships use, PURLs and names with or
+// without the version, onto the SPDX identifiers of the packages they
+// name. When several packages share a name, the bare name refers to the
+// first of them.
+type spdxIDs map[string]string
+
+func newSPDXIDs(doc *sbom.SBOM) spdxIDs {
+	ids := make(spdxIDs)
+	add := func(comp sbom.Component, id string) {
+		for _, key := range []string{comp.PURL, comp.Name + "@" + comp.Version, comp.Name} {
+			if _, ok := ids[key]; key != "" && !ok {
+				ids[key] = id
+			}
+		}
+	}
+	if doc.Root != nil {
+		add(*doc.Root, spdxRootID)
+	}
+	for i, comp := range doc.Components {
+		add(comp, spdxPackageID(i))
+	}
+	return ids
+}
+
+// spdxRelationships returns the relationships of an SPDX document for doc:
+// the mandatory DESCRIBES relationships, followed, unless describesOnly is
+// set, by the relationships implied by component scopes and those recorded
+// in the SBOM or as component dependencies. Relationships to elements that
+// are not in the document, or of types SPDX has no keyword for, are left
+// out, as are repeats of an earlier relationship.
+func spdxRelationships(doc *sbom.SBOM, describesOnly bool) []spdxRelationship {
+	rels := spdxDescribes(doc)
+	if describesOnly {
+		return rels
+	}
+
+	seen := make(map[spdxRelationship]bool)
+	add := func(rel spdxRelationship) {
+		if rel.Type != "" && rel.Element != rel.Related && !seen[rel] {
+			seen[rel] = true
+			rels = append(rels, rel)
+		}
+	}
+	for _, rel := range spdxScopeRelationships(doc) {
+		add(rel)
+	}
+
+	ids := newSPDXIDs(doc)
+	for _, rel := range doc.Relationships {
+		a, okA := ids[rel.RefA]
+		b, okB := ids[rel.RefB]
+		if okA && okB {
+			add(spdxRelationship{Element: a, Type: rel.Relationship.SPDX(), Related: b})
+		}
+	}
+	for i, comp := range doc.Components {
+		for _, dep := range comp.Dependencies {
+			if id, ok := ids[dep]; ok {
+				add(spdxRelationship{Element: spdxPackageID(i), Type: sbom.DependsOn.SPDX(), Related: id})
+			}
+		}
+	}
+	return rels
+}
+
+// spdxDescribes returns the DESCRIBES relationships SPDX requires of a
+// document: it describes the root package or, when there is none, each
+// package.
+func spdxDescribes(doc *sbom.SBOM) []spdxRelationship {
+	if doc.Root != nil {
+		return []spdxRelationship{{Element: "SPDXRef-DOCUMENT", Type: "DESCRIBES", Related: spdxRootID}}
+	}
+	rels := make([]spdxRelationship, len(doc.Components))
+	for i := range doc.Components {
+		rels[i] = spdxRelationship{Element: "SPDXRef-DOCUMENT", Type: "DESCRIBES", Related: spdxPackageID(i)}
+	}
+	return rels
+}
+
+// spdxSupplier renders a supplier in the SPDX agent syntax. Suppliers are
+// registries or organizations, so they are recorded as an Organization;
+// without one the supplier is NOASSERTION.
+func spdxSupplier(supplier string) string {
+	if supplier == "" {
+		return "NOASSERTION"
+	}
+	return "Organization: " + supplier
+}
+
+// spdxDownloadLocation returns the download location of comp, which SPDX
+// requires on every package: its PURL, which names where a package manager
+// fetches it from, or NOASSERTION.
+func spdxDownloadLocation(comp sbom.Component) string {
+	if comp.PURL == "" {
+		return "NOASSERTION"
+	}
+	return comp.PURL
+}
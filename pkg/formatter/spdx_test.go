@@ -0,0 +1,324 @@
+package formatter
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hallucinaut/sbomgen/pkg/sbom"
+	"github.com/hallucinaut/sbomgen/pkg/validator"
+)
+
+func TestSPDXFormatter_ScopeRelationships(t *testing.T) {
+	doc := sbom.New("web", "1.0.0", "serial-001")
+	doc.SetRoot(sbom.Component{Name: "web", Version: "1.0.0", Supplier: "npm"})
+	doc.AddComponent(sbom.Component{Name: "express", Version: "4.18.2", Supplier: "npm"})
+	doc.AddComponent(sbom.Component{Name: "jest", Version: "29.7.0", Supplier: "npm", Scope: sbom.ScopeDev})
+	doc.AddComponent(sbom.Component{Name: "fsevents", Version: "2.3.3", Supplier: "npm", Scope: sbom.ScopeOptional})
+	doc.AddComponent(sbom.Component{Name: "cc", Version: "1.0.83", Supplier: "cargo", Scope: sbom.ScopeBuild})
+
+	output, err := NewSPDXFormatter().Format(doc)
+	if err != nil {
+		t.Fatalf("Failed to format: %v", err)
+	}
+
+	for _, line := range []string{
+		"SPDXID: SPDXRef-Root",
+		"Relationship: SPDXRef-Package-1 DEV_DEPENDENCY_OF SPDXRef-Root",
+		"Relationship: SPDXRef-Package-2 OPTIONAL_DEPENDENCY_OF SPDXRef-Root",
+		"Relationship: SPDXRef-Package-3 BUILD_DEPENDENCY_OF SPDXRef-Root",
+	} {
+		if !strings.Contains(output, line+"\n") {
+			t.Errorf("Expected line %q in output:\n%s", line, output)
+		}
+	}
+	if strings.Contains(output, "SPDXRef-Package-0 ") {
+		t.Errorf("Expected no scope relationship for a runtime dependency:\n%s", output)
+	}
+
+	doc.DropRoot()
+	output, err = NewSPDXFormatter().Format(doc)
+	if err != nil {
+		t.Fatalf("Failed to format: %v", err)
+	}
+	if strings.Contains(output, "_DEPENDENCY_OF") {
+		t.Errorf("Expected no scope relationships without a root package:\n%s", output)
+	}
+}
+
+func TestSPDXFormatter_Supplier(t *testing.T) {
+	doc := sbom.New("web", "1.0.0", "serial-001")
+	doc.AddComponent(sbom.Component{Name: "express", Version: "4.18.2", Supplier: "npm", PURL: "pkg:npm/express@4.18.2"})
+	doc.AddComponent(sbom.Component{Name: "internal-lib", Version: "0.1.0"})
+
+	output, err := NewSPDXFormatter().Format(doc)
+	if err != nil {
+		t.Fatalf("Failed to format: %v", err)
+	}
+
+	if got := strings.Count(output, "PackageSupplier:"); got != doc.Count() {
+		t.Errorf("Expected one PackageSupplier per package, got %d:\n%s", got, output)
+	}
+	for _, line := range []string{
+		"PackageSupplier: Organization: npm",
+		"PackageDownloadLocation: pkg:npm/express@4.18.2",
+		"PackageSupplier: NOASSERTION",
+		"PackageDownloadLocation: NOASSERTION",
+	} {
+		if !strings.Contains(output, line+"\n") {
+			t.Errorf("Expected line %q in output:\n%s", line, output)
+		}
+	}
+}
+
+func TestSPDXFormatter_Creator(t *testing.T) {
+	doc := sbom.New("sbomgen", "9.9.9", "serial-001")
+	doc.ToolVersion = "9.9.9"
+	doc.SetRoot(sbom.Component{Name: "web", Version: "1.2.3"})
+
+	output, err := NewSPDXFormatter().Format(doc)
+	if err != nil {
+		t.Fatalf("Failed to format: %v", err)
+	}
+	if !strings.Contains(output, "Creator: Tool: sbomgen-9.9.9\n") {
+		t.Errorf("Expected the tool version, not the project's, in the creator:\n%s", output)
+	}
+
+	output, err = NewSPDXJSONFormatter().Format(doc)
+	if err != nil {
+		t.Fatalf("Failed to format: %v", err)
+	}
+	if !strings.Contains(output, `"Tool: sbomgen-9.9.9"`) {
+		t.Errorf("Expected the tool version in the SPDX JSON creators:\n%s", output)
+	}
+	output, err = NewCycloneDXFormatter().Format(doc)
+	if err != nil {
+		t.Fatalf("Failed to format: %v", err)
+	}
+	var cdx struct {
+		Metadata struct {
+			Tools struct {
+				Components []struct{ Name, Version string }
+			}
+		}
+	}
+	if err := json.Unmarshal([]byte(output), &cdx); err != nil {
+		t.Fatalf("Failed to parse CycloneDX: %v", err)
+	}
+	if tools := cdx.Metadata.Tools.Components; len(tools) != 1 || tools[0].Version != "9.9.9" {
+		t.Errorf("Expected sbomgen 9.9.9 as the CycloneDX tool, got %+v", tools)
+	}
+}
+
+func TestSPDXFormatter_DocumentNamespace(t *testing.T) {
+	namespace := func(f *SPDXFormatter, doc *sbom.SBOM) string {
+		output, err := f.Format(doc)
+		if err != nil {
+			t.Fatalf("Failed to format: %v", err)
+		}
+		for _, line := range strings.Split(output, "\n") {
+			if value, ok := strings.CutPrefix(line, "DocumentNamespace: "); ok {
+				return value
+			}
+		}
+		t.Fatalf("No DocumentNamespace in output:\n%s", output)
+		return ""
+	}
+
+	first := sbom.New("web", "1.0.0", sbom.NewSerialNumber())
+	second := sbom.New("web", "1.0.0", sbom.NewSerialNumber())
+	a, b := namespace(NewSPDXFormatter(), first), namespace(NewSPDXFormatter(), second)
+	if a == b {
+		t.Errorf("Expected two builds to get distinct namespaces, got %s twice", a)
+	}
+	if want := DefaultDocumentNamespaceBase + "/web-" + strings.TrimPrefix(first.SerialNumber, "urn:uuid:"); a != want {
+		t.Errorf("Expected namespace %s, got %s", want, a)
+	}
+
+	custom := &SPDXFormatter{specVersion: "2.3", DocumentNamespaceBase: "https://sbom.acme.example/spdx/"}
+	if got := namespace(custom, first); !strings.HasPrefix(got, "https://sbom.acme.example/spdx/web-") {
+		t.Errorf("Expected the configured base, got %s", got)
+	}
+
+	unnumbered := sbom.New("my app", "1.0.0", "")
+	if got := namespace(NewSPDXFormatter(), unnumbered); got != namespace(NewSPDXFormatter(), unnumbered) || !strings.Contains(got, "/my%20app-") {
+		t.Errorf("Expected a stable, escaped namespace without a serial number, got %s", got)
+	}
+
+	for _, base := range []string{"sbom.acme.example", "ftp://acme.example", "https://acme.example/?q=1"} {
+		if err := ValidateDocumentNamespaceBase(base); err == nil {
+			t.Errorf("Expected %q to be rejected", base)
+		}
+	}
+	if err := ValidateDocumentNamespaceBase("https://sbom.acme.example/spdx"); err != nil {
+		t.Errorf("Expected a valid base, got %v", err)
+	}
+}
+
+func TestSPDXJSONFormatter(t *testing.T) {
+	doc := sbom.New("web", "1.0.0", "serial-001")
+	doc.AddComponent(sbom.Component{
+		Name:     "express",
+		Version:  "4.18.2",
+		Supplier: "npm",
+		License:  "MIT",
+		PURL:     "pkg:npm/express@4.18.2",
+		CPE:      "cpe:2.3:a:expressjs:express:4.18.2:*:*:*:*:*:*:*",
+		Hashes:   []sbom.Hash{{Algorithm: "SHA-256", Value: "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"}},
+	})
+	doc.AddComponent(sbom.Component{Name: "internal-lib", Version: "0.1.0"})
+
+	output, err := NewSPDXJSONFormatter().Format(doc)
+	if err != nil {
+		t.Fatalf("Failed to format: %v", err)
+	}
+
+	var parsed struct {
+		SPDXVersion       string `json:"spdxVersion"`
+		DataLicense       string `json:"dataLicense"`
+		SPDXID            string `json:"SPDXID"`
+		Name              string `json:"name"`
+		DocumentNamespace string `json:"documentNamespace"`
+		CreationInfo      struct {
+			Created  string   `json:"created"`
+			Creators []string `json:"creators"`
+		} `json:"creationInfo"`
+		Packages []struct {
+			SPDXID           string `json:"SPDXID"`
+			Name             string `json:"name"`
+			VersionInfo      string `json:"versionInfo"`
+			Supplier         string `json:"supplier"`
+			LicenseConcluded string `json:"licenseConcluded"`
+			DownloadLocation string `json:"downloadLocation"`
+			Checksums        []struct {
+				Algorithm string `json:"algorithm"`
+			} `json:"checksums"`
+			ExternalRefs []struct {
+				ReferenceCategory string `json:"referenceCategory"`
+				ReferenceType     string `json:"referenceType"`
+				ReferenceLocator  string `json:"referenceLocator"`
+			} `json:"externalRefs"`
+		} `json:"packages"`
+	}
+	if err := json.Unmarshal([]byte(output), &parsed); err != nil {
+		t.Fatalf("Failed to parse SPDX JSON: %v", err)
+	}
+	if parsed.SPDXVersion != "SPDX-2.3" || parsed.DataLicense != "CC0-1.0" || parsed.SPDXID != "SPDXRef-DOCUMENT" || parsed.Name != "web" {
+		t.Errorf("Expected an SPDX 2.3 document named web, got %+v", parsed)
+	}
+	if !strings.HasPrefix(parsed.DocumentNamespace, DefaultDocumentNamespaceBase+"/web-") {
+		t.Errorf("Expected a namespace under the default base, got %q", parsed.DocumentNamespace)
+	}
+	if parsed.CreationInfo.Created == "" || len(parsed.CreationInfo.Creators) != 1 {
+		t.Errorf("Expected creation info, got %+v", parsed.CreationInfo)
+	}
+	if len(parsed.Packages) != doc.Count() {
+		t.Fatalf("Expected %d packages, got %d", doc.Count(), len(parsed.Packages))
+	}
+
+	express := parsed.Packages[0]
+	if express.SPDXID != "SPDXRef-Package-0" || express.Name != "express" || express.VersionInfo != "4.18.2" {
+		t.Errorf("Expected the express package, got %+v", express)
+	}
+	if express.Supplier != "Organization: npm" || express.LicenseConcluded != "MIT" || express.DownloadLocation != "pkg:npm/express@4.18.2" {
+		t.Errorf("Expected supplier, license and download location for express, got %+v", express)
+	}
+	if len(express.Checksums) != 1 || express.Checksums[0].Algorithm != "SHA256" {
+		t.Errorf("Expected a SHA256 checksum, got %+v", express.Checksums)
+	}
+	if len(express.ExternalRefs) != 2 ||
+		express.ExternalRefs[0].ReferenceCategory != "PACKAGE-MANAGER" || express.ExternalRefs[0].ReferenceType != "purl" || express.ExternalRefs[0].ReferenceLocator != "pkg:npm/express@4.18.2" ||
+		express.ExternalRefs[1].ReferenceCategory != "SECURITY" || express.ExternalRefs[1].ReferenceType != "cpe23Type" {
+		t.Errorf("Expected purl and cpe23Type references, got %+v", express.ExternalRefs)
+	}
+
+	internal := parsed.Packages[1]
+	if internal.Supplier != "NOASSERTION" || internal.LicenseConcluded != "NOASSERTION" || internal.DownloadLocation != "NOASSERTION" || len(internal.ExternalRefs) != 0 {
+		t.Errorf("Expected NOASSERTION for unknown fields, got %+v", internal)
+	}
+
+	violations, err := validator.Validate(validator.SPDX, []byte(output))
+	if err != nil {
+		t.Fatalf("Failed to validate SPDX JSON: %v", err)
+	}
+	if len(violations) > 0 {
+		t.Errorf("Expected the output to match the SPDX 2.3 schema, got %v", violations)
+	}
+}
+
+func TestSPDXJSONFormatter_ScopeRelationships(t *testing.T) {
+	doc := sbom.New("web", "1.0.0", "serial-001")
+	doc.SetRoot(sbom.Component{Name: "web", Version: "1.0.0"})
+	doc.AddComponent(sbom.Component{Name: "express", Version: "4.18.2"})
+	doc.AddComponent(sbom.Component{Name: "jest", Version: "29.7.0", Scope: sbom.ScopeDev})
+
+	output, err := NewSPDXJSONFormatter().Format(doc)
+	if err != nil {
+		t.Fatalf("Failed to format: %v", err)
+	}
+	var parsed struct {
+		Packages []struct {
+			SPDXID                string `json:"SPDXID"`
+			PrimaryPackagePurpose string `json:"primaryPackagePurpose"`
+		} `json:"packages"`
+		Relationships []spdxRelationship `json:"relationships"`
+	}
+	if err := json.Unmarshal([]byte(output), &parsed); err != nil {
+		t.Fatalf("Failed to parse SPDX JSON: %v", err)
+	}
+	if len(parsed.Packages) != doc.Count()+1 || parsed.Packages[0].SPDXID != spdxRootID || parsed.Packages[0].PrimaryPackagePurpose != "APPLICATION" {
+		t.Errorf("Expected the root package first, got %+v", parsed.Packages)
+	}
+	want := []spdxRelationship{
+		{Element: "SPDXRef-DOCUMENT", Type: "DESCRIBES", Related: spdxRootID},
+		{Element: "SPDXRef-Package-1", Type: "DEV_DEPENDENCY_OF", Related: spdxRootID},
+	}
+	if !reflect.DeepEqual(parsed.Relationships, want) {
+		t.Errorf("Expected %+v, got %+v", want, parsed.Relationships)
+	}
+}
+
+func TestSPDXFormatter_Relationships(t *testing.T) {
+	doc := sbom.New("web", "1.0.0", "serial-001")
+	doc.SetRoot(sbom.Component{Name: "web", Version: "1.0.0", PURL: "pkg:npm/web@1.0.0"})
+	doc.AddComponent(sbom.Component{Name: "express", Version: "4.18.2", PURL: "pkg:npm/express@4.18.2"})
+	doc.AddComponent(sbom.Component{Name: "body-parser", Version: "1.20.2"})
+	doc.AddComponent(sbom.Component{Name: "jest", Version: "29.7.0", PURL: "pkg:npm/jest@29.7.0", Scope: sbom.ScopeDev})
+	doc.AddRelationship("web", "pkg:npm/express@4.18.2", sbom.DependsOn)
+	doc.AddRelationship("pkg:npm/express@4.18.2", "body-parser@1.20.2", sbom.DependsOn)
+	// Already implied by the scope of jest.
+	doc.AddRelationship("pkg:npm/jest@29.7.0", "web", sbom.DevDependencyOf)
+	// Refers to a component that is not in the document.
+	doc.AddRelationship("web", "pkg:npm/left-pad@1.3.0", sbom.DependsOn)
+
+	output, err := NewSPDXFormatter().Format(doc)
+	if err != nil {
+		t.Fatalf("Failed to format: %v", err)
+	}
+	for _, line := range []string{
+		"Relationship: SPDXRef-DOCUMENT DESCRIBES SPDXRef-Root",
+		"Relationship: SPDXRef-Root DEPENDS_ON SPDXRef-Package-0",
+		"Relationship: SPDXRef-Package-0 DEPENDS_ON SPDXRef-Package-1",
+	} {
+		if !strings.Contains(output, line+"\n") {
+			t.Errorf("Expected line %q in output:\n%s", line, output)
+		}
+	}
+	if got := strings.Count(output, "DEV_DEPENDENCY_OF"); got != 1 {
+		t.Errorf("Expected the dev dependency once, got %d:\n%s", got, output)
+	}
+	if got := strings.Count(output, "Relationship:"); got != 4 {
+		t.Errorf("Expected 4 relationships, got %d:\n%s", got, output)
+	}
+
+	output, err = NewSPDXJSONFormatter().Format(doc)
+	if err != nil {
+		t.Fatalf("Failed to format: %v", err)
+	}
+	if !strings.Contains(output, `"spdxElementId": "SPDXRef-Package-0",
+      "relationshipType": "DEPENDS_ON",
+      "relatedSpdxElement": "SPDXRef-Package-1"`) {
+		t.Errorf("Expected the express dependency in SPDX JSON:\n%s", output)
+	}
+}
@@ -0,0 +1,158 @@
+package formatter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hallucinaut/sbomgen/pkg/sbom"
+)
+
+// spdxDocument is an SPDX JSON document.
+type spdxDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo   `json:"creationInfo"`
+	Packages          []spdxPackage      `json:"packages"`
+	Relationships     []spdxRelationship `json:"relationships,omitempty"`
+}
+
+// spdxCreationInfo records when and by what an SPDX document was created.
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+// spdxPackage is an SPDX package.
+type spdxPackage struct {
+	SPDXID                string            `json:"SPDXID"`
+	Name                  string            `json:"name"`
+	VersionInfo           string            `json:"versionInfo,omitempty"`
+	Supplier              string            `json:"supplier"`
+	DownloadLocation      string            `json:"downloadLocation"`
+	FilesAnalyzed         bool              `json:"filesAnalyzed"`
+	Homepage              string            `json:"homepage,omitempty"`
+	LicenseConcluded      string            `json:"licenseConcluded"`
+	Description           string            `json:"description,omitempty"`
+	Checksums             []spdxChecksum    `json:"checksums,omitempty"`
+	ExternalRefs          []spdxExternalRef `json:"externalRefs,omitempty"`
+	PrimaryPackagePurpose string            `json:"primaryPackagePurpose,omitempty"`
+}
+
+// spdxChecksum is a package checksum.
+type spdxChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+// spdxExternalRef is a package external reference, such as its PURL.
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+// SPDXJSONFormatter formats SBOM as an SPDX JSON document, the SPDX
+// serialization most consumers expect. It describes the same packages and
+// relationships as the tag-value SPDXFormatter.
+type SPDXJSONFormatter struct {
+	specVersion string
+	// DocumentNamespaceBase is the URL document namespaces are built
+	// under, DefaultDocumentNamespaceBase if empty.
+	DocumentNamespaceBase string
+	// OmitRelationships leaves out the dependency relationships,
+	// including those derived from component scopes. The DESCRIBES
+	// relationships SPDX requires are always written.
+	OmitRelationships bool
+}
+
+func NewSPDXJSONFormatter() *SPDXJSONFormatter {
+	return &SPDXJSONFormatter{specVersion: specVersions[SPDXJSON][0]}
+}
+
+func (f *SPDXJSONFormatter) Name() string {
+	return "spdx-json"
+}
+
+func (f *SPDXJSONFormatter) Format(sbom *sbom.SBOM) (string, error) {
+	sbom, err := withNormalizedRelationships(sbom)
+	if err != nil {
+		return "", err
+	}
+	doc := spdxDocument{
+		SPDXVersion:       "SPDX-" + f.specVersion,
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              sbom.Name,
+		DocumentNamespace: spdxDocumentNamespace(f.DocumentNamespaceBase, sbom),
+		CreationInfo: spdxCreationInfo{
+			Created:  sbom.Created.UTC().Format("2006-01-02T15:04:05Z"),
+			Creators: []string{spdxCreator(sbom)},
+		},
+		Packages: []spdxPackage{},
+	}
+	if sbom.Root != nil {
+		doc.Packages = append(doc.Packages, f.spdxPackage(*sbom.Root, spdxRootID, "APPLICATION"))
+	}
+	for i, comp := range sbom.Components {
+		doc.Packages = append(doc.Packages, f.spdxPackage(comp, spdxPackageID(i), "LIBRARY"))
+	}
+	doc.Relationships = spdxRelationships(sbom, f.OmitRelationships)
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize to SPDX JSON: %w", err)
+	}
+	return string(data), nil
+}
+
+func (f *SPDXJSONFormatter) spdxPackage(comp sbom.Component, id, purpose string) spdxPackage {
+	pkg := spdxPackage{
+		SPDXID:           id,
+		Name:             comp.Name,
+		VersionInfo:      comp.Version,
+		Supplier:         spdxSupplier(comp.Supplier),
+		DownloadLocation: spdxDownloadLocation(comp),
+		Homepage:         comp.Metadata.HomepageURL,
+		LicenseConcluded: comp.License,
+		Description:      comp.Metadata.Description,
+		Checksums:        spdxChecksums(comp.Hashes),
+	}
+	if pkg.LicenseConcluded == "" {
+		pkg.LicenseConcluded = "NOASSERTION"
+	}
+	for _, ref := range append([]string{comp.PURL}, comp.ExternalRefs...) {
+		if ref != "" {
+			pkg.ExternalRefs = append(pkg.ExternalRefs, spdxExternalRef{ReferenceCategory: "PACKAGE-MANAGER", ReferenceType: "purl", ReferenceLocator: ref})
+		}
+	}
+	if comp.CPE != "" {
+		pkg.ExternalRefs = append(pkg.ExternalRefs, spdxExternalRef{ReferenceCategory: "SECURITY", ReferenceType: "cpe23Type", ReferenceLocator: comp.CPE})
+	}
+	if f.specVersion != "2.2" {
+		pkg.PrimaryPackagePurpose = purpose
+	}
+	return pkg
+}
+
+// spdxChecksums converts hashes into SPDX checksums, whose algorithm names
+// drop the dash of SHA-256 and friends and whose values are hex. Hashes SPDX
+// cannot represent are left out.
+func spdxChecksums(hashes []sbom.Hash) []spdxChecksum {
+	var checksums []spdxChecksum
+	for _, h := range hashes {
+		alg := sbom.CanonicalHashAlgorithm(h.Algorithm)
+		value := hexDigest(h.Value)
+		if alg == "" || value == "" {
+			continue
+		}
+		if strings.HasPrefix(alg, "SHA-") {
+			alg = "SHA" + strings.TrimPrefix(alg, "SHA-")
+		}
+		checksums = append(checksums, spdxChecksum{Algorithm: alg, ChecksumValue: value})
+	}
+	return checksums
+}
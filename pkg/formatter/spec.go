@@ -0,0 +1,54 @@
+package formatter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// specVersions lists the specification versions the standards-based formats
+// can emit. The first entry is the default.
+var specVersions = map[Format][]string{
+	SPDX:      {"2.2", "2.3"},
+	SPDXJSON:  {"2.3", "2.2"},
+	CycloneDX: {"1.5", "1.4"},
+}
+
+// SpecVersions returns the specification versions format can emit, or nil
+// if it is not a versioned standard.
+func SpecVersions(format Format) []string {
+	return specVersions[format]
+}
+
+// NewVersionedFormatter returns the formatter for format that emits the
+// given specification version, e.g. SPDX 2.3 or CycloneDX 1.4. An empty
+// version selects the default. It fails for formats that are not versioned
+// standards and for versions they do not support.
+func NewVersionedFormatter(format Format, version string) (Formatter, error) {
+	versions, ok := specVersions[format]
+	if !ok {
+		return nil, fmt.Errorf("format %s has no specification versions", format)
+	}
+	version = strings.TrimPrefix(strings.TrimPrefix(version, "SPDX-"), "v")
+	if version == "" {
+		version = versions[0]
+	}
+
+	supported := false
+	for _, v := range versions {
+		if v == version {
+			supported = true
+			break
+		}
+	}
+	if !supported {
+		return nil, fmt.Errorf("unsupported %s version %s (supported: %s)", format, version, strings.Join(versions, ", "))
+	}
+
+	switch format {
+	case SPDX:
+		return &SPDXFormatter{specVersion: version}, nil
+	case SPDXJSON:
+		return &SPDXJSONFormatter{specVersion: version}, nil
+	}
+	return &CycloneDXFormatter{specVersion: version}, nil
+}
@@ -0,0 +1,87 @@
+package formatter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hallucinaut/sbomgen/pkg/sbom"
+)
+
+func TestNewVersionedFormatter_SPDX(t *testing.T) {
+	sbomDoc := sbom.New("test-app", "1.0.0", "serial-001")
+	sbomDoc.AddComponent(sbom.Component{Name: "lib-a", Version: "1.0.0", Supplier: "npm"})
+
+	tests := []struct {
+		version  string
+		expected string
+		purpose  bool
+	}{
+		{"", "SPDXVersion: SPDX-2.2", false},
+		{"2.2", "SPDXVersion: SPDX-2.2", false},
+		{"2.3", "SPDXVersion: SPDX-2.3", true},
+		{"SPDX-2.3", "SPDXVersion: SPDX-2.3", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			f, err := NewVersionedFormatter(SPDX, tt.version)
+			if err != nil {
+				t.Fatalf("Failed to create formatter: %v", err)
+			}
+			output, err := f.Format(sbomDoc)
+			if err != nil {
+				t.Fatalf("Failed to format: %v", err)
+			}
+			if !strings.Contains(output, tt.expected+"\n") {
+				t.Errorf("Expected '%s' in output, got:\n%s", tt.expected, output)
+			}
+			if strings.Contains(output, "PrimaryPackagePurpose:") != tt.purpose {
+				t.Errorf("Expected PrimaryPackagePurpose only for SPDX 2.3, got:\n%s", output)
+			}
+		})
+	}
+}
+
+func TestNewVersionedFormatter_CycloneDX(t *testing.T) {
+	f, err := NewVersionedFormatter(CycloneDX, "1.4")
+	if err != nil {
+		t.Fatalf("Failed to create formatter: %v", err)
+	}
+	if cdx, ok := f.(*CycloneDXFormatter); !ok || cdx.specVersion != "1.4" {
+		t.Errorf("Expected CycloneDX 1.4 formatter, got %#v", f)
+	}
+	if NewCycloneDXFormatter().specVersion != "1.5" {
+		t.Error("Expected CycloneDX 1.5 by default")
+	}
+}
+
+func TestNewVersionedFormatter_SPDXJSON(t *testing.T) {
+	f, err := NewVersionedFormatter(SPDXJSON, "2.2")
+	if err != nil {
+		t.Fatalf("Failed to create formatter: %v", err)
+	}
+	if spdx, ok := f.(*SPDXJSONFormatter); !ok || spdx.specVersion != "2.2" {
+		t.Errorf("Expected SPDX JSON 2.2 formatter, got %#v", f)
+	}
+	if NewSPDXJSONFormatter().specVersion != "2.3" {
+		t.Error("Expected SPDX JSON 2.3 by default")
+	}
+}
+
+func TestNewVersionedFormatter_Unsupported(t *testing.T) {
+	tests := []struct {
+		format  Format
+		version string
+	}{
+		{SPDX, "1.5"},
+		{CycloneDX, "2.3"},
+		{JSON, "1.0"},
+		{Markdown, ""},
+	}
+
+	for _, tt := range tests {
+		if _, err := NewVersionedFormatter(tt.format, tt.version); err == nil {
+			t.Errorf("Expected error for %s version '%s'", tt.format, tt.version)
+		}
+	}
+}
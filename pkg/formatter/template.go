@@ -0,0 +1,93 @@
+package formatter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/hallucinaut/sbomgen/pkg/sbom"
+)
+
+// TemplateFormatter renders an SBOM through a user-supplied text/template.
+// The template receives the *sbom.SBOM as its data and may use the helper
+// functions in templateFuncs.
+type TemplateFormatter struct {
+	tmpl *template.Template
+}
+
+// NewTemplateFormatter parses text as a Go text/template. name is used in
+// error messages, typically the template's file name.
+func NewTemplateFormatter(name, text string) (*TemplateFormatter, error) {
+	tmpl, err := template.New(name).Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+	return &TemplateFormatter{tmpl: tmpl}, nil
+}
+
+func (f *TemplateFormatter) Name() string {
+	return "template"
+}
+
+func (f *TemplateFormatter) Format(sbom *sbom.SBOM) (string, error) {
+	var sb strings.Builder
+	if err := f.tmpl.Execute(&sb, sbom); err != nil {
+		return "", fmt.Errorf("failed to execute template: %w", err)
+	}
+	return sb.String(), nil
+}
+
+// templateFuncs are available to templates in addition to the text/template
+// builtins such as len and index.
+var templateFuncs = template.FuncMap{
+	"byLicense":  groupComponents(GroupByLicense),
+	"bySupplier": groupComponents(GroupBySupplier),
+	"sortBy":     sortComponents,
+	"join":       strings.Join,
+	"upper":      strings.ToUpper,
+	"lower":      strings.ToLower,
+}
+
+// groupComponents returns a template function that groups components by
+// key, using "Unknown" for empty keys. Ranging over the resulting map visits
+// the groups in sorted order.
+func groupComponents(groupBy GroupBy) func([]sbom.Component) (map[string][]sbom.Component, error) {
+	return func(components []sbom.Component) (map[string][]sbom.Component, error) {
+		groups, err := groupComponentsBy(groupBy, components)
+		if err != nil {
+			return nil, err
+		}
+		result := make(map[string][]sbom.Component, len(groups))
+		for _, group := range groups {
+			result[group.Key] = group.Components
+		}
+		return result, nil
+	}
+}
+
+// sortComponents returns a copy of components sorted by field, one of name,
+// version, supplier, license or purl.
+func sortComponents(field string, components []sbom.Component) ([]sbom.Component, error) {
+	var key func(sbom.Component) string
+	switch field {
+	case "name":
+		key = func(c sbom.Component) string { return c.Name }
+	case "version":
+		key = func(c sbom.Component) string { return c.Version }
+	case "supplier":
+		key = func(c sbom.Component) string { return c.Supplier }
+	case "license":
+		key = func(c sbom.Component) string { return c.License }
+	case "purl":
+		key = func(c sbom.Component) string { return c.PURL }
+	default:
+		return nil, fmt.Errorf("sortBy: unknown field %q", field)
+	}
+
+	sorted := append([]sbom.Component(nil), components...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return key(sorted[i]) < key(sorted[j])
+	})
+	return sorted, nil
+}
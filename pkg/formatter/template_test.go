@@ -0,0 +1,62 @@
+package formatter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hallucinaut/sbomgen/pkg/sbom"
+)
+
+func TestTemplateFormatter(t *testing.T) {
+	sbomDoc := sbom.New("test-app", "1.0.0", "serial-001")
+	sbomDoc.AddComponent(sbom.Component{Name: "zlib", Version: "1.3", Supplier: "cargo", License: "Zlib"})
+	sbomDoc.AddComponent(sbom.Component{Name: "express", Version: "4.18.0", Supplier: "npm", License: "MIT"})
+	sbomDoc.AddComponent(sbom.Component{Name: "lodash", Version: "4.17.21", Supplier: "npm", License: "MIT"})
+	sbomDoc.AddComponent(sbom.Component{Name: "mystery", Version: "0.1.0", Supplier: "pypi"})
+
+	text := `{{.Name}} has {{len .Components}} components
+{{range sortBy "name" .Components}}- {{.Name}}@{{.Version}}
+{{end}}{{range $license, $comps := byLicense .Components}}{{$license}}: {{len $comps}}
+{{end}}`
+
+	f, err := NewTemplateFormatter("report.tmpl", text)
+	if err != nil {
+		t.Fatalf("Failed to parse template: %v", err)
+	}
+	if f.Name() != "template" {
+		t.Errorf("Expected name 'template', got '%s'", f.Name())
+	}
+
+	output, err := f.Format(sbomDoc)
+	if err != nil {
+		t.Fatalf("Failed to format: %v", err)
+	}
+
+	expected := `test-app has 4 components
+- express@4.18.0
+- lodash@4.17.21
+- mystery@0.1.0
+- zlib@1.3
+MIT: 2
+Unknown: 1
+Zlib: 1
+`
+	if output != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, output)
+	}
+}
+
+func TestTemplateFormatter_Errors(t *testing.T) {
+	if _, err := NewTemplateFormatter("bad.tmpl", "{{.Name"); err == nil {
+		t.Error("Expected parse error for malformed template")
+	}
+
+	f, err := NewTemplateFormatter("field.tmpl", `{{range sortBy "colour" .Components}}{{end}}`)
+	if err != nil {
+		t.Fatalf("Failed to parse template: %v", err)
+	}
+	_, err = f.Format(sbom.New("test-app", "1.0.0", "serial-001"))
+	if err == nil || !strings.Contains(err.Error(), "failed to execute template") {
+		t.Errorf("Expected execution error, got %v", err)
+	}
+}
@@ -0,0 +1,46 @@
+package sbom
+
+// DiffResult lists the components that differ between two SBOMs.
+type DiffResult struct {
+	Added   []Component `json:"added" yaml:"added"`
+	Removed []Component `json:"removed" yaml:"removed"`
+}
+
+// HasAdditions reports whether the newer SBOM introduced any components.
+func (d DiffResult) HasAdditions() bool {
+	return len(d.Added) > 0
+}
+
+// Diff compares two SBOMs by component PURL, falling back to name and
+// version for components without one.
+func Diff(baseline, current *SBOM) DiffResult {
+	baseKeys := make(map[string]bool)
+	for _, comp := range baseline.Components {
+		baseKeys[componentKey(comp)] = true
+	}
+	currentKeys := make(map[string]bool)
+	for _, comp := range current.Components {
+		currentKeys[componentKey(comp)] = true
+	}
+
+	var result DiffResult
+	for _, comp := range current.Components {
+		if !baseKeys[componentKey(comp)] {
+			result.Added = append(result.Added, comp)
+		}
+	}
+	for _, comp := range baseline.Components {
+		if !currentKeys[componentKey(comp)] {
+			result.Removed = append(result.Removed, comp)
+		}
+	}
+	return result
+}
+
+// componentKey identifies a component for comparison purposes.
+func componentKey(comp Component) string {
+	if comp.PURL != "" {
+		return comp.PURL
+	}
+	return comp.Supplier + "/" + comp.Name + "@" + comp.Version
+}
@@ -0,0 +1,66 @@
+package sbom
+
+import "testing"
+
+func newDiffSBOM(purls ...string) *SBOM {
+	s := New("test-app", "1.0.0", "serial-001")
+	for _, purl := range purls {
+		s.AddComponent(Component{Name: purl, PURL: purl})
+	}
+	return s
+}
+
+func TestDiff_Added(t *testing.T) {
+	baseline := newDiffSBOM("pkg:npm/a@1.0.0")
+	current := newDiffSBOM("pkg:npm/a@1.0.0", "pkg:npm/b@2.0.0")
+
+	diff := Diff(baseline, current)
+	if !diff.HasAdditions() || len(diff.Added) != 1 {
+		t.Fatalf("Expected 1 added component, got %d", len(diff.Added))
+	}
+	if diff.Added[0].PURL != "pkg:npm/b@2.0.0" {
+		t.Errorf("Expected pkg:npm/b@2.0.0 added, got '%s'", diff.Added[0].PURL)
+	}
+	if len(diff.Removed) != 0 {
+		t.Errorf("Expected no removed components, got %d", len(diff.Removed))
+	}
+}
+
+func TestDiff_Removed(t *testing.T) {
+	baseline := newDiffSBOM("pkg:npm/a@1.0.0", "pkg:npm/b@2.0.0")
+	current := newDiffSBOM("pkg:npm/a@1.0.0")
+
+	diff := Diff(baseline, current)
+	if diff.HasAdditions() {
+		t.Errorf("Expected no additions, got %d", len(diff.Added))
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0].PURL != "pkg:npm/b@2.0.0" {
+		t.Errorf("Expected pkg:npm/b@2.0.0 removed, got %v", diff.Removed)
+	}
+}
+
+func TestDiff_Identical(t *testing.T) {
+	baseline := newDiffSBOM("pkg:npm/a@1.0.0", "pkg:npm/b@2.0.0")
+	current := newDiffSBOM("pkg:npm/b@2.0.0", "pkg:npm/a@1.0.0")
+
+	diff := Diff(baseline, current)
+	if diff.HasAdditions() || len(diff.Removed) != 0 {
+		t.Errorf("Expected identical SBOMs, got %d added and %d removed", len(diff.Added), len(diff.Removed))
+	}
+}
+
+func TestLoadJSON(t *testing.T) {
+	data := []byte(`{"name":"test-app","version":"1.0.0","components":[{"name":"lib-a","version":"1.0.0","purl":"pkg:npm/lib-a@1.0.0"}]}`)
+
+	s, err := LoadJSON(data)
+	if err != nil {
+		t.Fatalf("Failed to load: %v", err)
+	}
+	if s.Count() != 1 || s.Components[0].PURL != "pkg:npm/lib-a@1.0.0" {
+		t.Errorf("Expected one component, got %v", s.Components)
+	}
+
+	if _, err := LoadJSON([]byte("not json")); err == nil {
+		t.Error("Expected error for invalid JSON")
+	}
+}
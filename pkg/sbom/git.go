@@ -0,0 +1,17 @@
+package sbom
+
+// GitDependency is the description analyzers give components that are
+// installed from a git repository, usually at a branch, rather than from a
+// registry at an immutable version. Their version is the git ref.
+const GitDependency = "git/branch dependency"
+
+// GitDependencies returns the components installed from git.
+func (s *SBOM) GitDependencies() []Component {
+	var deps []Component
+	for _, comp := range s.Components {
+		if comp.Metadata.Description == GitDependency {
+			deps = append(deps, comp)
+		}
+	}
+	return deps
+}
@@ -0,0 +1,85 @@
+package sbom
+
+import "strings"
+
+// hashAlgorithms maps normalized spellings onto the CycloneDX/SPDX style
+// names used in Hash.Algorithm.
+var hashAlgorithms = map[string]string{
+	"MD5":        "MD5",
+	"SHA1":       "SHA-1",
+	"SHA256":     "SHA-256",
+	"SHA384":     "SHA-384",
+	"SHA512":     "SHA-512",
+	"SHA3256":    "SHA3-256",
+	"SHA3384":    "SHA3-384",
+	"SHA3512":    "SHA3-512",
+	"BLAKE2B256": "BLAKE2b-256",
+	"BLAKE2B384": "BLAKE2b-384",
+	"BLAKE2B512": "BLAKE2b-512",
+	"BLAKE3":     "BLAKE3",
+}
+
+// weakHashAlgorithms are broken for collision resistance and should not be
+// relied on to identify a component.
+var weakHashAlgorithms = map[string]bool{
+	"MD5":   true,
+	"SHA-1": true,
+}
+
+// CanonicalHashAlgorithm returns the standard spelling of a hash algorithm
+// name such as "sha256" or "SHA-256", or an empty string if it is unknown.
+func CanonicalHashAlgorithm(name string) string {
+	key := strings.ToUpper(strings.NewReplacer("-", "", "_", "").Replace(strings.TrimSpace(name)))
+	return hashAlgorithms[key]
+}
+
+// IsWeakHash reports whether the algorithm of h is MD5 or SHA-1.
+func IsWeakHash(h Hash) bool {
+	return weakHashAlgorithms[CanonicalHashAlgorithm(h.Algorithm)]
+}
+
+// WeakHashes returns components whose only hashes use weak algorithms.
+// Components without hashes are not reported.
+func (s *SBOM) WeakHashes() []Component {
+	var result []Component
+	for _, comp := range s.Components {
+		if len(comp.Hashes) == 0 {
+			continue
+		}
+		weak := true
+		for _, h := range comp.Hashes {
+			if !IsWeakHash(h) {
+				weak = false
+				break
+			}
+		}
+		if weak {
+			result = append(result, comp)
+		}
+	}
+	return result
+}
+
+// SelectHashes returns the hashes of comp to emit. When preferred names an
+// algorithm the component has, only that hash is kept. Otherwise all hashes
+// are kept, minus weak ones if omitWeak is set.
+func SelectHashes(comp Component, preferred string, omitWeak bool) []Hash {
+	if preferred = CanonicalHashAlgorithm(preferred); preferred != "" {
+		for _, h := range comp.Hashes {
+			if CanonicalHashAlgorithm(h.Algorithm) == preferred {
+				return []Hash{h}
+			}
+		}
+	}
+
+	if !omitWeak {
+		return comp.Hashes
+	}
+	var kept []Hash
+	for _, h := range comp.Hashes {
+		if !IsWeakHash(h) {
+			kept = append(kept, h)
+		}
+	}
+	return kept
+}
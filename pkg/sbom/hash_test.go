@@ -0,0 +1,66 @@
+package sbom
+
+import "testing"
+
+func TestWeakHashes(t *testing.T) {
+	sbom := New("test-app", "1.0.0", "serial-001")
+	sbom.AddComponent(Component{Name: "md5-only", Hashes: []Hash{{Algorithm: "MD5", Value: "a"}}})
+	sbom.AddComponent(Component{Name: "sha1-and-md5", Hashes: []Hash{{Algorithm: "sha1", Value: "b"}, {Algorithm: "MD5", Value: "c"}}})
+	sbom.AddComponent(Component{Name: "mixed", Hashes: []Hash{{Algorithm: "SHA-256", Value: "d"}, {Algorithm: "MD5", Value: "e"}}})
+	sbom.AddComponent(Component{Name: "unhashed"})
+
+	weak := sbom.WeakHashes()
+	if len(weak) != 2 {
+		t.Fatalf("Expected 2 components with only weak hashes, got %d", len(weak))
+	}
+	if weak[0].Name != "md5-only" || weak[1].Name != "sha1-and-md5" {
+		t.Errorf("Unexpected weak-hash components %v", weak)
+	}
+}
+
+func TestSelectHashes(t *testing.T) {
+	comp := Component{
+		Name: "test-lib",
+		Hashes: []Hash{
+			{Algorithm: "MD5", Value: "def456"},
+			{Algorithm: "SHA-256", Value: "abc123"},
+			{Algorithm: "SHA-512", Value: "fed987"},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		preferred string
+		omitWeak  bool
+		expected  []string
+	}{
+		{"preferred present", "sha256", false, []string{"SHA-256"}},
+		{"preferred spelling", "SHA512", false, []string{"SHA-512"}},
+		{"preferred missing", "sha384", false, []string{"MD5", "SHA-256", "SHA-512"}},
+		{"preferred missing, omit weak", "sha384", true, []string{"SHA-256", "SHA-512"}},
+		{"no preference", "", false, []string{"MD5", "SHA-256", "SHA-512"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SelectHashes(comp, tt.preferred, tt.omitWeak)
+			if len(got) != len(tt.expected) {
+				t.Fatalf("Expected %v, got %v", tt.expected, got)
+			}
+			for i, h := range got {
+				if h.Algorithm != tt.expected[i] {
+					t.Errorf("Expected %s at %d, got %s", tt.expected[i], i, h.Algorithm)
+				}
+			}
+		})
+	}
+}
+
+func TestCanonicalHashAlgorithm(t *testing.T) {
+	if got := CanonicalHashAlgorithm("sha-1"); got != "SHA-1" {
+		t.Errorf("Expected 'SHA-1', got '%s'", got)
+	}
+	if got := CanonicalHashAlgorithm("crc32"); got != "" {
+		t.Errorf("Expected unknown algorithm to be empty, got '%s'", got)
+	}
+}
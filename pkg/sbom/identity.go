@@ -0,0 +1,139 @@
+package sbom
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// IdentityMap links PURLs that name the same software, such as a C library
+// vendored into a Python wheel or a package published to npm and a CDN.
+// Each key is linked to the PURL it maps to.
+type IdentityMap map[string]string
+
+// LoadIdentityMap reads a PURL to PURL mapping from a JSON or YAML file.
+// Both sides must be package URLs, and the links must not form a cycle.
+func LoadIdentityMap(path string) (IdentityMap, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read identity map: %w", err)
+	}
+
+	var identities IdentityMap
+	if err := yaml.Unmarshal(data, &identities); err != nil {
+		return nil, fmt.Errorf("failed to parse identity map: %w", err)
+	}
+	for from, to := range identities {
+		if !strings.HasPrefix(from, "pkg:") || !strings.HasPrefix(to, "pkg:") {
+			return nil, fmt.Errorf("identity map entries must link package URLs: %q: %q", from, to)
+		}
+		if _, err := identities.canonical(from); err != nil {
+			return nil, err
+		}
+	}
+	return identities, nil
+}
+
+// canonical follows the links from purl to the PURL it ultimately maps to.
+func (m IdentityMap) canonical(purl string) (string, error) {
+	for seen := 0; ; seen++ {
+		next, ok := m[purl]
+		if !ok || next == purl {
+			return purl, nil
+		}
+		if seen == len(m) {
+			return "", fmt.Errorf("identity map links %s in a cycle", purl)
+		}
+		purl = next
+	}
+}
+
+// LinkIdentities merges every component whose PURL the identity map links
+// to that of another component in the SBOM into that component, as
+// duplicates are merged by MergeUnion. The merged component keeps the PURLs
+// it absorbed in ExternalRefs, and relationships, annotations and
+// vulnerabilities referring to them are redirected to it. Components
+// linked to a PURL that no component has are left as they are. It returns
+// the number of components merged.
+func (s *SBOM) LinkIdentities(identities IdentityMap) int {
+	index := make(map[string]int)
+	for i, comp := range s.Components {
+		if _, ok := index[comp.PURL]; !ok && comp.PURL != "" {
+			index[comp.PURL] = i
+		}
+	}
+
+	redirect := make(map[string]string)
+	merged := make(map[int]bool)
+	for i, comp := range s.Components {
+		target, err := identities.canonical(comp.PURL)
+		if err != nil || target == comp.PURL || comp.PURL == "" {
+			continue
+		}
+		j, ok := index[target]
+		if !ok || j == i {
+			continue
+		}
+		into := &s.Components[j]
+		mergeComponent(into, comp, MergeUnion)
+		into.ExternalRefs = appendRefs(into.ExternalRefs, into.PURL, comp.PURL)
+		redirect[comp.PURL] = target
+		merged[i] = true
+	}
+	if len(merged) == 0 {
+		return 0
+	}
+
+	kept := s.Components[:0]
+	for i, comp := range s.Components {
+		if !merged[i] {
+			kept = append(kept, comp)
+		}
+	}
+	s.Components = kept
+
+	for i := range s.Relationships {
+		rel := &s.Relationships[i]
+		if to, ok := redirect[rel.RefA]; ok {
+			rel.RefA = to
+		}
+		if to, ok := redirect[rel.RefB]; ok {
+			rel.RefB = to
+		}
+	}
+	s.NormalizeRelationships()
+	for i := range s.Annotations {
+		if to, ok := redirect[s.Annotations[i].ComponentRef]; ok {
+			s.Annotations[i].ComponentRef = to
+		}
+	}
+	for i := range s.Vulnerabilities {
+		if to, ok := redirect[s.Vulnerabilities[i].Affects]; ok {
+			s.Vulnerabilities[i].Affects = to
+		}
+	}
+	return len(merged)
+}
+
+// appendRefs adds the refs that are neither own nor already listed to
+// list.
+func appendRefs(list []string, own string, refs ...string) []string {
+	for _, ref := range refs {
+		if ref == "" || ref == own || containsString(list, ref) {
+			continue
+		}
+		list = append(list, ref)
+	}
+	return list
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package sbom
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLinkIdentities(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "identities.yaml")
+	if err := os.WriteFile(file, []byte("pkg:generic/zlib@1.3.1: pkg:pypi/zlib-wrapper@1.3.1\n"), 0644); err != nil {
+		t.Fatalf("Failed to write identity map: %v", err)
+	}
+
+	identities, err := LoadIdentityMap(file)
+	if err != nil {
+		t.Fatalf("Failed to load identity map: %v", err)
+	}
+
+	doc := New("app", "1.0.0", "")
+	doc.AddComponent(Component{Name: "zlib-wrapper", Version: "1.3.1", PURL: "pkg:pypi/zlib-wrapper@1.3.1"})
+	doc.AddComponent(Component{Name: "zlib", Version: "1.3.1", License: "Zlib", PURL: "pkg:generic/zlib@1.3.1"})
+	doc.AddComponent(Component{Name: "requests", Version: "2.31.0", PURL: "pkg:pypi/requests@2.31.0"})
+	doc.AddRelationship("pkg:pypi/requests@2.31.0", "pkg:generic/zlib@1.3.1", DependsOn)
+
+	if linked := doc.LinkIdentities(identities); linked != 1 {
+		t.Errorf("Expected 1 component linked, got %d", linked)
+	}
+	if len(doc.Components) != 2 {
+		t.Fatalf("Expected 2 components after linking, got %d", len(doc.Components))
+	}
+	merged := doc.Components[0]
+	if merged.PURL != "pkg:pypi/zlib-wrapper@1.3.1" {
+		t.Errorf("Expected the linked component to keep its PURL, got '%s'", merged.PURL)
+	}
+	if len(merged.ExternalRefs) != 1 || merged.ExternalRefs[0] != "pkg:generic/zlib@1.3.1" {
+		t.Errorf("Expected the merged PURL as an external reference, got %v", merged.ExternalRefs)
+	}
+	if merged.License != "Zlib" {
+		t.Errorf("Expected the license of the merged component, got '%s'", merged.License)
+	}
+	if rel := doc.Relationships[0]; rel.RefB != "pkg:pypi/zlib-wrapper@1.3.1" {
+		t.Errorf("Expected the relationship to point at the linked component, got '%s'", rel.RefB)
+	}
+
+	cyclic := filepath.Join(dir, "cyclic.json")
+	if err := os.WriteFile(cyclic, []byte(`{"pkg:npm/a@1.0.0": "pkg:npm/b@1.0.0", "pkg:npm/b@1.0.0": "pkg:npm/a@1.0.0"}`), 0644); err != nil {
+		t.Fatalf("Failed to write identity map: %v", err)
+	}
+	if _, err := LoadIdentityMap(cyclic); err == nil {
+		t.Error("Expected error for identities linked in a cycle")
+	}
+}
@@ -0,0 +1,57 @@
+package sbom
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ReadJSONL decodes a stream of components written one JSON object per
+// line (JSONL, also known as NDJSON), calling visit for each in order.
+// Lines are read one at a time, so the stream is never held in memory as a
+// whole. Blank lines are skipped, and the first error from decoding or from
+// visit stops the read.
+func ReadJSONL(r io.Reader, visit func(Component) error) error {
+	br := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		data, err := br.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("failed to read JSONL: %w", err)
+		}
+		if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 {
+			var comp Component
+			if jsonErr := json.Unmarshal(trimmed, &comp); jsonErr != nil {
+				return fmt.Errorf("failed to parse JSONL line %d: %w", line, jsonErr)
+			}
+			if visitErr := visit(comp); visitErr != nil {
+				return visitErr
+			}
+		}
+		if err != nil {
+			return nil
+		}
+	}
+}
+
+// JSONLWriter writes components one compact JSON object per line, the
+// format ReadJSONL reads.
+type JSONLWriter struct {
+	enc *json.Encoder
+}
+
+func NewJSONLWriter(w io.Writer) *JSONLWriter {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return &JSONLWriter{enc: enc}
+}
+
+// Write encodes comp as one line.
+func (w *JSONLWriter) Write(comp Component) error {
+	if err := w.enc.Encode(comp); err != nil {
+		return fmt.Errorf("failed to write JSONL: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package sbom
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestReadJSONL(t *testing.T) {
+	input := `{"name": "lodash", "version": "4.17.21", "purl": "pkg:npm/lodash@4.17.21"}
+
+{"name": "requests", "version": "2.28.0", "purl": "pkg:pypi/requests@2.28.0"}`
+
+	var components []Component
+	err := ReadJSONL(strings.NewReader(input), func(comp Component) error {
+		components = append(components, comp)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to read JSONL: %v", err)
+	}
+	if len(components) != 2 || components[1].Name != "requests" {
+		t.Fatalf("Expected lodash and requests, got %+v", components)
+	}
+
+	var buf bytes.Buffer
+	out := NewJSONLWriter(&buf)
+	for _, comp := range components {
+		if err := out.Write(comp); err != nil {
+			t.Fatalf("Failed to write JSONL: %v", err)
+		}
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != 2 {
+		t.Errorf("Expected one line per component, got %d:\n%s", lines, buf.String())
+	}
+}
+
+func TestReadJSONL_InvalidLine(t *testing.T) {
+	input := "{\"name\": \"lodash\"}\n{not json}\n"
+	err := ReadJSONL(strings.NewReader(input), func(Component) error { return nil })
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("Expected an error naming line 2, got %v", err)
+	}
+}
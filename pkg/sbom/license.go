@@ -0,0 +1,253 @@
+package sbom
+
+import "strings"
+
+// LicenseSource records where a component's license came from, so that
+// reviewers know which licenses to double-check.
+type LicenseSource string
+
+const (
+	// LicenseSourceDeclared is a license stated by the manifest, lockfile or
+	// package metadata the component was read from.
+	LicenseSourceDeclared LicenseSource = "declared"
+	// LicenseSourceInferred is a license guessed from the text of a license file.
+	LicenseSourceInferred LicenseSource = "inferred"
+	// LicenseSourceOverride is a license set by a --license-override mapping.
+	LicenseSourceOverride LicenseSource = "override"
+	// LicenseSourceRegistry is a license fetched from a package registry during
+	// enrichment.
+	LicenseSourceRegistry LicenseSource = "registry"
+)
+
+// Conflict describes two components whose licenses may not be combinable.
+type Conflict struct {
+	A      Component `json:"a" yaml:"a"`
+	B      Component `json:"b" yaml:"b"`
+	Reason string    `json:"reason" yaml:"reason"`
+}
+
+// licenseAliases maps deprecated or shorthand SPDX identifiers onto the
+// identifiers used as keys in incompatibleLicenses.
+var licenseAliases = map[string]string{
+	"GPL-2.0":  "GPL-2.0-only",
+	"GPL-3.0":  "GPL-3.0-only",
+	"AGPL-3.0": "AGPL-3.0-only",
+	"LGPL-2.1": "LGPL-2.1-only",
+	"LGPL-3.0": "LGPL-3.0-only",
+}
+
+// incompatibleLicenses is a small compatibility matrix keyed by SPDX
+// identifier. Each entry lists licenses that the Free Software Foundation
+// considers incompatible when the two are combined into a single work.
+var incompatibleLicenses = map[string]map[string]string{
+	"GPL-2.0-only": {
+		"Apache-2.0":    "Apache-2.0 patent and indemnity terms are incompatible with GPL-2.0-only",
+		"GPL-3.0-only":  "GPL-2.0-only and GPL-3.0-only code cannot be combined",
+		"AGPL-3.0-only": "AGPL-3.0-only code cannot be combined with GPL-2.0-only",
+		"LGPL-3.0-only": "LGPL-3.0-only code cannot be relicensed under GPL-2.0-only",
+		"EPL-1.0":       "EPL-1.0 is incompatible with the GPL",
+		"EPL-2.0":       "EPL-2.0 is incompatible with the GPL unless a secondary license is designated",
+		"MPL-1.1":       "MPL-1.1 is incompatible with the GPL",
+		"CDDL-1.0":      "CDDL-1.0 is incompatible with the GPL",
+		"BSD-4-Clause":  "the BSD advertising clause is incompatible with the GPL",
+		"OpenSSL":       "the OpenSSL license advertising clause is incompatible with the GPL",
+	},
+	"GPL-3.0-only": {
+		"EPL-1.0":      "EPL-1.0 is incompatible with the GPL",
+		"EPL-2.0":      "EPL-2.0 is incompatible with the GPL unless a secondary license is designated",
+		"MPL-1.1":      "MPL-1.1 is incompatible with the GPL",
+		"CDDL-1.0":     "CDDL-1.0 is incompatible with the GPL",
+		"BSD-4-Clause": "the BSD advertising clause is incompatible with the GPL",
+		"OpenSSL":      "the OpenSSL license advertising clause is incompatible with the GPL",
+	},
+	"AGPL-3.0-only": {
+		"EPL-1.0":      "EPL-1.0 is incompatible with the AGPL",
+		"MPL-1.1":      "MPL-1.1 is incompatible with the AGPL",
+		"CDDL-1.0":     "CDDL-1.0 is incompatible with the AGPL",
+		"BSD-4-Clause": "the BSD advertising clause is incompatible with the AGPL",
+		"OpenSSL":      "the OpenSSL license advertising clause is incompatible with the AGPL",
+	},
+}
+
+// canonicalLicenseID normalizes an SPDX identifier for matrix lookups.
+func canonicalLicenseID(license string) string {
+	license = strings.TrimSpace(license)
+	if alias, ok := licenseAliases[license]; ok {
+		return alias
+	}
+	return license
+}
+
+// incompatibilityReason returns why licenses a and b conflict, or an empty
+// string if the matrix records no conflict between them.
+func incompatibilityReason(a, b string) string {
+	a, b = canonicalLicenseID(a), canonicalLicenseID(b)
+	if reason, ok := incompatibleLicenses[a][b]; ok {
+		return reason
+	}
+	if reason, ok := incompatibleLicenses[b][a]; ok {
+		return reason
+	}
+	return ""
+}
+
+// licenseConflict returns why the license expressions a and b conflict, or
+// an empty string if they can be combined. A component offered under an OR
+// expression may be used under any one of its terms, as in
+// GetComponentsByLicense, so the two only conflict when every choice of
+// terms does. A term combining licenses with AND conflicts when any of
+// them does.
+func licenseConflict(a, b string) string {
+	reason := ""
+	for _, termA := range LicenseDisjuncts(a) {
+		for _, termB := range LicenseDisjuncts(b) {
+			r := conjunctConflict(termA, termB)
+			if r == "" {
+				return ""
+			}
+			if reason == "" {
+				reason = r
+			}
+		}
+	}
+	return reason
+}
+
+// conjunctConflict returns why two OR-free license terms conflict, or an
+// empty string if the matrix records no conflict between their licenses.
+func conjunctConflict(a, b string) string {
+	for _, licenseA := range strings.Split(a, " AND ") {
+		for _, licenseB := range strings.Split(b, " AND ") {
+			if reason := incompatibilityReason(stripParens(licenseA), stripParens(licenseB)); reason != "" {
+				return reason
+			}
+		}
+	}
+	return ""
+}
+
+// LicenseCompatibility returns pairs of components whose licenses may be
+// incompatible when combined. The result is advisory only and is not legal
+// advice: whether a conflict applies depends on how the components are
+// linked and distributed.
+func (s *SBOM) LicenseCompatibility() []Conflict {
+	var conflicts []Conflict
+	for i := 0; i < len(s.Components); i++ {
+		if s.Components[i].License == "" {
+			continue
+		}
+		for j := i + 1; j < len(s.Components); j++ {
+			if s.Components[j].License == "" {
+				continue
+			}
+			reason := licenseConflict(s.Components[i].License, s.Components[j].License)
+			if reason != "" {
+				conflicts = append(conflicts, Conflict{
+					A:      s.Components[i],
+					B:      s.Components[j],
+					Reason: reason,
+				})
+			}
+		}
+	}
+	return conflicts
+}
+
+// MissingLicenses returns the components whose license is empty or
+// NOASSERTION.
+func (s *SBOM) MissingLicenses() []Component {
+	var missing []Component
+	for _, comp := range s.Components {
+		license := strings.TrimSpace(comp.License)
+		if license == "" || strings.EqualFold(license, "NOASSERTION") {
+			missing = append(missing, comp)
+		}
+	}
+	return missing
+}
+
+// LicenseExpression combines licenses into a single SPDX license expression
+// joined with OR, for components that may be used under any one of them.
+// Each input may itself be an OR expression or a legacy slash-separated list
+// ("MIT/Apache-2.0"); duplicate terms are dropped and order is preserved.
+func LicenseExpression(licenses ...string) string {
+	var terms []string
+	seen := make(map[string]bool)
+	for _, license := range licenses {
+		for _, term := range LicenseDisjuncts(license) {
+			if !seen[term] {
+				seen[term] = true
+				terms = append(terms, term)
+			}
+		}
+	}
+	if len(terms) == 1 {
+		return terms[0]
+	}
+	for i, term := range terms {
+		if strings.Contains(term, " AND ") || strings.Contains(term, " WITH ") {
+			terms[i] = "(" + term + ")"
+		}
+	}
+	return strings.Join(terms, " OR ")
+}
+
+// LicenseDisjuncts splits an SPDX license expression into its top-level OR
+// terms. Terms combined with AND stay together, and redundant outer
+// parentheses are removed.
+func LicenseDisjuncts(expr string) []string {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return nil
+	}
+	if !strings.Contains(expr, " ") {
+		expr = strings.ReplaceAll(expr, "/", " OR ")
+	}
+
+	var terms []string
+	depth, start := 0, 0
+	for i := 0; i < len(expr); i++ {
+		switch expr[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ' ':
+			if depth == 0 && strings.HasPrefix(expr[i:], " OR ") {
+				terms = append(terms, expr[start:i])
+				start = i + len(" OR ")
+				i += len(" OR ") - 1
+			}
+		}
+	}
+	terms = append(terms, expr[start:])
+
+	var result []string
+	for _, term := range terms {
+		term = stripParens(strings.TrimSpace(term))
+		if term != "" {
+			result = append(result, term)
+		}
+	}
+	return result
+}
+
+// stripParens removes parentheses that enclose the whole of term.
+func stripParens(term string) string {
+	for strings.HasPrefix(term, "(") && strings.HasSuffix(term, ")") {
+		depth := 0
+		for i := 0; i < len(term); i++ {
+			switch term[i] {
+			case '(':
+				depth++
+			case ')':
+				depth--
+			}
+			if depth == 0 && i < len(term)-1 {
+				return term
+			}
+		}
+		term = strings.TrimSpace(term[1 : len(term)-1])
+	}
+	return term
+}
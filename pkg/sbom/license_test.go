@@ -0,0 +1,111 @@
+package sbom
+
+import "testing"
+
+func TestLicenseCompatibility(t *testing.T) {
+	sbom := New("test-app", "1.0.0", "serial-001")
+
+	sbom.AddComponent(Component{Name: "lib-gpl", License: "GPL-2.0"})
+	sbom.AddComponent(Component{Name: "lib-apache", License: "Apache-2.0"})
+	sbom.AddComponent(Component{Name: "lib-mit", License: "MIT"})
+
+	conflicts := sbom.LicenseCompatibility()
+	if len(conflicts) != 1 {
+		t.Fatalf("Expected 1 conflict, got %d", len(conflicts))
+	}
+
+	if conflicts[0].A.Name != "lib-gpl" || conflicts[0].B.Name != "lib-apache" {
+		t.Errorf("Expected conflict between lib-gpl and lib-apache, got %s and %s",
+			conflicts[0].A.Name, conflicts[0].B.Name)
+	}
+	if conflicts[0].Reason == "" {
+		t.Error("Expected conflict reason to be set")
+	}
+}
+
+func TestLicenseCompatibility_Compatible(t *testing.T) {
+	sbom := New("test-app", "1.0.0", "serial-001")
+
+	sbom.AddComponent(Component{Name: "lib-gpl3", License: "GPL-3.0-only"})
+	sbom.AddComponent(Component{Name: "lib-apache", License: "Apache-2.0"})
+	sbom.AddComponent(Component{Name: "lib-unknown"})
+
+	if conflicts := sbom.LicenseCompatibility(); len(conflicts) != 0 {
+		t.Errorf("Expected no conflicts, got %d", len(conflicts))
+	}
+}
+
+func TestLicenseCompatibility_Disjuncts(t *testing.T) {
+	sbom := New("test-app", "1.0.0", "serial-001")
+	sbom.AddComponent(Component{Name: "lib-gpl", License: "GPL-2.0-only"})
+	sbom.AddComponent(Component{Name: "lib-dual", License: "Apache-2.0 OR MIT"})
+	sbom.AddComponent(Component{Name: "lib-bundle", License: "MIT AND Apache-2.0"})
+	sbom.AddComponent(Component{Name: "lib-either", License: "EPL-2.0 OR Apache-2.0"})
+
+	found := make(map[string]bool)
+	for _, conflict := range sbom.LicenseCompatibility() {
+		found[conflict.A.Name+"/"+conflict.B.Name] = true
+	}
+	if found["lib-gpl/lib-dual"] {
+		t.Error("Expected a dual-licensed component usable under MIT not to conflict")
+	}
+	if !found["lib-gpl/lib-bundle"] {
+		t.Error("Expected an AND expression including Apache-2.0 to conflict with GPL-2.0-only")
+	}
+	if !found["lib-gpl/lib-either"] {
+		t.Error("Expected a conflict when every alternative conflicts")
+	}
+	if len(found) != 2 {
+		t.Errorf("Expected 2 conflicts, got %v", found)
+	}
+}
+
+func TestLicenseExpression(t *testing.T) {
+	tests := []struct {
+		name     string
+		licenses []string
+		expected string
+	}{
+		{"single", []string{"MIT"}, "MIT"},
+		{"array", []string{"MIT", "Apache-2.0"}, "MIT OR Apache-2.0"},
+		{"duplicates", []string{"MIT", "MIT OR Apache-2.0", "Apache-2.0"}, "MIT OR Apache-2.0"},
+		{"cargo slash", []string{"MIT/Apache-2.0"}, "MIT OR Apache-2.0"},
+		{"conjunction", []string{"(MIT AND BSD-3-Clause)", "GPL-2.0-only"}, "(MIT AND BSD-3-Clause) OR GPL-2.0-only"},
+		{"empty", []string{"", " "}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LicenseExpression(tt.licenses...); got != tt.expected {
+				t.Errorf("Expected '%s', got '%s'", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestGetComponentsByLicense_Disjunct(t *testing.T) {
+	sbom := New("test-app", "1.0.0", "serial-001")
+	sbom.AddComponent(Component{Name: "dual", License: "MIT OR Apache-2.0"})
+	sbom.AddComponent(Component{Name: "both", License: "MIT AND Apache-2.0"})
+	sbom.AddComponent(Component{Name: "apache", License: "Apache-2.0"})
+
+	matches := sbom.GetComponentsByLicense("Apache-2.0")
+	if len(matches) != 2 {
+		t.Fatalf("Expected 2 Apache-2.0 components, got %d", len(matches))
+	}
+	if matches[0].Name != "dual" || matches[1].Name != "apache" {
+		t.Errorf("Unexpected matches %v", matches)
+	}
+}
+
+func TestMissingLicenses(t *testing.T) {
+	sbom := New("test-app", "1.0.0", "serial-001")
+	sbom.AddComponent(Component{Name: "licensed", License: "MIT"})
+	sbom.AddComponent(Component{Name: "empty"})
+	sbom.AddComponent(Component{Name: "noassertion", License: "NOASSERTION"})
+
+	missing := sbom.MissingLicenses()
+	if len(missing) != 2 || missing[0].Name != "empty" || missing[1].Name != "noassertion" {
+		t.Errorf("Expected empty and noassertion, got %v", missing)
+	}
+}
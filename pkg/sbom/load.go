@@ -0,0 +1,54 @@
+package sbom
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadJSON parses an SBOM previously serialized by the JSON formatter,
+// migrating documents written by earlier releases.
+func LoadJSON(data []byte) (*SBOM, error) {
+	s, err := migrate(data, json.Unmarshal)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SBOM JSON: %w", err)
+	}
+	return s, nil
+}
+
+// LoadYAML parses an SBOM previously serialized by the YAML formatter,
+// migrating documents written by earlier releases.
+func LoadYAML(data []byte) (*SBOM, error) {
+	s, err := migrate(data, yaml.Unmarshal)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SBOM YAML: %w", err)
+	}
+	return s, nil
+}
+
+// Load parses a JSON or YAML SBOM, telling the two apart by content. It is
+// the same as Migrate.
+func Load(data []byte) (*SBOM, error) {
+	return Migrate(data)
+}
+
+// LoadFile reads and parses the SBOM at path. Files ending in .yaml or .yml
+// are parsed as YAML, .json as JSON, and anything else by content.
+func LoadFile(path string) (*SBOM, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SBOM: %w", err)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return LoadJSON(data)
+	case ".yaml", ".yml":
+		return LoadYAML(data)
+	default:
+		return Load(data)
+	}
+}
@@ -0,0 +1,103 @@
+package sbom
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MergeStrategy controls how the License, Metadata and Hashes of duplicate
+// components are combined.
+type MergeStrategy string
+
+const (
+	// MergeFirstWins keeps the values of the first occurrence.
+	MergeFirstWins MergeStrategy = "first-wins"
+	// MergeLastWins takes the values of the last occurrence.
+	MergeLastWins MergeStrategy = "last-wins"
+	// MergeUnion keeps the first occurrence's values, fills in whatever it
+	// lacks from later ones and combines their hashes.
+	MergeUnion MergeStrategy = "union"
+)
+
+// MergeStrategies lists the valid merge strategies.
+var MergeStrategies = []MergeStrategy{MergeFirstWins, MergeLastWins, MergeUnion}
+
+// ParseMergeStrategy parses a --merge-strategy value.
+func ParseMergeStrategy(value string) (MergeStrategy, error) {
+	for _, strategy := range MergeStrategies {
+		if string(strategy) == value {
+			return strategy, nil
+		}
+	}
+	names := make([]string, len(MergeStrategies))
+	for i, strategy := range MergeStrategies {
+		names[i] = string(strategy)
+	}
+	return "", fmt.Errorf("unknown merge strategy %q (supported: %s)", value, strings.Join(names, ", "))
+}
+
+// mergeComponent combines dup, a later duplicate of comp, into comp. The
+// License, Metadata and Hashes follow strategy; the supplier, CPE and scope
+// are filled in when missing, and dependencies are always combined.
+func mergeComponent(comp *Component, dup Component, strategy MergeStrategy) {
+	switch strategy {
+	case MergeLastWins:
+		comp.License = dup.License
+		comp.Metadata = dup.Metadata
+		comp.Hashes = normalizeHashes(dup.Hashes)
+	case MergeUnion:
+		fillEmpty(&comp.License, dup.License)
+		mergeMetadata(&comp.Metadata, dup.Metadata)
+		comp.Hashes = normalizeHashes(append(comp.Hashes, dup.Hashes...))
+	}
+
+	fillEmpty(&comp.Supplier, dup.Supplier)
+	fillEmpty(&comp.CPE, dup.CPE)
+	if comp.Scope == "" {
+		comp.Scope = dup.Scope
+	}
+	comp.Direct = comp.Direct || dup.Direct
+	comp.ExternalRefs = appendRefs(comp.ExternalRefs, comp.PURL, dup.ExternalRefs...)
+
+	deps := append(comp.Dependencies, dup.Dependencies...)
+	sort.Strings(deps)
+	comp.Dependencies = deps[:0]
+	for i, dep := range deps {
+		if i == 0 || dep != deps[i-1] {
+			comp.Dependencies = append(comp.Dependencies, dep)
+		}
+	}
+}
+
+// mergeMetadata fills the empty fields of m from dup.
+func mergeMetadata(m *Metadata, dup Metadata) {
+	fillEmpty(&m.Author, dup.Author)
+	fillEmpty(&m.Publisher, dup.Publisher)
+	fillEmpty(&m.Description, dup.Description)
+	fillEmpty(&m.HomepageURL, dup.HomepageURL)
+	fillEmpty(&m.SourceURL, dup.SourceURL)
+	fillEmpty(&m.ProvenanceURL, dup.ProvenanceURL)
+	fillEmpty(&m.Revision, dup.Revision)
+	if m.LastModified.IsZero() {
+		m.LastModified = dup.LastModified
+	}
+	if m.SourceFile == "" {
+		m.SourceFile, m.SourceLine = dup.SourceFile, dup.SourceLine
+	}
+	fillEmpty(&m.Module, dup.Module)
+	fillEmpty(&m.Checksum, dup.Checksum)
+	fillEmpty(&m.LicenseFile, dup.LicenseFile)
+	if m.LicenseSource == "" {
+		m.LicenseSource = dup.LicenseSource
+	}
+	if dup.Deprecated && !m.Deprecated {
+		m.Deprecated, m.DeprecationReason = true, dup.DeprecationReason
+	}
+}
+
+func fillEmpty(field *string, value string) {
+	if *field == "" {
+		*field = value
+	}
+}
@@ -0,0 +1,66 @@
+package sbom
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeWith_MergeStrategies(t *testing.T) {
+	first := Component{Name: "lodash", Version: "4.17.21", PURL: "pkg:npm/lodash@4.17.21", License: "MIT",
+		Metadata:     Metadata{Author: "jdalton"},
+		Hashes:       []Hash{{Algorithm: "SHA-256", Value: "aaaa"}},
+		Dependencies: []string{"a"}}
+	second := Component{Name: "lodash", Version: "4.17.21", PURL: "pkg:npm/lodash@4.17.21", License: "Apache-2.0",
+		Supplier:     "npm",
+		Metadata:     Metadata{HomepageURL: "https://lodash.com"},
+		Hashes:       []Hash{{Algorithm: "SHA-1", Value: "bbbb"}},
+		Dependencies: []string{"b"}}
+
+	tests := []struct {
+		strategy MergeStrategy
+		expected Component
+	}{
+		{MergeFirstWins, Component{Name: "lodash", Version: "4.17.21", PURL: "pkg:npm/lodash@4.17.21", License: "MIT",
+			Supplier:     "npm",
+			Metadata:     Metadata{Author: "jdalton"},
+			Hashes:       []Hash{{Algorithm: "SHA-256", Value: "aaaa"}},
+			Dependencies: []string{"a", "b"}}},
+		{MergeLastWins, Component{Name: "lodash", Version: "4.17.21", PURL: "pkg:npm/lodash@4.17.21", License: "Apache-2.0",
+			Supplier:     "npm",
+			Metadata:     Metadata{HomepageURL: "https://lodash.com"},
+			Hashes:       []Hash{{Algorithm: "SHA-1", Value: "bbbb"}},
+			Dependencies: []string{"a", "b"}}},
+		{MergeUnion, Component{Name: "lodash", Version: "4.17.21", PURL: "pkg:npm/lodash@4.17.21", License: "MIT",
+			Supplier:     "npm",
+			Metadata:     Metadata{Author: "jdalton", HomepageURL: "https://lodash.com"},
+			Hashes:       []Hash{{Algorithm: "SHA-1", Value: "bbbb"}, {Algorithm: "SHA-256", Value: "aaaa"}},
+			Dependencies: []string{"a", "b"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.strategy), func(t *testing.T) {
+			doc := New("app", "1.0.0", "")
+			doc.Components = []Component{first, second}
+			doc.Components[0].Dependencies = append([]string(nil), first.Dependencies...)
+
+			doc.NormalizeWith(tt.strategy)
+			if len(doc.Components) != 1 {
+				t.Fatalf("Expected duplicates to merge into 1 component, got %d", len(doc.Components))
+			}
+			if !reflect.DeepEqual(doc.Components[0], tt.expected) {
+				t.Errorf("Expected\n%+v\ngot\n%+v", tt.expected, doc.Components[0])
+			}
+		})
+	}
+}
+
+func TestParseMergeStrategy(t *testing.T) {
+	for _, value := range []string{"first-wins", "last-wins", "union"} {
+		if got, err := ParseMergeStrategy(value); err != nil || string(got) != value {
+			t.Errorf("ParseMergeStrategy(%q): got %q, %v", value, got, err)
+		}
+	}
+	if _, err := ParseMergeStrategy("newest"); err == nil {
+		t.Error("Expected an error for an unknown strategy")
+	}
+}
@@ -0,0 +1,92 @@
+package sbom
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// CurrentSpecVersion is the version of the serialized SBOM layout written
+// by this release.
+const CurrentSpecVersion = "0.24.0"
+
+// migration upgrades a decoded document to the layout of version, the
+// first release that wrote it. Migrations are applied in order to every
+// document older than their version.
+type migration struct {
+	version string
+	apply   func(doc map[string]any)
+}
+
+// migrations is empty: every release so far has written the current
+// layout. When a field is renamed or its meaning changes, bump
+// CurrentSpecVersion and add the upgrade of older documents here.
+var migrations []migration
+
+// Migrate parses a JSON or YAML SBOM written by this or an earlier release
+// and upgrades it to the current layout, applying the migrations its
+// specVersion predates. A document without a specVersion is treated as
+// older than every migration. Documents from newer releases are parsed as
+// they are, ignoring fields this release does not know.
+func Migrate(raw []byte) (*SBOM, error) {
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		return LoadJSON(raw)
+	}
+	return LoadYAML(raw)
+}
+
+// migrate decodes data with unmarshal. Documents that need upgrading are
+// decoded a second time, generically, so the migrations their specVersion
+// calls for can edit them before they are bound to the struct.
+func migrate(data []byte, unmarshal func([]byte, any) error) (*SBOM, error) {
+	var s SBOM
+	if err := unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+
+	if migrationsApply(s.SpecVersion) {
+		var doc map[string]any
+		if err := unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+		if doc == nil {
+			doc = make(map[string]any)
+		}
+		for _, m := range migrations {
+			if olderThan(s.SpecVersion, m.version) {
+				m.apply(doc)
+			}
+		}
+		doc["specVersion"] = CurrentSpecVersion
+		upgraded, err := json.Marshal(doc)
+		if err != nil {
+			return nil, err
+		}
+		s = SBOM{}
+		if err := json.Unmarshal(upgraded, &s); err != nil {
+			return nil, err
+		}
+	}
+	if s.Components == nil {
+		s.Components = make([]Component, 0)
+	}
+	return &s, nil
+}
+
+// migrationsApply reports whether any migration applies to documents at
+// version.
+func migrationsApply(version string) bool {
+	for _, m := range migrations {
+		if olderThan(version, m.version) {
+			return true
+		}
+	}
+	return false
+}
+
+// olderThan reports whether a document at version predates target. A
+// missing or unparseable version predates every release.
+func olderThan(version, target string) bool {
+	c, ok := CompareVersions(version, target)
+	return !ok || c < 0
+}
@@ -0,0 +1,64 @@
+package sbom
+
+import "testing"
+
+func TestMigrate_AppliesOlderMigrations(t *testing.T) {
+	saved := migrations
+	defer func() { migrations = saved }()
+	var applied []string
+	migrations = []migration{
+		{"0.20.0", func(doc map[string]any) { applied = append(applied, "0.20.0") }},
+		{"0.22.0", func(doc map[string]any) {
+			applied = append(applied, "0.22.0")
+			doc["name"] = "renamed"
+		}},
+	}
+
+	doc, err := Migrate([]byte(`{"specVersion": "0.21.0", "name": "myapp", "version": "1.0.0"}`))
+	if err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	if len(applied) != 1 || applied[0] != "0.22.0" {
+		t.Errorf("Expected only the 0.22.0 migration to run, got %v", applied)
+	}
+	if doc.Name != "renamed" || doc.SpecVersion != CurrentSpecVersion {
+		t.Errorf("Expected the migrated document at %s, got %s at '%s'", CurrentSpecVersion, doc.Name, doc.SpecVersion)
+	}
+
+	applied = nil
+	doc, err = Migrate([]byte("name: myapp\nversion: 1.0.0\n"))
+	if err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	if len(applied) != 2 {
+		t.Errorf("Expected a document without specVersion to run every migration, got %v", applied)
+	}
+	if doc.Components == nil {
+		t.Error("Expected an empty component list")
+	}
+}
+
+func TestMigrate_CurrentLayoutUnchanged(t *testing.T) {
+	raw := []byte(`{"specVersion": "0.24.0", "name": "myapp", "version": "1.0.0", "components": [
+		{"name": "jest", "version": "29.0.0", "scope": "test", "metadata": {"homepage_url": "https://jestjs.io"}}
+	]}`)
+	doc, err := Migrate(raw)
+	if err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	if doc.Components[0].Scope != ScopeTest || doc.Components[0].Metadata.HomepageURL != "https://jestjs.io" {
+		t.Errorf("Expected the current layout to load as is, got %+v", doc.Components[0])
+	}
+}
+
+func TestMigrate_NewerRelease(t *testing.T) {
+	raw := []byte(`{"specVersion": "9.0.0", "name": "myapp", "version": "1.0.0", "futureField": true,
+		"components": [{"name": "express", "version": "4.18.0"}]}`)
+	doc, err := Migrate(raw)
+	if err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	if doc.SpecVersion != "9.0.0" || len(doc.Components) != 1 {
+		t.Errorf("Expected a newer document to load as is, got '%s' with %d components", doc.SpecVersion, len(doc.Components))
+	}
+}
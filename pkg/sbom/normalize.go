@@ -0,0 +1,181 @@
+package sbom
+
+import (
+	"sort"
+	"strings"
+)
+
+// spdxLicenseIDs lists common SPDX license identifiers so that differently
+// cased spellings, such as "mit" or "apache-2.0", can be canonicalized.
+var spdxLicenseIDs = []string{
+	"0BSD", "AFL-3.0", "AGPL-3.0", "AGPL-3.0-only", "AGPL-3.0-or-later",
+	"Apache-1.1", "Apache-2.0", "Artistic-2.0", "BSD-2-Clause",
+	"BSD-3-Clause", "BSD-4-Clause", "BSL-1.0", "CC-BY-3.0", "CC-BY-4.0",
+	"CC-BY-SA-4.0", "CC0-1.0", "CDDL-1.0", "CDDL-1.1", "EPL-1.0", "EPL-2.0",
+	"EUPL-1.2", "GPL-2.0", "GPL-2.0-only", "GPL-2.0-or-later", "GPL-3.0",
+	"GPL-3.0-only", "GPL-3.0-or-later", "ISC", "LGPL-2.0", "LGPL-2.1",
+	"LGPL-2.1-only", "LGPL-2.1-or-later", "LGPL-3.0", "LGPL-3.0-only",
+	"LGPL-3.0-or-later", "MIT", "MIT-0", "MPL-1.1", "MPL-2.0", "MS-PL",
+	"NOASSERTION", "NONE", "OFL-1.1", "OpenSSL", "PostgreSQL", "PSF-2.0",
+	"Python-2.0", "Unicode-DFS-2016", "Unlicense", "UPL-1.0", "WTFPL",
+	"Zlib", "Classpath-exception-2.0", "LLVM-exception",
+}
+
+var canonicalLicenses = func() map[string]string {
+	ids := make(map[string]string, len(spdxLicenseIDs))
+	for _, id := range spdxLicenseIDs {
+		ids[strings.ToLower(id)] = id
+	}
+	return ids
+}()
+
+// purlEcosystems are the suppliers recorded by the analyzers, for which a
+// missing PURL can be derived from the component's name and version.
+var purlEcosystems = map[string]bool{
+	"cargo": true, "go": true, "helm": true, "maven": true, "npm": true,
+	"pub": true, "pypi": true, "swift": true, "terraform": true,
+}
+
+// NormalizeLicense canonicalizes an SPDX license expression: known
+// identifiers get their SPDX casing, operators are upper-cased, and
+// duplicate OR terms and legacy slash-separated lists are folded as by
+// LicenseExpression. Unknown identifiers are kept as they are.
+func NormalizeLicense(expr string) string {
+	var tokens []string
+	for _, field := range strings.Fields(strings.NewReplacer("(", " ( ", ")", " ) ").Replace(expr)) {
+		switch upper := strings.ToUpper(field); {
+		case upper == "AND" || upper == "OR" || upper == "WITH":
+			field = upper
+		case canonicalLicenses[strings.ToLower(field)] != "":
+			field = canonicalLicenses[strings.ToLower(field)]
+		}
+		tokens = append(tokens, field)
+	}
+	joined := strings.NewReplacer("( ", "(", " )", ")").Replace(strings.Join(tokens, " "))
+	if !strings.Contains(joined, " ") {
+		// A slash-separated list: canonicalize each of its terms.
+		parts := strings.Split(joined, "/")
+		for i, part := range parts {
+			if id, ok := canonicalLicenses[strings.ToLower(part)]; ok {
+				parts[i] = id
+			}
+		}
+		joined = strings.Join(parts, "/")
+	}
+	return LicenseExpression(joined)
+}
+
+// Normalize cleans up an SBOM so that equivalent documents serialize
+// identically, e.g. before diffing SBOMs from other tools. It canonicalizes
+// licenses, derives missing PURLs for known ecosystems, merges duplicate
+// components with MergeUnion, normalizes relationships and sorts
+// components, hashes and relationships.
+func (s *SBOM) Normalize() {
+	s.NormalizeWith(MergeUnion)
+}
+
+// NormalizeWith is Normalize with duplicate components combined according
+// to strategy.
+func (s *SBOM) NormalizeWith(strategy MergeStrategy) {
+	if s.Root != nil {
+		normalizeComponent(s.Root)
+	}
+
+	var merged []Component
+	index := make(map[string]int)
+	for _, comp := range s.Components {
+		normalizeComponent(&comp)
+		key := componentKey(comp)
+		if i, ok := index[key]; ok {
+			mergeComponent(&merged[i], comp, strategy)
+			continue
+		}
+		index[key] = len(merged)
+		merged = append(merged, comp)
+	}
+	sortComponents(merged)
+	s.Components = merged
+
+	for i, rel := range s.Relationships {
+		s.Relationships[i] = rel.key()
+	}
+	s.NormalizeRelationships()
+	sortRelationships(s.Relationships)
+}
+
+// sortComponents orders components by name, version, PURL and source file.
+func sortComponents(components []Component) {
+	sort.SliceStable(components, func(i, j int) bool {
+		a, b := components[i], components[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		if a.Version != b.Version {
+			return a.Version < b.Version
+		}
+		if a.PURL != b.PURL {
+			return a.PURL < b.PURL
+		}
+		return a.Metadata.SourceFile < b.Metadata.SourceFile
+	})
+}
+
+func sortRelationships(rels []Relationship) {
+	sort.SliceStable(rels, func(i, j int) bool {
+		a, b := rels[i], rels[j]
+		if a.RefA != b.RefA {
+			return a.RefA < b.RefA
+		}
+		if a.Relationship != b.Relationship {
+			return a.Relationship < b.Relationship
+		}
+		return a.RefB < b.RefB
+	})
+}
+
+func normalizeComponent(comp *Component) {
+	comp.License = NormalizeLicense(comp.License)
+	if comp.PURL == "" && purlEcosystems[comp.Supplier] && comp.Name != "" {
+		comp.PURL = PURL(comp.Supplier, comp.Name, comp.Version)
+	}
+	comp.Hashes = normalizeHashes(comp.Hashes)
+	sort.Strings(comp.Dependencies)
+}
+
+// normalizeHashes canonicalizes algorithm names and lower-cases hex
+// digests, drops duplicates and sorts the result by algorithm.
+func normalizeHashes(hashes []Hash) []Hash {
+	if len(hashes) == 0 {
+		return hashes
+	}
+	seen := make(map[Hash]bool)
+	var result []Hash
+	for _, h := range hashes {
+		if name := CanonicalHashAlgorithm(h.Algorithm); name != "" {
+			h.Algorithm = name
+		}
+		if isHex(h.Value) {
+			h.Value = strings.ToLower(h.Value)
+		}
+		if !seen[h] {
+			seen[h] = true
+			result = append(result, h)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Algorithm != result[j].Algorithm {
+			return result[i].Algorithm < result[j].Algorithm
+		}
+		return result[i].Value < result[j].Value
+	})
+	return result
+}
+
+func isHex(s string) bool {
+	for _, r := range s {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", r) {
+			return false
+		}
+	}
+	return s != ""
+}
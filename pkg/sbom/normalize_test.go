@@ -0,0 +1,67 @@
+package sbom
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeLicense(t *testing.T) {
+	tests := []struct {
+		input, expected string
+	}{
+		{"mit", "MIT"},
+		{"apache-2.0 or MIT", "Apache-2.0 OR MIT"},
+		{"MIT/apache-2.0", "MIT OR Apache-2.0"},
+		{"mit OR MIT", "MIT"},
+		{"(bsd-3-clause and mit) or gpl-2.0-or-later", "(BSD-3-Clause AND MIT) OR GPL-2.0-or-later"},
+		{"gpl-2.0-only with classpath-exception-2.0", "GPL-2.0-only WITH Classpath-exception-2.0"},
+		{"LicenseRef-Acme", "LicenseRef-Acme"},
+		{"noassertion", "NOASSERTION"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizeLicense(tt.input); got != tt.expected {
+			t.Errorf("NormalizeLicense(%q): expected %q, got %q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestSBOM_Normalize(t *testing.T) {
+	doc := New("vendor", "1.0.0", "")
+	doc.Components = []Component{
+		{Name: "lodash", Version: "4.17.21", Supplier: "npm", License: "mit",
+			Hashes: []Hash{{Algorithm: "sha256", Value: "ABCDEF"}}},
+		{Name: "express", Version: "4.18.2", PURL: "pkg:npm/express@4.18.2"},
+		{Name: "lodash", Version: "4.17.21", PURL: "pkg:npm/lodash@4.17.21",
+			Metadata: Metadata{HomepageURL: "https://lodash.com"},
+			Hashes:   []Hash{{Algorithm: "SHA-256", Value: "abcdef"}, {Algorithm: "SHA-1", Value: "0123"}}},
+		{Name: "acme-lib", Version: "2.0", Supplier: "Acme Corp", License: "Apache-2.0 OR apache-2.0"},
+	}
+	doc.Relationships = []Relationship{
+		{RefA: "pkg:npm/lodash@4.17.21", RefB: "pkg:npm/lodash@4.17.21", Relationship: DependsOn},
+		{RefA: "pkg:npm/express@4.18.2", RefB: "pkg:npm/lodash@4.17.21", Relationship: "DEPENDS_ON"},
+		{RefA: "acme-lib", RefB: "pkg:npm/express@4.18.2", Relationship: DependsOn},
+		{RefA: "pkg:npm/express@4.18.2", RefB: "pkg:npm/lodash@4.17.21", Relationship: DependsOn},
+	}
+
+	doc.Normalize()
+
+	expected := []Component{
+		{Name: "acme-lib", Version: "2.0", Supplier: "Acme Corp", License: "Apache-2.0"},
+		{Name: "express", Version: "4.18.2", PURL: "pkg:npm/express@4.18.2"},
+		{Name: "lodash", Version: "4.17.21", Supplier: "npm", License: "MIT", PURL: "pkg:npm/lodash@4.17.21",
+			Metadata: Metadata{HomepageURL: "https://lodash.com"},
+			Hashes:   []Hash{{Algorithm: "SHA-1", Value: "0123"}, {Algorithm: "SHA-256", Value: "abcdef"}}},
+	}
+	if !reflect.DeepEqual(doc.Components, expected) {
+		t.Errorf("Expected components\n%+v\ngot\n%+v", expected, doc.Components)
+	}
+
+	expectedRels := []Relationship{
+		{RefA: "acme-lib", RefB: "pkg:npm/express@4.18.2", Relationship: DependsOn},
+		{RefA: "pkg:npm/express@4.18.2", RefB: "pkg:npm/lodash@4.17.21", Relationship: DependsOn},
+	}
+	if !reflect.DeepEqual(doc.Relationships, expectedRels) {
+		t.Errorf("Expected relationships %+v, got %+v", expectedRels, doc.Relationships)
+	}
+}
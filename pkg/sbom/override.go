@@ -0,0 +1,86 @@
+package sbom
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LicenseOverride sets the license of components whose PURL matches Pattern,
+// a path.Match glob such as "pkg:npm/internal-*".
+type LicenseOverride struct {
+	Pattern string
+	License string
+}
+
+// LoadLicenseOverrides reads a YAML or JSON mapping of PURL globs to SPDX
+// license expressions. Overrides keep the order of the file, and the first
+// matching pattern wins.
+func LoadLicenseOverrides(file string) ([]LicenseOverride, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read license overrides: %w", err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse license overrides: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+	mapping := doc.Content[0]
+	if mapping.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("license overrides must map PURL patterns to licenses")
+	}
+
+	var overrides []LicenseOverride
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		pattern, license := mapping.Content[i].Value, mapping.Content[i+1].Value
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid license override pattern %q: %w", pattern, err)
+		}
+		if mapping.Content[i+1].Kind != yaml.ScalarNode || license == "" {
+			return nil, fmt.Errorf("license override %q must be a license expression", pattern)
+		}
+		overrides = append(overrides, LicenseOverride{Pattern: pattern, License: license})
+	}
+	return overrides, nil
+}
+
+// ApplyLicenseOverrides sets the license of every component matched by an
+// override and records each change as an annotation naming the pattern and
+// source, so overridden licenses remain auditable. It returns the number of
+// components changed.
+func (s *SBOM) ApplyLicenseOverrides(overrides []LicenseOverride, source string) int {
+	changed := 0
+	for i := range s.Components {
+		comp := &s.Components[i]
+		for _, override := range overrides {
+			if !matchGlob(override.Pattern, comp.PURL) {
+				continue
+			}
+			if comp.License != override.License {
+				previous := comp.License
+				if previous == "" {
+					previous = "none"
+				}
+				s.Annotations = append(s.Annotations, Annotation{
+					ComponentRef: comp.PURL,
+					EventType:    "license_override",
+					Time:         time.Now().UTC(),
+					Summary: fmt.Sprintf("license set to %s (was %s) by override %q in %s",
+						override.License, previous, override.Pattern, source),
+				})
+				comp.License = override.License
+				changed++
+			}
+			comp.Metadata.LicenseSource = LicenseSourceOverride
+			break
+		}
+	}
+	return changed
+}
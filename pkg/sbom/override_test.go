@@ -0,0 +1,70 @@
+package sbom
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestApplyLicenseOverrides(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "licenses.yaml")
+	content := `"pkg:npm/%40acme/*": LicenseRef-Acme-Proprietary
+"pkg:npm/left-pad@*": WTFPL
+"pkg:npm/*": MIT
+`
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write overrides: %v", err)
+	}
+
+	overrides, err := LoadLicenseOverrides(file)
+	if err != nil {
+		t.Fatalf("Failed to load overrides: %v", err)
+	}
+	if len(overrides) != 3 || overrides[0].Pattern != "pkg:npm/%40acme/*" {
+		t.Fatalf("Expected overrides in file order, got %v", overrides)
+	}
+
+	sbom := New("test-app", "1.0.0", "serial-001")
+	sbom.AddComponent(Component{Name: "left-pad", PURL: "pkg:npm/left-pad@1.3.0"})
+	sbom.AddComponent(Component{Name: "express", PURL: "pkg:npm/express@4.18.0", License: "MIT"})
+	sbom.AddComponent(Component{Name: "@acme/ui", PURL: "pkg:npm/%40acme/ui@2.0.0", License: "ISC"})
+	sbom.AddComponent(Component{Name: "requests", PURL: "pkg:pypi/requests@2.28.0"})
+
+	if n := sbom.ApplyLicenseOverrides(overrides, file); n != 2 {
+		t.Errorf("Expected 2 licenses changed, got %d", n)
+	}
+
+	expected := []string{"WTFPL", "MIT", "LicenseRef-Acme-Proprietary", ""}
+	for i, license := range expected {
+		if sbom.Components[i].License != license {
+			t.Errorf("Expected %s to have license '%s', got '%s'", sbom.Components[i].Name, license, sbom.Components[i].License)
+		}
+	}
+	for i, source := range []LicenseSource{LicenseSourceOverride, LicenseSourceOverride, LicenseSourceOverride, ""} {
+		if got := sbom.Components[i].Metadata.LicenseSource; got != source {
+			t.Errorf("Expected %s to have license source '%s', got '%s'", sbom.Components[i].Name, source, got)
+		}
+	}
+
+	if len(sbom.Annotations) != 2 {
+		t.Fatalf("Expected an annotation per change, got %d", len(sbom.Annotations))
+	}
+	first := sbom.Annotations[0]
+	if first.ComponentRef != "pkg:npm/left-pad@1.3.0" || first.EventType != "license_override" {
+		t.Errorf("Unexpected annotation %+v", first)
+	}
+	if !strings.Contains(first.Summary, `"pkg:npm/left-pad@*"`) || !strings.Contains(first.Summary, file) {
+		t.Errorf("Expected annotation to name the pattern and source, got '%s'", first.Summary)
+	}
+}
+
+func TestLoadLicenseOverrides_Invalid(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "licenses.json")
+	if err := os.WriteFile(file, []byte(`["MIT"]`), 0644); err != nil {
+		t.Fatalf("Failed to write overrides: %v", err)
+	}
+	if _, err := LoadLicenseOverrides(file); err == nil {
+		t.Error("Expected error for a list instead of a mapping")
+	}
+}
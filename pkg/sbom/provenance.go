@@ -0,0 +1,52 @@
+package sbom
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProvenanceMap maps component PURLs to the URL of their build provenance,
+// such as a SLSA attestation.
+type ProvenanceMap map[string]string
+
+// LoadProvenanceMap reads a PURL to provenance URL mapping from a JSON or
+// YAML file. Every URL must be absolute.
+func LoadProvenanceMap(path string) (ProvenanceMap, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read provenance map: %w", err)
+	}
+
+	var provenance ProvenanceMap
+	if err := yaml.Unmarshal(data, &provenance); err != nil {
+		return nil, fmt.Errorf("failed to parse provenance map: %w", err)
+	}
+	for purl, link := range provenance {
+		if u, err := url.Parse(link); err != nil || !u.IsAbs() {
+			return nil, fmt.Errorf("provenance for %s is not an absolute URL: %q", purl, link)
+		}
+	}
+	return provenance, nil
+}
+
+// ApplyProvenance sets the provenance URL of every component, including the
+// root, whose PURL is in provenance, and returns how many were set.
+func (s *SBOM) ApplyProvenance(provenance ProvenanceMap) int {
+	applied := 0
+	set := func(comp *Component) {
+		if link, ok := provenance[comp.PURL]; ok && comp.PURL != "" {
+			comp.Metadata.ProvenanceURL = link
+			applied++
+		}
+	}
+	for i := range s.Components {
+		set(&s.Components[i])
+	}
+	if s.Root != nil {
+		set(s.Root)
+	}
+	return applied
+}
@@ -0,0 +1,41 @@
+package sbom
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadProvenanceMap(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "provenance.yaml")
+	if err := os.WriteFile(file, []byte("pkg:npm/express@4.18.2: https://example.com/express.intoto.jsonl\n"), 0644); err != nil {
+		t.Fatalf("Failed to write provenance map: %v", err)
+	}
+
+	provenance, err := LoadProvenanceMap(file)
+	if err != nil {
+		t.Fatalf("Failed to load provenance map: %v", err)
+	}
+
+	doc := New("app", "1.0.0", "")
+	doc.AddComponent(Component{Name: "express", PURL: "pkg:npm/express@4.18.2"})
+	doc.AddComponent(Component{Name: "lodash", PURL: "pkg:npm/lodash@4.17.21"})
+	if applied := doc.ApplyProvenance(provenance); applied != 1 {
+		t.Errorf("Expected 1 component linked, got %d", applied)
+	}
+	if got := doc.Components[0].Metadata.ProvenanceURL; got != "https://example.com/express.intoto.jsonl" {
+		t.Errorf("Expected provenance URL on express, got '%s'", got)
+	}
+	if got := doc.Components[1].Metadata.ProvenanceURL; got != "" {
+		t.Errorf("Expected no provenance URL on lodash, got '%s'", got)
+	}
+
+	relative := filepath.Join(dir, "relative.json")
+	if err := os.WriteFile(relative, []byte(`{"pkg:npm/express@4.18.2": "attestations/express.json"}`), 0644); err != nil {
+		t.Fatalf("Failed to write provenance map: %v", err)
+	}
+	if _, err := LoadProvenanceMap(relative); err == nil {
+		t.Error("Expected error for a relative provenance URL")
+	}
+}
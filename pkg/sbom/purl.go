@@ -0,0 +1,149 @@
+package sbom
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// defaultPURLTypes maps the ecosystem names used by the analyzers onto
+// package-url types where the two differ. Other ecosystems are their own
+// type.
+var defaultPURLTypes = map[string]string{
+	"go": "golang",
+}
+
+// purlTypePattern matches a valid package-url type.
+var purlTypePattern = regexp.MustCompile(`^[a-z][a-z0-9.+-]*$`)
+
+// PURLType returns the package-url type used for ecosystem.
+func PURLType(ecosystem string) string {
+	if t, ok := defaultPURLTypes[ecosystem]; ok {
+		return t
+	}
+	return ecosystem
+}
+
+// PURLTypes overrides the package-url type used for some ecosystems, e.g.
+// to emit pkg:alpine instead of pkg:deb. The zero value overrides nothing.
+type PURLTypes struct {
+	// byType maps the default type of each overridden ecosystem onto the
+	// type that replaces it.
+	byType map[string]string
+}
+
+// NewPURLTypes validates overrides, which map ecosystem names onto
+// package-url types, and returns them as PURLTypes. Types are lower-cased.
+func NewPURLTypes(overrides map[string]string) (PURLTypes, error) {
+	ecosystems := make([]string, 0, len(overrides))
+	for ecosystem := range overrides {
+		ecosystems = append(ecosystems, ecosystem)
+	}
+	sort.Strings(ecosystems)
+
+	types := PURLTypes{byType: make(map[string]string, len(overrides))}
+	for _, ecosystem := range ecosystems {
+		purlType := strings.ToLower(overrides[ecosystem])
+		if !purlTypePattern.MatchString(purlType) {
+			return PURLTypes{}, fmt.Errorf("invalid package-url type %q", purlType)
+		}
+		types.byType[PURLType(ecosystem)] = purlType
+	}
+	return types, nil
+}
+
+// Retype returns purl, as built by PURL, with its type replaced by the
+// override for the ecosystem it was built for. Other strings are returned
+// unchanged.
+func (t PURLTypes) Retype(purl string) string {
+	rest, ok := strings.CutPrefix(purl, "pkg:")
+	if !ok {
+		return purl
+	}
+	purlType, path, ok := strings.Cut(rest, "/")
+	if !ok {
+		return purl
+	}
+	if override, ok := t.byType[purlType]; ok {
+		return "pkg:" + override + "/" + path
+	}
+	return purl
+}
+
+// RetypePURLs applies types to the PURLs of the root and components of s,
+// and to the relationships and dependencies that refer to them.
+func (s *SBOM) RetypePURLs(types PURLTypes) {
+	if len(types.byType) == 0 {
+		return
+	}
+	if s.Root != nil {
+		s.Root.PURL = types.Retype(s.Root.PURL)
+	}
+	for i := range s.Components {
+		comp := &s.Components[i]
+		comp.PURL = types.Retype(comp.PURL)
+		for j, dep := range comp.Dependencies {
+			comp.Dependencies[j] = types.Retype(dep)
+		}
+	}
+	for i := range s.Relationships {
+		s.Relationships[i].RefA = types.Retype(s.Relationships[i].RefA)
+		s.Relationships[i].RefB = types.Retype(s.Relationships[i].RefB)
+	}
+	s.relIndex = nil
+}
+
+// PURL builds the package URL pkg:<type>/<name>@<version> for a package of
+// ecosystem. name may include a namespace, such as "group/artifact", and
+// the version is left out when empty. An "@" in name, as in npm scopes, is
+// percent-encoded so that it cannot be taken for the version separator.
+func PURL(ecosystem, name, version string) string {
+	purl := "pkg:" + PURLType(ecosystem) + "/" + strings.ReplaceAll(name, "@", "%40")
+	if version != "" {
+		purl += "@" + version
+	}
+	return purl
+}
+
+// PURLWithQualifiers builds a package URL as PURL does and appends
+// qualifiers such as arch, distro or repository_url, in the canonical form
+// the package-url specification asks for: keys lower-cased and sorted,
+// values percent-encoded, and qualifiers with empty values left out.
+func PURLWithQualifiers(ecosystem, name, version string, qualifiers map[string]string) string {
+	purl := PURL(ecosystem, name, version)
+	keys := make([]string, 0, len(qualifiers))
+	for key, value := range qualifiers {
+		if value != "" {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return purl
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return strings.ToLower(keys[i]) < strings.ToLower(keys[j])
+	})
+
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = strings.ToLower(key) + "=" + purlEscape(qualifiers[key])
+	}
+	return purl + "?" + strings.Join(pairs, "&")
+}
+
+// purlEscape percent-encodes every byte of s other than the unreserved
+// characters of RFC 3986.
+func purlEscape(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9', c == '-', c == '.', c == '_', c == '~':
+			sb.WriteByte(c)
+		default:
+			fmt.Fprintf(&sb, "%%%02X", c)
+		}
+	}
+	return sb.String()
+}
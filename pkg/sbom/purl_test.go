@@ -0,0 +1,83 @@
+package sbom
+
+import "testing"
+
+func TestPURL(t *testing.T) {
+	tests := []struct {
+		ecosystem string
+		name      string
+		version   string
+		expected  string
+	}{
+		{"go", "github.com/gin-gonic/gin", "v1.9.0", "pkg:golang/github.com/gin-gonic/gin@v1.9.0"},
+		{"npm", "lodash", "4.17.21", "pkg:npm/lodash@4.17.21"},
+		{"maven", "org.slf4j/slf4j-api", "2.0.9", "pkg:maven/org.slf4j/slf4j-api@2.0.9"},
+		{"helm", "postgresql", "", "pkg:helm/postgresql"},
+		{"npm", "@types/node", "20.8.0", "pkg:npm/%40types/node@20.8.0"},
+	}
+
+	for _, tt := range tests {
+		if got := PURL(tt.ecosystem, tt.name, tt.version); got != tt.expected {
+			t.Errorf("Expected '%s', got '%s'", tt.expected, got)
+		}
+	}
+}
+
+func TestPURLTypes(t *testing.T) {
+	types, err := NewPURLTypes(map[string]string{"deb": "Alpine", "go": "go"})
+	if err != nil {
+		t.Fatalf("Failed to create types: %v", err)
+	}
+	if got := types.Retype(PURL("deb", "musl", "1.2.4-r2")); got != "pkg:alpine/musl@1.2.4-r2" {
+		t.Errorf("Expected overridden type, got '%s'", got)
+	}
+	if got := types.Retype(PURL("go", "golang.org/x/net", "v0.17.0")); got != "pkg:go/golang.org/x/net@v0.17.0" {
+		t.Errorf("Expected overridden Go type, got '%s'", got)
+	}
+	if got := types.Retype("pkg:npm/lodash@4.17.21"); got != "pkg:npm/lodash@4.17.21" {
+		t.Errorf("Expected other types to be kept, got '%s'", got)
+	}
+	if PURLType("go") != "golang" {
+		t.Errorf("Expected default Go type 'golang', got '%s'", PURLType("go"))
+	}
+	if _, err := NewPURLTypes(map[string]string{"deb": "not a type"}); err == nil {
+		t.Error("Expected error for an invalid type")
+	}
+}
+
+func TestSBOM_RetypePURLs(t *testing.T) {
+	types, _ := NewPURLTypes(map[string]string{"go": "go"})
+	doc := New("app", "1.0.0", "serial-001")
+	doc.SetRoot(Component{Name: "app", PURL: "pkg:golang/example.com/app"})
+	doc.AddComponent(Component{Name: "golang.org/x/net", PURL: "pkg:golang/golang.org/x/net@v0.17.0"})
+	doc.AddRelationship("pkg:golang/example.com/app", "pkg:golang/golang.org/x/net@v0.17.0", DependsOn)
+
+	doc.RetypePURLs(types)
+
+	if doc.Root.PURL != "pkg:go/example.com/app" || doc.Components[0].PURL != "pkg:go/golang.org/x/net@v0.17.0" {
+		t.Errorf("Expected root and component to be retyped, got '%s' and '%s'", doc.Root.PURL, doc.Components[0].PURL)
+	}
+	if rel := doc.Relationships[0]; rel.RefA != doc.Root.PURL || rel.RefB != doc.Components[0].PURL {
+		t.Errorf("Expected relationship to follow the retyped PURLs, got %+v", rel)
+	}
+}
+
+func TestPURLWithQualifiers(t *testing.T) {
+	tests := []struct {
+		name       string
+		qualifiers map[string]string
+		expected   string
+	}{
+		{"arch", map[string]string{"distro": "jessie", "arch": "i386"}, "pkg:deb/debian/curl@7.50.3-1?arch=i386&distro=jessie"},
+		{"uppercase key", map[string]string{"Arch": "amd64"}, "pkg:deb/debian/curl@7.50.3-1?arch=amd64"},
+		{"empty value", map[string]string{"arch": "", "distro": "bookworm"}, "pkg:deb/debian/curl@7.50.3-1?distro=bookworm"},
+		{"none", nil, "pkg:deb/debian/curl@7.50.3-1"},
+		{"escaped", map[string]string{"repository_url": "https://repo.example.com/deb"}, "pkg:deb/debian/curl@7.50.3-1?repository_url=https%3A%2F%2Frepo.example.com%2Fdeb"},
+	}
+
+	for _, tt := range tests {
+		if got := PURLWithQualifiers("deb", "debian/curl", "7.50.3-1", tt.qualifiers); got != tt.expected {
+			t.Errorf("%s: Expected '%s', got '%s'", tt.name, tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package sbom
+
+import "sync"
+
+// SafeSBOM guards an SBOM so that components and relationships can be added
+// from several goroutines, such as analyzers running in parallel.
+type SafeSBOM struct {
+	mu  sync.Mutex
+	doc *SBOM
+}
+
+// NewSafe wraps doc for concurrent use. doc must not be modified directly
+// while the wrapper is in use.
+func NewSafe(doc *SBOM) *SafeSBOM {
+	return &SafeSBOM{doc: doc}
+}
+
+// AddComponent adds a component to the SBOM.
+func (s *SafeSBOM) AddComponent(component Component) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.doc.AddComponent(component)
+}
+
+// AddRelationship adds a relationship between components, as
+// SBOM.AddRelationship does.
+func (s *SafeSBOM) AddRelationship(refA, refB string, relationship RelationshipType) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.doc.AddRelationship(refA, refB, relationship)
+}
+
+// Update calls fn with the SBOM while holding the lock, for changes that
+// have no dedicated method.
+func (s *SafeSBOM) Update(fn func(doc *SBOM)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(s.doc)
+}
+
+// SBOM returns the wrapped SBOM. It must only be used once every goroutine
+// writing through the wrapper has finished.
+func (s *SafeSBOM) SBOM() *SBOM {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.doc
+}
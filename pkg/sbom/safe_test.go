@@ -0,0 +1,36 @@
+package sbom
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestSafeSBOM_ConcurrentAdds(t *testing.T) {
+	safe := NewSafe(New("test-app", "1.0.0", "serial-001"))
+
+	const workers, perWorker = 10, 100
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				purl := fmt.Sprintf("pkg:npm/lib-%d-%d@1.0.0", w, i)
+				safe.AddComponent(Component{Name: fmt.Sprintf("lib-%d-%d", w, i), PURL: purl})
+				if i > 0 {
+					safe.AddRelationship(fmt.Sprintf("pkg:npm/lib-%d-0@1.0.0", w), purl, DependsOn)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	doc := safe.SBOM()
+	if len(doc.Components) != workers*perWorker {
+		t.Errorf("Expected %d components, got %d", workers*perWorker, len(doc.Components))
+	}
+	if len(doc.Relationships) != workers*(perWorker-1) {
+		t.Errorf("Expected %d relationships, got %d", workers*(perWorker-1), len(doc.Relationships))
+	}
+}
@@ -2,30 +2,50 @@
 package sbom
 
 import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
 	"time"
 )
 
 // Component represents a software component in the SBOM.
 type Component struct {
-	Name         string    `json:"name" yaml:"name"`
-	Version      string    `json:"version" yaml:"version"`
-	Supplier     string    `json:"supplier,omitempty" yaml:"supplier,omitempty"`
-	License      string    `json:"license,omitempty" yaml:"license,omitempty"`
-	PURL         string    `json:"purl,omitempty" yaml:"purl,omitempty"`
-	CPE          string    `json:"cpe,omitempty" yaml:"cpe,omitempty"`
-	Metadata     Metadata  `json:"metadata,omitempty" yaml:"metadata,omitempty"`
-	Dependencies []string  `json:"dependencies,omitempty" yaml:"dependencies,omitempty"`
-	Hashes       []Hash    `json:"hashes,omitempty" yaml:"hashes,omitempty"`
+	Name         string   `json:"name" yaml:"name"`
+	Version      string   `json:"version" yaml:"version"`
+	Supplier     string   `json:"supplier,omitempty" yaml:"supplier,omitempty"`
+	License      string   `json:"license,omitempty" yaml:"license,omitempty"`
+	PURL         string   `json:"purl,omitempty" yaml:"purl,omitempty"`
+	CPE          string   `json:"cpe,omitempty" yaml:"cpe,omitempty"`
+	Metadata     Metadata `json:"metadata,omitempty" yaml:"metadata,omitempty"`
+	Dependencies []string `json:"dependencies,omitempty" yaml:"dependencies,omitempty"`
+	Hashes       []Hash   `json:"hashes,omitempty" yaml:"hashes,omitempty"`
+	Scope        Scope    `json:"scope,omitempty" yaml:"scope,omitempty"`
+	Direct       bool     `json:"direct,omitempty" yaml:"direct,omitempty"`
+	ExternalRefs []string `json:"externalRefs,omitempty" yaml:"externalRefs,omitempty"`
 }
 
 // Metadata contains additional information about a component.
 type Metadata struct {
-	Author       string    `json:"author,omitempty" yaml:"author,omitempty"`
-	Publisher    string    `json:"publisher,omitempty" yaml:"publisher,omitempty"`
-	Description  string    `json:"description,omitempty" yaml:"description,omitempty"`
-	HomepageURL  string    `json:"homepage_url,omitempty" yaml:"homepage_url,omitempty"`
-	SourceURL    string    `json:"source_url,omitempty" yaml:"source_url,omitempty"`
-	LastModified time.Time `json:"last_modified,omitempty" yaml:"last_modified,omitempty"`
+	Author            string        `json:"author,omitempty" yaml:"author,omitempty"`
+	Publisher         string        `json:"publisher,omitempty" yaml:"publisher,omitempty"`
+	Description       string        `json:"description,omitempty" yaml:"description,omitempty"`
+	HomepageURL       string        `json:"homepage_url,omitempty" yaml:"homepage_url,omitempty"`
+	SourceURL         string        `json:"source_url,omitempty" yaml:"source_url,omitempty"`
+	ProvenanceURL     string        `json:"provenance_url,omitempty" yaml:"provenance_url,omitempty"`
+	Revision          string        `json:"revision,omitempty" yaml:"revision,omitempty"`
+	LastModified      time.Time     `json:"last_modified,omitempty" yaml:"last_modified,omitempty"`
+	SourceFile        string        `json:"source_file,omitempty" yaml:"source_file,omitempty"`
+	SourceLine        int           `json:"source_line,omitempty" yaml:"source_line,omitempty"`
+	Module            string        `json:"module,omitempty" yaml:"module,omitempty"`
+	LicenseFile       string        `json:"license_file,omitempty" yaml:"license_file,omitempty"`
+	LicenseSource     LicenseSource `json:"license_source,omitempty" yaml:"license_source,omitempty"`
+	Deprecated        bool          `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+	DeprecationReason string        `json:"deprecation_reason,omitempty" yaml:"deprecation_reason,omitempty"`
+	// Checksum is a checksum with no SBOM hash algorithm, such as the
+	// "h1:" go.sum checksum of a Go module, which hashes the hashes of
+	// the module's files.
+	Checksum string `json:"checksum,omitempty" yaml:"checksum,omitempty"`
 }
 
 // Hash represents a cryptographic hash of a component.
@@ -34,26 +54,126 @@ type Hash struct {
 	Value     string `json:"value" yaml:"value"`
 }
 
-// SBOM represents the complete Software Bill of Materials.
+// SBOM represents the complete Software Bill of Materials. Its methods are
+// not safe for concurrent use; wrap it in a SafeSBOM to build it from
+// several goroutines.
 type SBOM struct {
-	SpecVersion   string      `json:"specVersion" yaml:"specVersion"`
-	Name          string      `json:"name" yaml:"name"`
-	Version       string      `json:"version" yaml:"version"`
-	SerialNumber  string      `json:"serialNumber" yaml:"serialNumber"`
-	Created       time.Time   `json:"created" yaml:"created"`
-	Author        string      `json:"author,omitempty" yaml:"author,omitempty"`
-	Provider      string      `json:"provider,omitempty" yaml:"provider,omitempty"`
-	Description   string      `json:"description,omitempty" yaml:"description,omitempty"`
-	Components    []Component `json:"components" yaml:"components"`
-	Relationships []Relationship `json:"relationships,omitempty" yaml:"relationships,omitempty"`
-	Annotations   []Annotation `json:"annotations,omitempty" yaml:"annotations,omitempty"`
+	SpecVersion  string    `json:"specVersion" yaml:"specVersion"`
+	Name         string    `json:"name" yaml:"name"`
+	Version      string    `json:"version" yaml:"version"`
+	SerialNumber string    `json:"serialNumber" yaml:"serialNumber"`
+	Created      time.Time `json:"created" yaml:"created"`
+	// ToolVersion is the version of sbomgen that generated the SBOM;
+	// Version is that of the project once a root is set.
+	ToolVersion     string          `json:"toolVersion,omitempty" yaml:"toolVersion,omitempty"`
+	Author          string          `json:"author,omitempty" yaml:"author,omitempty"`
+	Provider        string          `json:"provider,omitempty" yaml:"provider,omitempty"`
+	Description     string          `json:"description,omitempty" yaml:"description,omitempty"`
+	Root            *Component      `json:"root,omitempty" yaml:"root,omitempty"`
+	Components      []Component     `json:"components" yaml:"components"`
+	Relationships   []Relationship  `json:"relationships,omitempty" yaml:"relationships,omitempty"`
+	Annotations     []Annotation    `json:"annotations,omitempty" yaml:"annotations,omitempty"`
+	Vulnerabilities []Vulnerability `json:"vulnerabilities,omitempty" yaml:"vulnerabilities,omitempty"`
+
+	// relIndex holds the keys of the first relIndexed Relationships, so
+	// that AddRelationship finds duplicates without scanning them all. It
+	// is rebuilt whenever Relationships has changed length behind its back.
+	relIndex   map[Relationship]struct{}
+	relIndexed int
 }
 
 // Relationship represents a relationship between components.
 type Relationship struct {
-	RefA         string `json:"refA" yaml:"refA"`
-	RefB         string `json:"refB" yaml:"refB"`
-	Relationship string `json:"relationship" yaml:"relationship"`
+	RefA         string           `json:"refA" yaml:"refA"`
+	RefB         string           `json:"refB" yaml:"refB"`
+	Relationship RelationshipType `json:"relationship" yaml:"relationship"`
+}
+
+// RelationshipType identifies the kind of edge between two components.
+type RelationshipType string
+
+const (
+	DependsOn            RelationshipType = "depends_on"
+	Contains             RelationshipType = "contains"
+	BuildDependencyOf    RelationshipType = "build_dependency_of"
+	DevDependencyOf      RelationshipType = "dev_dependency_of"
+	OptionalDependencyOf RelationshipType = "optional_dependency_of"
+)
+
+var relationshipTypes = map[RelationshipType]struct{}{
+	DependsOn:            {},
+	Contains:             {},
+	BuildDependencyOf:    {},
+	DevDependencyOf:      {},
+	OptionalDependencyOf: {},
+}
+
+// ParseRelationshipType converts s into a known relationship type. Both the
+// internal spelling ("depends_on") and the SPDX keyword ("DEPENDS_ON") are
+// accepted, as are hyphenated variants.
+func ParseRelationshipType(s string) (RelationshipType, error) {
+	t := RelationshipType(strings.ToLower(strings.ReplaceAll(strings.TrimSpace(s), "-", "_")))
+	if !t.IsValid() {
+		return "", fmt.Errorf("unknown relationship type: %q", s)
+	}
+	return t, nil
+}
+
+// IsValid reports whether t is one of the known relationship types.
+func (t RelationshipType) IsValid() bool {
+	_, ok := relationshipTypes[t]
+	return ok
+}
+
+// UnmarshalText normalizes decoded relationship types through
+// ParseRelationshipType so serialized SBOMs always carry canonical values.
+func (t *RelationshipType) UnmarshalText(text []byte) error {
+	parsed, err := ParseRelationshipType(string(text))
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
+
+// SPDX returns the SPDX relationship keyword for t, or an empty string if t
+// is unknown.
+func (t RelationshipType) SPDX() string {
+	if !t.IsValid() {
+		return ""
+	}
+	return strings.ToUpper(string(t))
+}
+
+// CycloneDX returns the CycloneDX dependency graph field for t. CycloneDX
+// only models plain dependency edges, so build, dev and optional dependencies
+// deliberately merge into "dependsOn"; the distinction is carried by the
+// component scope instead (see CycloneDXScope). CONTAINS is expressed through
+// component nesting and maps to an empty string, as do unknown types.
+func (t RelationshipType) CycloneDX() string {
+	switch t {
+	case DependsOn, BuildDependencyOf, DevDependencyOf, OptionalDependencyOf:
+		return "dependsOn"
+	default:
+		return ""
+	}
+}
+
+// CycloneDXScope returns the CycloneDX component scope implied by t:
+// "required" for runtime dependencies, "optional" for optional ones and
+// "excluded" for build and dev dependencies that never ship at runtime.
+// Types without a scope meaning return an empty string.
+func (t RelationshipType) CycloneDXScope() string {
+	switch t {
+	case DependsOn:
+		return "required"
+	case OptionalDependencyOf:
+		return "optional"
+	case BuildDependencyOf, DevDependencyOf:
+		return "excluded"
+	default:
+		return ""
+	}
 }
 
 // Annotation represents an annotation on the SBOM.
@@ -67,7 +187,7 @@ type Annotation struct {
 // New creates a new empty SBOM instance.
 func New(name, version, serialNumber string) *SBOM {
 	return &SBOM{
-		SpecVersion:   "0.24.0",
+		SpecVersion:   CurrentSpecVersion,
 		Name:          name,
 		Version:       version,
 		SerialNumber:  serialNumber,
@@ -83,15 +203,180 @@ func (s *SBOM) AddComponent(component Component) {
 	s.Components = append(s.Components, component)
 }
 
-// AddRelationship adds a relationship between components.
-func (s *SBOM) AddRelationship(refA, refB, relationship string) {
-	s.Relationships = append(s.Relationships, Relationship{
+// SetRoot records the component describing the scanned project itself and
+// names the SBOM after it. The tool that generated the SBOM is recorded in
+// ToolVersion, which is left unchanged.
+func (s *SBOM) SetRoot(root Component) {
+	s.Root = &root
+	if root.Name != "" {
+		s.Name = root.Name
+	}
+	if root.Version != "" {
+		s.Version = root.Version
+	}
+}
+
+// DropRoot removes the root component and the relationships that reference
+// it, leaving only the project's dependencies. The SBOM keeps the name and
+// version taken from the root.
+func (s *SBOM) DropRoot() {
+	if s.Root == nil {
+		return
+	}
+	refs := map[string]bool{s.Root.Name: s.Root.Name != "", s.Root.PURL: s.Root.PURL != ""}
+	s.Root = nil
+	kept := s.Relationships[:0]
+	for _, rel := range s.Relationships {
+		if refs[rel.RefA] || refs[rel.RefB] {
+			continue
+		}
+		kept = append(kept, rel)
+	}
+	s.Relationships = kept
+}
+
+// StripMetadata clears the metadata of every component, including the
+// root, and drops the SBOM's annotations, for sharing an inventory without
+// authors, links, source locations or review notes. Names, versions,
+// licenses, PURLs, hashes and relationships are kept.
+func (s *SBOM) StripMetadata() {
+	for i := range s.Components {
+		s.Components[i].Metadata = Metadata{}
+	}
+	if s.Root != nil {
+		s.Root.Metadata = Metadata{}
+	}
+	s.Annotations = make([]Annotation, 0)
+}
+
+// ErrSelfLoop is returned when a relationship would relate a component to
+// itself.
+var ErrSelfLoop = errors.New("relationship refers to itself")
+
+// AddRelationship adds a relationship between components. A relationship
+// that is already present is ignored, and one from a component to itself is
+// rejected with ErrSelfLoop.
+func (s *SBOM) AddRelationship(refA, refB string, relationship RelationshipType) error {
+	rel := Relationship{
 		RefA:         refA,
 		RefB:         refB,
 		Relationship: relationship,
+	}
+	if refA == refB {
+		return fmt.Errorf("%w: %s %s %s", ErrSelfLoop, refA, relationship, refB)
+	}
+	if s.relIndex == nil || s.relIndexed != len(s.Relationships) {
+		s.relIndex = make(map[Relationship]struct{}, len(s.Relationships))
+		for _, existing := range s.Relationships {
+			s.relIndex[existing.key()] = struct{}{}
+		}
+		s.relIndexed = len(s.Relationships)
+	}
+	key := rel.key()
+	if _, ok := s.relIndex[key]; ok {
+		return nil
+	}
+	s.relIndex[key] = struct{}{}
+	s.Relationships = append(s.Relationships, rel)
+	s.relIndexed++
+	return nil
+}
+
+// NormalizeRelationships removes self-loops and duplicate relationships,
+// such as those read from an SBOM produced by another tool, keeping the
+// first occurrence of each.
+func (s *SBOM) NormalizeRelationships() {
+	seen := make(map[Relationship]bool)
+	rels := s.Relationships[:0]
+	for _, rel := range s.Relationships {
+		key := rel.key()
+		if rel.RefA == rel.RefB || seen[key] {
+			continue
+		}
+		seen[key] = true
+		rels = append(rels, rel)
+	}
+	s.Relationships = rels
+	s.relIndex = nil
+}
+
+// key identifies rel for duplicate detection, treating spellings that parse
+// to the same relationship type as equal.
+func (rel Relationship) key() Relationship {
+	if t, err := ParseRelationshipType(string(rel.Relationship)); err == nil {
+		rel.Relationship = t
+	}
+	return rel
+}
+
+// Remove drops every component whose name or PURL matches glob, using
+// path.Match syntax, along with any relationships that reference a removed
+// component. It returns the number of components removed. A malformed glob
+// matches nothing.
+func (s *SBOM) Remove(glob string) int {
+	return s.removeWhere(func(comp Component) bool {
+		return matchGlob(glob, comp.Name) || matchGlob(glob, comp.PURL)
 	})
 }
 
+// KeepDirect drops every component that is not a direct dependency of the
+// project, along with any relationships that reference one, leaving the
+// dependencies the project itself declares. It returns the number of
+// components removed.
+func (s *SBOM) KeepDirect() int {
+	return s.removeWhere(func(comp Component) bool {
+		return !comp.Direct
+	})
+}
+
+// removeWhere drops the components for which drop returns true and the
+// relationships that reference them, returning the number removed.
+// Relationships refer to a component by its PURL or its name@version; a
+// bare name only counts as a reference to a removed component when no
+// kept component has that name, so that removing one version of a package
+// keeps the relationships of another.
+func (s *SBOM) removeWhere(drop func(Component) bool) int {
+	removed := make(map[string]bool)
+	kept := s.Components[:0]
+	for _, comp := range s.Components {
+		if drop(comp) {
+			removed[comp.Name] = true
+			removed[comp.Name+"@"+comp.Version] = true
+			if comp.PURL != "" {
+				removed[comp.PURL] = true
+			}
+			continue
+		}
+		kept = append(kept, comp)
+	}
+	count := len(s.Components) - len(kept)
+	s.Components = kept
+
+	if count > 0 {
+		for _, comp := range kept {
+			delete(removed, comp.Name)
+		}
+		rels := s.Relationships[:0]
+		for _, rel := range s.Relationships {
+			if removed[rel.RefA] || removed[rel.RefB] {
+				continue
+			}
+			rels = append(rels, rel)
+		}
+		s.Relationships = rels
+	}
+
+	return count
+}
+
+func matchGlob(glob, value string) bool {
+	if value == "" {
+		return false
+	}
+	matched, err := path.Match(glob, value)
+	return err == nil && matched
+}
+
 // GetComponentByPURL finds a component by its package URL.
 func (s *SBOM) GetComponentByPURL(purl string) *Component {
 	for i := range s.Components {
@@ -102,12 +387,20 @@ func (s *SBOM) GetComponentByPURL(purl string) *Component {
 	return nil
 }
 
-// GetComponentsByLicense returns components matching a license.
+// GetComponentsByLicense returns components matching a license. A component
+// whose license is an OR expression matches if any of its terms does.
 func (s *SBOM) GetComponentsByLicense(license string) []Component {
 	var result []Component
 	for _, comp := range s.Components {
 		if comp.License == license {
 			result = append(result, comp)
+			continue
+		}
+		for _, term := range LicenseDisjuncts(comp.License) {
+			if term == license {
+				result = append(result, comp)
+				break
+			}
 		}
 	}
 	return result
@@ -128,4 +421,4 @@ func (s *SBOM) HasVulnerableLicense(licenses []string) bool {
 		}
 	}
 	return false
-}
\ No newline at end of file
+}
@@ -1,7 +1,12 @@
 package sbom
 
 import (
+	"encoding/json"
+	"errors"
+	"reflect"
 	"testing"
+
+	"gopkg.in/yaml.v3"
 )
 
 func TestNew(t *testing.T) {
@@ -166,12 +171,71 @@ func TestAddRelationship(t *testing.T) {
 	}
 }
 
+func TestAddRelationship_SelfLoopAndDuplicates(t *testing.T) {
+	sbom := New("test-app", "1.0.0", "serial-001")
+
+	if err := sbom.AddRelationship("ref-a", "ref-a", DependsOn); !errors.Is(err, ErrSelfLoop) {
+		t.Errorf("Expected ErrSelfLoop, got %v", err)
+	}
+	if err := sbom.AddRelationship("ref-a", "ref-b", DependsOn); err != nil {
+		t.Fatalf("Failed to add relationship: %v", err)
+	}
+	if err := sbom.AddRelationship("ref-a", "ref-b", "DEPENDS-ON"); err != nil {
+		t.Fatalf("Expected duplicate to be ignored, got %v", err)
+	}
+	sbom.AddRelationship("ref-a", "ref-b", Contains)
+
+	if len(sbom.Relationships) != 2 {
+		t.Errorf("Expected 2 relationships, got %d: %v", len(sbom.Relationships), sbom.Relationships)
+	}
+}
+
+func TestAddRelationship_DuplicatesAfterDirectChanges(t *testing.T) {
+	sbom := New("test-app", "1.0.0", "serial-001")
+	sbom.AddRelationship("ref-a", "ref-b", DependsOn)
+	sbom.Relationships = append(sbom.Relationships, Relationship{RefA: "ref-b", RefB: "ref-c", Relationship: DependsOn})
+
+	sbom.AddRelationship("ref-b", "ref-c", DependsOn)
+	if len(sbom.Relationships) != 2 {
+		t.Errorf("Expected a directly appended relationship to be deduplicated, got %v", sbom.Relationships)
+	}
+
+	sbom.Relationships[0].RefB = "ref-d"
+	sbom.NormalizeRelationships()
+	sbom.AddRelationship("ref-a", "ref-d", DependsOn)
+	sbom.AddRelationship("ref-a", "ref-b", DependsOn)
+	if len(sbom.Relationships) != 3 {
+		t.Errorf("Expected 3 relationships after normalizing, got %v", sbom.Relationships)
+	}
+}
+
+func TestNormalizeRelationships(t *testing.T) {
+	sbom := New("test-app", "1.0.0", "serial-001")
+	sbom.Relationships = []Relationship{
+		{RefA: "ref-a", RefB: "ref-b", Relationship: DependsOn},
+		{RefA: "ref-c", RefB: "ref-c", Relationship: DependsOn},
+		{RefA: "ref-a", RefB: "ref-b", Relationship: DependsOn},
+		{RefA: "ref-b", RefB: "ref-a", Relationship: DependsOn},
+		{RefA: "ref-a", RefB: "ref-b", Relationship: "depends-on"},
+	}
+
+	sbom.NormalizeRelationships()
+
+	expected := []Relationship{
+		{RefA: "ref-a", RefB: "ref-b", Relationship: DependsOn},
+		{RefA: "ref-b", RefB: "ref-a", Relationship: DependsOn},
+	}
+	if !reflect.DeepEqual(sbom.Relationships, expected) {
+		t.Errorf("Expected %v, got %v", expected, sbom.Relationships)
+	}
+}
+
 func TestComponentWithHashes(t *testing.T) {
 	sbom := New("test-app", "1.0.0", "serial-001")
 
 	comp := Component{
-		Name:     "test-lib",
-		Version:  "1.0.0",
+		Name:    "test-lib",
+		Version: "1.0.0",
 		Hashes: []Hash{
 			{Algorithm: "SHA-256", Value: "abc123"},
 			{Algorithm: "MD5", Value: "def456"},
@@ -240,4 +304,238 @@ func TestAddDuplicateComponent(t *testing.T) {
 	if sbom.Count() != 2 {
 		t.Errorf("Expected count 2, got %d", sbom.Count())
 	}
-}
\ No newline at end of file
+}
+
+func TestRelationshipType_SPDX(t *testing.T) {
+	tests := []struct {
+		relType  RelationshipType
+		expected string
+	}{
+		{DependsOn, "DEPENDS_ON"},
+		{Contains, "CONTAINS"},
+		{BuildDependencyOf, "BUILD_DEPENDENCY_OF"},
+		{DevDependencyOf, "DEV_DEPENDENCY_OF"},
+		{OptionalDependencyOf, "OPTIONAL_DEPENDENCY_OF"},
+		{"uses", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.relType), func(t *testing.T) {
+			if got := tt.relType.SPDX(); got != tt.expected {
+				t.Errorf("Expected SPDX keyword '%s', got '%s'", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestRelationshipType_CycloneDX(t *testing.T) {
+	tests := []struct {
+		relType       RelationshipType
+		expectedGraph string
+		expectedScope string
+	}{
+		{DependsOn, "dependsOn", "required"},
+		{BuildDependencyOf, "dependsOn", "excluded"},
+		{DevDependencyOf, "dependsOn", "excluded"},
+		{OptionalDependencyOf, "dependsOn", "optional"},
+		{Contains, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.relType), func(t *testing.T) {
+			if got := tt.relType.CycloneDX(); got != tt.expectedGraph {
+				t.Errorf("Expected graph field '%s', got '%s'", tt.expectedGraph, got)
+			}
+			if got := tt.relType.CycloneDXScope(); got != tt.expectedScope {
+				t.Errorf("Expected scope '%s', got '%s'", tt.expectedScope, got)
+			}
+		})
+	}
+}
+
+func TestParseRelationshipType(t *testing.T) {
+	for _, input := range []string{"depends_on", "DEPENDS_ON", "depends-on"} {
+		relType, err := ParseRelationshipType(input)
+		if err != nil {
+			t.Errorf("Unexpected error for '%s': %v", input, err)
+		}
+		if relType != DependsOn {
+			t.Errorf("Expected '%s' to parse as depends_on, got '%s'", input, relType)
+		}
+	}
+
+	if _, err := ParseRelationshipType("uses"); err == nil {
+		t.Error("Expected error for unknown relationship type")
+	}
+}
+
+func TestRelationshipType_UnmarshalJSON(t *testing.T) {
+	var rel Relationship
+	if err := json.Unmarshal([]byte(`{"refA":"a","refB":"b","relationship":"DEPENDS_ON"}`), &rel); err != nil {
+		t.Fatalf("Failed to unmarshal: %v", err)
+	}
+	if rel.Relationship != DependsOn {
+		t.Errorf("Expected 'depends_on', got '%s'", rel.Relationship)
+	}
+
+	if err := json.Unmarshal([]byte(`{"refA":"a","refB":"b","relationship":"uses"}`), &rel); err == nil {
+		t.Error("Expected error for unknown relationship type")
+	}
+}
+
+func TestRelationshipType_UnmarshalYAML(t *testing.T) {
+	var rel Relationship
+	if err := yaml.Unmarshal([]byte("refA: a\nrefB: b\nrelationship: depends-on\n"), &rel); err != nil {
+		t.Fatalf("Failed to unmarshal: %v", err)
+	}
+	if rel.Relationship != DependsOn {
+		t.Errorf("Expected 'depends_on', got '%s'", rel.Relationship)
+	}
+}
+
+func TestSetRoot(t *testing.T) {
+	sbom := New("sbomgen", "1.0.0", "serial-001")
+
+	sbom.SetRoot(Component{Name: "my-crate", Version: "0.3.1", License: "MIT"})
+
+	if sbom.Root == nil || sbom.Root.Name != "my-crate" {
+		t.Fatal("Expected root component to be set")
+	}
+	if sbom.Name != "my-crate" || sbom.Version != "0.3.1" {
+		t.Errorf("Expected SBOM named after root, got '%s' '%s'", sbom.Name, sbom.Version)
+	}
+	if sbom.Count() != 0 {
+		t.Errorf("Expected root not to be counted as a dependency, got %d", sbom.Count())
+	}
+}
+
+func TestRemove(t *testing.T) {
+	sbom := New("test-project", "1.0.0", "serial-001")
+	sbom.AddComponent(Component{Name: "lodash", Version: "4.17.21", PURL: "pkg:npm/lodash@4.17.21"})
+	sbom.AddComponent(Component{Name: "internal-utils", Version: "0.0.0", PURL: "pkg:npm/internal-utils@0.0.0"})
+	sbom.AddComponent(Component{Name: "github.com/acme/fork", Version: "v1.0.0", PURL: "pkg:golang/github.com/acme/fork@v1.0.0"})
+	sbom.AddComponent(Component{Name: "requests", Version: "2.28.0", PURL: "pkg:pypi/requests@2.28.0"})
+	sbom.AddRelationship("pkg:npm/lodash@4.17.21", "pkg:npm/internal-utils@0.0.0", DependsOn)
+	sbom.AddRelationship("pkg:npm/lodash@4.17.21", "pkg:pypi/requests@2.28.0", DependsOn)
+
+	if n := sbom.Remove("internal-*"); n != 1 {
+		t.Errorf("Expected 1 component removed by name, got %d", n)
+	}
+	if n := sbom.Remove("pkg:golang/github.com/acme/*"); n != 1 {
+		t.Errorf("Expected 1 component removed by PURL, got %d", n)
+	}
+	if n := sbom.Remove("no-such-*"); n != 0 {
+		t.Errorf("Expected 0 components removed, got %d", n)
+	}
+
+	if sbom.Count() != 2 {
+		t.Errorf("Expected 2 components left, got %d", sbom.Count())
+	}
+	if sbom.GetComponentByPURL("pkg:npm/internal-utils@0.0.0") != nil {
+		t.Error("Expected internal-utils to be removed")
+	}
+	if len(sbom.Relationships) != 1 || sbom.Relationships[0].RefB != "pkg:pypi/requests@2.28.0" {
+		t.Errorf("Expected relationship to removed component to be pruned, got %v", sbom.Relationships)
+	}
+}
+
+func TestRemove_KeepsOtherVersions(t *testing.T) {
+	doc := New("app", "1.0.0", "")
+	doc.AddComponent(Component{Name: "debug", Version: "2.6.9", PURL: "pkg:npm/debug@2.6.9"})
+	doc.AddComponent(Component{Name: "debug", Version: "4.3.4", PURL: "pkg:npm/debug@4.3.4"})
+	doc.AddComponent(Component{Name: "ms", Version: "2.1.3"})
+	doc.AddRelationship("app", "pkg:npm/debug@2.6.9", DependsOn)
+	doc.AddRelationship("app", "pkg:npm/debug@4.3.4", DependsOn)
+	doc.AddRelationship("pkg:npm/debug@4.3.4", "ms@2.1.3", DependsOn)
+	doc.AddRelationship("debug", "ms", DependsOn)
+
+	if n := doc.Remove("pkg:npm/debug@2*"); n != 1 {
+		t.Fatalf("Expected 1 component removed, got %d", n)
+	}
+	if len(doc.Relationships) != 3 || doc.Relationships[0].RefB != "pkg:npm/debug@4.3.4" {
+		t.Errorf("Expected the relationships of debug@4.3.4 to be kept, got %v", doc.Relationships)
+	}
+
+	doc.Remove("ms")
+	if len(doc.Relationships) != 1 {
+		t.Errorf("Expected relationships to ms by name@version and bare name to be pruned, got %v", doc.Relationships)
+	}
+}
+
+func TestKeepDirect(t *testing.T) {
+	doc := New("app", "1.0.0", "")
+	doc.SetRoot(Component{Name: "app", Version: "1.0.0"})
+	doc.AddComponent(Component{Name: "express", Version: "4.18.0", PURL: "pkg:npm/express@4.18.0", Direct: true})
+	doc.AddComponent(Component{Name: "body-parser", Version: "1.20.1", PURL: "pkg:npm/body-parser@1.20.1"})
+	doc.AddComponent(Component{Name: "jest", Version: "29.0.0", PURL: "pkg:npm/jest@29.0.0", Scope: ScopeDev, Direct: true})
+	doc.AddRelationship("app", "pkg:npm/express@4.18.0", DependsOn)
+	doc.AddRelationship("pkg:npm/express@4.18.0", "pkg:npm/body-parser@1.20.1", DependsOn)
+
+	if n := doc.KeepDirect(); n != 1 {
+		t.Errorf("Expected 1 transitive component removed, got %d", n)
+	}
+	if doc.GetComponentByPURL("pkg:npm/body-parser@1.20.1") != nil {
+		t.Error("Expected transitive body-parser to be removed")
+	}
+	if doc.Count() != 2 || doc.GetComponentByPURL("pkg:npm/jest@29.0.0") == nil {
+		t.Errorf("Expected direct components of every scope to be kept, got %v", doc.Components)
+	}
+	if len(doc.Relationships) != 1 || doc.Relationships[0].RefB != "pkg:npm/express@4.18.0" {
+		t.Errorf("Expected relationships to the transitive component to be pruned, got %v", doc.Relationships)
+	}
+}
+
+func TestSBOM_StripMetadata(t *testing.T) {
+	doc := New("app", "1.0.0", "")
+	doc.SetRoot(Component{Name: "app", Version: "1.0.0", Metadata: Metadata{SourceURL: "https://git.internal/app"}})
+	doc.AddComponent(Component{
+		Name:     "express",
+		Version:  "4.18.0",
+		License:  "MIT",
+		PURL:     "pkg:npm/express@4.18.0",
+		Hashes:   []Hash{{Algorithm: "SHA-256", Value: "abc"}},
+		Metadata: Metadata{Author: "TJ Holowaychuk", HomepageURL: "http://expressjs.com/", SourceFile: "/home/dev/app/package.json", SourceLine: 12},
+	})
+	doc.AddRelationship("app", "pkg:npm/express@4.18.0", DependsOn)
+	doc.Annotations = append(doc.Annotations, Annotation{ComponentRef: "pkg:npm/express@4.18.0", EventType: "license_override", Summary: "reviewed by legal"})
+
+	doc.StripMetadata()
+
+	comp := doc.Components[0]
+	if comp.Metadata != (Metadata{}) {
+		t.Errorf("Expected component metadata to be cleared, got %+v", comp.Metadata)
+	}
+	if doc.Root.Metadata != (Metadata{}) {
+		t.Errorf("Expected root metadata to be cleared, got %+v", doc.Root.Metadata)
+	}
+	if len(doc.Annotations) != 0 {
+		t.Errorf("Expected annotations to be dropped, got %v", doc.Annotations)
+	}
+	if comp.Name != "express" || comp.Version != "4.18.0" || comp.License != "MIT" || comp.PURL != "pkg:npm/express@4.18.0" || len(comp.Hashes) != 1 {
+		t.Errorf("Expected core fields to survive, got %+v", comp)
+	}
+	if len(doc.Relationships) != 1 {
+		t.Errorf("Expected relationships to be kept, got %v", doc.Relationships)
+	}
+}
+
+func TestSBOM_DropRoot(t *testing.T) {
+	doc := New("", "", "")
+	doc.SetRoot(Component{Name: "app", Version: "1.0.0", PURL: "pkg:npm/app@1.0.0"})
+	doc.AddComponent(Component{Name: "lib", PURL: "pkg:npm/lib@1.0.0"})
+	doc.AddComponent(Component{Name: "util", PURL: "pkg:npm/util@1.0.0"})
+	doc.AddRelationship("pkg:npm/app@1.0.0", "pkg:npm/lib@1.0.0", DependsOn)
+	doc.AddRelationship("pkg:npm/lib@1.0.0", "pkg:npm/util@1.0.0", DependsOn)
+
+	doc.DropRoot()
+
+	if doc.Root != nil {
+		t.Errorf("Expected no root, got %+v", doc.Root)
+	}
+	if doc.Name != "app" || len(doc.Components) != 2 {
+		t.Errorf("Expected document name and components to be kept, got %s with %d components", doc.Name, len(doc.Components))
+	}
+	if len(doc.Relationships) != 1 || doc.Relationships[0].RefA != "pkg:npm/lib@1.0.0" {
+		t.Errorf("Expected only the lib -> util relationship, got %v", doc.Relationships)
+	}
+}
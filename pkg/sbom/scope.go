@@ -0,0 +1,62 @@
+package sbom
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Scope classifies when a component is needed. The empty scope means the
+// analyzer recorded no restriction and is treated as runtime.
+type Scope string
+
+const (
+	ScopeRuntime  Scope = "runtime"
+	ScopeDev      Scope = "dev"
+	ScopeBuild    Scope = "build"
+	ScopeTest     Scope = "test"
+	ScopeOptional Scope = "optional"
+	// ScopeInternal marks first-party components, such as workspace
+	// packages and local replacements, which ship like runtime ones.
+	ScopeInternal Scope = "internal"
+	// ScopeToolchain marks language runtimes, such as Node.js or Python,
+	// that a project pins in a version file.
+	ScopeToolchain Scope = "runtime-toolchain"
+)
+
+// Effective returns the scope used for filtering: runtime for the empty
+// and internal scopes, s otherwise.
+func (s Scope) Effective() Scope {
+	if s == "" || s == ScopeInternal {
+		return ScopeRuntime
+	}
+	return s
+}
+
+// ParseScopes parses a comma-separated list of scopes such as
+// "runtime,build".
+func ParseScopes(list string) ([]Scope, error) {
+	var scopes []Scope
+	for _, name := range strings.Split(list, ",") {
+		scope := Scope(strings.ToLower(strings.TrimSpace(name)))
+		switch scope {
+		case ScopeRuntime, ScopeDev, ScopeBuild, ScopeTest, ScopeOptional, ScopeToolchain:
+			scopes = append(scopes, scope)
+		default:
+			return nil, fmt.Errorf("unknown scope %q (want runtime, dev, build, test, optional or runtime-toolchain)", name)
+		}
+	}
+	return scopes, nil
+}
+
+// KeepScopes drops every component whose effective scope is not listed,
+// along with any relationships that reference a removed component. It
+// returns the number of components removed.
+func (s *SBOM) KeepScopes(scopes []Scope) int {
+	keep := make(map[Scope]bool, len(scopes))
+	for _, scope := range scopes {
+		keep[scope] = true
+	}
+	return s.removeWhere(func(comp Component) bool {
+		return !keep[comp.Scope.Effective()]
+	})
+}
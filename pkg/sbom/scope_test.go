@@ -0,0 +1,49 @@
+package sbom
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseScopes(t *testing.T) {
+	scopes, err := ParseScopes("runtime, Build,runtime-toolchain")
+	if err != nil {
+		t.Fatalf("Failed to parse scopes: %v", err)
+	}
+	if !reflect.DeepEqual(scopes, []Scope{ScopeRuntime, ScopeBuild, ScopeToolchain}) {
+		t.Errorf("Expected [runtime build runtime-toolchain], got %v", scopes)
+	}
+
+	for _, list := range []string{"runtime,compile", "", "internal"} {
+		if _, err := ParseScopes(list); err == nil {
+			t.Errorf("Expected error for %q", list)
+		}
+	}
+}
+
+func TestSBOM_KeepScopes(t *testing.T) {
+	doc := New("app", "1.0.0", "")
+	doc.AddComponent(Component{Name: "express", PURL: "pkg:npm/express@4.18.0"})
+	doc.AddComponent(Component{Name: "shared", Scope: ScopeInternal})
+	doc.AddComponent(Component{Name: "cc", PURL: "pkg:cargo/cc@1.0.83", Scope: ScopeBuild})
+	doc.AddComponent(Component{Name: "jest", PURL: "pkg:npm/jest@29.0.0", Scope: ScopeDev})
+	doc.AddComponent(Component{Name: "junit", Scope: ScopeTest})
+	if err := doc.AddRelationship("pkg:npm/express@4.18.0", "pkg:npm/jest@29.0.0", DevDependencyOf); err != nil {
+		t.Fatalf("Failed to add relationship: %v", err)
+	}
+
+	if removed := doc.KeepScopes([]Scope{ScopeRuntime, ScopeBuild}); removed != 2 {
+		t.Errorf("Expected 2 components removed, got %d", removed)
+	}
+
+	var names []string
+	for _, comp := range doc.Components {
+		names = append(names, comp.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"express", "shared", "cc"}) {
+		t.Errorf("Expected express, shared and cc to remain, got %v", names)
+	}
+	if len(doc.Relationships) != 0 {
+		t.Errorf("Expected relationship to a removed component to be dropped, got %v", doc.Relationships)
+	}
+}
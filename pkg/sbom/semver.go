@@ -0,0 +1,139 @@
+package sbom
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// semver is a parsed semantic version. Build metadata is dropped as it
+// does not take part in ordering.
+type semver struct {
+	core       [3]int
+	prerelease []string
+}
+
+// parseSemver parses versions such as "1.2.3", "v1.2.3-rc.1+build.5" and
+// the exact npm ranges "^1.2.3" and "~1.2.3". A missing minor or patch
+// number counts as zero, so "2.28" reads as 2.28.0.
+func parseSemver(version string) (semver, bool) {
+	var v semver
+	version = strings.TrimLeft(strings.TrimSpace(version), "^~=v")
+	version, _, _ = strings.Cut(version, "+")
+	version, pre, hasPre := strings.Cut(version, "-")
+	if hasPre {
+		if pre == "" {
+			return v, false
+		}
+		v.prerelease = strings.Split(pre, ".")
+		for _, id := range v.prerelease {
+			if id == "" {
+				return v, false
+			}
+		}
+	}
+
+	parts := strings.Split(version, ".")
+	if len(parts) > 3 {
+		return v, false
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 || part[0] == '+' {
+			return v, false
+		}
+		v.core[i] = n
+	}
+	return v, true
+}
+
+// compare orders v and w by semver precedence.
+func (v semver) compare(w semver) int {
+	for i := range v.core {
+		if c := compareInts(v.core[i], w.core[i]); c != 0 {
+			return c
+		}
+	}
+	// A release sorts after all of its pre-releases.
+	switch {
+	case len(v.prerelease) == 0 && len(w.prerelease) == 0:
+		return 0
+	case len(v.prerelease) == 0:
+		return 1
+	case len(w.prerelease) == 0:
+		return -1
+	}
+	for i := 0; i < len(v.prerelease) && i < len(w.prerelease); i++ {
+		if c := comparePrerelease(v.prerelease[i], w.prerelease[i]); c != 0 {
+			return c
+		}
+	}
+	return compareInts(len(v.prerelease), len(w.prerelease))
+}
+
+// comparePrerelease orders two pre-release identifiers: numeric ones
+// numerically and below alphanumeric ones, which compare as ASCII.
+func comparePrerelease(a, b string) int {
+	na, errA := strconv.Atoi(a)
+	nb, errB := strconv.Atoi(b)
+	switch {
+	case errA == nil && errB == nil:
+		return compareInts(na, nb)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// CompareVersions compares two versions by semver precedence, returning
+// -1, 0 or 1. ok is false when either is not a semantic version, in which
+// case the result is meaningless.
+func CompareVersions(a, b string) (result int, ok bool) {
+	va, okA := parseSemver(a)
+	vb, okB := parseSemver(b)
+	if !okA || !okB {
+		return 0, false
+	}
+	return va.compare(vb), true
+}
+
+// Outdated describes a component whose version is behind the latest one.
+type Outdated struct {
+	Name    string
+	Current string
+	Latest  string
+}
+
+// OutdatedAgainst reports the components whose version is older than the
+// one latest maps their name to, sorted by name. Components without an
+// entry, and those where either version is not semver, are skipped.
+func (s *SBOM) OutdatedAgainst(latest map[string]string) []Outdated {
+	var outdated []Outdated
+	for _, comp := range s.Components {
+		newest, ok := latest[comp.Name]
+		if !ok {
+			continue
+		}
+		if c, ok := CompareVersions(comp.Version, newest); ok && c < 0 {
+			outdated = append(outdated, Outdated{Name: comp.Name, Current: comp.Version, Latest: newest})
+		}
+	}
+	sort.SliceStable(outdated, func(i, j int) bool {
+		return outdated[i].Name < outdated[j].Name
+	})
+	return outdated
+}
@@ -0,0 +1,86 @@
+package sbom
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"1.2.3", "1.2.3", 0},
+		{"1.2.3", "1.10.0", -1},
+		{"2.0.0", "1.99.99", 1},
+		{"v1.9.0", "1.9.1", -1},
+		{"^4.18.0", "4.18.2", -1},
+		{"2.28", "2.28.0", 0},
+		{"1.0.0+build.1", "1.0.0+build.2", 0},
+		{"1.0.0-rc.1", "1.0.0", -1},
+		{"1.0.0", "1.0.0-rc.1", 1},
+	}
+	for _, tt := range tests {
+		got, ok := CompareVersions(tt.a, tt.b)
+		if !ok || got != tt.expected {
+			t.Errorf("CompareVersions(%q, %q): expected %d, got %d (ok: %v)", tt.a, tt.b, tt.expected, got, ok)
+		}
+	}
+}
+
+func TestCompareVersions_PrereleaseOrdering(t *testing.T) {
+	// The precedence example from the semver 2.0.0 specification.
+	ordered := []string{
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+	}
+	for i := 0; i < len(ordered); i++ {
+		for j := 0; j < len(ordered); j++ {
+			expected := compareInts(i, j)
+			if got, ok := CompareVersions(ordered[i], ordered[j]); !ok || got != expected {
+				t.Errorf("CompareVersions(%q, %q): expected %d, got %d (ok: %v)", ordered[i], ordered[j], expected, got, ok)
+			}
+		}
+	}
+}
+
+func TestCompareVersions_NotSemver(t *testing.T) {
+	for _, version := range []string{"", "latest", ">=2.0", "1.2.3.4", "1.0.0-", "1.0.0-alpha..1", "abc123"} {
+		if _, ok := CompareVersions(version, "1.0.0"); ok {
+			t.Errorf("Expected %q not to compare as semver", version)
+		}
+	}
+}
+
+func TestSBOM_OutdatedAgainst(t *testing.T) {
+	doc := New("app", "1.0.0", "")
+	doc.AddComponent(Component{Name: "lodash", Version: "4.17.20"})
+	doc.AddComponent(Component{Name: "express", Version: "^4.18.0"})
+	doc.AddComponent(Component{Name: "react", Version: "18.3.0-canary.1"})
+	doc.AddComponent(Component{Name: "left-pad", Version: "1.3.0"})
+	doc.AddComponent(Component{Name: "gitdep", Version: "github:user/repo"})
+	doc.AddComponent(Component{Name: "unlisted", Version: "0.1.0"})
+
+	outdated := doc.OutdatedAgainst(map[string]string{
+		"lodash":   "4.17.21",
+		"express":  "4.19.2",
+		"react":    "18.3.0",
+		"left-pad": "1.3.0",
+		"gitdep":   "2.0.0",
+	})
+
+	expected := []Outdated{
+		{Name: "express", Current: "^4.18.0", Latest: "4.19.2"},
+		{Name: "lodash", Current: "4.17.20", Latest: "4.17.21"},
+		{Name: "react", Current: "18.3.0-canary.1", Latest: "18.3.0"},
+	}
+	if !reflect.DeepEqual(outdated, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, outdated)
+	}
+}
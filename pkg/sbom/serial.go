@@ -0,0 +1,81 @@
+package sbom
+
+import (
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// uuidNamespaceURL is the RFC 4122 namespace for URL names, used for the
+// name-based serial numbers of deterministic SBOMs.
+var uuidNamespaceURL = [16]byte{0x6b, 0xa7, 0xb8, 0x11, 0x9d, 0xad, 0x11, 0xd1, 0x80, 0xb4, 0x00, 0xc0, 0x4f, 0xd4, 0x30, 0xc8}
+
+// NewSerialNumber returns a random urn:uuid serial number (UUID version 4).
+func NewSerialNumber() string {
+	var uuid [16]byte
+	if _, err := rand.Read(uuid[:]); err != nil {
+		panic(fmt.Sprintf("failed to read random bytes: %v", err))
+	}
+	uuid[6] = uuid[6]&0x0f | 0x40
+	uuid[8] = uuid[8]&0x3f | 0x80
+	return formatUUID(uuid)
+}
+
+// ContentSerialNumber returns a urn:uuid serial number derived from the
+// SBOM's content rather than chosen at random: a UUID version 5 over the
+// SHA-256 of the root and the sorted component PURLs. Components without a
+// PURL count by name and version. SBOMs describing the same project and
+// components therefore get the same serial on any machine.
+func (s *SBOM) ContentSerialNumber() string {
+	keys := make([]string, len(s.Components))
+	for i, comp := range s.Components {
+		keys[i] = componentKey(comp)
+	}
+	sort.Strings(keys)
+
+	root := s.Name + "@" + s.Version
+	if s.Root != nil {
+		root = componentKey(*s.Root)
+	}
+	digest := sha256.Sum256([]byte(root + "\n" + strings.Join(keys, "\n")))
+	return NameSerialNumber("urn:sha256:" + hex.EncodeToString(digest[:]))
+}
+
+// NameSerialNumber returns the urn:uuid serial number named by name: a
+// UUID version 5 in the URL namespace, so the same name always yields the
+// same serial. It turns identifiers that are not UUIDs into serials that
+// standards requiring RFC 4122 UUIDs accept.
+func NameSerialNumber(name string) string {
+	h := sha1.New()
+	h.Write(uuidNamespaceURL[:])
+	h.Write([]byte(name))
+	var uuid [16]byte
+	copy(uuid[:], h.Sum(nil))
+	uuid[6] = uuid[6]&0x0f | 0x50
+	uuid[8] = uuid[8]&0x3f | 0x80
+	return formatUUID(uuid)
+}
+
+// MakeDeterministic sorts components and relationships, replaces the
+// serial number with ContentSerialNumber and sets the creation and
+// annotation times to created, so that identical inputs produce
+// byte-identical output.
+func (s *SBOM) MakeDeterministic(created time.Time) {
+	sortComponents(s.Components)
+	sortRelationships(s.Relationships)
+	s.SerialNumber = s.ContentSerialNumber()
+	s.Created = created.UTC()
+	for i := range s.Annotations {
+		s.Annotations[i].Time = s.Created
+	}
+}
+
+func formatUUID(uuid [16]byte) string {
+	h := hex.EncodeToString(uuid[:])
+	return "urn:uuid:" + h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+}
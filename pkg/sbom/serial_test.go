@@ -0,0 +1,63 @@
+package sbom
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+var uuidURNPattern = regexp.MustCompile(`^urn:uuid:[0-9a-f]{8}-[0-9a-f]{4}-([45])[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestNewSerialNumber(t *testing.T) {
+	a, b := NewSerialNumber(), NewSerialNumber()
+	if m := uuidURNPattern.FindStringSubmatch(a); m == nil || m[1] != "4" {
+		t.Errorf("Expected a version 4 urn:uuid, got %s", a)
+	}
+	if a == b {
+		t.Errorf("Expected random serial numbers, got %s twice", a)
+	}
+}
+
+func TestContentSerialNumber(t *testing.T) {
+	first := New("app", "1.0.0", "")
+	first.AddComponent(Component{Name: "lodash", Version: "4.17.21", PURL: "pkg:npm/lodash@4.17.21"})
+	first.AddComponent(Component{Name: "express", Version: "4.18.2", PURL: "pkg:npm/express@4.18.2"})
+
+	second := New("app", "1.0.0", "")
+	second.AddComponent(Component{Name: "express", Version: "4.18.2", PURL: "pkg:npm/express@4.18.2"})
+	second.AddComponent(Component{Name: "lodash", Version: "4.17.21", PURL: "pkg:npm/lodash@4.17.21"})
+
+	serial := first.ContentSerialNumber()
+	if m := uuidURNPattern.FindStringSubmatch(serial); m == nil || m[1] != "5" {
+		t.Errorf("Expected a version 5 urn:uuid, got %s", serial)
+	}
+	if other := second.ContentSerialNumber(); other != serial {
+		t.Errorf("Expected component order not to matter, got %s and %s", serial, other)
+	}
+
+	second.Components[0].PURL = "pkg:npm/express@4.19.0"
+	if other := second.ContentSerialNumber(); other == serial {
+		t.Error("Expected a different serial number for different components")
+	}
+}
+
+func TestMakeDeterministic(t *testing.T) {
+	doc := New("app", "1.0.0", NewSerialNumber())
+	doc.AddComponent(Component{Name: "lodash", PURL: "pkg:npm/lodash@4.17.21"})
+	doc.AddComponent(Component{Name: "express", PURL: "pkg:npm/express@4.18.2"})
+	doc.Annotations = append(doc.Annotations, Annotation{EventType: "truncated", Time: time.Now()})
+
+	doc.MakeDeterministic(time.Unix(1700000000, 0))
+	if doc.Components[0].Name != "express" {
+		t.Errorf("Expected sorted components, got %+v", doc.Components)
+	}
+	if doc.SerialNumber != doc.ContentSerialNumber() {
+		t.Errorf("Expected the content serial number, got %s", doc.SerialNumber)
+	}
+	if !doc.Created.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("Expected the given creation time, got %v", doc.Created)
+	}
+	if !doc.Annotations[0].Time.Equal(doc.Created) {
+		t.Errorf("Expected annotation times to match the creation time, got %v", doc.Annotations[0].Time)
+	}
+}
@@ -0,0 +1,79 @@
+package sbom
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SortKey is a field SortComponentsBy orders components by.
+type SortKey string
+
+const (
+	SortByName     SortKey = "name"
+	SortByLicense  SortKey = "license"
+	SortBySupplier SortKey = "supplier"
+	SortByVersion  SortKey = "version"
+)
+
+// sortKeys lists the valid sort keys in the order they are documented.
+var sortKeys = []SortKey{SortByName, SortByLicense, SortBySupplier, SortByVersion}
+
+// ParseSortKey validates a sort key given on the command line.
+func ParseSortKey(s string) (SortKey, error) {
+	for _, key := range sortKeys {
+		if string(key) == s {
+			return key, nil
+		}
+	}
+	names := make([]string, len(sortKeys))
+	for i, key := range sortKeys {
+		names[i] = string(key)
+	}
+	return "", fmt.Errorf("unknown sort key %q (supported: %s)", s, strings.Join(names, ", "))
+}
+
+// SortComponentsBy sorts the components by key. Versions compare by semver
+// precedence where both parse as semver and as strings otherwise. Ties are
+// broken by name and then version, so the order does not depend on the
+// order the analyzers found the components in.
+func (s *SBOM) SortComponentsBy(key SortKey) error {
+	var field func(Component) string
+	switch key {
+	case SortByName:
+		field = func(c Component) string { return c.Name }
+	case SortByLicense:
+		field = func(c Component) string { return c.License }
+	case SortBySupplier:
+		field = func(c Component) string { return c.Supplier }
+	case SortByVersion:
+	default:
+		_, err := ParseSortKey(string(key))
+		return err
+	}
+
+	sort.SliceStable(s.Components, func(i, j int) bool {
+		a, b := s.Components[i], s.Components[j]
+		if field != nil {
+			if fa, fb := field(a), field(b); fa != fb {
+				return fa < fb
+			}
+		} else if c := compareVersionStrings(a.Version, b.Version); c != 0 {
+			return c < 0
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return compareVersionStrings(a.Version, b.Version) < 0
+	})
+	return nil
+}
+
+// compareVersionStrings orders two versions by semver precedence if both
+// are semver, and lexically otherwise.
+func compareVersionStrings(a, b string) int {
+	if c, ok := CompareVersions(a, b); ok {
+		return c
+	}
+	return strings.Compare(a, b)
+}
@@ -0,0 +1,71 @@
+package sbom
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSortComponentsBy(t *testing.T) {
+	components := []Component{
+		{Name: "zlib", Version: "1.3.1", Supplier: "generic", License: "Zlib"},
+		{Name: "express", Version: "4.18.2", Supplier: "npm", License: "MIT"},
+		{Name: "requests", Version: "2.28.0", Supplier: "pypi", License: "Apache-2.0"},
+		{Name: "chalk", Version: "5.3.0", Supplier: "npm", License: "MIT"},
+		{Name: "debug", Version: "4.10.0", Supplier: "npm", License: "MIT"},
+		{Name: "chalk", Version: "4.1.2", Supplier: "npm", License: "MIT"},
+	}
+
+	tests := []struct {
+		key      SortKey
+		expected []string
+	}{
+		{SortByName, []string{"chalk@4.1.2", "chalk@5.3.0", "debug@4.10.0", "express@4.18.2", "requests@2.28.0", "zlib@1.3.1"}},
+		{SortByLicense, []string{"requests@2.28.0", "chalk@4.1.2", "chalk@5.3.0", "debug@4.10.0", "express@4.18.2", "zlib@1.3.1"}},
+		{SortBySupplier, []string{"zlib@1.3.1", "chalk@4.1.2", "chalk@5.3.0", "debug@4.10.0", "express@4.18.2", "requests@2.28.0"}},
+		// 4.10.0 sorts after 4.1.2 and 4.18.2 by semver, not before them
+		// as a string would.
+		{SortByVersion, []string{"zlib@1.3.1", "requests@2.28.0", "chalk@4.1.2", "debug@4.10.0", "express@4.18.2", "chalk@5.3.0"}},
+	}
+	for _, tt := range tests {
+		s := &SBOM{Components: append([]Component(nil), components...)}
+		if err := s.SortComponentsBy(tt.key); err != nil {
+			t.Fatalf("Failed to sort by %s: %v", tt.key, err)
+		}
+		var got []string
+		for _, comp := range s.Components {
+			got = append(got, comp.Name+"@"+comp.Version)
+		}
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("Sort by %s: expected %v, got %v", tt.key, tt.expected, got)
+		}
+	}
+}
+
+func TestSortComponentsBy_TieBreak(t *testing.T) {
+	s := &SBOM{Components: []Component{
+		{Name: "b", Version: "1.0.0"},
+		{Name: "a", Version: "1.0.0"},
+		{Name: "c", Version: "1.0.0"},
+	}}
+	if err := s.SortComponentsBy(SortByVersion); err != nil {
+		t.Fatalf("Failed to sort: %v", err)
+	}
+	for i, name := range []string{"a", "b", "c"} {
+		if s.Components[i].Name != name {
+			t.Errorf("Expected equal versions ordered by name, got %v", s.Components)
+			break
+		}
+	}
+}
+
+func TestParseSortKey(t *testing.T) {
+	if key, err := ParseSortKey("supplier"); err != nil || key != SortBySupplier {
+		t.Errorf("Expected supplier, got %q, %v", key, err)
+	}
+	if _, err := ParseSortKey("purl"); err == nil {
+		t.Error("Expected error for an unknown sort key")
+	}
+	if err := (&SBOM{}).SortComponentsBy("size"); err == nil {
+		t.Error("Expected SortComponentsBy to reject an unknown key")
+	}
+}
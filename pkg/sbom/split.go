@@ -0,0 +1,90 @@
+package sbom
+
+import "sort"
+
+// Part is one of the documents an SBOM is split into.
+type Part struct {
+	// Key is the value the part's components share, such as a supplier.
+	Key  string
+	SBOM *SBOM
+}
+
+// Split divides the SBOM into standalone documents, one per distinct value
+// of key over the components, ordered by key. Every part keeps the root and
+// the document metadata. It takes the relationships, annotations and
+// vulnerabilities that only involve its own components or the root, so
+// relationships between components of different parts are lost. Parts get
+// a serial number of their own: content-derived if the SBOM's serial is,
+// random otherwise.
+func (s *SBOM) Split(key func(Component) string) []Part {
+	deterministic := s.SerialNumber != "" && s.SerialNumber == s.ContentSerialNumber()
+
+	byKey := make(map[string][]Component)
+	for _, comp := range s.Components {
+		k := key(comp)
+		byKey[k] = append(byKey[k], comp)
+	}
+	keys := make([]string, 0, len(byKey))
+	for k := range byKey {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]Part, len(keys))
+	for i, k := range keys {
+		part := *s
+		part.Components = byKey[k]
+		if s.Root != nil {
+			root := *s.Root
+			part.Root = &root
+		}
+
+		refs := make(map[string]bool)
+		for _, comp := range part.Components {
+			addRefs(refs, comp)
+		}
+		shared := make(map[string]bool)
+		if s.Root != nil {
+			addRefs(shared, *s.Root)
+		}
+		in := func(ref string) bool { return refs[ref] || shared[ref] }
+
+		part.Relationships = make([]Relationship, 0)
+		for _, rel := range s.Relationships {
+			if in(rel.RefA) && in(rel.RefB) {
+				part.Relationships = append(part.Relationships, rel)
+			}
+		}
+		part.Annotations = make([]Annotation, 0)
+		for _, annotation := range s.Annotations {
+			if annotation.ComponentRef == "" || in(annotation.ComponentRef) {
+				part.Annotations = append(part.Annotations, annotation)
+			}
+		}
+		part.Vulnerabilities = nil
+		for _, vuln := range s.Vulnerabilities {
+			if refs[vuln.Affects] {
+				part.Vulnerabilities = append(part.Vulnerabilities, vuln)
+			}
+		}
+
+		if deterministic {
+			part.SerialNumber = part.ContentSerialNumber()
+		} else {
+			part.SerialNumber = NewSerialNumber()
+		}
+		parts[i] = Part{Key: k, SBOM: &part}
+	}
+	return parts
+}
+
+// addRefs records the references relationships may use for comp: its name
+// and, if it has one, its PURL.
+func addRefs(refs map[string]bool, comp Component) {
+	if comp.Name != "" {
+		refs[comp.Name] = true
+	}
+	if comp.PURL != "" {
+		refs[comp.PURL] = true
+	}
+}
@@ -0,0 +1,49 @@
+package sbom
+
+import "testing"
+
+func TestSplit(t *testing.T) {
+	s := New("app", "1.0.0", "urn:uuid:00000000-0000-4000-8000-000000000000")
+	s.SetRoot(Component{Name: "app", PURL: "pkg:npm/app@1.0.0"})
+	s.AddComponent(Component{Name: "express", Supplier: "npm", PURL: "pkg:npm/express@4.18.2"})
+	s.AddComponent(Component{Name: "debug", Supplier: "npm", PURL: "pkg:npm/debug@4.3.4"})
+	s.AddComponent(Component{Name: "requests", Supplier: "pypi", PURL: "pkg:pypi/requests@2.28.0"})
+	s.AddRelationship("pkg:npm/app@1.0.0", "pkg:npm/express@4.18.2", DependsOn)
+	s.AddRelationship("pkg:npm/express@4.18.2", "pkg:npm/debug@4.3.4", DependsOn)
+	s.AddRelationship("pkg:npm/express@4.18.2", "pkg:pypi/requests@2.28.0", DependsOn)
+	s.Vulnerabilities = []Vulnerability{{ID: "CVE-2023-32681", Affects: "pkg:pypi/requests@2.28.0"}}
+
+	parts := s.Split(func(comp Component) string { return comp.Supplier })
+	if len(parts) != 2 || parts[0].Key != "npm" || parts[1].Key != "pypi" {
+		t.Fatalf("Expected npm and pypi parts, got %+v", parts)
+	}
+
+	npm, pypi := parts[0].SBOM, parts[1].SBOM
+	if len(npm.Components) != 2 || len(pypi.Components) != 1 {
+		t.Errorf("Expected 2 npm and 1 pypi components, got %d and %d", len(npm.Components), len(pypi.Components))
+	}
+	if len(npm.Relationships) != 2 {
+		t.Errorf("Expected the root and npm relationships in the npm part, got %v", npm.Relationships)
+	}
+	if len(pypi.Relationships) != 0 {
+		t.Errorf("Expected relationships across parts to be dropped, got %v", pypi.Relationships)
+	}
+	if len(npm.Vulnerabilities) != 0 || len(pypi.Vulnerabilities) != 1 {
+		t.Errorf("Expected the vulnerability in the pypi part only, got %v and %v", npm.Vulnerabilities, pypi.Vulnerabilities)
+	}
+	if npm.Root == nil || pypi.Root == nil || npm.Root == pypi.Root {
+		t.Error("Expected every part to get its own copy of the root")
+	}
+	if npm.SerialNumber == s.SerialNumber || npm.SerialNumber == pypi.SerialNumber {
+		t.Errorf("Expected distinct serial numbers, got %s and %s", npm.SerialNumber, pypi.SerialNumber)
+	}
+	if len(s.Components) != 3 || len(s.Relationships) != 3 {
+		t.Error("Expected the original SBOM to be left unchanged")
+	}
+
+	s.SerialNumber = s.ContentSerialNumber()
+	again := s.Split(func(comp Component) string { return comp.Supplier })
+	if again[0].SBOM.SerialNumber != again[0].SBOM.ContentSerialNumber() {
+		t.Error("Expected content-derived serial numbers for a deterministic SBOM")
+	}
+}
@@ -0,0 +1,149 @@
+package sbom
+
+import (
+	"io"
+	"sort"
+	"strings"
+)
+
+// WriteTree renders the depends_on relationships as an indented ASCII tree
+// rooted at the project component. Components nothing depends on hang off
+// the root, children are sorted by name, and a component that was already
+// printed is marked with (*) instead of being expanded again, which also
+// breaks cycles.
+func (s *SBOM) WriteTree(w io.Writer) error {
+	index := make(map[string]int)
+	for i, comp := range s.Components {
+		if comp.PURL != "" {
+			index[comp.PURL] = i
+		}
+	}
+	for i, comp := range s.Components {
+		if _, ok := index[comp.Name]; !ok {
+			index[comp.Name] = i
+		}
+	}
+
+	root := Component{Name: s.Name, Version: s.Version}
+	if s.Root != nil {
+		root = *s.Root
+	}
+	isRoot := func(ref string) bool {
+		return ref != "" && (ref == root.PURL || ref == root.Name)
+	}
+
+	children := make(map[int][]int)
+	hasParent := make(map[int]bool)
+	var top []int
+	for _, rel := range s.Relationships {
+		if rel.Relationship != DependsOn {
+			continue
+		}
+		b, ok := index[rel.RefB]
+		if !ok {
+			continue
+		}
+		if isRoot(rel.RefA) {
+			top = append(top, b)
+			continue
+		}
+		if a, ok := index[rel.RefA]; ok && a != b {
+			children[a] = append(children[a], b)
+			hasParent[b] = true
+		}
+	}
+
+	byName := func(nodes []int) []int {
+		seen := make(map[int]bool)
+		unique := nodes[:0]
+		for _, n := range nodes {
+			if !seen[n] {
+				seen[n] = true
+				unique = append(unique, n)
+			}
+		}
+		sort.SliceStable(unique, func(i, j int) bool {
+			a, b := s.Components[unique[i]], s.Components[unique[j]]
+			if a.Name != b.Name {
+				return a.Name < b.Name
+			}
+			return a.Version < b.Version
+		})
+		return unique
+	}
+
+	for i := range s.Components {
+		if !hasParent[i] {
+			top = append(top, i)
+		}
+	}
+	top = byName(top)
+	for n := range children {
+		children[n] = byName(children[n])
+	}
+
+	// Components only reachable through a cycle have a parent but would
+	// never be printed, so the first of each such group joins the root.
+	reached := make(map[int]bool)
+	var reach func(n int)
+	reach = func(n int) {
+		if reached[n] {
+			return
+		}
+		reached[n] = true
+		for _, c := range children[n] {
+			reach(c)
+		}
+	}
+	for _, n := range top {
+		reach(n)
+	}
+	for _, n := range byName(allIndexes(len(s.Components))) {
+		if !reached[n] {
+			top = append(top, n)
+			reach(n)
+		}
+	}
+	top = byName(top)
+
+	var b strings.Builder
+	b.WriteString(treeLabel(root) + "\n")
+
+	printed := make(map[int]bool)
+	var walk func(nodes []int, prefix string)
+	walk = func(nodes []int, prefix string) {
+		for i, n := range nodes {
+			branch, indent := "|-- ", "|   "
+			if i == len(nodes)-1 {
+				branch, indent = "`-- ", "    "
+			}
+			label := treeLabel(s.Components[n])
+			if printed[n] {
+				b.WriteString(prefix + branch + label + " (*)\n")
+				continue
+			}
+			printed[n] = true
+			b.WriteString(prefix + branch + label + "\n")
+			walk(children[n], prefix+indent)
+		}
+	}
+	walk(top, "")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func allIndexes(n int) []int {
+	indexes := make([]int, n)
+	for i := range indexes {
+		indexes[i] = i
+	}
+	return indexes
+}
+
+func treeLabel(comp Component) string {
+	if comp.Version == "" {
+		return comp.Name
+	}
+	return comp.Name + "@" + comp.Version
+}
@@ -0,0 +1,56 @@
+package sbom
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteTree(t *testing.T) {
+	sbom := New("app", "1.0.0", "serial-001")
+	sbom.AddComponent(Component{Name: "web", Version: "1.0.0", PURL: "pkg:npm/web@1.0.0"})
+	sbom.AddComponent(Component{Name: "api", Version: "1.0.0", PURL: "pkg:npm/api@1.0.0"})
+	sbom.AddComponent(Component{Name: "shared", Version: "2.0.0", PURL: "pkg:npm/shared@2.0.0"})
+	sbom.AddComponent(Component{Name: "core", Version: "0.1.0", PURL: "pkg:npm/core@0.1.0"})
+	sbom.AddComponent(Component{Name: "cycle-a", PURL: "pkg:npm/cycle-a"})
+	sbom.AddComponent(Component{Name: "cycle-b", PURL: "pkg:npm/cycle-b"})
+
+	sbom.AddRelationship("pkg:npm/web@1.0.0", "pkg:npm/shared@2.0.0", DependsOn)
+	sbom.AddRelationship("pkg:npm/api@1.0.0", "pkg:npm/shared@2.0.0", DependsOn)
+	sbom.AddRelationship("pkg:npm/shared@2.0.0", "pkg:npm/core@0.1.0", DependsOn)
+	sbom.AddRelationship("pkg:npm/web@1.0.0", "pkg:npm/core@0.1.0", DevDependencyOf)
+	sbom.AddRelationship("pkg:npm/cycle-a", "pkg:npm/cycle-b", DependsOn)
+	sbom.AddRelationship("pkg:npm/cycle-b", "pkg:npm/cycle-a", DependsOn)
+
+	var out strings.Builder
+	if err := sbom.WriteTree(&out); err != nil {
+		t.Fatalf("Failed to write tree: %v", err)
+	}
+
+	expected := "app@1.0.0\n" +
+		"|-- api@1.0.0\n" +
+		"|   `-- shared@2.0.0\n" +
+		"|       `-- core@0.1.0\n" +
+		"|-- cycle-a\n" +
+		"|   `-- cycle-b\n" +
+		"|       `-- cycle-a (*)\n" +
+		"`-- web@1.0.0\n" +
+		"    `-- shared@2.0.0 (*)\n"
+	if out.String() != expected {
+		t.Errorf("Expected tree:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
+
+func TestWriteTree_RootEdges(t *testing.T) {
+	sbom := New("", "", "serial-001")
+	sbom.SetRoot(Component{Name: "app", PURL: "pkg:npm/app@1.0.0"})
+	sbom.AddComponent(Component{Name: "lib", Version: "1.0.0", PURL: "pkg:npm/lib@1.0.0"})
+	sbom.AddRelationship("pkg:npm/app@1.0.0", "pkg:npm/lib@1.0.0", DependsOn)
+
+	var out strings.Builder
+	if err := sbom.WriteTree(&out); err != nil {
+		t.Fatalf("Failed to write tree: %v", err)
+	}
+	if expected := "app\n`-- lib@1.0.0\n"; out.String() != expected {
+		t.Errorf("Expected tree:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
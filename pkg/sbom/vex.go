@@ -0,0 +1,132 @@
+package sbom
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Vulnerability records a known vulnerability affecting a component and,
+// for VEX (Vulnerability Exploitability eXchange), the result of triaging it.
+type Vulnerability struct {
+	ID       string   `json:"id" yaml:"id"`
+	Affects  string   `json:"affects" yaml:"affects"`
+	Analysis Analysis `json:"analysis,omitempty" yaml:"analysis,omitempty"`
+}
+
+// Analysis is the triage decision for a vulnerability, using the CycloneDX
+// impact analysis vocabulary.
+type Analysis struct {
+	State         string `json:"state,omitempty" yaml:"state,omitempty"`
+	Justification string `json:"justification,omitempty" yaml:"justification,omitempty"`
+	Detail        string `json:"detail,omitempty" yaml:"detail,omitempty"`
+}
+
+// vexStates are the CycloneDX impactAnalysisState values.
+var vexStates = map[string]bool{
+	"resolved":               true,
+	"resolved_with_pedigree": true,
+	"exploitable":            true,
+	"in_triage":              true,
+	"false_positive":         true,
+	"not_affected":           true,
+}
+
+// vexJustifications are the CycloneDX impactAnalysisJustification values.
+var vexJustifications = map[string]bool{
+	"code_not_present":                true,
+	"code_not_reachable":              true,
+	"requires_configuration":          true,
+	"requires_dependency":             true,
+	"requires_environment":            true,
+	"protected_by_compiler":           true,
+	"protected_at_runtime":            true,
+	"protected_at_perimeter":          true,
+	"protected_by_mitigating_control": true,
+}
+
+// VEXEntry is one triage decision in a VEX file.
+type VEXEntry struct {
+	ID            string `json:"id" yaml:"id"`
+	State         string `json:"state" yaml:"state"`
+	Justification string `json:"justification,omitempty" yaml:"justification,omitempty"`
+	Detail        string `json:"detail,omitempty" yaml:"detail,omitempty"`
+}
+
+// VEX maps component PURLs to the triage decisions recorded for them.
+type VEX map[string][]VEXEntry
+
+// LoadVEX reads a VEX mapping from a JSON or YAML file and validates its
+// states and justifications.
+func LoadVEX(path string) (VEX, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read VEX file: %w", err)
+	}
+
+	// JSON is a subset of YAML, so one decoder handles both.
+	var vex VEX
+	if err := yaml.Unmarshal(data, &vex); err != nil {
+		return nil, fmt.Errorf("failed to parse VEX file: %w", err)
+	}
+
+	for purl, entries := range vex {
+		for _, entry := range entries {
+			if entry.ID == "" {
+				return nil, fmt.Errorf("VEX entry for %s has no vulnerability id", purl)
+			}
+			if !vexStates[entry.State] {
+				return nil, fmt.Errorf("VEX entry %s for %s has unknown state %q", entry.ID, purl, entry.State)
+			}
+			if entry.Justification != "" && !vexJustifications[entry.Justification] {
+				return nil, fmt.Errorf("VEX entry %s for %s has unknown justification %q", entry.ID, purl, entry.Justification)
+			}
+		}
+	}
+	return vex, nil
+}
+
+// ApplyVEX records a vulnerability with its analysis for every VEX entry
+// whose PURL matches a component, and returns how many were recorded. A
+// vulnerability already in the SBOM for the same PURL, such as one found
+// in OSV, gets the analysis instead of a second entry. Entries for PURLs
+// that are not in the SBOM are ignored.
+func (s *SBOM) ApplyVEX(vex VEX) int {
+	purls := make([]string, 0, len(vex))
+	for purl := range vex {
+		purls = append(purls, purl)
+	}
+	sort.Strings(purls)
+
+	applied := 0
+	for _, purl := range purls {
+		if s.GetComponentByPURL(purl) == nil {
+			continue
+		}
+		for _, entry := range vex[purl] {
+			analysis := Analysis{
+				State:         entry.State,
+				Justification: entry.Justification,
+				Detail:        entry.Detail,
+			}
+			if existing := s.vulnerability(entry.ID, purl); existing != nil {
+				existing.Analysis = analysis
+			} else {
+				s.Vulnerabilities = append(s.Vulnerabilities, Vulnerability{ID: entry.ID, Affects: purl, Analysis: analysis})
+			}
+			applied++
+		}
+	}
+	return applied
+}
+
+func (s *SBOM) vulnerability(id, purl string) *Vulnerability {
+	for i := range s.Vulnerabilities {
+		if s.Vulnerabilities[i].ID == id && s.Vulnerabilities[i].Affects == purl {
+			return &s.Vulnerabilities[i]
+		}
+	}
+	return nil
+}
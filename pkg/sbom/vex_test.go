@@ -0,0 +1,79 @@
+package sbom
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestApplyVEX(t *testing.T) {
+	vexFile := `pkg:npm/lodash@4.17.20:
+  - id: CVE-2021-23337
+    state: not_affected
+    justification: code_not_reachable
+    detail: template() is never called
+pkg:npm/not-installed@1.0.0:
+  - id: CVE-2020-0001
+    state: exploitable
+`
+	path := filepath.Join(t.TempDir(), "vex.yaml")
+	if err := os.WriteFile(path, []byte(vexFile), 0644); err != nil {
+		t.Fatalf("Failed to write VEX file: %v", err)
+	}
+
+	vex, err := LoadVEX(path)
+	if err != nil {
+		t.Fatalf("Failed to load VEX: %v", err)
+	}
+
+	sbom := New("test-app", "1.0.0", "serial-001")
+	sbom.AddComponent(Component{Name: "lodash", Version: "4.17.20", PURL: "pkg:npm/lodash@4.17.20"})
+
+	if n := sbom.ApplyVEX(vex); n != 1 {
+		t.Fatalf("Expected 1 vulnerability recorded, got %d", n)
+	}
+	vuln := sbom.Vulnerabilities[0]
+	if vuln.ID != "CVE-2021-23337" || vuln.Affects != "pkg:npm/lodash@4.17.20" {
+		t.Errorf("Unexpected vulnerability %+v", vuln)
+	}
+	if vuln.Analysis.State != "not_affected" || vuln.Analysis.Justification != "code_not_reachable" {
+		t.Errorf("Expected VEX analysis to be recorded, got %+v", vuln.Analysis)
+	}
+}
+
+func TestApplyVEX_ExistingVulnerability(t *testing.T) {
+	sbom := New("test-app", "1.0.0", "serial-001")
+	sbom.AddComponent(Component{Name: "lodash", Version: "4.17.20", PURL: "pkg:npm/lodash@4.17.20"})
+	sbom.Vulnerabilities = []Vulnerability{{ID: "CVE-2021-23337", Affects: "pkg:npm/lodash@4.17.20"}}
+
+	vex := VEX{"pkg:npm/lodash@4.17.20": {{ID: "CVE-2021-23337", State: "in_triage"}}}
+	if n := sbom.ApplyVEX(vex); n != 1 {
+		t.Fatalf("Expected 1 statement applied, got %d", n)
+	}
+	if len(sbom.Vulnerabilities) != 1 || sbom.Vulnerabilities[0].Analysis.State != "in_triage" {
+		t.Errorf("Expected the existing vulnerability to carry the analysis, got %+v", sbom.Vulnerabilities)
+	}
+}
+
+func TestLoadVEX_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"unknown state", `{"pkg:npm/a@1.0.0": [{"id": "CVE-1", "state": "fine"}]}`},
+		{"unknown justification", `{"pkg:npm/a@1.0.0": [{"id": "CVE-1", "state": "not_affected", "justification": "trust me"}]}`},
+		{"missing id", `{"pkg:npm/a@1.0.0": [{"state": "in_triage"}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "vex.json")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to write VEX file: %v", err)
+			}
+			if _, err := LoadVEX(path); err == nil {
+				t.Error("Expected validation error")
+			}
+		})
+	}
+}
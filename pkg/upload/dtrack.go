@@ -0,0 +1,183 @@
+// Package upload sends SBOMs to SBOM management servers such as
+// Dependency-Track, retrying transient failures.
+package upload
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hallucinaut/sbomgen/pkg/enrich"
+)
+
+// DefaultRetries is the number of times a failed upload is retried.
+const DefaultRetries = 3
+
+// DefaultBackoff is the delay before the first retry. It doubles with
+// every further attempt.
+const DefaultBackoff = time.Second
+
+// Doer sends HTTP requests. *http.Client implements it; it is an interface
+// so that uploads can be tested offline. NewDependencyTrack uses the client
+// of enrich.NewHTTPClient, which times out instead of waiting forever on
+// an unresponsive server.
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// DependencyTrack uploads BOMs to a Dependency-Track server through its
+// PUT /api/v1/bom endpoint.
+//
+// Retrying is safe: the BOM is addressed to a project by name and version,
+// and the server replaces that project's BOM rather than adding another
+// one, while autoCreate looks the project up before creating it. Every
+// attempt of one upload also carries the same Idempotency-Key header, a
+// digest of the request, so that proxies and gateways deduplicate them too.
+type DependencyTrack struct {
+	// URL is the base URL of the server, e.g. https://dtrack.example.com.
+	URL    string
+	APIKey string
+	// Project and Version name the project the BOM belongs to. It is
+	// created if it does not exist yet.
+	Project string
+	Version string
+	Client  Doer
+	// Retries is the number of times a failed attempt is retried. Network
+	// errors, 429 Too Many Requests and 5xx responses are retried; other
+	// responses fail immediately.
+	Retries int
+	// Backoff is the delay before the first retry, doubled for each
+	// further one.
+	Backoff time.Duration
+}
+
+func NewDependencyTrack(baseURL, apiKey string) *DependencyTrack {
+	return &DependencyTrack{
+		URL:     strings.TrimSuffix(baseURL, "/"),
+		APIKey:  apiKey,
+		Client:  enrich.NewHTTPClient(),
+		Retries: DefaultRetries,
+		Backoff: DefaultBackoff,
+	}
+}
+
+// dtrackBOMRequest is the body of PUT /api/v1/bom.
+type dtrackBOMRequest struct {
+	ProjectName    string `json:"projectName"`
+	ProjectVersion string `json:"projectVersion,omitempty"`
+	AutoCreate     bool   `json:"autoCreate"`
+	BOM            string `json:"bom"`
+}
+
+// Upload sends bom and returns the token the server assigned to its
+// processing.
+func (d *DependencyTrack) Upload(ctx context.Context, bom []byte) (string, error) {
+	if d.Project == "" {
+		return "", fmt.Errorf("a Dependency-Track project name is required")
+	}
+	body, err := json.Marshal(dtrackBOMRequest{
+		ProjectName:    d.Project,
+		ProjectVersion: d.Version,
+		AutoCreate:     true,
+		BOM:            base64.StdEncoding.EncodeToString(bom),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode upload: %w", err)
+	}
+	sum := sha256.Sum256(body)
+	key := hex.EncodeToString(sum[:])
+
+	var lastErr error
+	attempts := d.Retries + 1
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			if err := sleep(ctx, d.Backoff<<(attempt-1)); err != nil {
+				return "", err
+			}
+		}
+		token, err := d.put(ctx, body, key)
+		if err == nil {
+			return token, nil
+		}
+		lastErr = err
+		var status *statusError
+		if errors.As(err, &status) && !status.retryable() {
+			return "", err
+		}
+	}
+	return "", fmt.Errorf("upload failed after %d attempt(s): %w", attempts, lastErr)
+}
+
+func (d *DependencyTrack) put(ctx context.Context, body []byte, key string) (string, error) {
+	endpoint := d.URL + "/api/v1/bom"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "sbomgen")
+	req.Header.Set("X-Api-Key", d.APIKey)
+	req.Header.Set("Idempotency-Key", key)
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", &statusError{method: http.MethodPut, url: endpoint, code: resp.StatusCode, status: resp.Status}
+	}
+	var result struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return "", fmt.Errorf("failed to parse upload response: %w", err)
+	}
+	return result.Token, nil
+}
+
+// statusError is an unsuccessful HTTP response.
+type statusError struct {
+	method, url string
+	code        int
+	status      string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("%s %s: %s", e.method, e.url, e.status)
+}
+
+// retryable reports whether the server may accept the same request later.
+func (e *statusError) retryable() bool {
+	return e.code == http.StatusTooManyRequests || e.code >= 500
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,128 @@
+package upload
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// fakeServer answers requests with the scripted responses in turn, an empty
+// status standing for a network error.
+type fakeServer struct {
+	statuses []int
+	requests []*http.Request
+	bodies   []string
+}
+
+func (f *fakeServer) Do(req *http.Request) (*http.Response, error) {
+	body, _ := io.ReadAll(req.Body)
+	f.requests = append(f.requests, req)
+	f.bodies = append(f.bodies, string(body))
+	status := f.statuses[len(f.requests)-1]
+	if status == 0 {
+		return nil, errors.New("connection reset by peer")
+	}
+	return &http.Response{
+		StatusCode: status,
+		Status:     http.StatusText(status),
+		Body:       io.NopCloser(strings.NewReader(`{"token":"d6e0c3f2"}`)),
+	}, nil
+}
+
+func newTestUploader(server *fakeServer) *DependencyTrack {
+	d := NewDependencyTrack("https://dtrack.example.com/", "secret")
+	d.Project = "myapp"
+	d.Version = "1.0.0"
+	d.Client = server
+	d.Backoff = 0
+	return d
+}
+
+func TestDependencyTrack_RetriesFailedUpload(t *testing.T) {
+	server := &fakeServer{statuses: []int{http.StatusServiceUnavailable, http.StatusOK}}
+	bom := []byte(`{"bomFormat":"CycloneDX"}`)
+
+	token, err := newTestUploader(server).Upload(context.Background(), bom)
+	if err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	if token != "d6e0c3f2" {
+		t.Errorf("Expected token d6e0c3f2, got '%s'", token)
+	}
+	if len(server.requests) != 2 {
+		t.Fatalf("Expected 2 attempts, got %d", len(server.requests))
+	}
+
+	first, second := server.requests[0], server.requests[1]
+	if first.Method != http.MethodPut || first.URL.String() != "https://dtrack.example.com/api/v1/bom" {
+		t.Errorf("Unexpected request %s %s", first.Method, first.URL)
+	}
+	if first.Header.Get("X-Api-Key") != "secret" {
+		t.Errorf("Expected API key header, got '%s'", first.Header.Get("X-Api-Key"))
+	}
+	key := first.Header.Get("Idempotency-Key")
+	if key == "" || second.Header.Get("Idempotency-Key") != key {
+		t.Errorf("Expected both attempts to share an idempotency key, got '%s' and '%s'", key, second.Header.Get("Idempotency-Key"))
+	}
+	if server.bodies[0] != server.bodies[1] {
+		t.Errorf("Expected the retry to resend the same body")
+	}
+
+	var payload dtrackBOMRequest
+	if err := json.Unmarshal([]byte(server.bodies[1]), &payload); err != nil {
+		t.Fatalf("Failed to parse request body: %v", err)
+	}
+	decoded, _ := base64.StdEncoding.DecodeString(payload.BOM)
+	if payload.ProjectName != "myapp" || payload.ProjectVersion != "1.0.0" || !payload.AutoCreate || string(decoded) != string(bom) {
+		t.Errorf("Unexpected request body %+v", payload)
+	}
+}
+
+func TestDependencyTrack_RetriesNetworkErrors(t *testing.T) {
+	server := &fakeServer{statuses: []int{0, http.StatusTooManyRequests, http.StatusOK}}
+	if _, err := newTestUploader(server).Upload(context.Background(), []byte("{}")); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	if len(server.requests) != 3 {
+		t.Errorf("Expected 3 attempts, got %d", len(server.requests))
+	}
+}
+
+func TestDependencyTrack_GivesUp(t *testing.T) {
+	server := &fakeServer{statuses: []int{502, 502, 502}}
+	uploader := newTestUploader(server)
+	uploader.Retries = 2
+
+	_, err := uploader.Upload(context.Background(), []byte("{}"))
+	if err == nil || !strings.Contains(err.Error(), "after 3 attempt(s)") {
+		t.Errorf("Expected failure after 3 attempts, got %v", err)
+	}
+	if len(server.requests) != 3 {
+		t.Errorf("Expected 3 attempts, got %d", len(server.requests))
+	}
+}
+
+func TestDependencyTrack_DoesNotRetryClientErrors(t *testing.T) {
+	server := &fakeServer{statuses: []int{http.StatusUnauthorized, http.StatusOK}}
+	if _, err := newTestUploader(server).Upload(context.Background(), []byte("{}")); err == nil {
+		t.Fatal("Expected an error for 401 Unauthorized")
+	}
+	if len(server.requests) != 1 {
+		t.Errorf("Expected a single attempt, got %d", len(server.requests))
+	}
+}
+
+func TestNewDependencyTrack_ClientTimesOut(t *testing.T) {
+	client, ok := NewDependencyTrack("https://dtrack.example.com", "secret").Client.(*http.Client)
+	if !ok {
+		t.Fatal("Expected an *http.Client by default")
+	}
+	if client.Timeout <= 0 {
+		t.Error("Expected the default client to time out")
+	}
+}
@@ -0,0 +1,37 @@
+package validator
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+)
+
+var (
+	licenseIDsOnce sync.Once
+	licenseIDs     map[string]string
+)
+
+// SPDXLicenseID returns the SPDX license identifier that id names, ignoring
+// case, and whether there is one. The identifiers are those the embedded
+// CycloneDX schema accepts as a license id, so a license reported this way
+// validates.
+func SPDXLicenseID(id string) (string, bool) {
+	licenseIDsOnce.Do(func() {
+		licenseIDs = make(map[string]string)
+		data, err := schemaFS.ReadFile("schemas/spdx.schema.json")
+		if err != nil {
+			return
+		}
+		var schema struct {
+			Enum []string `json:"enum"`
+		}
+		if json.Unmarshal(data, &schema) != nil {
+			return
+		}
+		for _, known := range schema.Enum {
+			licenseIDs[strings.ToLower(known)] = known
+		}
+	})
+	canonical, ok := licenseIDs[strings.ToLower(id)]
+	return canonical, ok
+}
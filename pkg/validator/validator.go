@@ -0,0 +1,140 @@
+// Package validator checks serialized SBOMs against the official JSON Schemas.
+package validator
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// The schemas are the published ones: bom-1.5, spdx and
+// jsf-0.82 from https://github.com/CycloneDX/specification/tree/master/schema
+// (Apache-2.0), and spdx-2.3 from
+// https://github.com/spdx/spdx-spec/blob/v2.3/schemas/spdx-schema.json
+// (Community Specification License 1.0).
+//
+//go:embed schemas/*.json
+var schemaFS embed.FS
+
+// Schema identifies a supported SBOM schema.
+type Schema string
+
+const (
+	CycloneDX Schema = "cyclonedx"
+	SPDX      Schema = "spdx"
+)
+
+// schemaFiles lists, per schema, the root document followed by any documents
+// it references. Each file is registered under its declared $id.
+var schemaFiles = map[Schema][]string{
+	CycloneDX: {"bom-1.5.schema.json", "spdx.schema.json", "jsf-0.82.schema.json"},
+	SPDX:      {"spdx-2.3.schema.json"},
+}
+
+// Violation describes a single schema violation in a document.
+type Violation struct {
+	Pointer string `json:"pointer"`
+	Message string `json:"message"`
+}
+
+func (v Violation) String() string {
+	pointer := v.Pointer
+	if pointer == "" {
+		pointer = "/"
+	}
+	return fmt.Sprintf("%s: %s", pointer, v.Message)
+}
+
+// Validate checks data against the named schema and returns every violation
+// found, located by JSON pointer. An error is returned only when the schema
+// is unknown or data is not valid JSON.
+func Validate(schema Schema, data []byte) ([]Violation, error) {
+	compiled, err := compile(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse document: %w", err)
+	}
+
+	err = compiled.Validate(doc)
+	if err == nil {
+		return nil, nil
+	}
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(err, &validationErr) {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	return collectViolations(validationErr), nil
+}
+
+func compile(schema Schema) (*jsonschema.Schema, error) {
+	files, ok := schemaFiles[schema]
+	if !ok {
+		return nil, fmt.Errorf("unknown schema: %s", schema)
+	}
+
+	compiler := jsonschema.NewCompiler()
+	var rootID string
+	for i, file := range files {
+		data, err := schemaFS.ReadFile("schemas/" + file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read embedded schema %s: %w", file, err)
+		}
+		var header struct {
+			ID string `json:"$id"`
+		}
+		if err := json.Unmarshal(data, &header); err != nil {
+			return nil, fmt.Errorf("failed to parse embedded schema %s: %w", file, err)
+		}
+		if err := compiler.AddResource(header.ID, bytes.NewReader(data)); err != nil {
+			return nil, fmt.Errorf("failed to load embedded schema %s: %w", file, err)
+		}
+		if i == 0 {
+			rootID = header.ID
+		}
+	}
+
+	return compiler.Compile(rootID)
+}
+
+// collectViolations flattens the error tree to its leaves, which carry the
+// most specific pointer and message.
+func collectViolations(err *jsonschema.ValidationError) []Violation {
+	var violations []Violation
+	seen := make(map[Violation]bool)
+
+	var walk func(e *jsonschema.ValidationError)
+	walk = func(e *jsonschema.ValidationError) {
+		if len(e.Causes) == 0 {
+			v := Violation{Pointer: e.InstanceLocation, Message: e.Message}
+			if !seen[v] {
+				seen[v] = true
+				violations = append(violations, v)
+			}
+			return
+		}
+		for _, cause := range e.Causes {
+			walk(cause)
+		}
+	}
+	walk(err)
+
+	sort.Slice(violations, func(i, j int) bool {
+		if violations[i].Pointer != violations[j].Pointer {
+			return violations[i].Pointer < violations[j].Pointer
+		}
+		return violations[i].Message < violations[j].Message
+	})
+	return violations
+}
@@ -0,0 +1,121 @@
+package validator
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestValidate_CycloneDX(t *testing.T) {
+	valid := `{
+		"bomFormat": "CycloneDX",
+		"specVersion": "1.5",
+		"version": 1,
+		"components": [
+			{"type": "library", "name": "lib-a", "version": "1.0.0"}
+		]
+	}`
+
+	violations, err := Validate(CycloneDX, []byte(valid))
+	if err != nil {
+		t.Fatalf("Failed to validate: %v", err)
+	}
+	if len(violations) != 0 {
+		t.Errorf("Expected no violations, got %v", violations)
+	}
+}
+
+func TestValidate_CycloneDXMalformed(t *testing.T) {
+	malformed := `{
+		"bomFormat": "CycloneDX",
+		"specVersion": "1.5",
+		"components": [
+			{"type": "gadget", "name": "lib-a"}
+		]
+	}`
+
+	violations, err := Validate(CycloneDX, []byte(malformed))
+	if err != nil {
+		t.Fatalf("Failed to validate: %v", err)
+	}
+	if len(violations) == 0 {
+		t.Fatal("Expected violations for malformed document")
+	}
+
+	found := false
+	for _, v := range violations {
+		if v.Pointer == "/components/0/type" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected violation at /components/0/type, got %v", violations)
+	}
+}
+
+func TestValidate_SPDXMalformed(t *testing.T) {
+	malformed := `{
+		"spdxVersion": "SPDX-2.3",
+		"SPDXID": "SPDXRef-DOCUMENT",
+		"name": "test-app",
+		"creationInfo": {"created": "2024-01-01T00:00:00Z", "creators": ["Tool: sbomgen"]},
+		"packages": [
+			{"SPDXID": "SPDXRef-Package-0", "name": "lib-a"}
+		]
+	}`
+
+	violations, err := Validate(SPDX, []byte(malformed))
+	if err != nil {
+		t.Fatalf("Failed to validate: %v", err)
+	}
+
+	var messages []string
+	for _, v := range violations {
+		messages = append(messages, v.String())
+	}
+	joined := strings.Join(messages, "\n")
+	if !strings.Contains(joined, "dataLicense") {
+		t.Errorf("Expected missing dataLicense violation, got:\n%s", joined)
+	}
+	if !strings.Contains(joined, "/packages/0") || !strings.Contains(joined, "downloadLocation") {
+		t.Errorf("Expected missing downloadLocation violation on /packages/0, got:\n%s", joined)
+	}
+}
+
+func TestValidate_SPDXExample(t *testing.T) {
+	// The example document published with the SPDX 2.3 specification uses
+	// files, snippets, annotations and extracted licenses as well as
+	// packages.
+	data, err := os.ReadFile("testdata/SPDXJSONExample-v2.3.spdx.json")
+	if err != nil {
+		t.Fatalf("Failed to read example: %v", err)
+	}
+	violations, err := Validate(SPDX, data)
+	if err != nil {
+		t.Fatalf("Failed to validate: %v", err)
+	}
+	if len(violations) != 0 {
+		t.Errorf("Expected no violations, got %v", violations)
+	}
+
+	data = []byte(strings.Replace(string(data), `"fileName": "./lib-source/jena-2.6.3-sources.jar"`, `"fileName": "./lib-source/jena-2.6.3-sources.jar", "fileKind": "jar"`, 1))
+	violations, err = Validate(SPDX, data)
+	if err != nil {
+		t.Fatalf("Failed to validate: %v", err)
+	}
+	if len(violations) == 0 {
+		t.Error("Expected a violation for an unknown file property")
+	}
+}
+
+func TestValidate_UnknownSchema(t *testing.T) {
+	if _, err := Validate("swid", []byte(`{}`)); err == nil {
+		t.Error("Expected error for unknown schema")
+	}
+}
+
+func TestValidate_InvalidJSON(t *testing.T) {
+	if _, err := Validate(CycloneDX, []byte(`{not json`)); err == nil {
+		t.Error("Expected error for invalid JSON")
+	}
+}